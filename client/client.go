@@ -10,12 +10,14 @@ import (
 	"google.golang.org/grpc/credentials"
 
 	"github.com/RTradeLtd/Nexus/config"
+	"github.com/RTradeLtd/Nexus/operations"
 )
 
 // IPFSOrchestratorClient is a lighweight container for the orchestrator's
-// gRPC API client
+// gRPC API clients
 type IPFSOrchestratorClient struct {
 	nexus.ServiceClient
+	operations.OperationsClient
 	grpc *grpc.ClientConn
 }
 
@@ -49,6 +51,7 @@ func New(opts config.API, devMode bool) (*IPFSOrchestratorClient, error) {
 		return nil, fmt.Errorf("failed to connect to core service: %s", err.Error())
 	}
 	c.ServiceClient = nexus.NewServiceClient(c.grpc)
+	c.OperationsClient = operations.NewOperationsClient(c.grpc)
 	return c, nil
 }
 
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/RTradeLtd/Nexus/client"
+	"github.com/RTradeLtd/Nexus/config"
+	"github.com/RTradeLtd/Nexus/operations"
+)
+
+func runBackup(configPath string, devMode bool, args []string) {
+	if len(args) < 1 {
+		fatal("network name required")
+	}
+
+	// load configuration
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		fatal(err.Error())
+	}
+
+	c, err := client.New(cfg.API, devMode)
+	if err != nil {
+		fatal(err.Error())
+	}
+	defer c.Close()
+
+	resp, err := c.BackupNetwork(context.Background(), &operations.NetworkRequest{
+		Network: args[0],
+	})
+	if err != nil {
+		fatal(err.Error())
+	}
+	fmt.Printf("network '%s' backed up to archive '%s'\n", args[0], resp.Archive)
+	fmt.Println(string(resp.Manifest))
+}
+
+func runRestore(configPath string, devMode bool, args []string) {
+	if len(args) < 2 {
+		fatal("network name and archive required")
+	}
+
+	// load configuration
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		fatal(err.Error())
+	}
+
+	c, err := client.New(cfg.API, devMode)
+	if err != nil {
+		fatal(err.Error())
+	}
+	defer c.Close()
+
+	if _, err := c.RestoreNetwork(context.Background(), &operations.RestoreRequest{
+		Network: args[0],
+		Archive: args[1],
+	}); err != nil {
+		fatal(err.Error())
+	}
+	fmt.Printf("network '%s' restored from archive '%s'\n", args[0], args[1])
+}
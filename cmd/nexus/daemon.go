@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"os"
 	"os/signal"
 	"syscall"
@@ -17,9 +18,28 @@ import (
 	"github.com/RTradeLtd/Nexus/ipfs"
 	"github.com/RTradeLtd/Nexus/log"
 	"github.com/RTradeLtd/Nexus/orchestrator"
+	"github.com/RTradeLtd/Nexus/temporal"
 )
 
+// newMemoryNodeClient instantiates a node client that simulates nodes in memory.
+// It is only available in binaries built with the 'memory' tag.
+var newMemoryNodeClient func() ipfs.NodeClient
+
 func runDaemon(configPath string, devMode bool, args []string) {
+	// parse daemon flags
+	var (
+		flags    = flag.NewFlagSet("daemon", flag.ExitOnError)
+		inMemory = new(bool)
+	)
+	if newMemoryNodeClient != nil {
+		flags.BoolVar(inMemory, "memory", false,
+			"[DEV] simulate nodes in memory instead of using Docker")
+	}
+	flags.Parse(args)
+	if *inMemory && !devMode {
+		fatal("do not use the in-memory node client outside of dev mode!")
+	}
+
 	// load configuration
 	cfg, err := config.LoadConfig(configPath)
 	if err != nil {
@@ -41,10 +61,15 @@ func runDaemon(configPath string, devMode bool, args []string) {
 	}
 
 	// initialize node client
-	println("initializing node client")
-	c, err := ipfs.NewClient(l, cfg.IPFS)
-	if err != nil {
-		fatal(err.Error())
+	var c ipfs.NodeClient
+	if *inMemory {
+		println("initializing in-memory node client")
+		c = newMemoryNodeClient()
+	} else {
+		println("initializing node client")
+		if c, err = ipfs.NewClient(l, cfg.IPFS); err != nil {
+			fatal(err.Error())
+		}
 	}
 
 	// set up database connection
@@ -72,10 +97,11 @@ func runDaemon(configPath string, devMode bool, args []string) {
 		}
 	}()
 
+	var networks = temporal.NewNetworkManager(models.NewHostedIPFSNetworkManager(dbm.DB))
+
 	// initialize orchestrator
 	println("initializing orchestrator")
-	o, err := orchestrator.New(l, cfg.Address, cfg.IPFS.Ports, devMode,
-		c, models.NewHostedIPFSNetworkManager(dbm.DB))
+	o, err := orchestrator.New(l, cfg.Address, cfg.IPFS, devMode, c, networks)
 	if err != nil {
 		fatal(err.Error())
 	}
@@ -86,12 +112,18 @@ func runDaemon(configPath string, devMode bool, args []string) {
 
 	// initialize delegator
 	println("initializing delegator")
+	wakeTimeout, err := time.ParseDuration(cfg.IPFS.Hibernation.WakeTimeout)
+	if err != nil {
+		fatalf("invalid wake timeout: %s", err.Error())
+	}
 	dl := delegator.New(l, delegator.EngineOpts{
 		Version:        Version,
 		DevMode:        devMode,
 		RequestTimeout: 30 * time.Second,
 		JWTKey:         []byte(cfg.Delegator.JWTKey),
-	}, o.Registry, models.NewHostedIPFSNetworkManager(dbm.DB))
+		Waker:          o,
+		WakeTimeout:    wakeTimeout,
+	}, o.Registry, networks)
 
 	// catch interrupts
 	ctx, cancel := context.WithCancel(context.Background())
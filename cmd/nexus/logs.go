@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/RTradeLtd/Nexus/client"
+	"github.com/RTradeLtd/Nexus/config"
+	"github.com/RTradeLtd/Nexus/operations"
+)
+
+func runLogs(configPath string, devMode bool, args []string) {
+	var (
+		flags  = flag.NewFlagSet("logs", flag.ExitOnError)
+		follow = flags.Bool("f", false,
+			"follow node output")
+		tail = flags.Int("tail", 0,
+			"number of most recent lines to show - shows all output if unset")
+		since = flags.Duration("since", 0,
+			"show output from this long ago, for example '10m'")
+	)
+
+	// network name is allowed before flags
+	var network string
+	if len(args) > 0 && len(args[0]) > 0 && args[0][0] != '-' {
+		network, args = args[0], args[1:]
+	}
+	flags.Parse(args)
+	if network == "" {
+		if flags.NArg() < 1 {
+			fatal("network name required")
+		}
+		network = flags.Arg(0)
+	}
+
+	// load configuration
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		fatal(err.Error())
+	}
+
+	c, err := client.New(cfg.API, devMode)
+	if err != nil {
+		fatal(err.Error())
+	}
+	defer c.Close()
+
+	var req = &operations.LogsRequest{
+		Network: network,
+		Tail:    int64(*tail),
+		Follow:  *follow,
+	}
+	if *since > 0 {
+		req.Since = time.Now().Add(-*since).Unix()
+	}
+	stream, err := c.NetworkLogs(context.Background(), req)
+	if err != nil {
+		fatal(err.Error())
+	}
+	for {
+		line, err := stream.Recv()
+		if err == io.EOF {
+			return
+		} else if err != nil {
+			fatal(err.Error())
+		}
+		fmt.Fprintln(os.Stdout, line.Text)
+	}
+}
@@ -28,7 +28,16 @@ COMMANDS:
 
   init        initialize configuration
 	daemon      spin up the Nexus daemon and related processes
+	            use '-memory' in dev mode to simulate nodes without Docker -
+	            requires a build with '-tags memory'
 	version     display program version
+	logs        display output of a network's node
+	            usage: logs <network> [-f] [-tail n] [-since duration]
+	backup      archive a network's node data in the daemon's backup directory
+	            usage: backup <network>
+	restore     rebuild a network's node from an archive in the daemon's
+	            backup directory
+	            usage: restore <network> <archive>
 
 	dev         [DEV] utilities for development purposes
 	ctl         [EXPERIMENTAL] interact with daemon via a low-level client
@@ -67,6 +76,17 @@ func main() {
 		case "daemon":
 			runDaemon(*configPath, *devMode, args[1:])
 			return
+		// read node output
+		case "logs":
+			runLogs(*configPath, *devMode, args[1:])
+			return
+		// back up and restore node data
+		case "backup":
+			runBackup(*configPath, *devMode, args[1:])
+			return
+		case "restore":
+			runRestore(*configPath, *devMode, args[1:])
+			return
 		// run ctl
 		case "ctl":
 			if len(args) > 1 && (args[1] == "-pretty" || args[1] == "--pretty") {
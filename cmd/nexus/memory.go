@@ -0,0 +1,15 @@
+//go:build memory
+// +build memory
+
+package main
+
+import (
+	"github.com/RTradeLtd/Nexus/ipfs"
+	"github.com/RTradeLtd/Nexus/ipfs/mock"
+)
+
+// the in-memory node client is a development tool, and is only linked into
+// binaries built with the 'memory' tag
+func init() {
+	newMemoryNodeClient = func() ipfs.NodeClient { return mock.NewMemoryNodeClient() }
+}
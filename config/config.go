@@ -27,10 +27,37 @@ type IPFSOrchestratorConfig struct {
 
 // IPFS configures settings relevant to IPFS nodes
 type IPFS struct {
-	Version       string `json:"version"`
+	Version string `json:"version"`
+	// Image optionally overrides the image run by nodes that are not pinned to a
+	// version, for example to pin it by digest as "ipfs/go-ipfs@sha256:<hex>".
+	// It should run the declared Version of go-ipfs.
+	Image string `json:"image"`
+	// ImageTarball is an optional path to an image archive, as created by
+	// "docker save", that is loaded if the default image is not present
+	// locally. This allows nodes to run on hosts without registry access.
+	ImageTarball string `json:"image_tarball"`
+
 	DataDirectory string `json:"data_dir"`
 	ModePerm      string `json:"perm_mode"`
+
+	// NodeConfig overrides the default go-ipfs configuration of nodes, and
+	// NetworkNodeConfigs overrides it further for specific networks. Overrides
+	// have the structure of ipfs.NodeConfig, with only the fields to change set,
+	// for example {"Swarm": {"ConnMgr": {"HighWater": 2000}}}.
+	NodeConfig         json.RawMessage            `json:"node_config"`
+	NetworkNodeConfigs map[string]json.RawMessage `json:"network_node_configs"`
+
+	// GCWatermark is the percentage of a node's storage limit at which garbage
+	// collection is triggered, and InitProfile is the comma-separated list of
+	// go-ipfs configuration profiles that new repos are initialized with. They
+	// default to 90 and "server" respectively if unset.
+	GCWatermark int    `json:"gc_watermark"`
+	InitProfile string `json:"init_profile"`
+
 	Ports         `json:"ports"`
+	Capacity      `json:"capacity"`
+	Hibernation   `json:"hibernation"`
+	RestartPolicy `json:"restart_policy"`
 }
 
 // Ports declares port-range configuration for IPFS nodes. Elements of each
@@ -41,6 +68,52 @@ type Ports struct {
 	Gateway []string `json:"gateway"`
 }
 
+// Capacity declares the resources this host can provide to IPFS nodes. A zero
+// value for a resource means it is not limited. Overcommit ratios allow more of
+// a resource to be allocated than the host has - for example, a ratio of 2
+// allows twice the declared number of CPUs to be allocated.
+type Capacity struct {
+	CPUs     int `json:"cpus"`
+	MemoryGB int `json:"memory_gb"`
+	DiskGB   int `json:"disk_gb"`
+
+	CPUOvercommit    float64 `json:"cpu_overcommit"`
+	MemoryOvercommit float64 `json:"memory_overcommit"`
+	DiskOvercommit   float64 `json:"disk_overcommit"`
+}
+
+// Hibernation configures the stopping of idle nodes. Durations are of the form
+// accepted by time.ParseDuration, such as "2h45m".
+type Hibernation struct {
+	// IdleTimeout is how long a node can go without requests before it is
+	// stopped. An empty value disables hibernation.
+	IdleTimeout string `json:"idle_timeout"`
+
+	// NetworkIdleTimeouts overrides IdleTimeout for specific networks. A value
+	// of "0" disables hibernation for that network.
+	NetworkIdleTimeouts map[string]string `json:"network_idle_timeouts"`
+
+	// WakeTimeout is how long requests for a hibernated network are held while
+	// its node starts up
+	WakeTimeout string `json:"wake_timeout"`
+}
+
+// RestartPolicy configures the automatic restarting of nodes that stop
+// unexpectedly or fail liveness probes. Durations are of the form accepted by
+// time.ParseDuration, such as "2h45m".
+type RestartPolicy struct {
+	// MinBackoff is the delay between the first restarts of a node, which
+	// doubles with each further restart up to MaxBackoff
+	MinBackoff string `json:"min_backoff"`
+	MaxBackoff string `json:"max_backoff"`
+
+	// MaxRestarts is the number of restarts allowed within Window before a node
+	// is considered to be crash-looping, after which it is no longer restarted
+	// until an operator intervenes. A negative value allows unlimited restarts.
+	MaxRestarts int    `json:"max_restarts"`
+	Window      string `json:"window"`
+}
+
 // API declares configuration for the orchestrator daemon's gRPC API
 type API struct {
 	Host string `json:"host"`
@@ -156,6 +229,9 @@ func (c *IPFSOrchestratorConfig) SetDefaults(dev bool) {
 	if c.IPFS.ModePerm == "" {
 		c.IPFS.ModePerm = "0700"
 	}
+	if c.IPFS.NodeConfig == nil {
+		c.IPFS.NodeConfig = json.RawMessage("{}")
+	}
 	if c.IPFS.Ports.Swarm == nil {
 		c.IPFS.Ports.Swarm = []string{"4001-5000"}
 	}
@@ -165,4 +241,28 @@ func (c *IPFSOrchestratorConfig) SetDefaults(dev bool) {
 	if c.IPFS.Ports.Gateway == nil {
 		c.IPFS.Ports.Gateway = []string{"8001-9000"}
 	}
+	if c.IPFS.Capacity.CPUOvercommit == 0 {
+		c.IPFS.Capacity.CPUOvercommit = 1
+	}
+	if c.IPFS.Capacity.MemoryOvercommit == 0 {
+		c.IPFS.Capacity.MemoryOvercommit = 1
+	}
+	if c.IPFS.Capacity.DiskOvercommit == 0 {
+		c.IPFS.Capacity.DiskOvercommit = 1
+	}
+	if c.IPFS.Hibernation.WakeTimeout == "" {
+		c.IPFS.Hibernation.WakeTimeout = "30s"
+	}
+	if c.IPFS.RestartPolicy.MinBackoff == "" {
+		c.IPFS.RestartPolicy.MinBackoff = "10s"
+	}
+	if c.IPFS.RestartPolicy.MaxBackoff == "" {
+		c.IPFS.RestartPolicy.MaxBackoff = "5m"
+	}
+	if c.IPFS.RestartPolicy.MaxRestarts == 0 {
+		c.IPFS.RestartPolicy.MaxRestarts = 5
+	}
+	if c.IPFS.RestartPolicy.Window == "" {
+		c.IPFS.RestartPolicy.Window = "1h"
+	}
 }
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/RTradeLtd/Nexus/config"
+	"github.com/RTradeLtd/Nexus/operations"
 	"github.com/RTradeLtd/Nexus/orchestrator"
 	"github.com/RTradeLtd/grpc/middleware"
 	"github.com/RTradeLtd/grpc/nexus"
@@ -86,6 +87,7 @@ func (d *Daemon) Run(ctx context.Context, cfg config.API) error {
 	// initialize server
 	server := grpc.NewServer(serverOpts...)
 	nexus.RegisterServiceServer(server, d)
+	operations.RegisterOperationsServer(server, d)
 
 	// interrupt server gracefully if context is cancelled
 	go func() {
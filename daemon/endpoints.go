@@ -3,11 +3,18 @@ package daemon
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 
 	"github.com/RTradeLtd/grpc/nexus"
+
+	"github.com/RTradeLtd/Nexus/ipfs"
+	"github.com/RTradeLtd/Nexus/operations"
+	"github.com/RTradeLtd/Nexus/orchestrator"
+	"github.com/RTradeLtd/Nexus/registry"
 )
 
 // Ping is useful for checking client-server connection
@@ -18,39 +25,243 @@ func (d *Daemon) Ping(
 	return &nexus.Empty{}, nil
 }
 
-// StartNetwork brings a node for the requested network online
+// StartNetwork queues a job to bring a node for the requested network online,
+// and returns without waiting for it. The job's ID is provided in the
+// response's "job_id" header, and the network's details are provided in the
+// job's result once it succeeds. The job can be polled using GetJob
 func (d *Daemon) StartNetwork(
 	ctx context.Context,
 	req *nexus.NetworkRequest,
 ) (*nexus.StartNetworkResponse, error) {
-	n, err := d.o.NetworkUp(ctx, req.GetNetwork())
-	if err != nil {
-		return nil, grpc.Errorf(codes.Internal, err.Error())
-	}
 
-	return &nexus.StartNetworkResponse{
-		PeerId:    n.PeerID,
-		SwarmPort: n.SwarmPort,
-		SwarmKey:  n.SwarmKey,
-	}, nil
+	if err := d.submitJob(ctx, orchestrator.OperationNetworkUp, req.GetNetwork()); err != nil {
+		return nil, err
+	}
+	return &nexus.StartNetworkResponse{}, nil
 }
 
-// UpdateNetwork updates the configuration of the given network
+// UpdateNetwork queues a job to update the configuration of the given network,
+// and returns without waiting for it. The job's ID is provided in the
+// response's "job_id" header. The job can be polled using GetJob
 func (d *Daemon) UpdateNetwork(
 	ctx context.Context,
 	req *nexus.NetworkRequest,
 ) (*nexus.Empty, error) {
 
-	return &nexus.Empty{}, d.o.NetworkUpdate(ctx, req.GetNetwork())
+	if err := d.submitJob(ctx, orchestrator.OperationNetworkUpdate, req.GetNetwork()); err != nil {
+		return nil, err
+	}
+	return &nexus.Empty{}, nil
 }
 
-// StopNetwork brings a node for the requested network offline
+// StopNetwork queues a job to bring a node for the requested network offline,
+// and returns without waiting for it. The job's ID is provided in the
+// response's "job_id" header. The job can be polled using GetJob
 func (d *Daemon) StopNetwork(
 	ctx context.Context,
 	req *nexus.NetworkRequest,
 ) (*nexus.Empty, error) {
 
-	return &nexus.Empty{}, d.o.NetworkDown(ctx, req.GetNetwork())
+	if err := d.submitJob(ctx, orchestrator.OperationNetworkDown, req.GetNetwork()); err != nil {
+		return nil, err
+	}
+	return &nexus.Empty{}, nil
+}
+
+// RestartNetwork queues a job to restart the node for the requested network in
+// place, and returns without waiting for it. The job's ID is provided in the
+// response's "job_id" header. The job can be polled using GetJob
+func (d *Daemon) RestartNetwork(
+	ctx context.Context,
+	req *operations.NetworkRequest,
+) (*operations.Empty, error) {
+
+	if err := d.submitJob(ctx, orchestrator.OperationNetworkRestart, req.Network); err != nil {
+		return nil, err
+	}
+	return &operations.Empty{}, nil
+}
+
+// PauseNetwork suspends the node for the requested network
+func (d *Daemon) PauseNetwork(
+	ctx context.Context,
+	req *operations.NetworkRequest,
+) (*operations.Empty, error) {
+
+	if err := d.o.NetworkPause(ctx, req.Network); err != nil {
+		if err == orchestrator.ErrOperationInProgress {
+			return nil, grpc.Errorf(codes.Aborted, err.Error())
+		}
+		return nil, grpc.Errorf(codes.Internal, err.Error())
+	}
+	return &operations.Empty{}, nil
+}
+
+// ResumeNetwork resumes the paused node for the requested network
+func (d *Daemon) ResumeNetwork(
+	ctx context.Context,
+	req *operations.NetworkRequest,
+) (*operations.Empty, error) {
+
+	if err := d.o.NetworkResume(ctx, req.Network); err != nil {
+		if err == orchestrator.ErrOperationInProgress {
+			return nil, grpc.Errorf(codes.Aborted, err.Error())
+		}
+		return nil, grpc.Errorf(codes.Internal, err.Error())
+	}
+	return &operations.Empty{}, nil
+}
+
+// UpgradeNetwork upgrades the node for the requested network to the requested
+// go-ipfs version, rolling it back if the upgrade fails. An empty version
+// upgrades the node to the default version.
+func (d *Daemon) UpgradeNetwork(
+	ctx context.Context,
+	req *operations.UpgradeRequest,
+) (*operations.Empty, error) {
+
+	if err := d.o.NetworkUpgrade(ctx, req.Network, req.Version); err != nil {
+		if err == orchestrator.ErrOperationInProgress {
+			return nil, grpc.Errorf(codes.Aborted, err.Error())
+		}
+		return nil, grpc.Errorf(codes.Internal, err.Error())
+	}
+	return &operations.Empty{}, nil
+}
+
+// BackupNetwork archives the node data of the requested network into the
+// orchestrator's backup directory. The archive's manifest is provided as JSON
+func (d *Daemon) BackupNetwork(
+	ctx context.Context,
+	req *operations.NetworkRequest,
+) (*operations.BackupResponse, error) {
+
+	b, err := d.o.NetworkBackup(ctx, req.Network)
+	if err != nil {
+		if err == orchestrator.ErrOperationInProgress {
+			return nil, grpc.Errorf(codes.Aborted, err.Error())
+		}
+		return nil, grpc.Errorf(codes.Internal, err.Error())
+	}
+	mb, err := json.Marshal(b.Manifest)
+	if err != nil {
+		return nil, grpc.Errorf(codes.Internal, err.Error())
+	}
+	return &operations.BackupResponse{
+		Archive:  b.Archive,
+		Manifest: mb,
+	}, nil
+}
+
+// RestoreNetwork rebuilds the node for the requested network from the requested
+// archive in the orchestrator's backup directory
+func (d *Daemon) RestoreNetwork(
+	ctx context.Context,
+	req *operations.RestoreRequest,
+) (*operations.Empty, error) {
+
+	if err := d.o.NetworkRestore(ctx, req.Network, req.Archive); err != nil {
+		if err == orchestrator.ErrOperationInProgress {
+			return nil, grpc.Errorf(codes.Aborted, err.Error())
+		}
+		return nil, grpc.Errorf(codes.Internal, err.Error())
+	}
+	return &operations.Empty{}, nil
+}
+
+// RotateSwarmKey replaces the swarm key of the requested network and restarts
+// its node with the new key, which is returned for distribution to the
+// network's members
+func (d *Daemon) RotateSwarmKey(
+	ctx context.Context,
+	req *operations.NetworkRequest,
+) (*operations.SwarmKeyResponse, error) {
+
+	key, err := d.o.RotateSwarmKey(ctx, req.Network)
+	if err != nil {
+		if err == orchestrator.ErrOperationInProgress {
+			return nil, grpc.Errorf(codes.Aborted, err.Error())
+		}
+		return nil, grpc.Errorf(codes.Internal, err.Error())
+	}
+	return &operations.SwarmKeyResponse{
+		SwarmKey: key,
+	}, nil
+}
+
+// AddBootstrapPeers adds the requested peers to the bootstrap list of the
+// requested network's node, recreating its container so that its labels stay
+// in sync, and returns the network's updated bootstrap peers
+func (d *Daemon) AddBootstrapPeers(
+	ctx context.Context,
+	req *operations.BootstrapPeersRequest,
+) (*operations.BootstrapPeersResponse, error) {
+
+	peers, err := d.o.AddBootstrapPeers(ctx, req.Network, req.Peers)
+	if err != nil {
+		if err == orchestrator.ErrOperationInProgress {
+			return nil, grpc.Errorf(codes.Aborted, err.Error())
+		}
+		return nil, grpc.Errorf(codes.Internal, err.Error())
+	}
+	return &operations.BootstrapPeersResponse{
+		Peers: peers,
+	}, nil
+}
+
+// RemoveBootstrapPeers removes the requested peers from the bootstrap list of
+// the requested network's node, recreating its container so that its labels
+// stay in sync, and returns the network's updated bootstrap peers
+func (d *Daemon) RemoveBootstrapPeers(
+	ctx context.Context,
+	req *operations.BootstrapPeersRequest,
+) (*operations.BootstrapPeersResponse, error) {
+
+	peers, err := d.o.RemoveBootstrapPeers(ctx, req.Network, req.Peers)
+	if err != nil {
+		if err == orchestrator.ErrOperationInProgress {
+			return nil, grpc.Errorf(codes.Aborted, err.Error())
+		}
+		return nil, grpc.Errorf(codes.Internal, err.Error())
+	}
+	return &operations.BootstrapPeersResponse{
+		Peers: peers,
+	}, nil
+}
+
+// GetJob retrieves the status of the requested job. Results of network up
+// jobs are provided as JSON
+func (d *Daemon) GetJob(
+	ctx context.Context,
+	req *operations.JobRequest,
+) (*operations.JobStatusResponse, error) {
+
+	j, err := d.o.GetJob(req.JobID)
+	if err != nil {
+		if err == orchestrator.ErrJobNotFound {
+			return nil, grpc.Errorf(codes.NotFound, err.Error())
+		}
+		return nil, grpc.Errorf(codes.Internal, err.Error())
+	}
+
+	var result []byte
+	if j.Result != nil {
+		if result, err = json.Marshal(j.Result); err != nil {
+			return nil, grpc.Errorf(codes.Internal, err.Error())
+		}
+	}
+
+	return &operations.JobStatusResponse{
+		JobID:      j.ID,
+		Network:    j.Network,
+		Operation:  string(j.Operation),
+		State:      string(j.State),
+		Error:      j.Error,
+		Result:     result,
+		QueuedAt:   unixTime(j.QueuedAt),
+		StartedAt:  unixTime(j.StartedAt),
+		FinishedAt: unixTime(j.FinishedAt),
+	}, nil
 }
 
 // RemoveNetwork removes assets for requested node
@@ -59,7 +270,13 @@ func (d *Daemon) RemoveNetwork(
 	req *nexus.NetworkRequest,
 ) (*nexus.Empty, error) {
 
-	return &nexus.Empty{}, d.o.NetworkRemove(ctx, req.GetNetwork())
+	if err := d.o.NetworkRemove(ctx, req.GetNetwork()); err != nil {
+		if err == orchestrator.ErrOperationInProgress {
+			return nil, grpc.Errorf(codes.Aborted, err.Error())
+		}
+		return nil, err
+	}
+	return &nexus.Empty{}, nil
 }
 
 // NetworkStats retrieves stats about the requested node
@@ -97,7 +314,16 @@ func (d *Daemon) NetworkDiagnostics(
 	if err != nil {
 		return nil, grpc.Errorf(codes.Internal, err.Error())
 	}
-	sb, err := json.Marshal(s.NodeStats)
+	sb, err := json.Marshal(struct {
+		ipfs.NodeStats
+		Host         registry.Utilization `json:"host"`
+		Hibernated   bool                 `json:"hibernated"`
+		LastActive   time.Time            `json:"last_active"`
+		Health       registry.Health      `json:"health"`
+		CrashLooping bool                 `json:"crash_looping"`
+		Restarts     int                  `json:"restarts"`
+	}{s.NodeStats, s.HostUtilization, s.Hibernated, s.LastActive, s.Health,
+		s.CrashLooping, s.Restarts})
 	if err != nil {
 		return nil, grpc.Errorf(codes.Internal, err.Error())
 	}
@@ -107,3 +333,99 @@ func (d *Daemon) NetworkDiagnostics(
 		Stats:    sb,
 	}, nil
 }
+
+// StreamNetworkStats streams resource usage samples of the requested network's
+// node at the requested interval, until the client disconnects or the node
+// stops
+func (d *Daemon) StreamNetworkStats(
+	req *operations.StatsRequest,
+	stream operations.StatsStreamServer,
+) error {
+
+	var interval = time.Duration(req.IntervalSeconds) * time.Second
+	samples, err := d.o.NetworkStats(stream.Context(), req.Network, interval)
+	if err != nil {
+		return grpc.Errorf(codes.Internal, err.Error())
+	}
+
+	for s := range samples {
+		if err := stream.Send(&operations.StatsSample{
+			Network:         req.Network,
+			Time:            unixTime(s.Time),
+			CPUPercent:      s.CPUPercent,
+			OnlineCPUs:      s.OnlineCPUs,
+			MemoryUsage:     s.MemoryUsage,
+			MemoryLimit:     s.MemoryLimit,
+			MemoryPercent:   s.MemoryPercent,
+			NetworkRxBytes:  s.NetworkRxBytes,
+			NetworkTxBytes:  s.NetworkTxBytes,
+			BlockReadBytes:  s.BlockReadBytes,
+			BlockWriteBytes: s.BlockWriteBytes,
+			PIDs:            s.PIDs,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NetworkLogs streams output from the requested network's node. If follow is
+// requested, output is streamed until the client disconnects or the node stops
+func (d *Daemon) NetworkLogs(
+	req *operations.LogsRequest,
+	stream operations.LogsStreamServer,
+) error {
+
+	lines, err := d.o.NetworkLogs(stream.Context(), req.Network, ipfs.LogOptions{
+		Since:  fromUnixTime(req.Since),
+		Until:  fromUnixTime(req.Until),
+		Tail:   int(req.Tail),
+		Follow: req.Follow,
+	})
+	if err != nil {
+		return grpc.Errorf(codes.Internal, err.Error())
+	}
+
+	for l := range lines {
+		if err := stream.Send(&operations.LogLine{
+			Network: req.Network,
+			Time:    unixTime(l.Time),
+			Text:    l.Text,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// submitJob queues a job for the given operation on the given network, and
+// provides the job's ID in the response's "job_id" header
+func (d *Daemon) submitJob(ctx context.Context, op orchestrator.JobOperation, network string) error {
+	j, err := d.o.SubmitJob(op, network)
+	if err == orchestrator.ErrOperationInProgress {
+		return grpc.Errorf(codes.Aborted, err.Error())
+	} else if err != nil {
+		return grpc.Errorf(codes.Internal, err.Error())
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs(operations.JobIDHeader, j.ID)); err != nil {
+		d.l.Warnw("failed to set job header", "error", err, "job_id", j.ID)
+	}
+	return nil
+}
+
+// unixTime converts the given time to a Unix timestamp, or 0 if it is unset
+func unixTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// fromUnixTime converts the given Unix timestamp to a time, or the zero time if
+// it is unset
+func fromUnixTime(t int64) time.Time {
+	if t == 0 {
+		return time.Time{}
+	}
+	return time.Unix(t, 0)
+}
@@ -2,6 +2,7 @@ package delegator
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httputil"
@@ -31,12 +32,21 @@ type Engine struct {
 
 	networks temporal.PrivateNetworks
 
+	waker       NetworkWaker
+	wakeTimeout time.Duration
+
 	timeout   time.Duration
 	keyLookup jwt.Keyfunc
 	timeFunc  func() time.Time
 	version   string
 }
 
+// NetworkWaker starts the nodes of hibernated networks. It is implemented by
+// orchestrator.Orchestrator
+type NetworkWaker interface {
+	Wake(ctx context.Context, network string) error
+}
+
 // EngineOpts denotes options for the delegator engine
 type EngineOpts struct {
 	Version string
@@ -44,6 +54,12 @@ type EngineOpts struct {
 
 	RequestTimeout time.Duration
 	JWTKey         []byte
+
+	// Waker, if provided, is used to start hibernated networks when requests
+	// are made to them. Requests are held for up to WakeTimeout while nodes
+	// start.
+	Waker       NetworkWaker
+	WakeTimeout time.Duration
 }
 
 // New instantiates a new delegator engine
@@ -58,6 +74,9 @@ func New(l *zap.SugaredLogger, opts EngineOpts,
 	if opts.RequestTimeout == 0 {
 		opts.RequestTimeout = 30 * time.Second
 	}
+	if opts.WakeTimeout == 0 {
+		opts.WakeTimeout = 30 * time.Second
+	}
 
 	return &Engine{
 		l:     l.Named("delegator"),
@@ -66,6 +85,9 @@ func New(l *zap.SugaredLogger, opts EngineOpts,
 
 		networks: networks,
 
+		waker:       opts.Waker,
+		wakeTimeout: opts.WakeTimeout,
+
 		timeout:   opts.RequestTimeout,
 		version:   opts.Version,
 		keyLookup: func(t *jwt.Token) (interface{}, error) { return opts.JWTKey, nil },
@@ -105,8 +127,9 @@ func (e *Engine) Run(ctx context.Context, opts config.Delegator) error {
 	var srv = &http.Server{
 		Handler: r,
 
-		Addr:         opts.Host + ":" + opts.Port,
-		WriteTimeout: e.timeout,
+		Addr: opts.Host + ":" + opts.Port,
+		// allow time for hibernated networks to start up
+		WriteTimeout: e.timeout + e.wakeTimeout,
 		ReadTimeout:  e.timeout,
 	}
 
@@ -229,6 +252,16 @@ func (e *Engine) Redirect(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// record activity and start node if network is hibernated
+	e.reg.Touch(n.NetworkID)
+	if e.reg.Hibernated(n.NetworkID) {
+		if err := e.wake(r.Context(), n.NetworkID); err != nil {
+			w.Header().Set("Retry-After", "30")
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+	}
+
 	// set up target
 	var protocol string
 	if r.URL.Scheme != "" {
@@ -259,6 +292,32 @@ func (e *Engine) Redirect(w http.ResponseWriter, r *http.Request) {
 	proxy.ServeHTTP(w, r)
 }
 
+// wake starts the given hibernated network, waiting for up to the configured
+// wake timeout for its node to be ready
+func (e *Engine) wake(ctx context.Context, network string) error {
+	if e.waker == nil {
+		return errors.New("network is hibernated")
+	}
+	ctx, cancel := context.WithTimeout(ctx, e.wakeTimeout)
+	defer cancel()
+
+	var start = time.Now()
+	if err := e.waker.Wake(ctx, network); err != nil {
+		e.l.Warnw("failed to wake hibernated network",
+			"error", err,
+			"network", network,
+			"wake.duration", time.Since(start))
+		if err == context.DeadlineExceeded {
+			return errors.New("network is starting up")
+		}
+		return fmt.Errorf("failed to start hibernated network: %s", err.Error())
+	}
+	e.l.Infow("woke hibernated network for request",
+		"network", network,
+		"wake.duration", time.Since(start))
+	return nil
+}
+
 // Status reports on proxy status
 func (e *Engine) Status(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
@@ -58,7 +58,7 @@ func TestEngine_Run(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			var networks = &mock.FakePrivateNetworks{}
-			var e = New(l, EngineOpts{"test", true, time.Minute, []byte("hello")}, nil, networks)
+			var e = New(l, EngineOpts{"test", true, time.Minute, []byte("hello"), nil, 0}, nil, networks)
 			var ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
 			defer cancel()
 			if err := e.Run(ctx, tt.args.opts); (err != nil) != tt.wantErr {
@@ -88,7 +88,7 @@ func TestEngine_NetworkContext(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			var networks = &mock.FakePrivateNetworks{}
-			var e = New(l, EngineOpts{"test", true, time.Second, []byte("hello")},
+			var e = New(l, EngineOpts{"test", true, time.Second, []byte("hello"), nil, 0},
 				registry.New(l, config.New().Ports, &ipfs.NodeInfo{
 					NetworkID: tt.args.nodeName,
 				}), networks)
@@ -211,7 +211,7 @@ func TestEngine_Redirect(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			var networks = &mock.FakePrivateNetworks{}
-			var e = New(l, EngineOpts{"test", true, time.Second, defaultTestKey},
+			var e = New(l, EngineOpts{"test", true, time.Second, defaultTestKey, nil, 0},
 				registry.New(l, config.New().Ports), networks)
 
 			var route = chi.NewRouteContext()
@@ -243,10 +243,88 @@ func TestEngine_Redirect(t *testing.T) {
 	}
 }
 
+type fakeWaker func(ctx context.Context, network string) error
+
+func (f fakeWaker) Wake(ctx context.Context, network string) error { return f(ctx, network) }
+
+func TestEngine_Redirect_hibernated(t *testing.T) {
+	var l, _ = log.NewLogger("", true)
+	type fields struct {
+		waker func(reg *registry.NodeRegistry) NetworkWaker
+	}
+	tests := []struct {
+		name     string
+		fields   fields
+		wantCode int
+		wantWoke bool
+	}{
+		{"no waker",
+			fields{func(*registry.NodeRegistry) NetworkWaker { return nil }},
+			http.StatusServiceUnavailable, false},
+		{"wake failed",
+			fields{func(*registry.NodeRegistry) NetworkWaker {
+				return fakeWaker(func(context.Context, string) error { return errors.New("oh no") })
+			}},
+			http.StatusServiceUnavailable, false},
+		{"wake timed out",
+			fields{func(*registry.NodeRegistry) NetworkWaker {
+				return fakeWaker(func(ctx context.Context, _ string) error {
+					<-ctx.Done()
+					return ctx.Err()
+				})
+			}},
+			http.StatusServiceUnavailable, false},
+		{"OK: woken",
+			fields{func(reg *registry.NodeRegistry) NetworkWaker {
+				return fakeWaker(func(_ context.Context, network string) error { return reg.Wake(network) })
+			}},
+			http.StatusBadGateway, true}, // badgateway because proxy points to nothing
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				node = &ipfs.NodeInfo{NetworkID: "bobheadxi", Ports: ipfs.NodePorts{Swarm: "5000"}}
+				reg  = registry.New(l, config.New().Ports, node)
+				e    = New(l, EngineOpts{"test", true, time.Second, defaultTestKey,
+					tt.fields.waker(reg), 10 * time.Millisecond}, reg, &mock.FakePrivateNetworks{})
+			)
+			defer reg.Close()
+			if err := reg.Hibernate(node.NetworkID); err != nil {
+				t.Fatal(err)
+			}
+			before, _ := reg.LastActive(node.NetworkID)
+
+			var route = chi.NewRouteContext()
+			route.URLParams.Add(string(keyFeature), "swarm")
+			var (
+				req = httptest.NewRequest("GET", "/", nil).
+					WithContext(
+						context.WithValue(
+							context.WithValue(
+								context.Background(),
+								keyNetwork, node),
+							chi.RouteCtxKey, route))
+				rec = httptest.NewRecorder()
+			)
+			e.Redirect(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Logf("received '%v'", rec.Result().Status)
+				t.Errorf("expected status '%d', found '%d'", tt.wantCode, rec.Code)
+			}
+			if woke := !reg.Hibernated(node.NetworkID); woke != tt.wantWoke {
+				t.Errorf("expected woken = %v", tt.wantWoke)
+			}
+			if after, _ := reg.LastActive(node.NetworkID); !after.After(before) {
+				t.Error("expected activity to be recorded")
+			}
+		})
+	}
+}
+
 func TestEngine_Status(t *testing.T) {
 	var l, _ = log.NewLogger("", true)
 	var networks = &mock.FakePrivateNetworks{}
-	var e = New(l, EngineOpts{"test", true, time.Second, []byte("hello")}, registry.New(l, config.New().Ports), networks)
+	var e = New(l, EngineOpts{"test", true, time.Second, []byte("hello"), nil, 0}, registry.New(l, config.New().Ports), networks)
 	var req = httptest.NewRequest("GET", "/", nil)
 	var rec = httptest.NewRecorder()
 	e.Status(rec, req)
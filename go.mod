@@ -36,15 +36,15 @@ require (
 	github.com/jinzhu/inflection v0.0.0-20180308033659-04140366298a // indirect
 	github.com/jinzhu/now v0.0.0-20180511015916-ed742868f2ae // indirect
 	github.com/lib/pq v1.0.0 // indirect
-	github.com/libp2p/go-libp2p-crypto v2.0.1+incompatible // indirect
-	github.com/libp2p/go-libp2p-peer v2.4.0+incompatible // indirect
+	github.com/libp2p/go-libp2p-crypto v2.0.1+incompatible
+	github.com/libp2p/go-libp2p-peer v2.4.0+incompatible
 	github.com/mattn/go-colorable v0.0.9 // indirect
 	github.com/mattn/go-isatty v0.0.4 // indirect
 	github.com/mattn/go-sqlite3 v1.10.0 // indirect
 	github.com/minio/blake2b-simd v0.0.0-20160723061019-3f5f724cb5b1 // indirect
 	github.com/minio/sha256-simd v0.0.0-20181005183134-51976451ce19 // indirect
 	github.com/mr-tron/base58 v1.1.0 // indirect
-	github.com/multiformats/go-multiaddr v1.3.0 // indirect
+	github.com/multiformats/go-multiaddr v1.3.0
 	github.com/multiformats/go-multihash v1.0.8 // indirect
 	github.com/opencontainers/go-digest v1.0.0-rc1 // indirect
 	github.com/opencontainers/image-spec v1.0.1 // indirect
@@ -0,0 +1,450 @@
+package ipfs
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+
+	"github.com/RTradeLtd/Nexus/log"
+)
+
+const (
+	// backupManifest is the name of the manifest in backup archives, which is
+	// always the first entry
+	backupManifest = "manifest.json"
+	// backupRepo is the directory in backup archives that holds the contents
+	// of the node's data directory
+	backupRepo = "repo"
+)
+
+// backupExcludes are files in a node's data directory that only apply to a
+// running daemon, and are not backed up
+var backupExcludes = map[string]bool{
+	"repo.lock": true,
+	"api":       true,
+}
+
+// BackupManifest describes a node backup
+type BackupManifest struct {
+	NetworkID string `json:"network_id"`
+	PeerID    string `json:"peer_id"`
+	// SwarmKeyHash is the hex-encoded SHA256 hash of the network's swarm key,
+	// which allows a backup to be matched to its network without storing the
+	// key in the manifest
+	SwarmKeyHash string `json:"swarm_key_hash"`
+	// Version is the go-ipfs version the node ran when it was backed up
+	Version string    `json:"version"`
+	Created time.Time `json:"created"`
+}
+
+// SwarmKeyHash generates the hash of the given swarm key recorded in backup
+// manifests. Valid keys are normalized first, so that the hash does not depend
+// on the key's encoding.
+func SwarmKeyHash(key []byte) string {
+	if k, err := ParseSwarmKey(key); err == nil {
+		key = k.Bytes()
+	}
+	var sum = sha256.Sum256(key)
+	return hex.EncodeToString(sum[:])
+}
+
+// ReadBackupManifest reads the manifest of the given backup archive
+func ReadBackupManifest(r io.Reader) (BackupManifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return BackupManifest{}, fmt.Errorf("invalid backup archive: %s", err.Error())
+	}
+	defer gz.Close()
+	return readBackupManifest(tar.NewReader(gz))
+}
+
+func readBackupManifest(tr *tar.Reader) (BackupManifest, error) {
+	var m BackupManifest
+	hdr, err := tr.Next()
+	if err != nil {
+		return m, fmt.Errorf("invalid backup archive: %s", err.Error())
+	}
+	if hdr.Name != backupManifest {
+		return m, fmt.Errorf("invalid backup archive: expected %s, found '%s'", backupManifest, hdr.Name)
+	}
+	if err := json.NewDecoder(tr).Decode(&m); err != nil {
+		return m, fmt.Errorf("invalid backup manifest: %s", err.Error())
+	}
+	if m.NetworkID == "" || m.PeerID == "" || m.SwarmKeyHash == "" {
+		return m, errors.New("invalid backup manifest: network, peer ID and swarm key hash required")
+	}
+	return m, nil
+}
+
+func (c *Client) getRestoreDir(network string) string {
+	p, _ := filepath.Abs(filepath.Join(c.dataDir, fmt.Sprintf("/data/restores/%s", network)))
+	return p
+}
+
+// BackupNode writes a gzipped tar archive of the given network's data
+// directory to w, preceded by a manifest describing the node. Running nodes are
+// paused while the archive is written so that the repo is consistent, and
+// resumed afterwards. Nodes without a container are backed up as they are.
+func (c *Client) BackupNode(ctx context.Context, n *NodeInfo, w io.Writer) (BackupManifest, error) {
+	if n == nil || n.NetworkID == "" {
+		return BackupManifest{}, errors.New("invalid node")
+	}
+
+	var (
+		start = time.Now()
+		dir   = c.getDataDir(n.NetworkID)
+		l     = log.NewProcessLogger(c.l, "backup_node",
+			"network_id", n.NetworkID,
+			"docker_id", n.DockerID)
+	)
+
+	manifest, err := c.newBackupManifest(n)
+	if err != nil {
+		l.Errorw("failed to read node assets", "error", err)
+		return BackupManifest{}, err
+	}
+
+	// pause running node so that the repo does not change during the backup
+	if n.DockerID != "" {
+		state, err := c.containerState(ctx, n.DockerID)
+		if err != nil {
+			l.Errorw("failed to check node state", "error", err)
+			return BackupManifest{}, fmt.Errorf("failed to check node state: %s", err.Error())
+		}
+		if state.Running && !state.Paused {
+			if err := c.PauseNode(ctx, n); err != nil {
+				return BackupManifest{}, err
+			}
+			defer func() {
+				if err := c.ResumeNode(context.Background(), n); err != nil {
+					l.Errorw("failed to resume node after backup", "error", err)
+				}
+			}()
+		}
+	}
+
+	l.Info("writing backup archive")
+	if err := writeBackup(w, manifest, dir); err != nil {
+		l.Errorw("failed to write backup archive", "error", err)
+		return BackupManifest{}, fmt.Errorf("failed to write backup archive: %s", err.Error())
+	}
+
+	l.Infow("node backed up",
+		"peer_id", manifest.PeerID,
+		"backup.duration", time.Since(start))
+	return manifest, nil
+}
+
+// newBackupManifest describes the given node from the contents of its data
+// directory
+func (c *Client) newBackupManifest(n *NodeInfo) (BackupManifest, error) {
+	var dir = c.getDataDir(n.NetworkID)
+	cfg, err := getConfig(filepath.Join(dir, "config"))
+	if err != nil {
+		return BackupManifest{}, fmt.Errorf("failed to read node configuration: %s", err.Error())
+	}
+	key, err := ioutil.ReadFile(filepath.Join(dir, "swarm.key"))
+	if err != nil {
+		return BackupManifest{}, fmt.Errorf("failed to read swarm key: %s", err.Error())
+	}
+	var version = n.Version
+	if version == "" {
+		version = c.pinnedVersion(n.NetworkID)
+	}
+	return BackupManifest{
+		NetworkID:    n.NetworkID,
+		PeerID:       cfg.Identity.PeerID,
+		SwarmKeyHash: SwarmKeyHash(key),
+		Version:      version,
+		Created:      time.Now().UTC(),
+	}, nil
+}
+
+// writeBackup writes the given manifest and the contents of the given directory
+// to w as a gzipped tar archive
+func writeBackup(w io.Writer, manifest BackupManifest, dir string) error {
+	var (
+		gz = gzip.NewWriter(w)
+		tw = tar.NewWriter(gz)
+	)
+	b, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    backupManifest,
+		Mode:    0600,
+		Size:    int64(len(b)),
+		ModTime: manifest.Created,
+	}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(b); err != nil {
+		return err
+	}
+
+	if err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		if backupExcludes[rel] {
+			return nil
+		}
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = path.Join(backupRepo, filepath.ToSlash(rel))
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		_, err = io.CopyN(tw, f, info.Size())
+		f.Close()
+		return err
+	}); err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// extractBackup extracts the given backup archive into the given directory, and
+// returns its manifest. The contents of the node's data directory are extracted
+// into the "repo" subdirectory.
+func extractBackup(r io.Reader, dir string, mode os.FileMode) (BackupManifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return BackupManifest{}, fmt.Errorf("invalid backup archive: %s", err.Error())
+	}
+	defer gz.Close()
+	var tr = tar.NewReader(gz)
+	manifest, err := readBackupManifest(tr)
+	if err != nil {
+		return manifest, err
+	}
+
+	var repo = filepath.Join(dir, backupRepo)
+	if err := os.MkdirAll(repo, mode); err != nil {
+		return manifest, err
+	}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return manifest, nil
+		} else if err != nil {
+			return manifest, fmt.Errorf("invalid backup archive: %s", err.Error())
+		}
+
+		// only allow entries within the repo
+		var name = path.Clean(hdr.Name)
+		if name == backupRepo {
+			continue
+		}
+		if !strings.HasPrefix(name, backupRepo+"/") {
+			return manifest, fmt.Errorf("invalid backup archive: unexpected entry '%s'", hdr.Name)
+		}
+		var target = filepath.Join(dir, filepath.FromSlash(name))
+
+		var perm = os.FileMode(hdr.Mode).Perm()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, perm); err != nil {
+				return manifest, err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := os.MkdirAll(filepath.Dir(target), mode); err != nil {
+				return manifest, err
+			}
+			f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+			if err != nil {
+				return manifest, err
+			}
+			_, err = io.Copy(f, tr)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return manifest, err
+			}
+		case tar.TypeSymlink:
+			if filepath.IsAbs(hdr.Linkname) ||
+				!strings.HasPrefix(filepath.Join(filepath.Dir(target), hdr.Linkname), repo+string(filepath.Separator)) {
+				return manifest, fmt.Errorf("invalid backup archive: link '%s' leaves repo", hdr.Name)
+			}
+			if err := os.Symlink(hdr.Linkname, target); err != nil {
+				return manifest, err
+			}
+		default:
+			return manifest, fmt.Errorf("invalid backup archive: unsupported entry '%s'", hdr.Name)
+		}
+	}
+}
+
+// verifyBackup checks that the given extracted repo matches its manifest
+func verifyBackup(repo string, manifest BackupManifest) error {
+	cfg, err := getConfig(filepath.Join(repo, "config"))
+	if err != nil {
+		return fmt.Errorf("backup has no valid node configuration: %s", err.Error())
+	}
+	if cfg.Identity.PeerID != manifest.PeerID {
+		return fmt.Errorf("backup has peer ID '%s', manifest declares '%s'",
+			cfg.Identity.PeerID, manifest.PeerID)
+	}
+	key, err := ioutil.ReadFile(filepath.Join(repo, "swarm.key"))
+	if err != nil {
+		return fmt.Errorf("backup has no swarm key: %s", err.Error())
+	}
+	if SwarmKeyHash(key) != manifest.SwarmKeyHash {
+		return errors.New("backup swarm key does not match manifest")
+	}
+	return nil
+}
+
+// RestoreNode rebuilds the given network's node from a backup archive created
+// by BackupNode, and returns the archive's manifest. Any existing container
+// for the node is replaced, and its data directory is replaced with the repo
+// from the archive. The restored node runs the go-ipfs version it was backed up
+// with, and must start with the peer ID declared in the manifest - otherwise,
+// the previous data directory and node are restored.
+func (c *Client) RestoreNode(ctx context.Context, n *NodeInfo, r io.Reader) (BackupManifest, error) {
+	if n == nil || n.NetworkID == "" {
+		return BackupManifest{}, errors.New("invalid node")
+	}
+
+	var (
+		start   = time.Now()
+		dir     = c.getDataDir(n.NetworkID)
+		staging = c.getRestoreDir(n.NetworkID)
+		l       = log.NewProcessLogger(c.l, "restore_node",
+			"network_id", n.NetworkID,
+			"docker_id", n.DockerID)
+	)
+
+	// extract and verify archive before touching the existing node
+	l.Info("extracting backup archive")
+	os.RemoveAll(staging)
+	defer os.RemoveAll(staging)
+	manifest, err := extractBackup(r, staging, c.fileMode)
+	if err != nil {
+		l.Errorw("failed to extract backup archive", "error", err)
+		return manifest, err
+	}
+	l = l.With("peer_id", manifest.PeerID, "version", manifest.Version)
+	if manifest.NetworkID != n.NetworkID {
+		return manifest, fmt.Errorf("backup is of network '%s'", manifest.NetworkID)
+	}
+	if err := verifyBackup(filepath.Join(staging, backupRepo), manifest); err != nil {
+		l.Errorw("invalid backup", "error", err)
+		return manifest, err
+	}
+
+	// replace existing node and move its data directory aside
+	var (
+		previous    = *n
+		snapshot    = c.getSnapshotDir(n.NetworkID)
+		hasPrevious bool
+	)
+	if n.DockerID != "" {
+		l.Info("removing existing node")
+		c.d.ContainerRemove(ctx, toNodeContainerName(n.NetworkID),
+			types.ContainerRemoveOptions{Force: true})
+	}
+	os.RemoveAll(snapshot)
+	if _, err := os.Stat(dir); err == nil {
+		os.MkdirAll(filepath.Dir(snapshot), 0755)
+		if err := os.Rename(dir, snapshot); err != nil {
+			l.Errorw("failed to move existing data directory", "error", err)
+			return manifest, fmt.Errorf("failed to move existing data directory: %s", err.Error())
+		}
+		hasPrevious = true
+		defer os.RemoveAll(snapshot)
+	}
+	os.MkdirAll(filepath.Dir(dir), 0755)
+	if err = os.Rename(filepath.Join(staging, backupRepo), dir); err != nil {
+		err = fmt.Errorf("failed to move restored repo into place: %s", err.Error())
+	}
+
+	// start restored node, and check that it retains the backed up identity
+	if err == nil {
+		var restored = previous
+		restored.Version = manifest.Version
+		l.Info("starting restored node")
+		if err = c.recreateNode(ctx, n, restored); err == nil {
+			err = c.verifyIdentity(ctx, n, manifest.PeerID)
+		}
+	}
+	if err != nil {
+		l.Errorw("restore failed - rolling back", "error", err)
+		if rerr := c.rollbackRestore(ctx, n, previous, snapshot, hasPrevious); rerr != nil {
+			l.Errorw("rollback failed", "error", rerr)
+			return manifest, fmt.Errorf("failed to restore node: %s, and failed to roll back: %s",
+				err.Error(), rerr.Error())
+		}
+		return manifest, fmt.Errorf("failed to restore node: %s", err.Error())
+	}
+
+	l.Infow("node restored",
+		"restore.duration", time.Since(start))
+	return manifest, nil
+}
+
+// rollbackRestore restores the given previous data directory, if there was one,
+// and restarts the previous node if it had a container
+func (c *Client) rollbackRestore(ctx context.Context, n *NodeInfo, previous NodeInfo,
+	snapshot string, hasPrevious bool) error {
+	c.d.ContainerRemove(ctx, toNodeContainerName(previous.NetworkID),
+		types.ContainerRemoveOptions{Force: true})
+	var dir = c.getDataDir(previous.NetworkID)
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to remove restored repo: %s", err.Error())
+	}
+	*n = previous
+	if !hasPrevious {
+		n.DockerID = ""
+		return nil
+	}
+	if err := os.Rename(snapshot, dir); err != nil {
+		return fmt.Errorf("failed to restore previous data directory: %s", err.Error())
+	}
+	if previous.DockerID == "" {
+		return nil
+	}
+	return c.recreateNode(ctx, n, previous)
+}
@@ -0,0 +1,143 @@
+package ipfs
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_writeBackup_extractBackup(t *testing.T) {
+	src, err := ioutil.TempDir("", "nexus-backup-src")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+	dst, err := ioutil.TempDir("", "nexus-backup-dst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dst)
+
+	os.MkdirAll(filepath.Join(src, "blocks", "CIQA"), 0700)
+	ioutil.WriteFile(filepath.Join(src, "config"), []byte(`{"Identity":{"PeerID":"QmPeer"}}`), 0600)
+	ioutil.WriteFile(filepath.Join(src, "swarm.key"), []byte("key"), 0600)
+	ioutil.WriteFile(filepath.Join(src, "blocks", "CIQA", "block.data"), []byte("block"), 0644)
+	ioutil.WriteFile(filepath.Join(src, "repo.lock"), []byte(""), 0600)
+	os.Symlink("config", filepath.Join(src, "config.link"))
+
+	var manifest = BackupManifest{
+		NetworkID:    "test_backup",
+		PeerID:       "QmPeer",
+		SwarmKeyHash: SwarmKeyHash([]byte("key")),
+		Version:      "v0.4.18",
+	}
+	var archive bytes.Buffer
+	if err := writeBackup(&archive, manifest, src); err != nil {
+		t.Fatalf("writeBackup() error = %v", err)
+	}
+
+	// manifest should be readable on its own
+	got, err := ReadBackupManifest(bytes.NewReader(archive.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadBackupManifest() error = %v", err)
+	}
+	if got != manifest {
+		t.Errorf("ReadBackupManifest() = %+v, want %+v", got, manifest)
+	}
+
+	// repo should be extracted without daemon files
+	if _, err := extractBackup(&archive, dst, 0700); err != nil {
+		t.Fatalf("extractBackup() error = %v", err)
+	}
+	var repo = filepath.Join(dst, backupRepo)
+	for path, want := range map[string]string{
+		"swarm.key":              "key",
+		"blocks/CIQA/block.data": "block",
+		"config.link":            `{"Identity":{"PeerID":"QmPeer"}}`,
+	} {
+		if b, err := ioutil.ReadFile(filepath.Join(repo, path)); err != nil || string(b) != want {
+			t.Errorf("expected '%s' to contain '%s', got '%s' (%v)", path, want, b, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(repo, "repo.lock")); !os.IsNotExist(err) {
+		t.Errorf("expected repo lock not to be backed up, got %v", err)
+	}
+	if err := verifyBackup(repo, manifest); err != nil {
+		t.Errorf("verifyBackup() error = %v", err)
+	}
+
+	// backups should not match different identities or keys
+	var wrongPeer = manifest
+	wrongPeer.PeerID = "QmOther"
+	if err := verifyBackup(repo, wrongPeer); err == nil {
+		t.Error("expected error for mismatched peer ID")
+	}
+	var wrongKey = manifest
+	wrongKey.SwarmKeyHash = SwarmKeyHash([]byte("other"))
+	if err := verifyBackup(repo, wrongKey); err == nil {
+		t.Error("expected error for mismatched swarm key")
+	}
+}
+
+func Test_extractBackup_invalid(t *testing.T) {
+	type entry struct {
+		name     string
+		typeflag byte
+		linkname string
+		body     string
+	}
+	var manifest = entry{backupManifest, tar.TypeReg, "",
+		`{"network_id":"test","peer_id":"QmPeer","swarm_key_hash":"abcd"}`}
+	tests := []struct {
+		name    string
+		entries []entry
+		wantErr bool
+	}{
+		{"valid", []entry{manifest, {"repo/config", tar.TypeReg, "", "{}"}}, false},
+		{"no manifest", []entry{{"repo/config", tar.TypeReg, "", "{}"}}, true},
+		{"empty manifest", []entry{{backupManifest, tar.TypeReg, "", "{}"}}, true},
+		{"outside repo", []entry{manifest, {"config", tar.TypeReg, "", "{}"}}, true},
+		{"path traversal", []entry{manifest, {"repo/../../config", tar.TypeReg, "", "{}"}}, true},
+		{"absolute link", []entry{manifest, {"repo/link", tar.TypeSymlink, "/etc/passwd", ""}}, true},
+		{"escaping link", []entry{manifest, {"repo/link", tar.TypeSymlink, "../../config", ""}}, true},
+		{"device", []entry{manifest, {"repo/dev", tar.TypeChar, "", ""}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "nexus-extract")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			var (
+				archive bytes.Buffer
+				gz      = gzip.NewWriter(&archive)
+				tw      = tar.NewWriter(gz)
+			)
+			for _, e := range tt.entries {
+				tw.WriteHeader(&tar.Header{
+					Name:     e.name,
+					Typeflag: e.typeflag,
+					Linkname: e.linkname,
+					Mode:     0600,
+					Size:     int64(len(e.body)),
+				})
+				tw.Write([]byte(e.body))
+			}
+			tw.Close()
+			gz.Close()
+
+			if _, err := extractBackup(&archive, filepath.Join(dir, "restore"), 0700); (err != nil) != tt.wantErr {
+				t.Errorf("extractBackup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "config")); !os.IsNotExist(err) {
+				t.Error("expected no files to be written outside of the target directory")
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package ipfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	peer "github.com/libp2p/go-libp2p-peer"
+	ma "github.com/multiformats/go-multiaddr"
+
+	"github.com/RTradeLtd/Nexus/log"
+)
+
+// ParseBootstrapPeers validates the given bootstrap peer multiaddrs, each of
+// which must end with the peer's ID, and returns them in canonical form
+func ParseBootstrapPeers(peers []string) ([]string, error) {
+	var parsed = make([]string, 0, len(peers))
+	for _, p := range peers {
+		addr, err := ma.NewMultiaddr(strings.TrimSpace(p))
+		if err != nil {
+			return nil, fmt.Errorf("invalid bootstrap peer '%s': %s", p, err.Error())
+		}
+		id, err := addr.ValueForProtocol(ma.P_IPFS)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bootstrap peer '%s': no peer ID", p)
+		}
+		if _, err := peer.IDB58Decode(id); err != nil {
+			return nil, fmt.Errorf("invalid bootstrap peer '%s': invalid peer ID: %s", p, err.Error())
+		}
+		parsed = append(parsed, addr.String())
+	}
+	return parsed, nil
+}
+
+// AddBootstrapPeers adds the given peers to the bootstrap list of the given
+// running node, and recreates the node's container so that its
+// "bootstrap_peers" label holds the resulting list. The node keeps its ports,
+// resources, and identity, and the given node is updated to match the new
+// container.
+func (c *Client) AddBootstrapPeers(ctx context.Context, n *NodeInfo, peers []string) error {
+	return c.changeBootstrapPeers(ctx, n, peers, true)
+}
+
+// RemoveBootstrapPeers removes the given peers from the bootstrap list of the
+// given running node, and recreates the node's container so that its
+// "bootstrap_peers" label holds the resulting list. The node keeps its ports,
+// resources, and identity, and the given node is updated to match the new
+// container.
+func (c *Client) RemoveBootstrapPeers(ctx context.Context, n *NodeInfo, peers []string) error {
+	return c.changeBootstrapPeers(ctx, n, peers, false)
+}
+
+func (c *Client) changeBootstrapPeers(ctx context.Context, n *NodeInfo, peers []string, add bool) error {
+	if n == nil || n.DockerID == "" {
+		return errors.New("invalid node")
+	}
+	if len(peers) == 0 {
+		return errors.New("no peers provided")
+	}
+	parsed, err := ParseBootstrapPeers(peers)
+	if err != nil {
+		return err
+	}
+
+	current, err := ParseBootstrapPeers(n.BootstrapPeers)
+	if err != nil {
+		current = n.BootstrapPeers
+	}
+	var (
+		cmd     = "add"
+		updated = mergeBootstrapPeers(current, parsed)
+	)
+	if !add {
+		cmd = "rm"
+		updated = subtractBootstrapPeers(current, parsed)
+	}
+	var l = log.NewProcessLogger(c.l, "bootstrap_"+cmd,
+		"network_id", n.NetworkID,
+		"docker_id", n.DockerID,
+		"peers", parsed)
+
+	state, err := c.containerState(ctx, n.DockerID)
+	if err != nil {
+		return err
+	}
+	if !state.Running || state.Paused {
+		return fmt.Errorf("node for network '%s' is not running", n.NetworkID)
+	}
+
+	// go-ipfs reads its bootstrap list from the repo configuration, which
+	// outlives the node's container
+	if _, _, err := c.containerExec(ctx, n.DockerID,
+		append([]string{"ipfs", "bootstrap", cmd}, parsed...)); err != nil {
+		l.Errorw("failed to update bootstrap list", "error", err)
+		return fmt.Errorf("failed to update bootstrap peers: %s", err.Error())
+	}
+
+	// container labels cannot be changed, so the container is recreated with
+	// the same ports, resources, and data directory - and hence identity
+	var recreated = *n
+	recreated.BootstrapPeers = updated
+	l.Info("recreating node container")
+	if err := c.recreateNode(ctx, n, recreated); err != nil {
+		l.Errorw("failed to recreate node container", "error", err)
+		return fmt.Errorf("failed to recreate node with updated bootstrap peers: %s", err.Error())
+	}
+
+	l.Infow("bootstrap peers updated",
+		"bootstrap_peers", updated,
+		"docker_id.new", n.DockerID)
+	return nil
+}
+
+// mergeBootstrapPeers returns the given peers followed by the added peers that
+// are not already among them
+func mergeBootstrapPeers(peers, added []string) []string {
+	var (
+		merged = make([]string, 0, len(peers)+len(added))
+		seen   = make(map[string]bool)
+	)
+	for _, p := range append(append([]string{}, peers...), added...) {
+		if !seen[p] {
+			seen[p] = true
+			merged = append(merged, p)
+		}
+	}
+	return merged
+}
+
+// subtractBootstrapPeers returns the given peers without the removed peers
+func subtractBootstrapPeers(peers, removed []string) []string {
+	var (
+		remaining = make([]string, 0, len(peers))
+		drop      = make(map[string]bool)
+	)
+	for _, p := range removed {
+		drop[p] = true
+	}
+	for _, p := range peers {
+		if !drop[p] {
+			remaining = append(remaining, p)
+		}
+	}
+	return remaining
+}
@@ -0,0 +1,48 @@
+package ipfs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseBootstrapPeers(t *testing.T) {
+	var peer = "/ip4/104.131.131.82/tcp/4001/ipfs/" + testPeerID
+	tests := []struct {
+		name    string
+		peers   []string
+		want    []string
+		wantErr bool
+	}{
+		{"no peers", nil, []string{}, false},
+		{"valid peers", []string{peer, "/dns4/example.com/tcp/4001/ipfs/" + testPeerID},
+			[]string{peer, "/dns4/example.com/tcp/4001/ipfs/" + testPeerID}, false},
+		{"surrounding whitespace", []string{" " + peer + "\n"}, []string{peer}, false},
+		{"host and port", []string{"104.131.131.82:4001"}, nil, true},
+		{"no peer ID", []string{"/ip4/104.131.131.82/tcp/4001"}, nil, true},
+		{"invalid peer ID", []string{"/ip4/104.131.131.82/tcp/4001/ipfs/QmNotAPeerIDAtAll0"}, nil, true},
+		{"one invalid peer", []string{peer, "/ip4/1.2.3.4/tcp/4001"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseBootstrapPeers(tt.peers)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseBootstrapPeers() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseBootstrapPeers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_mergeBootstrapPeers(t *testing.T) {
+	var got = mergeBootstrapPeers([]string{"a", "b"}, []string{"b", "c", "c"})
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeBootstrapPeers() = %v, want %v", got, want)
+	}
+	got = subtractBootstrapPeers([]string{"a", "b", "c"}, []string{"b", "d"})
+	if want := []string{"a", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("subtractBootstrapPeers() = %v, want %v", got, want)
+	}
+}
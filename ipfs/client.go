@@ -1,6 +1,7 @@
 package ipfs
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"errors"
@@ -13,7 +14,6 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
-	docker "github.com/docker/docker/client"
 	"github.com/docker/go-connections/nat"
 	"go.uber.org/zap"
 
@@ -25,15 +25,43 @@ import (
 // using ipfs.NewClient()
 type Client struct {
 	l *zap.SugaredLogger
-	d *docker.Client
-
-	ipfsImage string
-	dataDir   string
-	fileMode  os.FileMode
+	d Runtime
+
+	// version is the go-ipfs version run by nodes that are not pinned to a
+	// version
+	version string
+	// defaultImage optionally overrides the image run by nodes on the default
+	// version
+	defaultImage string
+	dataDir      string
+	fileMode     os.FileMode
+
+	// gcWatermark and initProfile configure node startup scripts, and fall
+	// back to their defaults if unset
+	gcWatermark int
+	initProfile string
+
+	images *imageManager
+
+	// apiHost is the host address that node API ports are published on
+	apiHost string
 }
 
-// Nodes retrieves a list of active IPFS ndoes
+// Nodes retrieves a list of running IPFS nodes. Paused nodes are considered to
+// be running.
 func (c *Client) Nodes(ctx context.Context) ([]*NodeInfo, error) {
+	return c.listNodes(ctx, false)
+}
+
+// StoppedNodes retrieves a list of IPFS nodes whose containers have stopped.
+// Stopped nodes retain their containers, and can be started again using
+// RestartNode.
+func (c *Client) StoppedNodes(ctx context.Context) ([]*NodeInfo, error) {
+	return c.listNodes(ctx, true)
+}
+
+// listNodes retrieves nodes that are either stopped or running
+func (c *Client) listNodes(ctx context.Context, stopped bool) ([]*NodeInfo, error) {
 	ctrs, err := c.d.ContainerList(ctx, types.ContainerListOptions{
 		All: true,
 	})
@@ -41,56 +69,33 @@ func (c *Client) Nodes(ctx context.Context) ([]*NodeInfo, error) {
 		return nil, err
 	}
 
-	// small function to restart a stopped node
-	restartNode := func(node NodeInfo) error {
-		var l = c.l.With("node", node)
-		l.Infow("restarting stopped node")
-		if err := c.CreateNode(ctx, &node, NodeOpts{}); err != nil {
-			l.Errorw("failed to restart node",
-				"error", err)
-			return err
-		}
-		return nil
-	}
-
-	// parse node data, restarting stopped containers if necessary
 	var (
-		nodes    = make([]*NodeInfo, 0)
-		ignored  = 0
-		restarts = 0
-		failed   = 0
+		nodes   = make([]*NodeInfo, 0)
+		ignored = 0
 	)
 	for _, container := range ctrs {
-		var l = c.l.With("container.id", container.ID, "container.name", container.Names[0])
 		n, err := newNode(container.ID, container.Names[0], container.Labels)
 		if err != nil {
-			l.Debugw("container ignored", "reason", err)
+			c.l.Debugw("container ignored",
+				"container.id", container.ID,
+				"container.name", container.Names[0],
+				"reason", err)
 			ignored++
 			continue
 		}
-		n.updateFromContainerDetails(&container)
-		l = l.With("node", n)
-		if isStopped(container.State) {
-			if err := restartNode(n); err != nil {
-				l.Errorw("node container failed to restart - removing", "error", err)
-				if err := c.StopNode(ctx, &n); err != nil {
-					l.Warn("failed to stop node", "error", err)
-				}
-				failed++
-				continue
-			}
-			restarts++
+		if isStopped(container.State) != stopped {
+			continue
 		}
+		n.updateFromContainerDetails(&container)
 		nodes = append(nodes, &n)
 	}
 
 	// report activity
-	c.l.Infow("all nodes checked",
+	c.l.Debugw("nodes listed",
 		"found", len(ctrs),
-		"valid", len(nodes),
-		"ignored", ignored,
-		"restarts", restarts,
-		"failed_restarts", failed)
+		"nodes", len(nodes),
+		"stopped", stopped,
+		"ignored", ignored)
 
 	return nodes, nil
 }
@@ -99,6 +104,16 @@ func (c *Client) Nodes(ctx context.Context) ([]*NodeInfo, error) {
 type NodeOpts struct {
 	SwarmKey   []byte
 	AutoRemove bool
+
+	// PeerKey is the base64-encoded private key of an existing identity that
+	// the node should be created with. New repos are initialized with it, and
+	// existing repos must already have it.
+	PeerKey string
+
+	// Config is the configuration the node should run with. If unset, the
+	// node's existing configuration is kept, or the default configuration is
+	// used for new nodes.
+	Config *NodeConfig
 }
 
 // CreateNode activates a new IPFS node
@@ -107,12 +122,44 @@ func (c *Client) CreateNode(ctx context.Context, n *NodeInfo, opts NodeOpts) err
 		return errors.New("invalid configuration provided")
 	}
 
+	// reject invalid keys before any resources are set up
+	if opts.SwarmKey != nil {
+		if _, err := ParseSwarmKey(opts.SwarmKey); err != nil {
+			return err
+		}
+	}
+	if opts.PeerKey != "" {
+		if _, err := PeerIDFromKey(opts.PeerKey); err != nil {
+			return err
+		}
+	}
+	if opts.Config != nil {
+		if err := opts.Config.Validate(); err != nil {
+			return err
+		}
+	}
+	if _, err := ParseBootstrapPeers(n.BootstrapPeers); err != nil {
+		return err
+	}
+
 	// make sure important fields are all populated
 	n.withDefaults()
+	if n.Version == "" {
+		n.Version = c.pinnedVersion(n.NetworkID)
+	}
 
 	// set up logger to record process events
 	var l = log.NewProcessLogger(c.l, "create_node",
-		"network_id", n.NetworkID)
+		"network_id", n.NetworkID,
+		"version", n.Version)
+
+	// make sure the node's image is available
+	image, imageID, err := c.image(ctx, n.Version)
+	if err != nil {
+		l.Warnw("failed to retrieve image", "error", err)
+		return fmt.Errorf("failed to retrieve image for node: %s", err.Error())
+	}
+	n.Image, n.ImageID = image, imageID
 
 	// initialize node assets, such as swarm keys and startup scripts
 	if err := c.initNodeAssets(n, opts); err != nil {
@@ -144,20 +191,14 @@ func (c *Client) CreateNode(ctx context.Context, n *NodeInfo, opts NodeOpts) err
 			c.getDataDir(n.NetworkID) + ":/data/ipfs",
 			c.getDataDir(n.NetworkID) + "/ipfs_start:/usr/local/bin/start_ipfs",
 		}
-		restartPolicy = container.RestartPolicy{Name: "unless-stopped"}
 
 		// important metadata about node
 		labels = n.labels(n.BootstrapPeers, c.getDataDir(n.NetworkID))
 	)
 
-	// remove restart policy if AutoRemove is enabled
-	if opts.AutoRemove {
-		restartPolicy = container.RestartPolicy{}
-	}
-
 	// create ipfs node container
 	containerConfig := &container.Config{
-		Image: c.ipfsImage,
+		Image: image,
 		Cmd: []string{
 			"daemon", "--migrate=true", "--enable-pubsub-experiment",
 		},
@@ -170,8 +211,10 @@ func (c *Client) CreateNode(ctx context.Context, n *NodeInfo, opts NodeOpts) err
 		AttachStderr: true,
 	}
 	containerHostConfig := &container.HostConfig{
-		AutoRemove:    opts.AutoRemove,
-		RestartPolicy: restartPolicy,
+		AutoRemove: opts.AutoRemove,
+		// nodes are not restarted by Docker - the orchestrator restarts stopped
+		// nodes according to its restart policy, so that crash loops are detected
+		RestartPolicy: container.RestartPolicy{},
 		Binds:         volumes,
 		PortBindings:  ports,
 		Resources:     containerResources(n),
@@ -208,12 +251,12 @@ func (c *Client) CreateNode(ctx context.Context, n *NodeInfo, opts NodeOpts) err
 	if err := c.d.ContainerStart(ctx, n.DockerID, types.ContainerStartOptions{}); err != nil {
 		l.Errorw("error occurred on startup - removing container",
 			"error", err, "start.duration", time.Since(start))
-		go c.d.ContainerRemove(ctx, n.ContainerName, types.ContainerRemoveOptions{Force: true})
+		c.d.ContainerRemove(ctx, n.ContainerName, types.ContainerRemoveOptions{Force: true})
 		return fmt.Errorf("failed to start ipfs node: %s", err.Error())
 	}
 
 	// wait for node to start
-	if err := c.waitForNode(ctx, n.DockerID); err != nil {
+	if err := c.waitForNode(ctx, n); err != nil {
 		l.Errorw("error occurred waiting for IPFS daemon startup",
 			"error", err, "start.duration", time.Since(start))
 		return err
@@ -225,7 +268,7 @@ func (c *Client) CreateNode(ctx context.Context, n *NodeInfo, opts NodeOpts) err
 		if err := c.bootstrapNode(ctx, n.DockerID, n.BootstrapPeers...); err != nil {
 			l.Warnw("failed to bootstrap node - stopping container",
 				"error", err, "start.duration", time.Since(start))
-			go c.StopNode(ctx, n)
+			c.StopNode(ctx, n)
 			return fmt.Errorf("failed to bootstrap network node with provided peers: %s", err.Error())
 		}
 	}
@@ -325,6 +368,92 @@ func (c *Client) StopNode(ctx context.Context, n *NodeInfo) error {
 	)
 }
 
+// RestartNode restarts an existing IPFS node's container, waits for the daemon
+// to become ready, and bootstraps the node with its configured peers. The
+// node's ports and assets are retained.
+func (c *Client) RestartNode(ctx context.Context, n *NodeInfo) error {
+	if n == nil || n.DockerID == "" {
+		return errors.New("invalid node")
+	}
+
+	var (
+		start   = time.Now()
+		timeout = time.Duration(10 * time.Second)
+
+		l = log.NewProcessLogger(c.l, "restart_node",
+			"network_id", n.NetworkID,
+			"docker_id", n.DockerID)
+	)
+
+	l.Info("restarting container")
+	if err := c.d.ContainerRestart(ctx, n.DockerID, &timeout); err != nil {
+		l.Errorw("failed to restart container",
+			"error", err, "restart.duration", time.Since(start))
+		return fmt.Errorf("failed to restart node: %s", err.Error())
+	}
+
+	// wait for node to start
+	if err := c.waitForNode(ctx, n); err != nil {
+		l.Errorw("error occurred waiting for IPFS daemon startup",
+			"error", err, "restart.duration", time.Since(start))
+		return fmt.Errorf("error occurred waiting for node to start: %s", err.Error())
+	}
+
+	// bootstrap peers if required
+	if len(n.BootstrapPeers) > 0 {
+		l.Debugw("bootstrapping network node with provided peers")
+		if err := c.bootstrapNode(ctx, n.DockerID, n.BootstrapPeers...); err != nil {
+			l.Warnw("failed to bootstrap node",
+				"error", err, "restart.duration", time.Since(start))
+			return fmt.Errorf("failed to bootstrap network node with provided peers: %s", err.Error())
+		}
+	}
+
+	l.Infow("node restarted",
+		"restart.duration", time.Since(start))
+	return nil
+}
+
+// PauseNode suspends all processes in an existing IPFS node's container. The
+// node retains its resources and ports, but does not respond to requests
+// until it is resumed.
+func (c *Client) PauseNode(ctx context.Context, n *NodeInfo) error {
+	if n == nil || n.DockerID == "" {
+		return errors.New("invalid node")
+	}
+
+	var l = c.l.With(
+		"network_id", n.NetworkID,
+		"docker_id", n.DockerID)
+
+	if err := c.d.ContainerPause(ctx, n.DockerID); err != nil {
+		l.Warnw("error pausing container", "error", err)
+		return fmt.Errorf("failed to pause node: %s", err.Error())
+	}
+
+	l.Info("node paused")
+	return nil
+}
+
+// ResumeNode resumes a node paused by PauseNode
+func (c *Client) ResumeNode(ctx context.Context, n *NodeInfo) error {
+	if n == nil || n.DockerID == "" {
+		return errors.New("invalid node")
+	}
+
+	var l = c.l.With(
+		"network_id", n.NetworkID,
+		"docker_id", n.DockerID)
+
+	if err := c.d.ContainerUnpause(ctx, n.DockerID); err != nil {
+		l.Warnw("error resuming container", "error", err)
+		return fmt.Errorf("failed to resume node: %s", err.Error())
+	}
+
+	l.Info("node resumed")
+	return nil
+}
+
 // RemoveNode removes assets for given node
 func (c *Client) RemoveNode(ctx context.Context, network string) error {
 	var (
@@ -346,13 +475,57 @@ func (c *Client) RemoveNode(ctx context.Context, network string) error {
 	return nil
 }
 
+// NodeAssetsExist checks if assets for the given network, such as its data
+// directory, are present on the host
+func (c *Client) NodeAssetsExist(ctx context.Context, network string) (bool, error) {
+	if network == "" {
+		return false, errors.New("invalid network name provided")
+	}
+	if _, err := os.Stat(c.getDataDir(network)); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check assets for '%s': %s", network, err.Error())
+	}
+	return true, nil
+}
+
 // NodeStats provides details about a node container
 type NodeStats struct {
 	PeerID    string
 	PeerKey   string
 	Uptime    time.Duration
 	DiskUsage int64
-	Stats     interface{}
+	Stats     ContainerStats
+
+	// SwarmKeyFingerprint identifies the swarm key the node uses without
+	// revealing it
+	SwarmKeyFingerprint string
+}
+
+// ContainerStats describes the resource usage of a node container at a point
+// in time
+type ContainerStats struct {
+	Time time.Time `json:"time"`
+
+	// CPUPercent is the percentage of host CPU used since the previous sample,
+	// where 100% denotes full use of a single core
+	CPUPercent float64 `json:"cpu_percent"`
+	OnlineCPUs uint32  `json:"online_cpus"`
+
+	// MemoryUsage is the memory used by the container in bytes, excluding the
+	// page cache
+	MemoryUsage   uint64  `json:"memory_usage"`
+	MemoryLimit   uint64  `json:"memory_limit"`
+	MemoryPercent float64 `json:"memory_percent"`
+
+	// network and block I/O are cumulative since the container started
+	NetworkRxBytes  uint64 `json:"network_rx_bytes"`
+	NetworkTxBytes  uint64 `json:"network_tx_bytes"`
+	BlockReadBytes  uint64 `json:"block_read_bytes"`
+	BlockWriteBytes uint64 `json:"block_write_bytes"`
+
+	PIDs uint64 `json:"pids"`
 }
 
 // NodeStats retrieves statistics about the provided node
@@ -405,6 +578,14 @@ func (c *Client) NodeStats(ctx context.Context, n *NodeInfo) (NodeStats, error)
 		return NodeStats{}, fmt.Errorf("failed to get network node configuration")
 	}
 
+	// identify swarm key
+	var fingerprint string
+	if key, err := ioutil.ReadFile(filepath.Join(n.DataDir, "swarm.key")); err != nil {
+		l.Warnw("failed to read swarm key", "error", err)
+	} else {
+		fingerprint = swarmKeyFingerprint(key)
+	}
+
 	c.l.Debugw("retrieved node container data",
 		"network_id", n.NetworkID,
 		"docker_id", n.DockerID,
@@ -414,11 +595,126 @@ func (c *Client) NodeStats(ctx context.Context, n *NodeInfo) (NodeStats, error)
 		PeerID:    peer.Identity.PeerID,
 		PeerKey:   peer.Identity.PrivKey,
 		Uptime:    time.Since(created),
-		Stats:     stats,
+		Stats:     stats.metrics(nil),
 		DiskUsage: usage,
+
+		SwarmKeyFingerprint: fingerprint,
 	}, nil
 }
 
+// StreamStats samples the resource usage of the given node's container every
+// interval, until the given context is cancelled or the container stops, at
+// which point the returned channel is closed. CPU usage is averaged over each
+// interval, which cannot be shorter than a second.
+func (c *Client) StreamStats(ctx context.Context, n *NodeInfo, interval time.Duration) (<-chan ContainerStats, error) {
+	if n == nil || n.DockerID == "" {
+		return nil, errors.New("invalid node")
+	}
+	if interval < minStatsInterval {
+		interval = minStatsInterval
+	}
+	var l = c.l.With("node", n, "interval", interval)
+
+	s, err := c.d.ContainerStats(ctx, n.DockerID, true)
+	if err != nil {
+		l.Errorw("failed to get container stats", "error", err)
+		return nil, errors.New("failed to get node stats")
+	}
+
+	var samples = make(chan ContainerStats)
+	go func() {
+		defer close(samples)
+		defer s.Body.Close()
+		var (
+			dec  = json.NewDecoder(s.Body)
+			pre  *rawCPUStats
+			last time.Time
+		)
+		for {
+			var stats rawContainerStats
+			if err := dec.Decode(&stats); err != nil {
+				if ctx.Err() == nil {
+					l.Infow("container stats stream ended", "error", err)
+				}
+				return
+			}
+			if stats.Read.IsZero() {
+				stats.Read = time.Now()
+			}
+
+			// Docker's sampling jitters slightly, so allow samples that arrive a
+			// little early
+			if !last.IsZero() && stats.Read.Sub(last) < interval*9/10 {
+				continue
+			}
+			var sample = stats.metrics(pre)
+			pre, last = &stats.CPUStats, stats.Read
+
+			select {
+			case samples <- sample:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return samples, nil
+}
+
+// LogOptions declares which node output to retrieve
+type LogOptions struct {
+	// Since and Until restrict output to the given time range if set
+	Since time.Time
+	Until time.Time
+	// Tail restricts output to the given number of most recent lines if set
+	Tail int
+	// Follow continues to stream output as it is produced
+	Follow bool
+}
+
+// LogLine is a line of output from a node
+type LogLine struct {
+	Time time.Time `json:"time"`
+	Text string    `json:"text"`
+}
+
+// NodeLogs retrieves output from the given node's container. The returned
+// channel is closed once all requested output has been delivered - if
+// opts.Follow is set, this is when the container stops, opts.Until passes, or
+// the given context is cancelled.
+func (c *Client) NodeLogs(ctx context.Context, n *NodeInfo, opts LogOptions) (<-chan LogLine, error) {
+	if n == nil || n.DockerID == "" {
+		return nil, errors.New("invalid node")
+	}
+	var l = c.l.With("node", n, "logs.options", opts)
+
+	logs, err := c.containerLogs(ctx, n.DockerID, opts, true)
+	if err != nil {
+		l.Errorw("failed to get container logs", "error", err)
+		return nil, fmt.Errorf("failed to get node logs: %s", err.Error())
+	}
+
+	var lines = make(chan LogLine)
+	go func() {
+		defer close(lines)
+		defer logs.Close()
+		var scanner = bufio.NewScanner(logs)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxLogLineSize)
+		for scanner.Scan() {
+			select {
+			case lines <- parseLogLine(scanner.Text()):
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil && ctx.Err() == nil {
+			l.Warnw("error reading container logs", "error", err)
+		}
+	}()
+
+	return lines, nil
+}
+
 // Event is a node-related container event
 type Event struct {
 	Time   int64    `json:"time"`
@@ -426,7 +722,10 @@ type Event struct {
 	Node   NodeInfo `json:"node"`
 }
 
-// Watch initializes a goroutine that tracks IPFS node events
+// Watch initializes a goroutine that tracks IPFS node events. If the Docker
+// event stream drops, the watcher reports the error and reconnects with
+// exponential backoff. Errors are not reported if nobody is receiving them.
+// The watcher stops when the given context is cancelled.
 func (c *Client) Watch(ctx context.Context) (<-chan Event, <-chan error) {
 	var (
 		events = make(chan Event)
@@ -435,39 +734,78 @@ func (c *Client) Watch(ctx context.Context) (<-chan Event, <-chan error) {
 
 	go func() {
 		defer close(errs)
-		eventsCh, eventsErrCh := c.d.Events(ctx,
-			types.EventsOptions{Filters: filters.NewArgs(
-				filters.KeyValuePair{Key: "event", Value: "die"},
-				filters.KeyValuePair{Key: "event", Value: "start"},
-			)})
-
+		var backoff = watchBackoffMin
 		for {
-			select {
-			case <-ctx.Done():
-				break
+			var connected = time.Now()
+			err := c.pipeEvents(ctx, events)
+			if ctx.Err() != nil {
+				return
+			}
 
-			// pipe errors back
-			case err := <-eventsErrCh:
-				if err != nil {
-					errs <- err
-				}
+			// pipe errors back without blocking
+			select {
+			case errs <- err:
+			default:
+			}
 
-			// report events
-			case status := <-eventsCh:
-				id := status.ID[:11]
-				name := status.Actor.Attributes["name"]
-				node, err := newNode(id, name, status.Actor.Attributes)
-				if err != nil {
-					c.l.Warnw("failed to parse node", "error", err)
-					continue
-				}
-				e := Event{Time: status.Time, Status: status.Status, Node: node}
-				c.l.Infow("event received",
-					"event", e)
-				events <- e
+			// reset backoff if the stream was healthy for a while
+			if time.Since(connected) > watchBackoffMax {
+				backoff = watchBackoffMin
+			}
+			c.l.Warnw("event stream dropped - reconnecting",
+				"error", err, "backoff", backoff)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			if backoff *= 2; backoff > watchBackoffMax {
+				backoff = watchBackoffMax
 			}
 		}
 	}()
 
 	return events, errs
 }
+
+// pipeEvents subscribes to the Docker event stream and reports node events
+// until the stream drops or the given context is cancelled
+func (c *Client) pipeEvents(ctx context.Context, events chan<- Event) error {
+	eventsCh, eventsErrCh := c.d.Events(ctx,
+		types.EventsOptions{Filters: filters.NewArgs(
+			filters.KeyValuePair{Key: "event", Value: "die"},
+			filters.KeyValuePair{Key: "event", Value: "start"},
+		)})
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		// the stream ends on error
+		case err := <-eventsErrCh:
+			if err == nil {
+				err = errors.New("event stream closed")
+			}
+			return err
+
+		// report events
+		case status := <-eventsCh:
+			id := status.ID[:11]
+			name := status.Actor.Attributes["name"]
+			node, err := newNode(id, name, status.Actor.Attributes)
+			if err != nil {
+				c.l.Warnw("failed to parse node", "error", err)
+				continue
+			}
+			e := Event{Time: status.Time, Status: status.Status, Node: node}
+			c.l.Infow("event received",
+				"event", e)
+			select {
+			case events <- e:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
@@ -2,6 +2,8 @@ package ipfs
 
 import (
 	"context"
+	"io/ioutil"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -63,13 +65,13 @@ func Test_client_NodeOperations(t *testing.T) {
 	}{
 		{"invalid config", args{
 			&NodeInfo{
-				"test1", "", NodePorts{"4001", "5001", "8080"}, NodeResources{}, "", "", "", nil},
+				"test1", "", NodePorts{"4001", "5001", "8080"}, NodeResources{}, "", "", "", nil, "", "", ""},
 			NodeOpts{},
 		}, true},
 		{"new node", args{
 			&NodeInfo{
-				"test2", "", NodePorts{"4001", "5001", "8080"}, NodeResources{}, "", "", "", nil},
-			NodeOpts{[]byte(key), false},
+				"test2", "", NodePorts{"4001", "5001", "8080"}, NodeResources{}, "", "", "", nil, "", "", ""},
+			NodeOpts{[]byte(key), false, "", nil},
 		}, false},
 		{"with bootstrap", args{
 			&NodeInfo{
@@ -77,9 +79,9 @@ func Test_client_NodeOperations(t *testing.T) {
 				[]string{
 					"/ip4/104.131.131.82/tcp/4001/ipfs/QmaCpDMGvV2BGHeYERUEnRQAwe3N8SzbUtfsmvsqQLuvuJ",
 					"/ip4/104.236.179.241/tcp/4001/ipfs/QmSoLPppuBtQSGwKDZT2M73ULpjvfd3aZ6ha4oFGL1KrGM",
-				}},
+				}, "", "", ""},
 			NodeOpts{[]byte(key),
-				true},
+				true, "", nil},
 		}, false},
 	}
 	for _, tt := range tests {
@@ -196,3 +198,330 @@ func Test_client_UpdateNode(t *testing.T) {
 		return
 	}
 }
+
+func Test_client_EmulatedNodeOperations(t *testing.T) {
+	c, e, srv, err := newEmulatedTestClient()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer srv.Close()
+	key, err := SwarmKey()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// test watcher
+	watchCtx, cancelWatch := context.WithCancel(context.Background())
+	defer cancelWatch()
+	events, _ := c.Watch(watchCtx)
+
+	var (
+		ctx = context.Background()
+		n   = &NodeInfo{
+			NetworkID: "test_emulated",
+			Ports:     NodePorts{Swarm: "4001", API: "5001", Gateway: "8080"},
+			BootstrapPeers: []string{
+				"/ip4/104.131.131.82/tcp/4001/ipfs/QmaCpDMGvV2BGHeYERUEnRQAwe3N8SzbUtfsmvsqQLuvuJ",
+			},
+		}
+	)
+	defer c.RemoveNode(ctx, n.NetworkID)
+
+	// create node
+	if err := c.CreateNode(ctx, n, NodeOpts{SwarmKey: []byte(key)}); err != nil {
+		t.Errorf("client.CreateNode() error = %v", err)
+		return
+	}
+	expectNodeEvent(t, events, "start", n.NetworkID)
+
+	// ports are in use
+	if err := c.CreateNode(ctx, &NodeInfo{
+		NetworkID: "test_emulated_conflict",
+		Ports:     n.Ports,
+	}, NodeOpts{SwarmKey: []byte(key)}); err == nil {
+		t.Error("expected port conflict")
+	}
+	c.RemoveNode(ctx, "test_emulated_conflict")
+
+	// node should be bootstrapped
+	var execs = e.Execs(n.DockerID)
+	if len(execs) != 2 || execs[1][2] != "add" {
+		t.Errorf("expected node to be bootstrapped, got execs %v", execs)
+	}
+
+	// node should be listed
+	nodes, err := c.Nodes(ctx)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(nodes) != 1 || nodes[0].NetworkID != n.NetworkID || nodes[0].Ports != n.Ports {
+		t.Errorf("unexpected nodes %+v", nodes)
+	}
+
+	// bootstrap peers should be changed on the running node, and its container
+	// recreated so that listed nodes reflect the change
+	var (
+		added = "/ip4/104.236.179.241/tcp/4001/ipfs/QmSoLPppuBtQSGwKDZT2M73ULpjvfd3aZ6ha4oFGL1KrGM"
+		id    = n.DockerID
+	)
+	if err := c.AddBootstrapPeers(ctx, n, []string{"104.236.179.241:4001"}); err == nil {
+		t.Error("expected error for invalid bootstrap peer")
+	}
+	if err := c.AddBootstrapPeers(ctx, n, []string{added}); err != nil {
+		t.Errorf("client.AddBootstrapPeers() error = %v", err)
+	}
+	expectNodeEvent(t, events, "die", n.NetworkID)
+	expectNodeEvent(t, events, "start", n.NetworkID)
+	if execs = e.Execs(n.DockerID); n.DockerID == id || len(execs) != 2 ||
+		execs[1][2] != "add" || execs[1][len(execs[1])-1] != added {
+		t.Errorf("expected container to be recreated with peer added, got %s with execs %v",
+			n.DockerID, execs)
+	}
+	if err := c.RemoveBootstrapPeers(ctx, n, n.BootstrapPeers[:1]); err != nil {
+		t.Errorf("client.RemoveBootstrapPeers() error = %v", err)
+	}
+	expectNodeEvent(t, events, "die", n.NetworkID)
+	expectNodeEvent(t, events, "start", n.NetworkID)
+	if nodes, err = c.Nodes(ctx); err != nil || len(nodes) != 1 ||
+		len(nodes[0].BootstrapPeers) != 1 || nodes[0].BootstrapPeers[0] != added {
+		t.Errorf("expected listed node to have bootstrap peers [%s], got %+v (%v)", added, nodes, err)
+	}
+
+	// command output and exit codes should be captured
+	if out, _, err := c.(*Client).containerExec(ctx, n.DockerID,
+		[]string{"ipfs", "bootstrap", "add", added}); err != nil || out != added+"\n" {
+		t.Errorf("client.containerExec() = %q, %v", out, err)
+	}
+	if _, _, err := c.(*Client).containerExec(ctx, n.DockerID,
+		[]string{"ipfs", "bootstrap", "add", "asdf"}); err == nil {
+		t.Error("expected error for failed command")
+	} else if eerr, ok := err.(*ExecError); !ok || eerr.ExitCode != 1 || eerr.Stderr == "" {
+		t.Errorf("expected *ExecError with exit code 1, got %v", err)
+	}
+
+	// get node stats
+	s, err := c.NodeStats(ctx, n)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if s.PeerID == "" {
+		t.Errorf("expected peer ID, got stats %+v", s)
+	}
+	if s.Stats.CPUPercent <= 0 || s.Stats.MemoryLimit == 0 {
+		t.Errorf("expected resource usage, got stats %+v", s.Stats)
+	}
+
+	// node API should respond to probes
+	if err := c.ProbeNode(ctx, n); err != nil {
+		t.Errorf("client.ProbeNode() error = %v", err)
+	}
+
+	// retrieve node logs
+	lines, err := c.NodeLogs(ctx, n, LogOptions{Tail: 1})
+	if err != nil {
+		t.Errorf("client.NodeLogs() error = %v", err)
+		return
+	}
+	var logs = make([]LogLine, 0)
+	for line := range lines {
+		logs = append(logs, line)
+	}
+	if len(logs) != 1 || logs[0].Text != "Daemon is ready" || logs[0].Time.IsZero() {
+		t.Errorf("unexpected logs %+v", logs)
+	}
+
+	// stream node stats
+	streamCtx, cancelStream := context.WithCancel(ctx)
+	samples, err := c.StreamStats(streamCtx, n, time.Second)
+	if err != nil {
+		t.Errorf("client.StreamStats() error = %v", err)
+		cancelStream()
+		return
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case sample, ok := <-samples:
+			if !ok {
+				t.Error("stats stream closed unexpectedly")
+			} else if i > 0 && sample.CPUPercent <= 0 {
+				t.Errorf("expected cpu usage, got sample %+v", sample)
+			}
+		case <-time.After(3 * time.Second):
+			t.Error("timed out waiting for stats sample")
+		}
+	}
+	cancelStream()
+
+	// update restarts node
+	if err := c.UpdateNode(ctx, &NodeInfo{
+		NetworkID: n.NetworkID,
+		Resources: NodeResources{DiskGB: 1, MemoryGB: 1, CPUs: 1},
+	}); err != nil {
+		t.Errorf("client.UpdateNode() error = %v", err)
+		return
+	}
+	expectNodeEvent(t, events, "die", n.NetworkID)
+	expectNodeEvent(t, events, "start", n.NetworkID)
+
+	// restart node in place, which should bootstrap it again
+	if err := c.RestartNode(ctx, n); err != nil {
+		t.Errorf("client.RestartNode() error = %v", err)
+		return
+	}
+	expectNodeEvent(t, events, "die", n.NetworkID)
+	expectNodeEvent(t, events, "start", n.NetworkID)
+	if execs = e.Execs(n.DockerID); len(execs) != 4 || execs[3][2] != "add" {
+		t.Errorf("expected node to be bootstrapped again, got execs %v", execs)
+	}
+
+	// pause and resume node
+	if err := c.PauseNode(ctx, n); err != nil {
+		t.Errorf("client.PauseNode() error = %v", err)
+		return
+	}
+	if err := c.PauseNode(ctx, n); err == nil {
+		t.Error("expected error pausing paused node")
+	}
+	if err := c.ResumeNode(ctx, n); err != nil {
+		t.Errorf("client.ResumeNode() error = %v", err)
+		return
+	}
+
+	// upgrade node to a pinned version
+	if err := c.UpgradeNode(ctx, n, "v0.4.19"); err != nil {
+		t.Errorf("client.UpgradeNode() error = %v", err)
+		return
+	}
+	expectNodeEvent(t, events, "die", n.NetworkID)
+	expectNodeEvent(t, events, "start", n.NetworkID)
+	if nodes, _ = c.Nodes(ctx); len(nodes) != 1 || nodes[0].Version != "v0.4.19" {
+		t.Errorf("expected node to be upgraded, got %+v", nodes)
+	}
+	if err := c.UpgradeNode(ctx, n, "not a version"); err == nil {
+		t.Error("expected error for invalid version")
+	}
+
+	// rotate swarm key, which restarts node
+	rotated, err := SwarmKey()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if err := c.RotateSwarmKey(ctx, n, []byte(rotated)); err != nil {
+		t.Errorf("client.RotateSwarmKey() error = %v", err)
+		return
+	}
+	expectNodeEvent(t, events, "die", n.NetworkID)
+	expectNodeEvent(t, events, "start", n.NetworkID)
+	if b, _ := ioutil.ReadFile(filepath.Join(c.(*Client).getDataDir(n.NetworkID), "swarm.key")); string(b) != rotated {
+		t.Errorf("expected swarm key to be rotated, got '%s'", b)
+	}
+	if err := c.RotateSwarmKey(ctx, n, nil); err == nil {
+		t.Error("expected error for empty swarm key")
+	}
+
+	// stop node
+	if err := c.StopNode(ctx, n); err != nil {
+		t.Errorf("client.StopNode() error = %v", err)
+		return
+	}
+	expectNodeEvent(t, events, "die", n.NetworkID)
+	if nodes, _ = c.Nodes(ctx); len(nodes) != 0 {
+		t.Errorf("expected no nodes, got %+v", nodes)
+	}
+
+	// assets should outlive node until removed
+	if exists, err := c.NodeAssetsExist(ctx, n.NetworkID); err != nil || !exists {
+		t.Errorf("expected assets to exist, got %v (error %v)", exists, err)
+	}
+	if err := c.RemoveNode(ctx, n.NetworkID); err != nil {
+		t.Errorf("client.RemoveNode() error = %v", err)
+		return
+	}
+	if exists, err := c.NodeAssetsExist(ctx, n.NetworkID); err != nil || exists {
+		t.Errorf("expected assets to be removed, got %v (error %v)", exists, err)
+	}
+}
+
+func expectNodeEvent(t *testing.T, events <-chan Event, status, network string) {
+	select {
+	case e := <-events:
+		if e.Status != status || e.Node.NetworkID != network {
+			t.Errorf("expected '%s' event for '%s', got %+v", status, network, e)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("timed out waiting for '%s' event for '%s'", status, network)
+	}
+}
+
+func Test_client_Watch(t *testing.T) {
+	c, e, srv, err := newEmulatedTestClient()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer srv.Close()
+	key, err := SwarmKey()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// nobody is receiving errors - watcher should not block
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, errs := c.Watch(ctx)
+	time.Sleep(100 * time.Millisecond)
+	srv.CloseClientConnections()
+	time.Sleep(watchBackoffMin + 500*time.Millisecond)
+
+	// watcher should reconnect and continue to deliver events
+	var n = &NodeInfo{
+		NetworkID: "test_watch",
+		Ports:     NodePorts{Swarm: "4001", API: "5001", Gateway: "8080"},
+	}
+	defer c.RemoveNode(context.Background(), n.NetworkID)
+	if err := c.CreateNode(context.Background(), n, NodeOpts{SwarmKey: []byte(key)}); err != nil {
+		t.Error(err)
+		return
+	}
+	defer c.StopNode(context.Background(), n)
+	if err := e.Kill(n.DockerID); err != nil {
+		t.Error(err)
+		return
+	}
+	var deadline = time.After(5 * time.Second)
+	for received := false; !received; {
+		select {
+		case event := <-events:
+			received = event.Status == "die" && event.Node.NetworkID == n.NetworkID
+		case <-deadline:
+			t.Error("timed out waiting for event after reconnect")
+			received = true
+		}
+	}
+
+	// killed nodes should not be restarted by the runtime
+	if nodes, err := c.Nodes(context.Background()); err != nil || len(nodes) != 0 {
+		t.Errorf("expected no running nodes, got %+v (error %v)", nodes, err)
+	}
+	stopped, err := c.StoppedNodes(context.Background())
+	if err != nil || len(stopped) != 1 || stopped[0].NetworkID != n.NetworkID {
+		t.Errorf("expected stopped node, got %+v (error %v)", stopped, err)
+	}
+
+	// watcher should shut down when context is cancelled
+	cancel()
+	select {
+	case _, ok := <-errs:
+		for ok {
+			_, ok = <-errs
+		}
+	case <-time.After(time.Second):
+		t.Error("watcher did not shut down")
+	}
+}
@@ -1,14 +1,14 @@
 package ipfs
 
 import (
-	"bufio"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"strings"
+	"strconv"
 	"time"
 
 	"github.com/docker/docker/api/types"
@@ -19,55 +19,98 @@ func (c *Client) getDataDir(network string) string {
 	return p
 }
 
-func (c *Client) waitForNode(ctx context.Context, dockerID string) error {
-	logs, err := c.d.ContainerLogs(ctx, dockerID, types.ContainerLogsOptions{
+// containerLogs retrieves output from the given container, optionally prefixing
+// each line with its timestamp. Node containers are created with a TTY, so
+// output is not multiplexed.
+func (c *Client) containerLogs(ctx context.Context, dockerID string, opts LogOptions,
+	timestamps bool) (io.ReadCloser, error) {
+	var logOpts = types.ContainerLogsOptions{
 		ShowStdout: true,
-		Follow:     true,
-	})
-	if err != nil {
-		return err
+		ShowStderr: true,
+		Timestamps: timestamps,
+		Follow:     opts.Follow,
 	}
-	defer logs.Close()
-
-	scanner := bufio.NewScanner(logs)
-	for scanner.Scan() {
-		select {
-		case <-ctx.Done():
-			return fmt.Errorf("cancelled wait for %s", dockerID)
-		default:
-			if strings.Contains(scanner.Text(), "Daemon is ready") {
-				return nil
-			}
-		}
+	if !opts.Since.IsZero() {
+		logOpts.Since = logTimestamp(opts.Since)
+	}
+	if !opts.Until.IsZero() {
+		logOpts.Until = logTimestamp(opts.Until)
 	}
+	if opts.Tail > 0 {
+		logOpts.Tail = strconv.Itoa(opts.Tail)
+	}
+	return c.d.ContainerLogs(ctx, dockerID, logOpts)
+}
 
-	return scanner.Err()
+// logTimestamp formats the given time in the "seconds.nanoseconds" format
+// accepted by the Engine API
+func logTimestamp(t time.Time) string {
+	return fmt.Sprintf("%d.%09d", t.Unix(), t.Nanosecond())
 }
 
 func (c *Client) initNodeAssets(n *NodeInfo, opts NodeOpts) error {
 	// set up directories
 	os.MkdirAll(c.getDataDir(n.NetworkID), c.fileMode)
 
-	// write swarm.key to mount point, otherwise check if a swarm key exists
+	// write normalized swarm.key to mount point, otherwise check if a valid
+	// swarm key exists
 	keyPath := c.getDataDir(n.NetworkID) + "/swarm.key"
 	if opts.SwarmKey != nil {
+		key, err := ParseSwarmKey(opts.SwarmKey)
+		if err != nil {
+			return err
+		}
 		c.l.Infow("writing provided swarm key to disk",
-			"node.key_path", keyPath)
-		if err := ioutil.WriteFile(keyPath, opts.SwarmKey, c.fileMode); err != nil {
+			"node.key_path", keyPath,
+			"node.key_fingerprint", key.Fingerprint())
+		if err := ioutil.WriteFile(keyPath, key.Bytes(), c.fileMode); err != nil {
 			return fmt.Errorf("failed to write key: %s", err.Error())
 		}
 	} else {
 		c.l.Infow("no swarm key provided - attempting to find existing key",
 			"node.key_path", keyPath)
-		if _, err := os.Stat(keyPath); err != nil {
+		b, err := ioutil.ReadFile(keyPath)
+		if err != nil {
 			return fmt.Errorf("unable to find swarm key: %s", err.Error())
 		}
+		key, err := ParseSwarmKey(b)
+		if err != nil {
+			return fmt.Errorf("existing swarm key is invalid: %s", err.Error())
+		}
+		c.l.Infow("found existing swarm key",
+			"node.key_path", keyPath,
+			"node.key_fingerprint", key.Fingerprint())
+	}
+
+	// prepare existing identity, if one is provided
+	if opts.PeerKey != "" {
+		if err := c.initIdentity(n, opts.PeerKey); err != nil {
+			return err
+		}
+	}
+
+	// write node configuration, otherwise keep existing configuration
+	configPath := filepath.Join(c.getDataDir(n.NetworkID), nodeConfigFile)
+	if _, err := os.Stat(configPath); opts.Config != nil || err != nil {
+		var cfg = DefaultNodeConfig()
+		if opts.Config != nil {
+			cfg = *opts.Config
+		}
+		b, err := encodeNodeConfig(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to generate node configuration: %s", err.Error())
+		}
+		c.l.Infow("writing node configuration to disk",
+			"node.config_path", configPath)
+		if err := ioutil.WriteFile(configPath, b, c.fileMode); err != nil {
+			return fmt.Errorf("failed to write node configuration: %s", err.Error())
+		}
 	}
 
 	// generate initialization script
-	script, err := newNodeStartScript(n.Resources.DiskGB)
+	script, err := c.newStartScript(n).Render()
 	if err != nil {
-		return fmt.Errorf("failed to generate startup script: %s", err.Error())
+		return err
 	}
 	if err := ioutil.WriteFile(
 		c.getDataDir(n.NetworkID)+"/ipfs_start",
@@ -86,31 +129,25 @@ func (c *Client) bootstrapNode(ctx context.Context, dockerID string, peers ...st
 	}
 
 	// remove default peers
-	c.containerExec(ctx, dockerID, []string{"ipfs", "bootstrap", "rm", "--all"})
+	if _, _, err := c.containerExec(ctx, dockerID,
+		[]string{"ipfs", "bootstrap", "rm", "--all"}); err != nil {
+		return err
+	}
 
 	// bootstrap custom peers
-	return c.containerExec(ctx, dockerID,
+	_, _, err := c.containerExec(ctx, dockerID,
 		append([]string{"ipfs", "bootstrap", "add"}, peers...))
+	return err
 }
 
 func (c *Client) updateIPFSConfig(ctx context.Context, n *NodeInfo) error {
-	// NOTE: ideally, we would use ipfs commands to update the daemon configuration.
-	// this is currently impossible - see:
-	// https://github.com/ipfs/go-ipfs/issues/4380
-	/*
-		if len(config) == 0 {
-			return errors.New("no config provided")
-		}
-		for key, val := range config {
-			if err := c.containerExec(ctx, dockerID, []string{"ipfs", "config", key, val}); err != nil {
-				return err
-			}
-		}
-	*/
-
-	script, err := newNodeStartScript(n.Resources.DiskGB)
+	// the daemon only reads its configuration on startup - see
+	// https://github.com/ipfs/go-ipfs/issues/4380 - so configuration changes are
+	// written to the repo by the startup script, and the node is restarted.
+	// Node configuration in the node's data directory is applied as well.
+	script, err := c.newStartScript(n).Render()
 	if err != nil {
-		return fmt.Errorf("failed to generate startup script: %s", err.Error())
+		return err
 	}
 	if err := ioutil.WriteFile(
 		c.getDataDir(n.NetworkID)+"/ipfs_start",
@@ -125,7 +162,7 @@ func (c *Client) updateIPFSConfig(ctx context.Context, n *NodeInfo) error {
 		return fmt.Errorf("failed to restart container: %s", err.Error())
 	}
 
-	if err := c.waitForNode(ctx, n.DockerID); err != nil {
+	if err := c.waitForNode(ctx, n); err != nil {
 		return fmt.Errorf("error occured waiting for node to start: %s", err.Error())
 	}
 
@@ -137,11 +174,3 @@ func (c *Client) updateIPFSConfig(ctx context.Context, n *NodeInfo) error {
 
 	return nil
 }
-
-func (c *Client) containerExec(ctx context.Context, dockerID string, args []string) error {
-	exec, err := c.d.ContainerExecCreate(ctx, dockerID, types.ExecConfig{Cmd: args})
-	if err != nil {
-		return err
-	}
-	return c.d.ContainerExecStart(ctx, exec.ID, types.ExecStartCheck{})
-}
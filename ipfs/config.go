@@ -1,13 +1,41 @@
 package ipfs
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
-
-	internal "github.com/RTradeLtd/Nexus/ipfs/internal"
+	"net"
+	"strings"
+	"time"
 )
 
+// nodeConfigFile is the file in a node's data directory that holds the
+// configuration the node's startup script writes to its repo before the daemon
+// starts. Each line is a go-ipfs configuration key followed by its JSON value.
+const nodeConfigFile = "nexus_config"
+
+// privateRanges are the address ranges go-ipfs's "server" profile does not
+// announce, since they are unreachable from other hosts
+var privateRanges = []string{
+	"/ip4/10.0.0.0/ipcidr/8",
+	"/ip4/100.64.0.0/ipcidr/10",
+	"/ip4/169.254.0.0/ipcidr/16",
+	"/ip4/172.16.0.0/ipcidr/12",
+	"/ip4/192.0.0.0/ipcidr/24",
+	"/ip4/192.0.0.0/ipcidr/29",
+	"/ip4/192.0.0.8/ipcidr/32",
+	"/ip4/192.0.0.170/ipcidr/32",
+	"/ip4/192.0.0.171/ipcidr/32",
+	"/ip4/192.0.2.0/ipcidr/24",
+	"/ip4/192.168.0.0/ipcidr/16",
+	"/ip4/198.18.0.0/ipcidr/15",
+	"/ip4/198.51.100.0/ipcidr/24",
+	"/ip4/203.0.113.0/ipcidr/24",
+	"/ip4/240.0.0.0/ipcidr/4",
+}
+
 // GoIPFSConfig is a subset of go-ipfs's configuration structure
 type GoIPFSConfig struct {
 	Identity struct {
@@ -16,6 +44,285 @@ type GoIPFSConfig struct {
 	}
 }
 
+// NodeConfig is the subset of go-ipfs's configuration managed by Nexus. It is
+// written to a node's repo every time the node starts, replacing the repo's
+// values for these fields. API and gateway addresses are not included, since
+// they are fixed by the node's container.
+type NodeConfig struct {
+	Addresses    AddressesConfig
+	Swarm        SwarmConfig
+	Gateway      GatewayConfig
+	Experimental ExperimentalConfig
+	Datastore    DatastoreConfig
+	Reprovider   ReproviderConfig
+	Routing      RoutingConfig
+}
+
+// AddressesConfig declares the addresses a node listens on and advertises
+type AddressesConfig struct {
+	Swarm      []string
+	Announce   []string
+	NoAnnounce []string
+}
+
+// SwarmConfig declares swarm settings
+type SwarmConfig struct {
+	ConnMgr ConnMgrConfig
+}
+
+// ConnMgrConfig declares connection manager settings. Once a node has more
+// than HighWater connections, connections older than GracePeriod are closed
+// until LowWater connections remain.
+type ConnMgrConfig struct {
+	Type        string
+	LowWater    int
+	HighWater   int
+	GracePeriod string
+}
+
+// GatewayConfig declares gateway settings
+type GatewayConfig struct {
+	HTTPHeaders map[string][]string
+}
+
+// ExperimentalConfig declares experimental go-ipfs features
+type ExperimentalConfig struct {
+	FilestoreEnabled     bool
+	UrlstoreEnabled      bool
+	ShardingEnabled      bool
+	Libp2pStreamMounting bool
+	P2pHttpProxy         bool
+	QUIC                 bool
+}
+
+// DatastoreConfig declares datastore settings. The storage limit is set from
+// the node's disk resources.
+type DatastoreConfig struct {
+	GCPeriod string
+}
+
+// ReproviderConfig declares how often and which content is reprovided
+type ReproviderConfig struct {
+	Interval string
+	Strategy string
+}
+
+// RoutingConfig declares content routing settings
+type RoutingConfig struct {
+	Type string
+}
+
+// DefaultNodeConfig returns the configuration nodes run with unless it is
+// overridden, which matches the defaults of go-ipfs's "server" profile
+func DefaultNodeConfig() NodeConfig {
+	return NodeConfig{
+		Addresses: AddressesConfig{
+			Swarm:      []string{"/ip4/0.0.0.0/tcp/4001", "/ip6/::/tcp/4001"},
+			Announce:   []string{},
+			NoAnnounce: append([]string{}, privateRanges...),
+		},
+		Swarm: SwarmConfig{
+			ConnMgr: ConnMgrConfig{
+				Type:        "basic",
+				LowWater:    600,
+				HighWater:   900,
+				GracePeriod: "20s",
+			},
+		},
+		Gateway: GatewayConfig{
+			HTTPHeaders: map[string][]string{
+				"Access-Control-Allow-Origin":  {"*"},
+				"Access-Control-Allow-Methods": {"GET"},
+				"Access-Control-Allow-Headers": {"X-Requested-With", "Range"},
+			},
+		},
+		Datastore: DatastoreConfig{
+			GCPeriod: "1h",
+		},
+		Reprovider: ReproviderConfig{
+			Interval: "12h",
+			Strategy: "all",
+		},
+		Routing: RoutingConfig{
+			Type: "dht",
+		},
+	}
+}
+
+// WithOverrides returns a copy of the configuration with the given overrides
+// applied. Overrides are JSON documents of the same structure as NodeConfig in
+// which only the fields to change are set - lists are replaced, while headers
+// are merged.
+func (c NodeConfig) WithOverrides(overrides ...[]byte) (NodeConfig, error) {
+	// copy configuration, so that overrides do not modify shared lists and maps
+	b, err := json.Marshal(c)
+	if err != nil {
+		return c, err
+	}
+	var cfg NodeConfig
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return c, err
+	}
+
+	for _, o := range overrides {
+		if len(bytes.TrimSpace(o)) == 0 {
+			continue
+		}
+		var dec = json.NewDecoder(bytes.NewReader(o))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&cfg); err != nil {
+			return c, fmt.Errorf("invalid node configuration: %s", err.Error())
+		}
+	}
+	return cfg, cfg.Validate()
+}
+
+// WithAnnounce returns a copy of the configuration that announces the given
+// addresses, unless announce addresses are already configured. Unannounced
+// ranges that contain any of the given addresses are dropped, so that nodes on
+// hosts with private addresses remain reachable within their network.
+func (c NodeConfig) WithAnnounce(addrs ...string) NodeConfig {
+	if len(c.Addresses.Announce) > 0 || len(addrs) == 0 {
+		return c
+	}
+	var noAnnounce = make([]string, 0, len(c.Addresses.NoAnnounce))
+	for _, r := range c.Addresses.NoAnnounce {
+		if !rangeContainsAny(r, addrs) {
+			noAnnounce = append(noAnnounce, r)
+		}
+	}
+	c.Addresses.Announce = append([]string{}, addrs...)
+	c.Addresses.NoAnnounce = noAnnounce
+	return c
+}
+
+// Validate checks that the configuration can be used by go-ipfs
+func (c NodeConfig) Validate() error {
+	if len(c.Addresses.Swarm) == 0 {
+		return errors.New("invalid node configuration: no swarm addresses")
+	}
+	switch c.Swarm.ConnMgr.Type {
+	case "basic":
+		if c.Swarm.ConnMgr.LowWater < 0 || c.Swarm.ConnMgr.HighWater < c.Swarm.ConnMgr.LowWater {
+			return fmt.Errorf("invalid node configuration: connection manager low water %d "+
+				"must be between 0 and high water %d", c.Swarm.ConnMgr.LowWater, c.Swarm.ConnMgr.HighWater)
+		}
+	case "none":
+	default:
+		return fmt.Errorf("invalid node configuration: unknown connection manager type '%s'",
+			c.Swarm.ConnMgr.Type)
+	}
+	for name, d := range map[string]string{
+		"connection manager grace period": c.Swarm.ConnMgr.GracePeriod,
+		"datastore GC period":             c.Datastore.GCPeriod,
+		"reprovider interval":             c.Reprovider.Interval,
+	} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(d); err != nil {
+			return fmt.Errorf("invalid node configuration: invalid %s '%s'", name, d)
+		}
+	}
+	switch c.Reprovider.Strategy {
+	case "", "all", "pinned", "roots":
+	default:
+		return fmt.Errorf("invalid node configuration: unknown reprovider strategy '%s'",
+			c.Reprovider.Strategy)
+	}
+	switch c.Routing.Type {
+	case "", "dht", "dhtclient", "none":
+	default:
+		return fmt.Errorf("invalid node configuration: unknown routing type '%s'", c.Routing.Type)
+	}
+	return nil
+}
+
+// entries returns the configuration as go-ipfs configuration keys and their
+// JSON values, in the order they are written to the repo. Empty settings are
+// left out, so that go-ipfs's defaults apply.
+func (c NodeConfig) entries() ([][2]string, error) {
+	var headers = c.Gateway.HTTPHeaders
+	if headers == nil {
+		headers = map[string][]string{}
+	}
+	var values = []struct {
+		key   string
+		value interface{}
+	}{
+		{"Addresses.Swarm", nonNil(c.Addresses.Swarm)},
+		{"Addresses.Announce", nonNil(c.Addresses.Announce)},
+		{"Addresses.NoAnnounce", nonNil(c.Addresses.NoAnnounce)},
+		{"Swarm.ConnMgr", c.Swarm.ConnMgr},
+		{"Gateway.HTTPHeaders", headers},
+		{"Experimental.FilestoreEnabled", c.Experimental.FilestoreEnabled},
+		{"Experimental.UrlstoreEnabled", c.Experimental.UrlstoreEnabled},
+		{"Experimental.ShardingEnabled", c.Experimental.ShardingEnabled},
+		{"Experimental.Libp2pStreamMounting", c.Experimental.Libp2pStreamMounting},
+		{"Experimental.P2pHttpProxy", c.Experimental.P2pHttpProxy},
+		{"Experimental.QUIC", c.Experimental.QUIC},
+		{"Datastore.GCPeriod", c.Datastore.GCPeriod},
+		{"Reprovider.Interval", c.Reprovider.Interval},
+		{"Reprovider.Strategy", c.Reprovider.Strategy},
+		{"Routing.Type", c.Routing.Type},
+	}
+	var entries = make([][2]string, 0, len(values))
+	for _, v := range values {
+		if v.value == "" {
+			continue
+		}
+		b, err := json.Marshal(v.value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode '%s': %s", v.key, err.Error())
+		}
+		entries = append(entries, [2]string{v.key, string(b)})
+	}
+	return entries, nil
+}
+
+// encodeNodeConfig formats the given configuration for the node config file
+func encodeNodeConfig(c NodeConfig) ([]byte, error) {
+	entries, err := c.entries()
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	for _, e := range entries {
+		b.WriteString(e[0] + " " + e[1] + "\n")
+	}
+	return b.Bytes(), nil
+}
+
+// rangeContainsAny checks if the given "/ip4/.../ipcidr/..." or
+// "/ip6/.../ipcidr/..." range contains the IP of any of the given multiaddrs
+func rangeContainsAny(r string, addrs []string) bool {
+	var parts = strings.Split(r, "/")
+	if len(parts) != 5 || parts[3] != "ipcidr" {
+		return false
+	}
+	_, cidr, err := net.ParseCIDR(parts[2] + "/" + parts[4])
+	if err != nil {
+		return false
+	}
+	for _, a := range addrs {
+		var p = strings.Split(a, "/")
+		if len(p) < 3 || (p[1] != "ip4" && p[1] != "ip6") {
+			continue
+		}
+		if ip := net.ParseIP(p[2]); ip != nil && cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
 func getConfig(path string) (*GoIPFSConfig, error) {
 	/* #nosec */
 	b, err := ioutil.ReadFile(path)
@@ -29,13 +336,3 @@ func getConfig(path string) (*GoIPFSConfig, error) {
 	}
 	return &c, nil
 }
-
-func newNodeStartScript(diskMax int) (string, error) {
-	f, err := internal.ReadFile("ipfs/internal/ipfs_start.sh")
-	if err != nil {
-		return "", fmt.Errorf("failed to generate startup script: %s", err.Error())
-	}
-	return fmt.Sprintf(string(f),
-		diskMax,
-	), nil
-}
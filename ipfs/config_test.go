@@ -1,9 +1,8 @@
 package ipfs
 
 import (
-	"fmt"
-	"io/ioutil"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -38,20 +37,140 @@ func Test_getConfig(t *testing.T) {
 	}
 }
 
-func Test_newNodeStartScript(t *testing.T) {
+func TestNodeConfig_WithOverrides(t *testing.T) {
 	var (
-		disk = 10
+		connMgr   = DefaultNodeConfig()
+		filestore = DefaultNodeConfig()
+		headers   = DefaultNodeConfig()
+		addresses = DefaultNodeConfig()
 	)
+	connMgr.Swarm.ConnMgr.LowWater, connMgr.Swarm.ConnMgr.HighWater = 1000, 2000
+	filestore.Experimental.FilestoreEnabled = true
+	headers.Gateway.HTTPHeaders["X-Custom"] = []string{"hello"}
+	addresses.Addresses.Swarm = []string{"/ip4/0.0.0.0/tcp/4001"}
 
-	f, _ := ioutil.ReadFile("./internal/ipfs_start.sh")
-	expected := fmt.Sprintf(string(f), disk)
+	tests := []struct {
+		name      string
+		overrides []string
+		want      NodeConfig
+		wantErr   bool
+	}{
+		{"no overrides", nil, DefaultNodeConfig(), false},
+		{"empty override", []string{""}, DefaultNodeConfig(), false},
+		{"connection manager",
+			[]string{`{"Swarm": {"ConnMgr": {"LowWater": 1000, "HighWater": 2000}}}`}, connMgr, false},
+		{"layered overrides",
+			[]string{`{"Swarm": {"ConnMgr": {"LowWater": 1000}}}`, `{"Swarm": {"ConnMgr": {"HighWater": 2000}}}`},
+			connMgr, false},
+		{"filestore", []string{`{"Experimental": {"FilestoreEnabled": true}}`}, filestore, false},
+		{"headers are merged", []string{`{"Gateway": {"HTTPHeaders": {"X-Custom": ["hello"]}}}`}, headers, false},
+		{"lists are replaced", []string{`{"Addresses": {"Swarm": ["/ip4/0.0.0.0/tcp/4001"]}}`}, addresses, false},
+		{"invalid json", []string{`{"Swarm": `}, NodeConfig{}, true},
+		{"unknown field", []string{`{"Swarm": {"ConnMgr": {"MaxConns": 2000}}}`}, NodeConfig{}, true},
+		{"invalid water marks", []string{`{"Swarm": {"ConnMgr": {"LowWater": 1000, "HighWater": 10}}}`}, NodeConfig{}, true},
+		{"invalid connection manager", []string{`{"Swarm": {"ConnMgr": {"Type": "fancy"}}}`}, NodeConfig{}, true},
+		{"invalid duration", []string{`{"Datastore": {"GCPeriod": "sometimes"}}`}, NodeConfig{}, true},
+		{"invalid strategy", []string{`{"Reprovider": {"Strategy": "some"}}`}, NodeConfig{}, true},
+		{"invalid routing", []string{`{"Routing": {"Type": "gossip"}}`}, NodeConfig{}, true},
+		{"no swarm addresses", []string{`{"Addresses": {"Swarm": []}}`}, NodeConfig{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var overrides = make([][]byte, len(tt.overrides))
+			for i, o := range tt.overrides {
+				overrides[i] = []byte(o)
+			}
+			var base = DefaultNodeConfig()
+			got, err := base.WithOverrides(overrides...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NodeConfig.WithOverrides() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NodeConfig.WithOverrides() = %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(base, DefaultNodeConfig()) {
+				t.Errorf("expected base configuration to be unchanged, got %+v", base)
+			}
+		})
+	}
+}
 
-	got, err := newNodeStartScript(disk)
+func Test_encodeNodeConfig(t *testing.T) {
+	var cfg = DefaultNodeConfig()
+	cfg.Experimental.FilestoreEnabled = true
+	cfg.Routing.Type = ""
+	cfg.Gateway.HTTPHeaders = nil
+
+	b, err := encodeNodeConfig(cfg)
 	if err != nil {
-		t.Error("unexpected err:", err.Error())
+		t.Error(err)
 		return
 	}
-	if got != expected {
-		t.Errorf("expected '%s', got '%s'", expected, got)
+	var values = map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		var parts = strings.SplitN(line, " ", 2)
+		if len(parts) != 2 {
+			t.Errorf("invalid line '%s'", line)
+			continue
+		}
+		values[parts[0]] = parts[1]
+	}
+	for key, want := range map[string]string{
+		"Addresses.Swarm":               `["/ip4/0.0.0.0/tcp/4001","/ip6/::/tcp/4001"]`,
+		"Addresses.Announce":            `[]`,
+		"Swarm.ConnMgr":                 `{"Type":"basic","LowWater":600,"HighWater":900,"GracePeriod":"20s"}`,
+		"Gateway.HTTPHeaders":           `{}`,
+		"Experimental.FilestoreEnabled": `true`,
+		"Experimental.QUIC":             `false`,
+		"Datastore.GCPeriod":            `"1h"`,
+	} {
+		if values[key] != want {
+			t.Errorf("expected '%s' to be %s, got %s", key, want, values[key])
+		}
+	}
+	if _, found := values["Routing.Type"]; found {
+		t.Error("expected empty settings to be left out")
+	}
+}
+
+func TestNodeConfig_WithAnnounce(t *testing.T) {
+	var configured = DefaultNodeConfig()
+	configured.Addresses.Announce = []string{"/dns4/example.com/tcp/4001"}
+
+	tests := []struct {
+		name           string
+		base           NodeConfig
+		addrs          []string
+		wantAnnounce   []string
+		wantNoAnnounce int
+	}{
+		{"no addresses", DefaultNodeConfig(), nil,
+			[]string{}, len(privateRanges)},
+		{"public address", DefaultNodeConfig(), []string{"/ip4/1.2.3.4/tcp/4001"},
+			[]string{"/ip4/1.2.3.4/tcp/4001"}, len(privateRanges)},
+		{"private address", DefaultNodeConfig(), []string{"/ip4/192.168.1.4/tcp/4001"},
+			[]string{"/ip4/192.168.1.4/tcp/4001"}, len(privateRanges) - 1},
+		{"dns address", DefaultNodeConfig(), []string{"/dns4/example.com/tcp/4001"},
+			[]string{"/dns4/example.com/tcp/4001"}, len(privateRanges)},
+		{"already configured", configured, []string{"/ip4/1.2.3.4/tcp/4001"},
+			[]string{"/dns4/example.com/tcp/4001"}, len(privateRanges)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got = tt.base.WithAnnounce(tt.addrs...)
+			if !reflect.DeepEqual(got.Addresses.Announce, tt.wantAnnounce) {
+				t.Errorf("expected announce addresses %v, got %v", tt.wantAnnounce, got.Addresses.Announce)
+			}
+			if len(got.Addresses.NoAnnounce) != tt.wantNoAnnounce {
+				t.Errorf("expected %d unannounced ranges, got %v", tt.wantNoAnnounce, got.Addresses.NoAnnounce)
+			}
+			if len(tt.base.Addresses.NoAnnounce) != len(privateRanges) {
+				t.Error("expected base configuration to be unchanged")
+			}
+		})
 	}
 }
@@ -1,6 +1,7 @@
 package ipfs
 
 import (
+	"strings"
 	"time"
 
 	"github.com/docker/docker/api/types/container"
@@ -12,6 +13,22 @@ const (
 	containerGatewayPort = "8080"
 )
 
+const (
+	// watchBackoffMin is the initial delay before reconnecting to a dropped
+	// event stream
+	watchBackoffMin = 1 * time.Second
+	// watchBackoffMax is the maximum delay before reconnecting to a dropped
+	// event stream
+	watchBackoffMax = 30 * time.Second
+
+	// minStatsInterval is the minimum interval between streamed statistics
+	// samples, since Docker samples container statistics once a second
+	minStatsInterval = 1 * time.Second
+
+	// maxLogLineSize is the maximum length of a line of node output
+	maxLogLineSize = 1024 * 1024
+)
+
 // containerResources generates Docker resource constraints for a container,
 // based on documentation:
 // https://docs.docker.com/config/containers/resource_constraints/
@@ -27,106 +44,147 @@ func containerResources(n *NodeInfo) container.Resources {
 	}
 }
 
+// parseLogLine reads a line of container output that is prefixed with a
+// timestamp. TTY output may have trailing carriage returns, which are removed.
+func parseLogLine(line string) LogLine {
+	line = strings.TrimRight(line, "\r")
+	var parts = strings.SplitN(line, " ", 2)
+	t, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return LogLine{Text: line}
+	}
+	if len(parts) < 2 {
+		return LogLine{Time: t}
+	}
+	return LogLine{Time: t, Text: parts[1]}
+}
+
+// rawContainerStats is the format of container statistics reported by the
+// Docker Engine API
 type rawContainerStats struct {
 	Read      time.Time `json:"read"`
 	Preread   time.Time `json:"preread"`
 	PidsStats struct {
-		Current int `json:"current"`
+		Current uint64 `json:"current"`
 	} `json:"pids_stats"`
 	BlkioStats struct {
-		IoServiceBytesRecursive []interface{} `json:"io_service_bytes_recursive"`
-		IoServicedRecursive     []interface{} `json:"io_serviced_recursive"`
-		IoQueueRecursive        []interface{} `json:"io_queue_recursive"`
-		IoServiceTimeRecursive  []interface{} `json:"io_service_time_recursive"`
-		IoWaitTimeRecursive     []interface{} `json:"io_wait_time_recursive"`
-		IoMergedRecursive       []interface{} `json:"io_merged_recursive"`
-		IoTimeRecursive         []interface{} `json:"io_time_recursive"`
-		SectorsRecursive        []interface{} `json:"sectors_recursive"`
+		IoServiceBytesRecursive []rawBlkioEntry `json:"io_service_bytes_recursive"`
 	} `json:"blkio_stats"`
-	NumProcs     int `json:"num_procs"`
-	StorageStats struct {
-	} `json:"storage_stats"`
-	CPUStats struct {
-		CPUUsage struct {
-			TotalUsage        int   `json:"total_usage"`
-			PercpuUsage       []int `json:"percpu_usage"`
-			UsageInKernelmode int   `json:"usage_in_kernelmode"`
-			UsageInUsermode   int   `json:"usage_in_usermode"`
-		} `json:"cpu_usage"`
-		SystemCPUUsage int64 `json:"system_cpu_usage"`
-		OnlineCpus     int   `json:"online_cpus"`
-		ThrottlingData struct {
-			Periods          int `json:"periods"`
-			ThrottledPeriods int `json:"throttled_periods"`
-			ThrottledTime    int `json:"throttled_time"`
-		} `json:"throttling_data"`
-	} `json:"cpu_stats"`
-	PrecpuStats struct {
-		CPUUsage struct {
-			TotalUsage        int   `json:"total_usage"`
-			PercpuUsage       []int `json:"percpu_usage"`
-			UsageInKernelmode int   `json:"usage_in_kernelmode"`
-			UsageInUsermode   int   `json:"usage_in_usermode"`
-		} `json:"cpu_usage"`
-		SystemCPUUsage int64 `json:"system_cpu_usage"`
-		OnlineCpus     int   `json:"online_cpus"`
-		ThrottlingData struct {
-			Periods          int `json:"periods"`
-			ThrottledPeriods int `json:"throttled_periods"`
-			ThrottledTime    int `json:"throttled_time"`
-		} `json:"throttling_data"`
-	} `json:"precpu_stats"`
+	CPUStats    rawCPUStats `json:"cpu_stats"`
+	PrecpuStats rawCPUStats `json:"precpu_stats"`
 	MemoryStats struct {
-		Usage    int `json:"usage"`
-		MaxUsage int `json:"max_usage"`
-		Stats    struct {
-			ActiveAnon              int   `json:"active_anon"`
-			ActiveFile              int   `json:"active_file"`
-			Cache                   int   `json:"cache"`
-			Dirty                   int   `json:"dirty"`
-			HierarchicalMemoryLimit int64 `json:"hierarchical_memory_limit"`
-			HierarchicalMemswLimit  int64 `json:"hierarchical_memsw_limit"`
-			InactiveAnon            int   `json:"inactive_anon"`
-			InactiveFile            int   `json:"inactive_file"`
-			MappedFile              int   `json:"mapped_file"`
-			Pgfault                 int   `json:"pgfault"`
-			Pgmajfault              int   `json:"pgmajfault"`
-			Pgpgin                  int   `json:"pgpgin"`
-			Pgpgout                 int   `json:"pgpgout"`
-			Rss                     int   `json:"rss"`
-			RssHuge                 int   `json:"rss_huge"`
-			TotalActiveAnon         int   `json:"total_active_anon"`
-			TotalActiveFile         int   `json:"total_active_file"`
-			TotalCache              int   `json:"total_cache"`
-			TotalDirty              int   `json:"total_dirty"`
-			TotalInactiveAnon       int   `json:"total_inactive_anon"`
-			TotalInactiveFile       int   `json:"total_inactive_file"`
-			TotalMappedFile         int   `json:"total_mapped_file"`
-			TotalPgfault            int   `json:"total_pgfault"`
-			TotalPgmajfault         int   `json:"total_pgmajfault"`
-			TotalPgpgin             int   `json:"total_pgpgin"`
-			TotalPgpgout            int   `json:"total_pgpgout"`
-			TotalRss                int   `json:"total_rss"`
-			TotalRssHuge            int   `json:"total_rss_huge"`
-			TotalUnevictable        int   `json:"total_unevictable"`
-			TotalWriteback          int   `json:"total_writeback"`
-			Unevictable             int   `json:"unevictable"`
-			Writeback               int   `json:"writeback"`
-		} `json:"stats"`
-		Limit int `json:"limit"`
+		Usage    uint64            `json:"usage"`
+		MaxUsage uint64            `json:"max_usage"`
+		Stats    map[string]uint64 `json:"stats"`
+		Limit    uint64            `json:"limit"`
 	} `json:"memory_stats"`
-	Name     string `json:"name"`
-	ID       string `json:"id"`
-	Networks struct {
-		Eth0 struct {
-			RxBytes   int `json:"rx_bytes"`
-			RxPackets int `json:"rx_packets"`
-			RxErrors  int `json:"rx_errors"`
-			RxDropped int `json:"rx_dropped"`
-			TxBytes   int `json:"tx_bytes"`
-			TxPackets int `json:"tx_packets"`
-			TxErrors  int `json:"tx_errors"`
-			TxDropped int `json:"tx_dropped"`
-		} `json:"eth0"`
-	} `json:"networks"`
+	Name     string                     `json:"name"`
+	ID       string                     `json:"id"`
+	Networks map[string]rawNetworkStats `json:"networks"`
+}
+
+type rawCPUStats struct {
+	CPUUsage struct {
+		TotalUsage        uint64   `json:"total_usage"`
+		PercpuUsage       []uint64 `json:"percpu_usage"`
+		UsageInKernelmode uint64   `json:"usage_in_kernelmode"`
+		UsageInUsermode   uint64   `json:"usage_in_usermode"`
+	} `json:"cpu_usage"`
+	SystemCPUUsage uint64 `json:"system_cpu_usage"`
+	OnlineCPUs     uint32 `json:"online_cpus"`
+	ThrottlingData struct {
+		Periods          uint64 `json:"periods"`
+		ThrottledPeriods uint64 `json:"throttled_periods"`
+		ThrottledTime    uint64 `json:"throttled_time"`
+	} `json:"throttling_data"`
+}
+
+type rawBlkioEntry struct {
+	Major uint64 `json:"major"`
+	Minor uint64 `json:"minor"`
+	Op    string `json:"op"`
+	Value uint64 `json:"value"`
+}
+
+type rawNetworkStats struct {
+	RxBytes   uint64 `json:"rx_bytes"`
+	RxPackets uint64 `json:"rx_packets"`
+	RxErrors  uint64 `json:"rx_errors"`
+	RxDropped uint64 `json:"rx_dropped"`
+	TxBytes   uint64 `json:"tx_bytes"`
+	TxPackets uint64 `json:"tx_packets"`
+	TxErrors  uint64 `json:"tx_errors"`
+	TxDropped uint64 `json:"tx_dropped"`
+}
+
+// metrics computes resource usage from raw container statistics, in the same
+// manner as "docker stats". CPU usage is computed relative to pre, which should
+// be the CPU statistics of an earlier sample - if it is nil, the previous
+// sample reported by Docker is used.
+func (s *rawContainerStats) metrics(pre *rawCPUStats) ContainerStats {
+	if pre == nil {
+		pre = &s.PrecpuStats
+	}
+	var m = ContainerStats{
+		Time:        s.Read,
+		CPUPercent:  cpuPercent(&s.CPUStats, pre),
+		OnlineCPUs:  s.CPUStats.OnlineCPUs,
+		MemoryUsage: memoryUsage(s.MemoryStats.Usage, s.MemoryStats.Stats),
+		MemoryLimit: s.MemoryStats.Limit,
+		PIDs:        s.PidsStats.Current,
+	}
+	if m.OnlineCPUs == 0 {
+		m.OnlineCPUs = uint32(len(s.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if m.MemoryLimit > 0 {
+		m.MemoryPercent = float64(m.MemoryUsage) / float64(m.MemoryLimit) * 100
+	}
+	for _, n := range s.Networks {
+		m.NetworkRxBytes += n.RxBytes
+		m.NetworkTxBytes += n.TxBytes
+	}
+	for _, e := range s.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(e.Op) {
+		case "read":
+			m.BlockReadBytes += e.Value
+		case "write":
+			m.BlockWriteBytes += e.Value
+		}
+	}
+	return m
+}
+
+// cpuPercent computes the percentage of host CPU used by a container between
+// two samples, where 100% denotes full use of a single core. Without an
+// earlier sample, usage cannot be computed and 0 is returned.
+func cpuPercent(cur, pre *rawCPUStats) float64 {
+	if pre.SystemCPUUsage == 0 ||
+		cur.CPUUsage.TotalUsage <= pre.CPUUsage.TotalUsage ||
+		cur.SystemCPUUsage <= pre.SystemCPUUsage {
+		return 0
+	}
+	var (
+		cpuDelta    = float64(cur.CPUUsage.TotalUsage - pre.CPUUsage.TotalUsage)
+		systemDelta = float64(cur.SystemCPUUsage - pre.SystemCPUUsage)
+		online      = float64(cur.OnlineCPUs)
+	)
+	if online == 0 {
+		online = float64(len(cur.CPUUsage.PercpuUsage))
+	}
+	return cpuDelta / systemDelta * online * 100
+}
+
+// memoryUsage computes the memory used by a container, excluding the page
+// cache, which the kernel reclaims under memory pressure
+func memoryUsage(usage uint64, stats map[string]uint64) uint64 {
+	// cgroups v1 reports total_inactive_file, while v2 reports inactive_file
+	for _, key := range []string{"total_inactive_file", "inactive_file"} {
+		if cache, found := stats[key]; found {
+			if cache < usage {
+				return usage - cache
+			}
+			return usage
+		}
+	}
+	return usage
 }
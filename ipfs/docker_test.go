@@ -0,0 +1,135 @@
+package ipfs
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+)
+
+func Test_rawContainerStats_metrics(t *testing.T) {
+	const sample = `{
+		"read": "2019-01-01T00:00:02Z",
+		"pids_stats": {"current": 12},
+		"cpu_stats": {
+			"cpu_usage": {"total_usage": 3000000000, "percpu_usage": [1500000000, 1500000000]},
+			"system_cpu_usage": 20000000000
+		},
+		"precpu_stats": {
+			"cpu_usage": {"total_usage": 2000000000},
+			"system_cpu_usage": 16000000000,
+			"online_cpus": 2
+		},
+		"memory_stats": {
+			"usage": 104857600,
+			"limit": 419430400,
+			"stats": {"total_inactive_file": 20971520}
+		},
+		"networks": {
+			"eth0": {"rx_bytes": 100, "tx_bytes": 200},
+			"eth1": {"rx_bytes": 10, "tx_bytes": 20}
+		},
+		"blkio_stats": {
+			"io_service_bytes_recursive": [
+				{"major": 8, "minor": 0, "op": "Read", "value": 1000},
+				{"major": 8, "minor": 0, "op": "Write", "value": 2000},
+				{"major": 8, "minor": 0, "op": "Total", "value": 3000}
+			]
+		}
+	}`
+	var raw rawContainerStats
+	if err := json.Unmarshal([]byte(sample), &raw); err != nil {
+		t.Fatal(err)
+	}
+
+	type args struct {
+		pre *rawCPUStats
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantCPU float64
+	}{
+		{"docker previous sample", args{nil}, 50},
+		{"no previous sample", args{&rawCPUStats{}}, 0},
+		{"explicit previous sample", args{func() *rawCPUStats {
+			var pre rawCPUStats
+			pre.CPUUsage.TotalUsage = 1000000000
+			pre.SystemCPUUsage = 12000000000
+			return &pre
+		}()}, 50},
+		{"no usage since previous sample", args{&raw.CPUStats}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m = raw.metrics(tt.args.pre)
+			if math.Abs(m.CPUPercent-tt.wantCPU) > 0.001 {
+				t.Errorf("expected cpu %f%%, got %f%%", tt.wantCPU, m.CPUPercent)
+			}
+			if m.OnlineCPUs != 2 {
+				t.Errorf("expected online cpus from per-cpu usage, got %d", m.OnlineCPUs)
+			}
+			if m.MemoryUsage != 83886080 || m.MemoryLimit != 419430400 || m.MemoryPercent != 20 {
+				t.Errorf("unexpected memory usage %d/%d (%f%%)",
+					m.MemoryUsage, m.MemoryLimit, m.MemoryPercent)
+			}
+			if m.NetworkRxBytes != 110 || m.NetworkTxBytes != 220 {
+				t.Errorf("unexpected network usage rx=%d tx=%d", m.NetworkRxBytes, m.NetworkTxBytes)
+			}
+			if m.BlockReadBytes != 1000 || m.BlockWriteBytes != 2000 {
+				t.Errorf("unexpected block usage read=%d write=%d", m.BlockReadBytes, m.BlockWriteBytes)
+			}
+			if m.PIDs != 12 || m.Time != raw.Read {
+				t.Errorf("unexpected metrics %+v", m)
+			}
+		})
+	}
+}
+
+func Test_memoryUsage(t *testing.T) {
+	type args struct {
+		usage uint64
+		stats map[string]uint64
+	}
+	tests := []struct {
+		name string
+		args args
+		want uint64
+	}{
+		{"no stats", args{100, nil}, 100},
+		{"cgroups v1", args{100, map[string]uint64{"total_inactive_file": 40}}, 60},
+		{"cgroups v2", args{100, map[string]uint64{"inactive_file": 30}}, 70},
+		{"cache exceeds usage", args{100, map[string]uint64{"inactive_file": 300}}, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := memoryUsage(tt.args.usage, tt.args.stats); got != tt.want {
+				t.Errorf("memoryUsage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseLogLine(t *testing.T) {
+	var ts = time.Date(2019, 1, 1, 0, 0, 0, 123456789, time.UTC)
+	type args struct {
+		line string
+	}
+	tests := []struct {
+		name string
+		args args
+		want LogLine
+	}{
+		{"timestamped", args{"2019-01-01T00:00:00.123456789Z Daemon is ready"}, LogLine{ts, "Daemon is ready"}},
+		{"carriage return", args{"2019-01-01T00:00:00.123456789Z Daemon is ready\r"}, LogLine{ts, "Daemon is ready"}},
+		{"empty line", args{"2019-01-01T00:00:00.123456789Z"}, LogLine{ts, ""}},
+		{"no timestamp", args{"Daemon is ready"}, LogLine{time.Time{}, "Daemon is ready"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseLogLine(tt.args.line); !got.Time.Equal(tt.want.Time) || got.Text != tt.want.Text {
+				t.Errorf("parseLogLine() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
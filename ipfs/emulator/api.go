@@ -0,0 +1,118 @@
+package emulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/go-connections/nat"
+)
+
+// nodeAPIPort is the container port of the IPFS API
+const nodeAPIPort = nat.Port("5001/tcp")
+
+// serveAPI starts an emulated IPFS API on the host port published for the
+// container's API port, if there is one. The caller must hold Engine::mux
+func (e *Engine) serveAPI(c *emuContainer) error {
+	var bindings = c.host.PortBindings[nodeAPIPort]
+	if len(bindings) == 0 || bindings[0].HostPort == "" {
+		return nil
+	}
+	var addr = net.JoinHostPort(bindings[0].HostIP, bindings[0].HostPort)
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf(
+			"driver failed programming external connectivity on endpoint %s (%s): Error starting userland proxy: %s",
+			c.name, c.id, err.Error())
+	}
+
+	var mux = http.NewServeMux()
+	mux.HandleFunc("/api/v0/id", func(w http.ResponseWriter, r *http.Request) {
+		e.handleNodeID(w, r, c)
+	})
+	c.api = &http.Server{Handler: mux}
+	go c.api.Serve(ln)
+	return nil
+}
+
+// stopAPI shuts down the container's emulated IPFS API. The caller must hold
+// Engine::mux
+func (c *emuContainer) stopAPI() {
+	if c.api != nil {
+		c.api.Close()
+		c.api = nil
+	}
+}
+
+// handleNodeID emulates the IPFS API's /api/v0/id endpoint. Requests to paused
+// containers hang until the container is unpaused or the request is cancelled,
+// as they would with a frozen daemon.
+func (e *Engine) handleNodeID(w http.ResponseWriter, r *http.Request, c *emuContainer) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	for {
+		e.mux.RLock()
+		var (
+			update = c.update
+			state  = c.state
+			dir    = c.dataDir()
+			image  = c.config.Image
+		)
+		e.mux.RUnlock()
+		if state != statePaused {
+			if state != stateRunning {
+				writeError(w, http.StatusServiceUnavailable, "daemon is not running")
+				return
+			}
+			writeNodeID(w, dir, image)
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-update:
+		}
+	}
+}
+
+// writeNodeID writes the identity of the node with the given data directory.
+// The reported agent version is derived from the tag of the node's image.
+func writeNodeID(w http.ResponseWriter, dir, image string) {
+	var config struct {
+		Identity struct {
+			PeerID string
+		}
+	}
+	if dir != "" {
+		if b, err := ioutil.ReadFile(filepath.Join(dir, "config")); err == nil {
+			json.Unmarshal(b, &config)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"ID":              config.Identity.PeerID,
+		"Addresses":       []string{},
+		"AgentVersion":    "go-ipfs/" + agentVersion(image) + "/",
+		"ProtocolVersion": "ipfs/0.1.0",
+	})
+}
+
+// agentVersion derives a go-ipfs version from the given image reference, such
+// as "0.4.18" for "ipfs/go-ipfs:v0.4.18". Images referenced by digest alone
+// report an "emulated" version.
+func agentVersion(image string) string {
+	var tag = "emulated"
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i >= 0 && !strings.Contains(image[i:], "/") {
+		tag = image[i+1:]
+	}
+	return strings.TrimPrefix(tag, "v")
+}
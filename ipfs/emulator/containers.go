@@ -0,0 +1,902 @@
+package emulator
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+)
+
+const (
+	stateCreated = "created"
+	stateRunning = "running"
+	statePaused  = "paused"
+	stateExited  = "exited"
+
+	// dataMount is the container path at which node data directories are
+	// mounted
+	dataMount = "/data/ipfs"
+
+	streamStdout = 1
+	streamStderr = 2
+)
+
+// emuContainer is a simulated container. Fields are locked by Engine::mux
+type emuContainer struct {
+	id     string
+	name   string
+	config container.Config
+	host   container.HostConfig
+	// image is the ID of the container's image
+	image string
+
+	state      string
+	exitCode   int
+	created    time.Time
+	startedAt  time.Time
+	finishedAt time.Time
+	restarts   int
+
+	logs []logLine
+	// update is closed and replaced whenever logs are written or the container
+	// state changes, to wake up log followers
+	update chan struct{}
+
+	// execs records the commands executed in this container
+	execs [][]string
+
+	// api is the container's emulated IPFS API, if it is running
+	api *http.Server
+}
+
+type logLine struct {
+	time   time.Time
+	stream byte
+	text   string
+}
+
+// createRequest is the body of a container creation request
+type createRequest struct {
+	*container.Config
+	HostConfig *container.HostConfig
+}
+
+// Kill simulates an unexpected exit of the given container, which is then
+// handled according to the container's restart policy
+func (e *Engine) Kill(id string) error {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	var c = e.find(id)
+	if c == nil {
+		return fmt.Errorf("No such container: %s", id)
+	}
+	if !c.running() {
+		return fmt.Errorf("Container %s is not running", id)
+	}
+	e.exit(c, 137, false)
+	return nil
+}
+
+// Execs retrieves the commands executed in the given container
+func (e *Engine) Execs(id string) [][]string {
+	e.mux.RLock()
+	defer e.mux.RUnlock()
+	var c = e.find(id)
+	if c == nil {
+		return nil
+	}
+	var execs = make([][]string, len(c.execs))
+	copy(execs, c.execs)
+	return execs
+}
+
+func (e *Engine) routeContainers(w http.ResponseWriter, r *http.Request, parts []string) {
+	// collection operations
+	if len(parts) == 1 {
+		switch {
+		case parts[0] == "json" && r.Method == http.MethodGet:
+			e.handleList(w, r)
+		case parts[0] == "create" && r.Method == http.MethodPost:
+			e.handleCreate(w, r)
+		case r.Method == http.MethodDelete:
+			e.handleRemove(w, r, parts[0])
+		default:
+			writeError(w, http.StatusNotFound, "page not found")
+		}
+		return
+	}
+	if len(parts) != 2 {
+		writeError(w, http.StatusNotFound, "page not found")
+		return
+	}
+
+	// container operations
+	e.mux.RLock()
+	var c = e.find(parts[0])
+	e.mux.RUnlock()
+	if c == nil {
+		writeError(w, http.StatusNotFound, "No such container: "+parts[0])
+		return
+	}
+	switch r.Method + " " + parts[1] {
+	case "GET json":
+		e.handleInspect(w, r, c)
+	case "POST start":
+		e.handleStart(w, r, c)
+	case "POST stop":
+		e.handleStop(w, r, c)
+	case "POST restart":
+		e.handleRestart(w, r, c)
+	case "POST pause":
+		e.handlePause(w, r, c)
+	case "POST unpause":
+		e.handleUnpause(w, r, c)
+	case "POST update":
+		e.handleUpdate(w, r, c)
+	case "GET stats":
+		e.handleStats(w, r, c)
+	case "GET logs":
+		e.handleLogs(w, r, c)
+	case "POST exec":
+		e.handleExecCreate(w, r, c)
+	default:
+		writeError(w, http.StatusNotFound, "page not found")
+	}
+}
+
+func (e *Engine) handleList(w http.ResponseWriter, r *http.Request) {
+	var all = isTrue(r.URL.Query().Get("all"))
+
+	e.mux.RLock()
+	var list = make([]types.Container, 0, len(e.containers))
+	for _, c := range e.containers {
+		if !all && !c.running() {
+			continue
+		}
+		list = append(list, types.Container{
+			ID:      c.id,
+			Names:   []string{"/" + c.name},
+			Image:   c.config.Image,
+			ImageID: c.image,
+			Command: strings.Join(c.config.Cmd, " "),
+			Created: c.created.Unix(),
+			Ports:   c.ports(),
+			Labels:  c.config.Labels,
+			State:   c.state,
+			Status:  c.status(),
+		})
+	}
+	e.mux.RUnlock()
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (e *Engine) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req createRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Config == nil {
+		writeError(w, http.StatusBadRequest, "invalid container configuration")
+		return
+	}
+	if req.HostConfig == nil {
+		req.HostConfig = &container.HostConfig{}
+	}
+	var name = strings.TrimPrefix(r.URL.Query().Get("name"), "/")
+
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	var image = e.findImage(req.Image)
+	if image == nil {
+		writeError(w, http.StatusNotFound, "No such image: "+req.Image)
+		return
+	}
+	if name != "" {
+		for _, existing := range e.containers {
+			if existing.name == name {
+				writeError(w, http.StatusConflict, fmt.Sprintf(
+					`Conflict. The container name "/%s" is already in use by container "%s". You have to remove (or rename) that container to be able to reuse that name.`,
+					name, existing.id))
+				return
+			}
+		}
+	}
+
+	var c = &emuContainer{
+		id:      newID(),
+		name:    name,
+		config:  *req.Config,
+		host:    *req.HostConfig,
+		image:   image.id,
+		state:   stateCreated,
+		created: time.Now(),
+		update:  make(chan struct{}),
+	}
+	if c.name == "" {
+		c.name = c.id[:12]
+	}
+	e.containers[c.id] = c
+	e.emit(c, "create")
+
+	writeJSON(w, http.StatusCreated, container.ContainerCreateCreatedBody{ID: c.id, Warnings: []string{}})
+}
+
+func (e *Engine) handleInspect(w http.ResponseWriter, r *http.Request, c *emuContainer) {
+	e.mux.RLock()
+	var (
+		config = c.config
+		host   = c.host
+		info   = types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{
+				ID:      c.id,
+				Created: c.created.UTC().Format(time.RFC3339Nano),
+				Args:    c.config.Cmd,
+				State: &types.ContainerState{
+					Status:     c.state,
+					Running:    c.running(),
+					Paused:     c.state == statePaused,
+					ExitCode:   c.exitCode,
+					StartedAt:  formatTime(c.startedAt),
+					FinishedAt: formatTime(c.finishedAt),
+				},
+				Image:        c.image,
+				Name:         "/" + c.name,
+				RestartCount: c.restarts,
+				HostConfig:   &host,
+			},
+			Config: &config,
+		}
+	)
+	e.mux.RUnlock()
+
+	writeJSON(w, http.StatusOK, info)
+}
+
+func (e *Engine) handleStart(w http.ResponseWriter, r *http.Request, c *emuContainer) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	if c.running() {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if err := e.start(c); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (e *Engine) handleStop(w http.ResponseWriter, r *http.Request, c *emuContainer) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	if !c.running() {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	e.exit(c, 0, true)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (e *Engine) handleRestart(w http.ResponseWriter, r *http.Request, c *emuContainer) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	if c.running() {
+		// restarts bypass auto-removal
+		var autoRemove = c.host.AutoRemove
+		c.host.AutoRemove = false
+		e.exit(c, 0, true)
+		c.host.AutoRemove = autoRemove
+	}
+	if err := e.start(c); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	e.emit(c, "restart")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (e *Engine) handlePause(w http.ResponseWriter, r *http.Request, c *emuContainer) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	switch c.state {
+	case stateRunning:
+	case statePaused:
+		writeError(w, http.StatusConflict, fmt.Sprintf("Container %s is already paused", c.id))
+		return
+	default:
+		writeError(w, http.StatusConflict, fmt.Sprintf("Container %s is not running", c.id))
+		return
+	}
+	c.state = statePaused
+	c.notify()
+	e.emit(c, "pause")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (e *Engine) handleUnpause(w http.ResponseWriter, r *http.Request, c *emuContainer) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	if c.state != statePaused {
+		writeError(w, http.StatusConflict, fmt.Sprintf("Container %s is not paused", c.id))
+		return
+	}
+	c.state = stateRunning
+	c.notify()
+	e.emit(c, "unpause")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (e *Engine) handleUpdate(w http.ResponseWriter, r *http.Request, c *emuContainer) {
+	var update container.UpdateConfig
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid update configuration")
+		return
+	}
+
+	e.mux.Lock()
+	c.host.Resources = update.Resources
+	if update.RestartPolicy.Name != "" {
+		c.host.RestartPolicy = update.RestartPolicy
+	}
+	e.emit(c, "update")
+	e.mux.Unlock()
+
+	writeJSON(w, http.StatusOK, container.ContainerUpdateOKBody{Warnings: []string{}})
+}
+
+func (e *Engine) handleRemove(w http.ResponseWriter, r *http.Request, id string) {
+	var force = isTrue(r.URL.Query().Get("force"))
+
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	var c = e.find(id)
+	if c == nil {
+		writeError(w, http.StatusNotFound, "No such container: "+id)
+		return
+	}
+	if c.running() {
+		if !force {
+			writeError(w, http.StatusConflict, fmt.Sprintf(
+				"You cannot remove a running container %s. Stop the container before attempting removal or force remove", c.id))
+			return
+		}
+		e.exit(c, 137, true)
+	}
+	if _, found := e.containers[c.id]; found {
+		e.remove(c)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (e *Engine) handleStats(w http.ResponseWriter, r *http.Request, c *emuContainer) {
+	var stream = r.URL.Query().Get("stream") == "" || isTrue(r.URL.Query().Get("stream"))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	var (
+		enc = json.NewEncoder(w)
+		pre map[string]interface{}
+	)
+	if !stream {
+		// one-shot samples report a previous sample, as Docker does
+		e.mux.RLock()
+		pre = c.stats(time.Now().Add(-e.statsInterval), nil)
+		e.mux.RUnlock()
+	}
+	for {
+		e.mux.RLock()
+		var s = c.stats(time.Now(), pre)
+		e.mux.RUnlock()
+		if err := enc.Encode(s); err != nil || !stream {
+			return
+		}
+		flush(w)
+		pre = s
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(e.statsInterval):
+		}
+	}
+}
+
+func (e *Engine) handleLogs(w http.ResponseWriter, r *http.Request, c *emuContainer) {
+	var (
+		q          = r.URL.Query()
+		stdout     = isTrue(q.Get("stdout"))
+		stderr     = isTrue(q.Get("stderr"))
+		follow     = isTrue(q.Get("follow"))
+		timestamps = isTrue(q.Get("timestamps"))
+	)
+	since, err := parseTimestamp(q.Get("since"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	until, err := parseTimestamp(q.Get("until"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// filter lines to write
+	var include = func(l logLine) bool {
+		return ((l.stream == streamStdout && stdout) || (l.stream == streamStderr && stderr)) &&
+			(since.IsZero() || !l.time.Before(since)) &&
+			(until.IsZero() || l.time.Before(until))
+	}
+	var write = func(lines []logLine, tty bool) error {
+		for _, l := range lines {
+			if !include(l) {
+				continue
+			}
+			var text = l.text
+			if timestamps {
+				text = l.time.UTC().Format(time.RFC3339Nano) + " " + text
+			}
+			if err := writeStream(w, l.stream, text, tty); err != nil {
+				return err
+			}
+		}
+		flush(w)
+		return nil
+	}
+
+	e.mux.RLock()
+	var (
+		tty    = c.config.Tty
+		offset = len(c.logs)
+		lines  = tail(c.logs, q.Get("tail"))
+	)
+	e.mux.RUnlock()
+
+	if tty {
+		w.Header().Set("Content-Type", "application/vnd.docker.raw-stream")
+	} else {
+		w.Header().Set("Content-Type", "application/vnd.docker.multiplexed-stream")
+	}
+	w.WriteHeader(http.StatusOK)
+	if err := write(lines, tty); err != nil || !follow {
+		return
+	}
+
+	// follow logs until the container stops or the request is cancelled
+	for {
+		e.mux.RLock()
+		var (
+			update  = c.update
+			running = c.running()
+		)
+		lines = c.logs[offset:]
+		offset = len(c.logs)
+		e.mux.RUnlock()
+
+		if err := write(lines, tty); err != nil || !running {
+			return
+		}
+		if !until.IsZero() && time.Now().After(until) {
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-update:
+		}
+	}
+}
+
+// start simulates the startup of a container and the IPFS daemon within it.
+// The caller must hold Engine::mux
+func (e *Engine) start(c *emuContainer) error {
+	// check for port conflicts with running containers
+	for _, other := range e.containers {
+		if other.id == c.id || !other.running() {
+			continue
+		}
+		for _, p := range c.hostPorts() {
+			for _, op := range other.hostPorts() {
+				if p == op {
+					return fmt.Errorf(
+						"driver failed programming external connectivity on endpoint %s (%s): Bind for 0.0.0.0:%s failed: port is already allocated",
+						c.name, c.id, p)
+				}
+			}
+		}
+	}
+
+	// set up node data
+	if dir := c.dataDir(); dir != "" {
+		if err := initNodeData(dir); err != nil {
+			return fmt.Errorf("error while creating mount source path '%s': %s", dir, err.Error())
+		}
+	}
+	if err := e.serveAPI(c); err != nil {
+		return err
+	}
+
+	c.state = stateRunning
+	c.exitCode = 0
+	c.startedAt = time.Now()
+	c.log(streamStdout,
+		"Initializing daemon...",
+		"Swarm listening on /ip4/0.0.0.0/tcp/4001",
+		"API server listening on /ip4/0.0.0.0/tcp/5001",
+		"Gateway (readonly) server listening on /ip4/0.0.0.0/tcp/8080",
+		"Daemon is ready")
+	e.emit(c, "start")
+	return nil
+}
+
+// exit simulates the exit of a container. Unless the exit was requested, the
+// container's restart policy is applied. The caller must hold Engine::mux
+func (e *Engine) exit(c *emuContainer, code int, requested bool) {
+	c.state = stateExited
+	c.exitCode = code
+	c.finishedAt = time.Now()
+	c.stopAPI()
+	c.notify()
+	e.emit(c, "die")
+	if requested {
+		e.emit(c, "stop")
+	}
+
+	if c.host.AutoRemove {
+		e.remove(c)
+		return
+	}
+	if !requested {
+		switch c.host.RestartPolicy.Name {
+		case "always", "unless-stopped":
+		case "on-failure":
+			if code == 0 {
+				return
+			}
+		default:
+			return
+		}
+		c.restarts++
+		e.start(c)
+	}
+}
+
+// remove deletes a container. The caller must hold Engine::mux
+func (e *Engine) remove(c *emuContainer) {
+	delete(e.containers, c.id)
+	e.emit(c, "destroy")
+}
+
+// find retrieves a container by ID, ID prefix, or name. The caller must hold
+// Engine::mux
+func (e *Engine) find(id string) *emuContainer {
+	id = strings.TrimPrefix(id, "/")
+	if id == "" {
+		return nil
+	}
+	if c, found := e.containers[id]; found {
+		return c
+	}
+	for _, c := range e.containers {
+		if c.name == id {
+			return c
+		}
+	}
+	for _, c := range e.containers {
+		if strings.HasPrefix(c.id, id) {
+			return c
+		}
+	}
+	return nil
+}
+
+// log records output from the container
+func (c *emuContainer) log(stream byte, lines ...string) {
+	var now = time.Now()
+	for _, l := range lines {
+		c.logs = append(c.logs, logLine{now, stream, l + "\n"})
+	}
+	c.notify()
+}
+
+// notify wakes up log followers
+func (c *emuContainer) notify() {
+	close(c.update)
+	c.update = make(chan struct{})
+}
+
+// running checks if the container's process is alive. As with Docker, paused
+// containers are considered to be running
+func (c *emuContainer) running() bool {
+	return c.state == stateRunning || c.state == statePaused
+}
+
+// dataDir retrieves the host path mounted as the node's data directory
+func (c *emuContainer) dataDir() string {
+	for _, b := range c.host.Binds {
+		var parts = strings.Split(b, ":")
+		if len(parts) >= 2 && parts[1] == dataMount {
+			return parts[0]
+		}
+	}
+	return ""
+}
+
+// hostPorts lists the host ports bound by the container
+func (c *emuContainer) hostPorts() []string {
+	var ports = make([]string, 0)
+	for _, bindings := range c.host.PortBindings {
+		for _, b := range bindings {
+			if b.HostPort != "" {
+				ports = append(ports, b.HostPort)
+			}
+		}
+	}
+	return ports
+}
+
+// ports lists the published ports of the container, if it is running
+func (c *emuContainer) ports() []types.Port {
+	var ports = make([]types.Port, 0)
+	if !c.running() {
+		return ports
+	}
+	for port, bindings := range c.host.PortBindings {
+		var (
+			parts     = strings.Split(string(port), "/")
+			private   = parts[0]
+			protocol  = "tcp"
+			privateNo int
+		)
+		if len(parts) > 1 {
+			protocol = parts[1]
+		}
+		privateNo, _ = strconv.Atoi(private)
+		for _, b := range bindings {
+			public, _ := strconv.Atoi(b.HostPort)
+			ports = append(ports, types.Port{
+				IP:          b.HostIP,
+				PrivatePort: uint16(privateNo),
+				PublicPort:  uint16(public),
+				Type:        protocol,
+			})
+		}
+	}
+	return ports
+}
+
+// status generates a human-readable container status
+func (c *emuContainer) status() string {
+	switch c.state {
+	case stateRunning:
+		return "Up " + time.Since(c.startedAt).Round(time.Second).String()
+	case statePaused:
+		return "Up " + time.Since(c.startedAt).Round(time.Second).String() + " (Paused)"
+	case stateExited:
+		return fmt.Sprintf("Exited (%d) %s ago", c.exitCode,
+			time.Since(c.finishedAt).Round(time.Second).String())
+	default:
+		return "Created"
+	}
+}
+
+// stats generates simulated resource usage statistics in the format of the
+// Engine API, using pre as the previous sample
+func (c *emuContainer) stats(now time.Time, pre map[string]interface{}) map[string]interface{} {
+	var (
+		cpus  = c.host.CPUQuota / 100000
+		limit = c.host.Memory
+		usage int64
+	)
+	if cpus < 1 {
+		cpus = 1
+	}
+	if limit == 0 {
+		limit = 2 * 1073741824
+	}
+	if c.running() && now.After(c.startedAt) {
+		// simulate a node using 5% of a single core
+		usage = int64(now.Sub(c.startedAt)) / 20
+	}
+
+	var s = map[string]interface{}{
+		"id":   c.id,
+		"name": "/" + c.name,
+		"read": now.UTC().Format(time.RFC3339Nano),
+		"pids_stats": map[string]interface{}{
+			"current": 8,
+		},
+		"cpu_stats": map[string]interface{}{
+			"cpu_usage": map[string]interface{}{
+				"total_usage": usage,
+			},
+			"system_cpu_usage": now.UnixNano() * cpus,
+			"online_cpus":      cpus,
+		},
+		"memory_stats": map[string]interface{}{
+			"usage":     64 * 1048576,
+			"max_usage": 96 * 1048576,
+			"limit":     limit,
+			"stats": map[string]interface{}{
+				"total_inactive_file": 16 * 1048576,
+			},
+		},
+		"networks": map[string]interface{}{
+			"eth0": map[string]interface{}{
+				"rx_bytes": usage / 1000,
+				"tx_bytes": usage / 2000,
+			},
+		},
+		"blkio_stats": map[string]interface{}{
+			"io_service_bytes_recursive": []map[string]interface{}{
+				{"major": 8, "minor": 0, "op": "Read", "value": 4 * 1048576},
+				{"major": 8, "minor": 0, "op": "Write", "value": usage / 1000},
+			},
+		},
+		"precpu_stats": map[string]interface{}{},
+		"preread":      time.Time{}.Format(time.RFC3339Nano),
+	}
+	if pre != nil {
+		s["precpu_stats"] = pre["cpu_stats"]
+		s["preread"] = pre["read"]
+	}
+	return s
+}
+
+// initNodeData writes an IPFS configuration with a generated identity to the
+// given data directory, as "ipfs init" does, unless one already exists. As with
+// the node startup script, a provided identity file is used instead of a
+// generated identity, and removed afterwards, and the provided node
+// configuration is then applied.
+func initNodeData(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	var (
+		path     = filepath.Join(dir, "config")
+		identity = filepath.Join(dir, "identity")
+	)
+	defer os.Remove(identity)
+
+	var config = map[string]interface{}{}
+	if b, err := ioutil.ReadFile(path); err == nil {
+		if err := json.Unmarshal(b, &config); err != nil {
+			return err
+		}
+	} else {
+		var key = make([]byte, 64)
+		rand.Read(key)
+		var id = map[string]interface{}{
+			"PeerID":  newPeerID(),
+			"PrivKey": base64.StdEncoding.EncodeToString(key),
+		}
+		if b, err := ioutil.ReadFile(identity); err == nil {
+			var lines = strings.Split(strings.TrimSpace(string(b)), "\n")
+			if len(lines) != 2 {
+				return errors.New("invalid identity file")
+			}
+			id["PeerID"], id["PrivKey"] = lines[0], lines[1]
+		}
+		config["Identity"] = id
+	}
+
+	if b, err := ioutil.ReadFile(filepath.Join(dir, "nexus_config")); err == nil {
+		if err := applyNodeConfig(config, string(b)); err != nil {
+			return err
+		}
+	}
+
+	b, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+// applyNodeConfig sets the keys in the given node configuration file contents,
+// as "ipfs config --json" does
+func applyNodeConfig(config map[string]interface{}, contents string) error {
+	for _, line := range strings.Split(contents, "\n") {
+		var parts = strings.SplitN(strings.TrimSpace(line), " ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(parts[1]), &value); err != nil {
+			return fmt.Errorf("invalid value for '%s': %s", parts[0], err.Error())
+		}
+		var (
+			keys = strings.Split(parts[0], ".")
+			m    = config
+		)
+		for _, k := range keys[:len(keys)-1] {
+			next, ok := m[k].(map[string]interface{})
+			if !ok {
+				next = map[string]interface{}{}
+				m[k] = next
+			}
+			m = next
+		}
+		m[keys[len(keys)-1]] = value
+	}
+	return nil
+}
+
+// writeStream writes output in the raw format used for TTY containers, or in
+// the multiplexed format used otherwise
+func writeStream(w io.Writer, stream byte, text string, tty bool) error {
+	if !tty {
+		var header = make([]byte, 8)
+		header[0] = stream
+		binary.BigEndian.PutUint32(header[4:], uint32(len(text)))
+		if _, err := w.Write(header); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write([]byte(text))
+	return err
+}
+
+// tail retrieves the last n lines, where n is a number or "all"
+func tail(lines []logLine, n string) []logLine {
+	count, err := strconv.Atoi(n)
+	if err != nil || count < 0 || count >= len(lines) {
+		return lines
+	}
+	return lines[len(lines)-count:]
+}
+
+// parseTimestamp reads timestamps in the "seconds.nanoseconds" format used by
+// the Engine API
+func parseTimestamp(ts string) (time.Time, error) {
+	if ts == "" {
+		return time.Time{}, nil
+	}
+	var parts = strings.SplitN(ts, ".", 2)
+	secs, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp '%s'", ts)
+	}
+	var nanos int64
+	if len(parts) > 1 {
+		if nanos, err = strconv.ParseInt(parts[1], 10, 64); err != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp '%s'", ts)
+		}
+	}
+	if secs == 0 && nanos == 0 {
+		return time.Time{}, nil
+	}
+	return time.Unix(secs, nanos), nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "0001-01-01T00:00:00Z"
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func isTrue(v string) bool {
+	return v == "1" || v == "true" || v == "True"
+}
+
+func newPeerID() string {
+	const alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	var b = make([]byte, 44)
+	rand.Read(b)
+	for i := range b {
+		b[i] = alphabet[int(b[i])%len(alphabet)]
+	}
+	return "Qm" + string(b)
+}
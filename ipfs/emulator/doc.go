@@ -0,0 +1,3 @@
+// Package emulator provides an emulated Docker Engine API that simulates IPFS
+// node containers, allowing ipfs.Client to be exercised without a Docker daemon
+package emulator
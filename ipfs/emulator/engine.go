@@ -0,0 +1,250 @@
+package emulator
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+)
+
+// APIVersion is the Engine API version reported by the emulator
+const APIVersion = "1.38"
+
+// eventBuffer is the number of events buffered per subscriber before further
+// events are dropped
+const eventBuffer = 128
+
+// versionPrefix matches the API version prefix of request paths, for example
+// "/v1.38"
+var versionPrefix = regexp.MustCompile(`^/v[0-9]+(\.[0-9]+)*`)
+
+// Engine emulates the subset of the Docker Engine API used by ipfs.Client.
+// Started containers write an IPFS configuration to their mounted data
+// directory, report that the IPFS daemon is ready in their logs, and serve an
+// emulated IPFS API on their published API port. Serve it
+// using net/http, and connect to it using ipfs.NewEmulatedRuntime().
+// Instantiate using emulator.New()
+type Engine struct {
+	// containers indexed by ID - locked by Engine::mux
+	containers map[string]*emuContainer
+	// execs indexed by ID - locked by Engine::mux
+	execs map[string]*emuExec
+	// images indexed by ID - locked by Engine::mux
+	images map[string]*emuImage
+	// event subscribers - locked by Engine::mux
+	subscribers map[int]*subscriber
+	subID       int
+
+	statsInterval time.Duration
+
+	mux sync.RWMutex
+}
+
+type subscriber struct {
+	events  chan events.Message
+	filters map[string][]string
+}
+
+// New instantiates an emulated Engine with no images or containers
+func New() *Engine {
+	return &Engine{
+		containers:    make(map[string]*emuContainer),
+		execs:         make(map[string]*emuExec),
+		images:        make(map[string]*emuImage),
+		subscribers:   make(map[int]*subscriber),
+		statsInterval: time.Second,
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (e *Engine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var (
+		path  = versionPrefix.ReplaceAllString(r.URL.Path, "")
+		parts = strings.Split(strings.Trim(path, "/"), "/")
+	)
+
+	switch parts[0] {
+	case "_ping":
+		w.Header().Set("API-Version", APIVersion)
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte("OK"))
+	case "version":
+		writeJSON(w, http.StatusOK, map[string]string{
+			"Version":    "emulated",
+			"ApiVersion": APIVersion,
+			"Os":         runtime.GOOS,
+			"Arch":       runtime.GOARCH,
+		})
+	case "containers":
+		e.routeContainers(w, r, parts[1:])
+	case "exec":
+		e.routeExec(w, r, parts[1:])
+	case "images":
+		e.routeImages(w, r, parts[1:])
+	case "events":
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusNotFound, "page not found")
+			return
+		}
+		e.handleEvents(w, r)
+	default:
+		writeError(w, http.StatusNotFound, "page not found")
+	}
+}
+
+// handleEvents streams events to the client until the request is cancelled
+func (e *Engine) handleEvents(w http.ResponseWriter, r *http.Request) {
+	filters, err := parseFilters(r.URL.Query().Get("filters"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// register subscriber
+	var sub = &subscriber{
+		events:  make(chan events.Message, eventBuffer),
+		filters: filters,
+	}
+	e.mux.Lock()
+	var id = e.subID
+	e.subID++
+	e.subscribers[id] = sub
+	e.mux.Unlock()
+	defer func() {
+		e.mux.Lock()
+		delete(e.subscribers, id)
+		e.mux.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	flush(w)
+
+	var enc = json.NewEncoder(w)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case m := <-sub.events:
+			if err := enc.Encode(m); err != nil {
+				return
+			}
+			flush(w)
+		}
+	}
+}
+
+// emit delivers a container event to all matching subscribers without
+// blocking. The caller must hold Engine::mux
+func (e *Engine) emit(c *emuContainer, action string) {
+	var (
+		now        = time.Now()
+		attributes = map[string]string{
+			"name":  c.name,
+			"image": c.config.Image,
+		}
+	)
+	for k, v := range c.config.Labels {
+		attributes[k] = v
+	}
+	var m = events.Message{
+		Status: action,
+		ID:     c.id,
+		From:   c.config.Image,
+		Type:   "container",
+		Action: action,
+		Actor: events.Actor{
+			ID:         c.id,
+			Attributes: attributes,
+		},
+		Scope:    "local",
+		Time:     now.Unix(),
+		TimeNano: now.UnixNano(),
+	}
+
+	for _, s := range e.subscribers {
+		if !matches(s.filters, "type", "container") ||
+			!matches(s.filters, "event", action) ||
+			!matches(s.filters, "container", c.id, c.name) {
+			continue
+		}
+		select {
+		case s.events <- m:
+		default:
+		}
+	}
+}
+
+// parseFilters reads filters in both the current and legacy Engine API
+// formats
+func parseFilters(raw string) (map[string][]string, error) {
+	var filters = make(map[string][]string)
+	if raw == "" {
+		return filters, nil
+	}
+
+	var current map[string]map[string]bool
+	if err := json.Unmarshal([]byte(raw), &current); err == nil {
+		for k, values := range current {
+			for v, ok := range values {
+				if ok {
+					filters[k] = append(filters[k], v)
+				}
+			}
+		}
+		return filters, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &filters); err != nil {
+		return nil, fmt.Errorf("invalid filters: %s", err.Error())
+	}
+	return filters, nil
+}
+
+// matches checks if any of the given values satisfy the filter for key. Keys
+// without filters match everything
+func matches(filters map[string][]string, key string, values ...string) bool {
+	accepted, found := filters[key]
+	if !found {
+		return true
+	}
+	for _, a := range accepted {
+		for _, v := range values {
+			if a == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError responds with an error in the format used by the Engine API
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, types.ErrorResponse{Message: message})
+}
+
+func flush(w http.ResponseWriter) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func newID() string {
+	var b = make([]byte, 32)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,551 @@
+package emulator
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/go-connections/nat"
+)
+
+const testImage = "ipfs/go-ipfs:v0.4.18"
+
+type testEngine struct {
+	*testing.T
+	e   *Engine
+	srv *httptest.Server
+}
+
+func newTestEngine(t *testing.T) *testEngine {
+	var e = New()
+	var te = &testEngine{t, e, httptest.NewServer(e)}
+
+	// all tests require the ipfs image
+	resp := te.do("POST", "/images/create?fromImage=ipfs/go-ipfs&tag=v0.4.18", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("failed to pull image: %d", resp.StatusCode)
+	}
+	return te
+}
+
+func (te *testEngine) do(method, path string, body interface{}) *http.Response {
+	var b []byte
+	if body != nil {
+		b, _ = json.Marshal(body)
+	}
+	req, _ := http.NewRequest(method, te.srv.URL+"/v"+APIVersion+path, bytes.NewReader(b))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		te.Fatalf("request %s %s failed: %v", method, path, err)
+	}
+	return resp
+}
+
+func (te *testEngine) expect(resp *http.Response, status int) {
+	defer resp.Body.Close()
+	if resp.StatusCode != status {
+		b, _ := ioutil.ReadAll(resp.Body)
+		te.Errorf("%s %s: expected status %d, got %d (%s)",
+			resp.Request.Method, resp.Request.URL.Path, status, resp.StatusCode, string(b))
+	}
+}
+
+func (te *testEngine) create(name, dir string, hostPort string, policy string) string {
+	resp := te.do("POST", "/containers/create?name="+name, createRequest{
+		Config: &container.Config{
+			Image:  testImage,
+			Tty:    true,
+			Labels: map[string]string{"network_id": name},
+		},
+		HostConfig: &container.HostConfig{
+			Binds: []string{dir + ":/data/ipfs"},
+			PortBindings: nat.PortMap{
+				"4001/tcp": []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: hostPort}},
+			},
+			RestartPolicy: container.RestartPolicy{Name: policy},
+		},
+	})
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		te.Fatalf("failed to create container: %d", resp.StatusCode)
+	}
+	var created container.ContainerCreateCreatedBody
+	json.NewDecoder(resp.Body).Decode(&created)
+	return created.ID
+}
+
+func TestEngine_ping(t *testing.T) {
+	var te = newTestEngine(t)
+	defer te.srv.Close()
+
+	resp := te.do("GET", "/_ping", nil)
+	defer resp.Body.Close()
+	if resp.Header.Get("API-Version") != APIVersion {
+		t.Errorf("expected API version %s, got %s", APIVersion, resp.Header.Get("API-Version"))
+	}
+}
+
+func TestEngine_containerLifecycle(t *testing.T) {
+	var te = newTestEngine(t)
+	defer te.srv.Close()
+	dir, _ := ioutil.TempDir("", "emulator")
+	defer os.RemoveAll(dir)
+
+	// subscribe to events
+	var filters = url.QueryEscape(`{"event":{"start":true,"die":true}}`)
+	eventsResp := te.do("GET", "/events?filters="+filters, nil)
+	defer eventsResp.Body.Close()
+	var eventsDec = json.NewDecoder(eventsResp.Body)
+
+	// images must be pulled
+	te.expect(te.do("POST", "/containers/create", createRequest{
+		Config: &container.Config{Image: "ipfs/go-ipfs:v0.0.0"},
+	}), http.StatusNotFound)
+
+	// create and start container
+	id := te.create("ipfs-test", dir, "4001", "unless-stopped")
+	te.expect(te.do("POST", "/containers/create?name=ipfs-test", createRequest{
+		Config: &container.Config{Image: testImage},
+	}), http.StatusConflict)
+	te.expect(te.do("POST", "/containers/ipfs-test/start", nil), http.StatusNoContent)
+	expectEvent(t, eventsDec, "start", "ipfs-test")
+
+	// data directory should be initialized
+	if _, err := os.Stat(filepath.Join(dir, "config")); err != nil {
+		t.Errorf("expected config to be written: %v", err)
+	}
+
+	// logs should report readiness
+	logs := te.do("GET", "/containers/"+id[:12]+"/logs?stdout=1", nil)
+	b, _ := ioutil.ReadAll(logs.Body)
+	logs.Body.Close()
+	if !strings.Contains(string(b), "Daemon is ready") {
+		t.Errorf("expected daemon to be ready, got logs %s", string(b))
+	}
+
+	// list should show ports
+	list := te.do("GET", "/containers/json", nil)
+	var containers []types.Container
+	json.NewDecoder(list.Body).Decode(&containers)
+	list.Body.Close()
+	if len(containers) != 1 || len(containers[0].Ports) != 1 || containers[0].Ports[0].PublicPort != 4001 {
+		t.Errorf("unexpected container list %+v", containers)
+	}
+
+	// port conflicts
+	other, _ := ioutil.TempDir("", "emulator")
+	defer os.RemoveAll(other)
+	te.create("ipfs-other", other, "4001", "")
+	te.expect(te.do("POST", "/containers/ipfs-other/start", nil), http.StatusInternalServerError)
+
+	// unexpected exits are handled by restart policy
+	if err := te.e.Kill("ipfs-test"); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, eventsDec, "die", "ipfs-test")
+	expectEvent(t, eventsDec, "start", "ipfs-test")
+
+	// exec commands
+	exec := te.do("POST", "/containers/ipfs-test/exec", types.ExecConfig{
+		Cmd: []string{"ipfs", "bootstrap", "rm", "--all"}})
+	var execID types.IDResponse
+	json.NewDecoder(exec.Body).Decode(&execID)
+	exec.Body.Close()
+	te.expect(te.do("POST", "/exec/"+execID.ID+"/start", types.ExecStartCheck{}), http.StatusOK)
+	if execs := te.e.Execs("ipfs-test"); len(execs) != 1 || execs[0][1] != "bootstrap" {
+		t.Errorf("unexpected execs %v", execs)
+	}
+
+	// running containers cannot be removed without force
+	te.expect(te.do("DELETE", "/containers/ipfs-test", nil), http.StatusConflict)
+	te.expect(te.do("POST", "/containers/ipfs-test/stop?t=10", nil), http.StatusNoContent)
+	expectEvent(t, eventsDec, "die", "ipfs-test")
+	te.expect(te.do("POST", "/containers/ipfs-test/stop?t=10", nil), http.StatusNotModified)
+	te.expect(te.do("DELETE", "/containers/ipfs-test?v=1", nil), http.StatusNoContent)
+	te.expect(te.do("GET", "/containers/ipfs-test/json", nil), http.StatusNotFound)
+}
+
+func TestEngine_followLogs(t *testing.T) {
+	var te = newTestEngine(t)
+	defer te.srv.Close()
+	dir, _ := ioutil.TempDir("", "emulator")
+	defer os.RemoveAll(dir)
+
+	te.create("ipfs-test", dir, "4001", "")
+	te.expect(te.do("POST", "/containers/ipfs-test/start", nil), http.StatusNoContent)
+
+	// follow should end once the container stops
+	logs := te.do("GET", "/containers/ipfs-test/logs?stdout=1&follow=1&tail=1", nil)
+	defer logs.Body.Close()
+	var scanner = bufio.NewScanner(logs.Body)
+	if !scanner.Scan() || scanner.Text() != "Daemon is ready" {
+		t.Errorf("expected last log line, got '%s'", scanner.Text())
+	}
+
+	var done = make(chan struct{})
+	go func() {
+		for scanner.Scan() {
+		}
+		close(done)
+	}()
+	te.expect(te.do("POST", "/containers/ipfs-test/stop", nil), http.StatusNoContent)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("log stream did not end after container stopped")
+	}
+}
+
+func TestEngine_execAttach(t *testing.T) {
+	var te = newTestEngine(t)
+	defer te.srv.Close()
+	dir, _ := ioutil.TempDir("", "emulator")
+	defer os.RemoveAll(dir)
+	te.create("ipfs-test", dir, "4001", "")
+	te.expect(te.do("POST", "/containers/ipfs-test/start", nil), http.StatusNoContent)
+
+	var attach = func(cmd ...string) (stdout, stderr string, code int) {
+		t.Helper()
+		resp := te.do("POST", "/containers/ipfs-test/exec", types.ExecConfig{
+			Cmd: cmd, AttachStdout: true, AttachStderr: true})
+		var execID types.IDResponse
+		json.NewDecoder(resp.Body).Decode(&execID)
+		resp.Body.Close()
+
+		// attached clients hijack the connection used to start the exec
+		conn, err := net.Dial("tcp", te.srv.Listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		b, _ := json.Marshal(types.ExecStartCheck{})
+		req, _ := http.NewRequest("POST", "/v"+APIVersion+"/exec/"+execID.ID+"/start", bytes.NewReader(b))
+		req.Host = te.srv.Listener.Addr().String()
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "tcp")
+		if err := req.Write(conn); err != nil {
+			t.Fatal(err)
+		}
+		var r = bufio.NewReader(conn)
+		upgrade, err := http.ReadResponse(r, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if upgrade.StatusCode != http.StatusSwitchingProtocols {
+			t.Fatalf("expected upgrade, got status %d", upgrade.StatusCode)
+		}
+		var out, errOut bytes.Buffer
+		if _, err := stdcopy.StdCopy(&out, &errOut, r); err != nil {
+			t.Fatal(err)
+		}
+
+		resp = te.do("GET", "/exec/"+execID.ID+"/json", nil)
+		var info types.ContainerExecInspect
+		json.NewDecoder(resp.Body).Decode(&info)
+		resp.Body.Close()
+		if info.Running {
+			t.Error("expected exec to have completed")
+		}
+		return out.String(), errOut.String(), info.ExitCode
+	}
+
+	var peer = "/ip4/104.131.131.82/tcp/4001/ipfs/QmaCpDMGvV2BGHeYERUEnRQAwe3N8SzbUtfsmvsqQLuvuJ"
+	if stdout, stderr, code := attach("ipfs", "bootstrap", "add", peer); stdout != peer+"\n" ||
+		stderr != "" || code != 0 {
+		t.Errorf("unexpected output %q, %q, exit code %d", stdout, stderr, code)
+	}
+	if stdout, stderr, code := attach("ipfs", "bootstrap", "add", "104.131.131.82:4001"); stdout != "" ||
+		!strings.Contains(stderr, "invalid peer address") || code != 1 {
+		t.Errorf("unexpected output %q, %q, exit code %d", stdout, stderr, code)
+	}
+	if _, _, code := attach("sh", "-c", "exit 1"); code != 127 {
+		t.Errorf("expected missing executable, got exit code %d", code)
+	}
+}
+
+func TestEngine_pause(t *testing.T) {
+	var te = newTestEngine(t)
+	defer te.srv.Close()
+	dir, _ := ioutil.TempDir("", "emulator")
+	defer os.RemoveAll(dir)
+
+	var filters = url.QueryEscape(`{"event":{"pause":true,"unpause":true}}`)
+	eventsResp := te.do("GET", "/events?filters="+filters, nil)
+	defer eventsResp.Body.Close()
+	var eventsDec = json.NewDecoder(eventsResp.Body)
+
+	// only running containers can be paused
+	te.create("ipfs-test", dir, "4001", "unless-stopped")
+	te.expect(te.do("POST", "/containers/ipfs-test/pause", nil), http.StatusConflict)
+	te.expect(te.do("POST", "/containers/ipfs-test/start", nil), http.StatusNoContent)
+	te.expect(te.do("POST", "/containers/ipfs-test/unpause", nil), http.StatusConflict)
+	te.expect(te.do("POST", "/containers/ipfs-test/pause", nil), http.StatusNoContent)
+	expectEvent(t, eventsDec, "pause", "ipfs-test")
+	te.expect(te.do("POST", "/containers/ipfs-test/pause", nil), http.StatusConflict)
+
+	// paused containers are still running, but cannot execute commands
+	inspect := te.do("GET", "/containers/ipfs-test/json", nil)
+	var info types.ContainerJSON
+	json.NewDecoder(inspect.Body).Decode(&info)
+	inspect.Body.Close()
+	if !info.State.Running || !info.State.Paused || info.State.Status != "paused" {
+		t.Errorf("unexpected container state %+v", info.State)
+	}
+	te.expect(te.do("POST", "/containers/ipfs-test/exec", types.ExecConfig{
+		Cmd: []string{"ipfs", "id"}}), http.StatusConflict)
+	te.expect(te.do("POST", "/containers/ipfs-test/start", nil), http.StatusNotModified)
+
+	// resume container
+	te.expect(te.do("POST", "/containers/ipfs-test/unpause", nil), http.StatusNoContent)
+	expectEvent(t, eventsDec, "unpause", "ipfs-test")
+	inspect = te.do("GET", "/containers/ipfs-test/json", nil)
+	json.NewDecoder(inspect.Body).Decode(&info)
+	inspect.Body.Close()
+	if !info.State.Running || info.State.Paused {
+		t.Errorf("unexpected container state %+v", info.State)
+	}
+
+	// paused containers can be stopped
+	te.expect(te.do("POST", "/containers/ipfs-test/pause", nil), http.StatusNoContent)
+	te.expect(te.do("POST", "/containers/ipfs-test/stop", nil), http.StatusNoContent)
+	te.expect(te.do("POST", "/containers/ipfs-test/unpause", nil), http.StatusConflict)
+}
+
+func TestEngine_nodeAPI(t *testing.T) {
+	var te = newTestEngine(t)
+	defer te.srv.Close()
+	dir, _ := ioutil.TempDir("", "emulator")
+	defer os.RemoveAll(dir)
+
+	// find an available port for the node API
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, apiPort, _ := net.SplitHostPort(ln.Addr().String())
+	ln.Close()
+
+	resp := te.do("POST", "/containers/create?name=ipfs-test", createRequest{
+		Config: &container.Config{Image: testImage, Tty: true},
+		HostConfig: &container.HostConfig{
+			Binds: []string{dir + ":/data/ipfs"},
+			PortBindings: nat.PortMap{
+				"5001/tcp": []nat.PortBinding{{HostIP: "127.0.0.1", HostPort: apiPort}},
+			},
+		},
+	})
+	te.expect(resp, http.StatusCreated)
+
+	var id = func(timeout time.Duration) (string, error) {
+		var c = &http.Client{Timeout: timeout}
+		resp, err := c.Post("http://127.0.0.1:"+apiPort+"/api/v0/id", "", nil)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		var body struct{ ID string }
+		json.NewDecoder(resp.Body).Decode(&body)
+		return body.ID, nil
+	}
+
+	// API is only available while the container is running
+	if _, err := id(time.Second); err == nil {
+		t.Error("expected API to be unavailable before start")
+	}
+	te.expect(te.do("POST", "/containers/ipfs-test/start", nil), http.StatusNoContent)
+	if peer, err := id(time.Second); err != nil || !strings.HasPrefix(peer, "Qm") {
+		t.Errorf("expected peer ID from API, got '%s' (%v)", peer, err)
+	}
+
+	// paused containers do not respond
+	te.expect(te.do("POST", "/containers/ipfs-test/pause", nil), http.StatusNoContent)
+	if _, err := id(100 * time.Millisecond); err == nil {
+		t.Error("expected API of paused container to hang")
+	}
+	te.expect(te.do("POST", "/containers/ipfs-test/unpause", nil), http.StatusNoContent)
+	if _, err := id(time.Second); err != nil {
+		t.Errorf("expected API to respond after unpause, got %v", err)
+	}
+
+	te.expect(te.do("POST", "/containers/ipfs-test/stop", nil), http.StatusNoContent)
+	if _, err := id(time.Second); err == nil {
+		t.Error("expected API to be unavailable after stop")
+	}
+}
+
+func TestEngine_images(t *testing.T) {
+	var te = newTestEngine(t)
+	defer te.srv.Close()
+	var inspect = func(ref string) types.ImageInspect {
+		t.Helper()
+		resp := te.do("GET", "/images/"+ref+"/json", nil)
+		defer resp.Body.Close()
+		var info types.ImageInspect
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("failed to inspect image '%s': %d", ref, resp.StatusCode)
+		}
+		json.NewDecoder(resp.Body).Decode(&info)
+		return info
+	}
+
+	// pulled images should have a digest
+	var pulled = inspect(testImage)
+	if len(pulled.RepoDigests) != 1 || !strings.HasPrefix(pulled.RepoDigests[0], "ipfs/go-ipfs@sha256:") {
+		t.Errorf("expected pulled image to have a digest, got %v", pulled.RepoDigests)
+	}
+	if inspect(pulled.RepoDigests[0]).ID != pulled.ID {
+		t.Error("expected image to be retrievable by digest")
+	}
+	te.expect(te.do("GET", "/images/ipfs/go-ipfs:v0.0.0/json", nil), http.StatusNotFound)
+
+	// images can be pulled by digest
+	var digest = "sha256:" + strings.Repeat("a", 64)
+	te.expect(te.do("POST", "/images/create?fromImage=ipfs/go-ipfs&tag="+digest, nil), http.StatusOK)
+	if got := inspect("ipfs/go-ipfs@" + digest); len(got.RepoTags) != 0 {
+		t.Errorf("expected image pulled by digest to have no tags, got %v", got.RepoTags)
+	}
+
+	// images can be loaded from archives
+	var archive bytes.Buffer
+	var tw = tar.NewWriter(&archive)
+	var manifest = []byte(`[{"Config":"abcd.json","RepoTags":["ipfs/go-ipfs:v0.4.19"]}]`)
+	tw.WriteHeader(&tar.Header{Name: "manifest.json", Mode: 0644, Size: int64(len(manifest))})
+	tw.Write(manifest)
+	tw.Close()
+	resp, err := http.Post(te.srv.URL+"/v"+APIVersion+"/images/load?quiet=1",
+		"application/x-tar", &archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	te.expect(resp, http.StatusOK)
+	var loaded = inspect("ipfs/go-ipfs:v0.4.19")
+	if len(loaded.RepoDigests) != 0 {
+		t.Errorf("expected loaded image to have no digests, got %v", loaded.RepoDigests)
+	}
+	resp, _ = http.Post(te.srv.URL+"/v"+APIVersion+"/images/load", "application/x-tar",
+		strings.NewReader("asdf"))
+	te.expect(resp, http.StatusInternalServerError)
+
+	// containers should report their image
+	te.expect(te.do("POST", "/containers/create?name=ipfs-loaded", createRequest{
+		Config: &container.Config{Image: "ipfs/go-ipfs:v0.4.19"},
+	}), http.StatusCreated)
+	resp = te.do("GET", "/containers/json?all=1", nil)
+	defer resp.Body.Close()
+	var list []types.Container
+	json.NewDecoder(resp.Body).Decode(&list)
+	if len(list) != 1 || list[0].Image != "ipfs/go-ipfs:v0.4.19" || list[0].ImageID != loaded.ID {
+		t.Errorf("expected container to report loaded image, got %+v", list)
+	}
+}
+
+func expectEvent(t *testing.T, dec *json.Decoder, action, name string) {
+	t.Helper()
+	var (
+		m    events.Message
+		errs = make(chan error, 1)
+	)
+	go func() { errs <- dec.Decode(&m) }()
+	select {
+	case err := <-errs:
+		if err != nil {
+			t.Fatalf("failed to read event: %v", err)
+		}
+		if m.Action != action || m.Actor.Attributes["name"] != name {
+			t.Errorf("expected '%s' event for '%s', got %+v", action, name, m)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for '%s' event for '%s'", action, name)
+	}
+}
+
+func Test_agentVersion(t *testing.T) {
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{"ipfs/go-ipfs:v0.4.18", "0.4.18"},
+		{"ipfs/go-ipfs:latest", "latest"},
+		{"localhost:5000/go-ipfs", "emulated"},
+		{"ipfs/go-ipfs", "emulated"},
+		{"ipfs/go-ipfs@sha256:" + strings.Repeat("a", 64), "emulated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			if got := agentVersion(tt.image); got != tt.want {
+				t.Errorf("agentVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_initNodeData(t *testing.T) {
+	dir, err := ioutil.TempDir("", "emulator-node")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "nexus_config"), []byte(
+		"Swarm.ConnMgr {\"HighWater\":2000}\nExperimental.FilestoreEnabled true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := initNodeData(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// configuration should be applied on every start, keeping the identity
+	var read = func() (cfg struct {
+		Identity     struct{ PeerID string }
+		Swarm        struct{ ConnMgr struct{ HighWater int } }
+		Experimental struct{ FilestoreEnabled bool }
+	}) {
+		b, err := ioutil.ReadFile(filepath.Join(dir, "config"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(b, &cfg); err != nil {
+			t.Fatal(err)
+		}
+		return cfg
+	}
+	var cfg = read()
+	if cfg.Identity.PeerID == "" || cfg.Swarm.ConnMgr.HighWater != 2000 || !cfg.Experimental.FilestoreEnabled {
+		t.Errorf("unexpected configuration %+v", cfg)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "nexus_config"), []byte(
+		"Experimental.FilestoreEnabled false\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := initNodeData(dir); err != nil {
+		t.Fatal(err)
+	}
+	if updated := read(); updated.Identity != cfg.Identity || updated.Experimental.FilestoreEnabled {
+		t.Errorf("unexpected configuration %+v", updated)
+	}
+
+	// invalid values should be rejected
+	if err := ioutil.WriteFile(filepath.Join(dir, "nexus_config"), []byte(
+		"Experimental.FilestoreEnabled yes\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := initNodeData(dir); err == nil {
+		t.Error("expected error for invalid configuration")
+	}
+}
@@ -0,0 +1,165 @@
+package emulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+)
+
+// emuExec is a simulated command execution. Fields are locked by Engine::mux
+type emuExec struct {
+	id        string
+	container *emuContainer
+	config    types.ExecConfig
+
+	running  bool
+	exitCode int
+}
+
+func (e *Engine) handleExecCreate(w http.ResponseWriter, r *http.Request, c *emuContainer) {
+	var config types.ExecConfig
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid exec configuration")
+		return
+	}
+	if len(config.Cmd) == 0 {
+		writeError(w, http.StatusBadRequest, "No exec command specified")
+		return
+	}
+
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	switch c.state {
+	case stateRunning:
+	case statePaused:
+		writeError(w, http.StatusConflict, fmt.Sprintf(
+			"Container %s is paused, unpause the container before exec", c.id))
+		return
+	default:
+		writeError(w, http.StatusConflict, fmt.Sprintf("Container %s is not running", c.id))
+		return
+	}
+	var x = &emuExec{id: newID(), container: c, config: config}
+	e.execs[x.id] = x
+	e.emit(c, "exec_create: "+strings.Join(config.Cmd, " "))
+
+	writeJSON(w, http.StatusCreated, types.IDResponse{ID: x.id})
+}
+
+func (e *Engine) routeExec(w http.ResponseWriter, r *http.Request, parts []string) {
+	if len(parts) != 2 {
+		writeError(w, http.StatusNotFound, "page not found")
+		return
+	}
+	e.mux.RLock()
+	x, found := e.execs[parts[0]]
+	e.mux.RUnlock()
+	if !found {
+		writeError(w, http.StatusNotFound, "No such exec instance: "+parts[0])
+		return
+	}
+
+	switch r.Method + " " + parts[1] {
+	case "POST start":
+		e.handleExecStart(w, r, x)
+	case "GET json":
+		e.mux.RLock()
+		var info = types.ContainerExecInspect{
+			ExecID:      x.id,
+			ContainerID: x.container.id,
+			Running:     x.running,
+			ExitCode:    x.exitCode,
+		}
+		e.mux.RUnlock()
+		writeJSON(w, http.StatusOK, info)
+	default:
+		writeError(w, http.StatusNotFound, "page not found")
+	}
+}
+
+func (e *Engine) handleExecStart(w http.ResponseWriter, r *http.Request, x *emuExec) {
+	var check types.ExecStartCheck
+	if err := json.NewDecoder(r.Body).Decode(&check); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid exec start configuration")
+		return
+	}
+
+	e.mux.Lock()
+	if x.container.state != stateRunning {
+		e.mux.Unlock()
+		writeError(w, http.StatusConflict, fmt.Sprintf("Container %s is not running", x.container.id))
+		return
+	}
+	var stdout, stderr, code = simulateCommand(x.config.Cmd)
+	x.exitCode = code
+	x.container.execs = append(x.container.execs, x.config.Cmd)
+	e.emit(x.container, "exec_start: "+strings.Join(x.config.Cmd, " "))
+	e.mux.Unlock()
+
+	// detached executions produce no output
+	if check.Detach {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// attached clients request an upgrade, and are sent output over the
+	// hijacked connection
+	if hj, ok := w.(http.Hijacker); ok && r.Header.Get("Upgrade") != "" {
+		conn, buf, err := hj.Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf.WriteString("HTTP/1.1 101 UPGRADED\r\n" +
+			"Content-Type: application/vnd.docker.raw-stream\r\n" +
+			"Connection: Upgrade\r\n" +
+			"Upgrade: " + r.Header.Get("Upgrade") + "\r\n\r\n")
+		writeExecOutput(buf, stdout, stderr, check.Tty)
+		buf.Flush()
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.docker.raw-stream")
+	w.WriteHeader(http.StatusOK)
+	writeExecOutput(w, stdout, stderr, check.Tty)
+}
+
+// writeExecOutput writes the output of an execution in the format used for
+// the execution's TTY setting
+func writeExecOutput(w io.Writer, stdout, stderr string, tty bool) {
+	if stdout != "" {
+		writeStream(w, streamStdout, stdout, tty)
+	}
+	if stderr != "" {
+		writeStream(w, streamStderr, stderr, tty)
+	}
+}
+
+// simulateCommand generates plausible output for commands executed in node
+// containers
+func simulateCommand(cmd []string) (stdout, stderr string, code int) {
+	if cmd[0] != "ipfs" {
+		return "", fmt.Sprintf("exec: \"%s\": executable file not found in $PATH\n", cmd[0]), 127
+	}
+	if len(cmd) >= 3 && cmd[1] == "bootstrap" {
+		switch cmd[2] {
+		case "add", "rm":
+			var out string
+			for _, peer := range cmd[3:] {
+				if strings.HasPrefix(peer, "-") {
+					continue
+				}
+				if !strings.HasPrefix(peer, "/") {
+					return "", fmt.Sprintf("Error: invalid peer address: %s\n", peer), 1
+				}
+				out += peer + "\n"
+			}
+			return out, "", 0
+		}
+	}
+	return "", "", 0
+}
@@ -0,0 +1,210 @@
+package emulator
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+)
+
+// digestTag matches tags that the Engine API uses to pull images by digest
+var digestTag = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// emuImage is an emulated image
+type emuImage struct {
+	id      string
+	tags    []string // "<repository>:<tag>"
+	digests []string // "<repository>@<digest>"
+	created time.Time
+}
+
+// matches checks if the given reference refers to the image, either by ID,
+// tag, or digest
+func (i *emuImage) matches(ref string) bool {
+	if ref == i.id || "sha256:"+ref == i.id {
+		return true
+	}
+	for _, refs := range [][]string{i.tags, i.digests} {
+		for _, r := range refs {
+			if r == ref {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findImage retrieves the image with the given reference. References without
+// a tag or digest refer to the "latest" tag. The caller must hold Engine::mux.
+func (e *Engine) findImage(ref string) *emuImage {
+	for _, i := range e.images {
+		if i.matches(ref) {
+			return i
+		}
+	}
+	if !strings.Contains(ref, "@") && !strings.Contains(ref[strings.LastIndex(ref, "/")+1:], ":") {
+		return e.findImage(ref + ":latest")
+	}
+	return nil
+}
+
+// addImage records an image with the given ID, tag, and digest, merging it with
+// any existing image with the same ID. The caller must hold Engine::mux.
+func (e *Engine) addImage(id, tag, digest string) *emuImage {
+	i, found := e.images[id]
+	if !found {
+		i = &emuImage{id: id, created: time.Now()}
+		e.images[id] = i
+	}
+	if tag != "" && !i.matches(tag) {
+		// tags can only refer to one image
+		for _, other := range e.images {
+			for j, t := range other.tags {
+				if t == tag {
+					other.tags = append(other.tags[:j], other.tags[j+1:]...)
+					break
+				}
+			}
+		}
+		i.tags = append(i.tags, tag)
+	}
+	if digest != "" && !i.matches(digest) {
+		i.digests = append(i.digests, digest)
+	}
+	return i
+}
+
+func (e *Engine) routeImages(w http.ResponseWriter, r *http.Request, parts []string) {
+	switch {
+	case len(parts) == 1 && parts[0] == "create" && r.Method == http.MethodPost:
+		e.handlePull(w, r)
+	case len(parts) == 1 && parts[0] == "load" && r.Method == http.MethodPost:
+		e.handleLoad(w, r)
+	case len(parts) > 1 && parts[len(parts)-1] == "json" && r.Method == http.MethodGet:
+		e.handleImageInspect(w, r, strings.Join(parts[:len(parts)-1], "/"))
+	default:
+		writeError(w, http.StatusNotFound, "page not found")
+	}
+}
+
+// handlePull emulates pulling an image by tag or digest. Images pulled by tag
+// are given a digest derived from their reference.
+func (e *Engine) handlePull(w http.ResponseWriter, r *http.Request) {
+	var (
+		image = r.URL.Query().Get("fromImage")
+		tag   = r.URL.Query().Get("tag")
+	)
+	if image == "" {
+		writeError(w, http.StatusBadRequest, "image name required")
+		return
+	}
+	if tag == "" {
+		tag = "latest"
+	}
+	var ref, named, digest string
+	if digestTag.MatchString(tag) {
+		ref = image + "@" + tag
+		digest = ref
+	} else {
+		ref = image + ":" + tag
+		named = ref
+		digest = image + "@" + hash(ref)
+	}
+
+	e.mux.Lock()
+	e.addImage(hash("image:"+ref), named, digest)
+	e.mux.Unlock()
+
+	// report progress as the Engine API does
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	var enc = json.NewEncoder(w)
+	enc.Encode(map[string]string{"status": "Pulling from " + image, "id": tag})
+	enc.Encode(map[string]string{"status": "Digest: " + strings.SplitN(digest, "@", 2)[1]})
+	enc.Encode(map[string]string{"status": "Status: Downloaded newer image for " + ref})
+}
+
+// handleLoad emulates loading images from an archive created by "docker save".
+// Loaded images are tagged as declared in the archive's manifest, and have no
+// digests.
+func (e *Engine) handleLoad(w http.ResponseWriter, r *http.Request) {
+	var manifest []struct {
+		Config   string
+		RepoTags []string
+	}
+	var tr = tar.NewReader(r.Body)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			writeError(w, http.StatusInternalServerError, "invalid archive: "+err.Error())
+			return
+		}
+		if hdr.Name != "manifest.json" {
+			continue
+		}
+		b, err := ioutil.ReadAll(tr)
+		if err == nil {
+			err = json.Unmarshal(b, &manifest)
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "invalid manifest: "+err.Error())
+			return
+		}
+	}
+	if manifest == nil {
+		writeError(w, http.StatusInternalServerError, "invalid archive: manifest.json not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	var enc = json.NewEncoder(w)
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	for _, m := range manifest {
+		var id = hash("config:" + m.Config)
+		e.addImage(id, "", "")
+		for _, tag := range m.RepoTags {
+			e.addImage(id, tag, "")
+			enc.Encode(map[string]string{"stream": "Loaded image: " + tag + "\n"})
+		}
+		if len(m.RepoTags) == 0 {
+			enc.Encode(map[string]string{"stream": "Loaded image ID: " + id + "\n"})
+		}
+	}
+}
+
+func (e *Engine) handleImageInspect(w http.ResponseWriter, r *http.Request, ref string) {
+	e.mux.RLock()
+	var i = e.findImage(ref)
+	if i == nil {
+		e.mux.RUnlock()
+		writeError(w, http.StatusNotFound, "No such image: "+ref)
+		return
+	}
+	var info = types.ImageInspect{
+		ID:          i.id,
+		RepoTags:    append([]string{}, i.tags...),
+		RepoDigests: append([]string{}, i.digests...),
+		Created:     i.created.UTC().Format(time.RFC3339Nano),
+	}
+	e.mux.RUnlock()
+
+	writeJSON(w, http.StatusOK, info)
+}
+
+// hash generates a digest of the given value
+func hash(v string) string {
+	var sum = sha256.Sum256([]byte(v))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,113 @@
+package ipfs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+const (
+	// execTimeout is the maximum duration allowed for commands executed in node
+	// containers, regardless of how long callers are willing to wait
+	execTimeout = 30 * time.Second
+	// execPollInterval is the interval at which executions that have closed
+	// their output are checked for completion
+	execPollInterval = 50 * time.Millisecond
+)
+
+// ExecError is returned when a command executed in a node container exits
+// with a non-zero code
+type ExecError struct {
+	Cmd      []string
+	ExitCode int
+	Stdout   string
+	Stderr   string
+}
+
+func (e *ExecError) Error() string {
+	var output = strings.TrimSpace(e.Stderr)
+	if output == "" {
+		output = strings.TrimSpace(e.Stdout)
+	}
+	return fmt.Sprintf("command '%s' exited with code %d: %s",
+		strings.Join(e.Cmd, " "), e.ExitCode, output)
+}
+
+// containerExec executes the given command in the given container and waits
+// for it to complete, until the given context is cancelled or execTimeout
+// elapses. The command's output is returned, and an *ExecError is returned if
+// the command exits with a non-zero code.
+func (c *Client) containerExec(ctx context.Context, dockerID string, cmd []string) (stdout, stderr string, err error) {
+	ctx, cancel := context.WithTimeout(ctx, execTimeout)
+	defer cancel()
+
+	var config = types.ExecConfig{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	}
+	exec, err := c.d.ContainerExecCreate(ctx, dockerID, config)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create exec for '%s': %s",
+			strings.Join(cmd, " "), err.Error())
+	}
+	resp, err := c.d.ContainerExecAttach(ctx, exec.ID, config)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to start '%s': %s", strings.Join(cmd, " "), err.Error())
+	}
+	defer resp.Close()
+
+	// read output until the command closes it - the connection is closed if the
+	// context is cancelled first, which stops the read
+	var (
+		outBuf, errBuf bytes.Buffer
+		read           = make(chan error, 1)
+	)
+	go func() {
+		_, err := stdcopy.StdCopy(&outBuf, &errBuf, resp.Reader)
+		read <- err
+	}()
+	select {
+	case err = <-read:
+	case <-ctx.Done():
+		resp.Close()
+		<-read
+		return "", "", fmt.Errorf("'%s' did not complete: %s", strings.Join(cmd, " "), ctx.Err().Error())
+	}
+	stdout, stderr = outBuf.String(), errBuf.String()
+	if err != nil {
+		return stdout, stderr, fmt.Errorf("failed to read output of '%s': %s",
+			strings.Join(cmd, " "), err.Error())
+	}
+
+	// output may close shortly before the command is reported as exited
+	for {
+		info, err := c.d.ContainerExecInspect(ctx, exec.ID)
+		if err != nil {
+			return stdout, stderr, fmt.Errorf("failed to inspect '%s': %s",
+				strings.Join(cmd, " "), err.Error())
+		}
+		if !info.Running {
+			if info.ExitCode != 0 {
+				return stdout, stderr, &ExecError{
+					Cmd:      cmd,
+					ExitCode: info.ExitCode,
+					Stdout:   stdout,
+					Stderr:   stderr,
+				}
+			}
+			return stdout, stderr, nil
+		}
+		select {
+		case <-ctx.Done():
+			return stdout, stderr, fmt.Errorf("'%s' did not complete: %s",
+				strings.Join(cmd, " "), ctx.Err().Error())
+		case <-time.After(execPollInterval):
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package ipfs
+
+import "testing"
+
+func TestExecError_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *ExecError
+		want string
+	}{
+		{"stderr",
+			&ExecError{Cmd: []string{"ipfs", "bootstrap", "add", "asdf"}, ExitCode: 1,
+				Stdout: "some output\n", Stderr: "Error: invalid peer address: asdf\n"},
+			"command 'ipfs bootstrap add asdf' exited with code 1: Error: invalid peer address: asdf"},
+		{"stdout only",
+			&ExecError{Cmd: []string{"ipfs", "repo", "fsck"}, ExitCode: 2, Stdout: "oh no\n"},
+			"command 'ipfs repo fsck' exited with code 2: oh no"},
+		{"no output",
+			&ExecError{Cmd: []string{"asdf"}, ExitCode: 127},
+			"command 'asdf' exited with code 127: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("ExecError.Error() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
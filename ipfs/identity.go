@@ -0,0 +1,69 @@
+package ipfs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	crypto "github.com/libp2p/go-libp2p-crypto"
+	peer "github.com/libp2p/go-libp2p-peer"
+)
+
+// identityFile is the file in a node's data directory that holds the identity
+// the node's startup script initializes a new repo with. The script removes it
+// once the repo exists.
+const identityFile = "identity"
+
+// PeerIDFromKey derives the peer ID of the given private key, which is encoded
+// as it is in go-ipfs configuration and network database entries
+func PeerIDFromKey(privKey string) (string, error) {
+	b, err := crypto.ConfigDecodeKey(privKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid peer key: %s", err.Error())
+	}
+	sk, err := crypto.UnmarshalPrivateKey(b)
+	if err != nil {
+		return "", fmt.Errorf("invalid peer key: %s", err.Error())
+	}
+	id, err := peer.IDFromPrivateKey(sk)
+	if err != nil {
+		return "", fmt.Errorf("failed to derive peer ID: %s", err.Error())
+	}
+	return id.Pretty(), nil
+}
+
+// initIdentity prepares the given node to start with the identity of the given
+// private key. New repos are initialized with the identity by the node's
+// startup script, while existing repos must already have it.
+func (c *Client) initIdentity(n *NodeInfo, privKey string) error {
+	peerID, err := PeerIDFromKey(privKey)
+	if err != nil {
+		return err
+	}
+
+	var (
+		dir  = c.getDataDir(n.NetworkID)
+		path = filepath.Join(dir, identityFile)
+	)
+	if _, err := os.Stat(filepath.Join(dir, "config")); err == nil {
+		os.Remove(path)
+		cfg, err := getConfig(filepath.Join(dir, "config"))
+		if err != nil {
+			return err
+		}
+		if cfg.Identity.PeerID != peerID {
+			return fmt.Errorf("existing repo has peer ID '%s', expected '%s'",
+				cfg.Identity.PeerID, peerID)
+		}
+		return nil
+	}
+
+	c.l.Infow("writing existing identity for new repo",
+		"network_id", n.NetworkID,
+		"peer_id", peerID)
+	if err := ioutil.WriteFile(path, []byte(peerID+"\n"+privKey+"\n"), c.fileMode); err != nil {
+		return fmt.Errorf("failed to write identity: %s", err.Error())
+	}
+	return nil
+}
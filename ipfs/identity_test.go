@@ -0,0 +1,124 @@
+package ipfs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	crypto "github.com/libp2p/go-libp2p-crypto"
+)
+
+// identity of the test node configuration
+const (
+	testPeerID  = "QmbzqXEoWcAEWC2AERztspfZzgLSUqGoFiWEvC2XsEGnCG"
+	testPeerKey = "CAASpgkwggSiAgEAAoIBAQCqbaLjmbfDoABV3FxXlYkiiAWzQee2k+kFBRgKiDdGORH9eaH951ifv2vHe3xKUROMNaGTRvN4sIeI7tBSCrBSDRJ7PgS5zcdLwKPl8DID7/kkZ+QK7BmEnIcFvCCFxOc3DUROu7oT16kH4chWqoEWPH0SWp3DjIN3zN6FC0oizCKTZeg2HZDZX1nkIhpkKPs3Bp0X4kQVA6EW5xugez3uzbkVcxGzBvPtArisXlTE/GLG31SwrF8OnYbAqZB+TtEm5i7r5kHj4iJScsv1xBVxrdzBDOE16KW3inDGxPnW6vwOPzcN4fTMZ3wgRk89Cm5iI/MmtkiaUjGJPr08+xWzAgMBAAECggEAbla5BN36qX6neO9IIbRAqsih2CKtH/m2/XcEz5zNHHvKd+8Nv9LN/+7wmqAKIhtHqpj2WOGws8ymkzL6UIN3EEhCVOQcLydZBmRcOHxABWiSRs20SJX/F2o3yLC55aFLiMrgFJFZsYsIdn/pMqMFHB5hY0ajqX0JiMBsuHpMryWnm/5sllp7MFc6yEQF0UQ+z+Dx83hYoh/40qwHiuohm7i1MQYx9qKjMMKLMoHdGlg6g3nMRgeXw1xI8ry50A2AQjcQsbpYN+/FxASnf9l1VuIFgOnzGmbBGGC5I+SStWoupinrNH68MuQxCqmIiexYPCFvPW/YjThIMqYZ37+ooQKBgQDDxDdJ6G899qqbrR4ly/XWbe+CDxf8ueMvWsirvkL3ozufsvNPEIrXXKxuMex0JB5x7wPPr7jhwSNVuGD1bsu8R2b66n985rbTgFT1B5eGuW9FHuhPoC+bNeawi/W7ZsCQKCsUHY1orA2NAanaSnzSS9ico3F6gQ/txafwoIMlCwKBgQDe3aBC4TFZa79nRpOyPiTU8y5iPSJ8xf/6f2GA9voXLLnII8RLZXVtc71zj1OIDks//fNwDnqdwcczalqCfNSdrXWavRJ7dqKOHPQBpO8eGNHtYBd876ushbUR4gjrkxgXZ2uyrXe+D8TcDHWCdCJ3aa3FjzuMv/JJ4Fe/ErDq+QKBgDRdNdTFIYxXgIcnpVrC1b1HprsJQodNSaGPDQIzYEJRHU+4VDCf4iN9HHpVTEQ8rRAYuNJC1Jc+TC9PpE/CFSkFiFwxgWxtYhXsy8zG/RcCXusEO2uhE1rW7h/nMBGyiGuG8w7sYLjQ3McM3NwQ9JZjx0sOxPnZr+MP7b4FkU7FAoGASFS5rLsVnyX/Ku+XA+RzY8HBLhUVWlWQrKYm6Qo/RMI5UaF6FdZJ9En6FMVRoPiyp4QuPBIW7Zh0pFVCJtOI1dv0LVJr6zIns+PltZroGGaJy3bCaMQIfaeviqxHpN1Kll30cDsof8DybVCF2t8CSKs9wL6p3xZ09lEfaV4RmVECgYASBPs0qAdJ+WS2i8OErkN/HZRfNQwRo83YPeYTbhhe1oHfXyQqBM1CC965+Xbh82t8xYPG3Nc1Av63Rfh/D9+J3ylduUqpNxN0xgdQWYXzDA7Lzz+GkHwGGVAlbabK966m6PDZIpCxgRU/pLbUQ9YIJUORCPXp6psl/yWTgn9mgA=="
+)
+
+func newTestPeerKey() (string, error) {
+	sk, _, err := crypto.GenerateKeyPair(crypto.Ed25519, 0)
+	if err != nil {
+		return "", err
+	}
+	b, err := crypto.MarshalPrivateKey(sk)
+	if err != nil {
+		return "", err
+	}
+	return crypto.ConfigEncodeKey(b), nil
+}
+
+func TestPeerIDFromKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{"valid key", testPeerKey, testPeerID, false},
+		{"empty", "", "", true},
+		{"invalid base64", "not a key!", "", true},
+		{"invalid key", "aGVsbG8gd29ybGQ=", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PeerIDFromKey(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PeerIDFromKey() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("PeerIDFromKey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_client_EmulatedNodeIdentity(t *testing.T) {
+	c, _, srv, err := newEmulatedTestClient()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer srv.Close()
+	key, err := SwarmKey()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	var (
+		ctx  = context.Background()
+		n    = &NodeInfo{NetworkID: "test_identity", Ports: NodePorts{Swarm: "4002", API: "5002", Gateway: "8081"}}
+		opts = NodeOpts{SwarmKey: []byte(key), PeerKey: testPeerKey}
+	)
+	defer c.RemoveNode(ctx, n.NetworkID)
+
+	// invalid peer keys are rejected before anything is created
+	if err := c.CreateNode(ctx, n, NodeOpts{SwarmKey: []byte(key), PeerKey: "not a key!"}); err == nil {
+		t.Error("expected error for invalid peer key")
+	}
+
+	// new node should start with the provided identity
+	if err := c.CreateNode(ctx, n, opts); err != nil {
+		t.Errorf("client.CreateNode() error = %v", err)
+		return
+	}
+	s, err := c.NodeStats(ctx, n)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if s.PeerID != testPeerID || s.PeerKey != testPeerKey {
+		t.Errorf("expected peer ID '%s', got '%s'", testPeerID, s.PeerID)
+	}
+	var dir = c.(*Client).getDataDir(n.NetworkID)
+	if _, err := os.Stat(filepath.Join(dir, identityFile)); !os.IsNotExist(err) {
+		t.Errorf("expected identity file to be removed, got %v", err)
+	}
+
+	// recreating node on existing repo keeps identity
+	if err := c.StopNode(ctx, n); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := c.CreateNode(ctx, n, opts); err != nil {
+		t.Errorf("client.CreateNode() error = %v", err)
+		return
+	}
+	if s, err = c.NodeStats(ctx, n); err != nil || s.PeerID != testPeerID {
+		t.Errorf("expected peer ID '%s', got '%s' (error %v)", testPeerID, s.PeerID, err)
+	}
+	if err := c.StopNode(ctx, n); err != nil {
+		t.Error(err)
+		return
+	}
+
+	// existing repos with a different identity are rejected
+	if opts.PeerKey, err = newTestPeerKey(); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := c.CreateNode(ctx, n, opts); err == nil {
+		t.Error("expected error for mismatched peer key")
+	}
+}
@@ -0,0 +1,252 @@
+package ipfs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+	docker "github.com/docker/docker/client"
+	"go.uber.org/zap"
+)
+
+// ipfsRepository is the image repository go-ipfs releases are pulled from
+const ipfsRepository = "ipfs/go-ipfs"
+
+var (
+	// validVersion matches valid Docker image tags
+	validVersion = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+	// validDigest matches image digests
+	validDigest = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+)
+
+// versionImage generates the reference of the go-ipfs image for the given
+// version, which can either be a tag or a digest
+func versionImage(version string) (string, error) {
+	switch {
+	case validDigest.MatchString(version):
+		return ipfsRepository + "@" + version, nil
+	case validVersion.MatchString(version):
+		return ipfsRepository + ":" + version, nil
+	default:
+		return "", fmt.Errorf("invalid version '%s'", version)
+	}
+}
+
+// image makes sure the image for the given go-ipfs version is available, and
+// returns its reference and ID. The client's default version runs the
+// configured image if one is set.
+func (c *Client) image(ctx context.Context, version string) (string, string, error) {
+	var ref = c.defaultImage
+	if ref == "" || version != c.version {
+		var err error
+		if ref, err = versionImage(version); err != nil {
+			return "", "", err
+		}
+	}
+	id, err := c.images.ensure(ctx, ref)
+	if err != nil {
+		return "", "", err
+	}
+	return ref, id, nil
+}
+
+// imageRef is a parsed image reference of the form
+// "<repository>[:<tag>][@<digest>]"
+type imageRef struct {
+	repository string
+	tag        string
+	digest     string
+}
+
+func parseImageRef(ref string) (imageRef, error) {
+	var r imageRef
+	if i := strings.Index(ref, "@"); i >= 0 {
+		r.digest = ref[i+1:]
+		ref = ref[:i]
+		if !validDigest.MatchString(r.digest) {
+			return r, fmt.Errorf("invalid digest '%s'", r.digest)
+		}
+	}
+	// tags follow the last colon, unless it is part of a registry host
+	if i := strings.LastIndex(ref, ":"); i >= 0 && !strings.Contains(ref[i:], "/") {
+		r.tag = ref[i+1:]
+		ref = ref[:i]
+		if !validVersion.MatchString(r.tag) {
+			return r, fmt.Errorf("invalid tag '%s'", r.tag)
+		}
+	}
+	if ref == "" {
+		return r, errors.New("image repository required")
+	}
+	r.repository = ref
+	return r, nil
+}
+
+// matchesDigest checks if any of the given repository digests, as reported by
+// the Engine API, match the reference's digest
+func (r imageRef) matchesDigest(repoDigests []string) bool {
+	for _, d := range repoDigests {
+		var parts = strings.SplitN(d, "@", 2)
+		if len(parts) == 2 && parts[1] == r.digest &&
+			familiarName(parts[0]) == familiarName(r.repository) {
+			return true
+		}
+	}
+	return false
+}
+
+// familiarName strips the default registry and namespace from the given
+// repository, as the Docker CLI does when displaying images
+func familiarName(repository string) string {
+	repository = strings.TrimPrefix(repository, "docker.io/")
+	return strings.TrimPrefix(repository, "library/")
+}
+
+// imageManager makes sure images are available to the runtime. Images present
+// locally are used without contacting a registry, images are loaded from a
+// tarball if one is configured, and pulled otherwise. Images pinned by digest
+// are verified before they are used.
+type imageManager struct {
+	l *zap.SugaredLogger
+	d Runtime
+
+	// tarball is an optional image archive that is loaded before pulling
+	tarball string
+
+	// IDs of available images indexed by reference - locked by
+	// imageManager::mux
+	available map[string]string
+	// loaded indicates that the tarball has been loaded - locked by
+	// imageManager::mux
+	loaded bool
+	mux    sync.Mutex
+}
+
+func newImageManager(logger *zap.SugaredLogger, d Runtime, tarball string) *imageManager {
+	return &imageManager{
+		l:         logger.Named("images"),
+		d:         d,
+		tarball:   tarball,
+		available: make(map[string]string),
+	}
+}
+
+// ensure makes sure the given image is available, and returns its ID
+func (m *imageManager) ensure(ctx context.Context, ref string) (string, error) {
+	r, err := parseImageRef(ref)
+	if err != nil {
+		return "", fmt.Errorf("invalid image '%s': %s", ref, err.Error())
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	if id, found := m.available[ref]; found {
+		return id, nil
+	}
+	var l = m.l.With("image", ref)
+
+	// check for local image
+	id, err := m.inspect(ctx, r, ref)
+	if err == nil {
+		l.Debugw("image found locally", "image.id", id)
+		m.available[ref] = id
+		return id, nil
+	} else if !docker.IsErrNotFound(err) {
+		return "", err
+	}
+
+	// load image archive if one is provided
+	if m.tarball != "" && !m.loaded {
+		l.Infow("image not found locally - loading image archive", "tarball", m.tarball)
+		if err := m.load(ctx); err != nil {
+			l.Warnw("failed to load image archive", "error", err, "tarball", m.tarball)
+		} else {
+			m.loaded = true
+			if id, err := m.inspect(ctx, r, ref); err == nil {
+				l.Infow("image loaded from archive", "image.id", id)
+				m.available[ref] = id
+				return id, nil
+			} else if !docker.IsErrNotFound(err) {
+				return "", err
+			}
+		}
+	}
+
+	// pull image from registry
+	l.Info("image not found locally - pulling image")
+	if err := m.pull(ctx, ref); err != nil {
+		return "", fmt.Errorf("failed to pull image '%s': %s", ref, err.Error())
+	}
+	if id, err = m.inspect(ctx, r, ref); err != nil {
+		return "", err
+	}
+	l.Infow("image pulled", "image.id", id)
+	m.available[ref] = id
+	return id, nil
+}
+
+// inspect retrieves the ID of the given local image, and verifies its digest if
+// it is pinned by one
+func (m *imageManager) inspect(ctx context.Context, r imageRef, ref string) (string, error) {
+	info, _, err := m.d.ImageInspectWithRaw(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	if r.digest != "" && !r.matchesDigest(info.RepoDigests) {
+		return "", fmt.Errorf("image '%s' does not match digest %s - found %v",
+			ref, r.digest, info.RepoDigests)
+	}
+	return info.ID, nil
+}
+
+// load loads the images in the tarball
+func (m *imageManager) load(ctx context.Context) error {
+	f, err := os.Open(m.tarball)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	resp, err := m.d.ImageLoad(ctx, f, true)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return readProgress(resp.Body)
+}
+
+// pull pulls the given image from its registry
+func (m *imageManager) pull(ctx context.Context, ref string) error {
+	out, err := m.d.ImagePull(ctx, ref, types.ImagePullOptions{})
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	return readProgress(out)
+}
+
+// readProgress consumes the JSON progress messages the Engine API reports for
+// image operations, which only complete once their output is consumed. Errors
+// reported in the messages are returned.
+func readProgress(r io.Reader) error {
+	var dec = json.NewDecoder(r)
+	for {
+		var msg struct {
+			Error string `json:"error"`
+		}
+		if err := dec.Decode(&msg); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("invalid progress message: %s", err.Error())
+		}
+		if msg.Error != "" {
+			return errors.New(msg.Error)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package ipfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+
+	"github.com/RTradeLtd/Nexus/log"
+)
+
+var testDigest = "sha256:" + strings.Repeat("a", 64)
+
+func Test_versionImage(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+		wantErr bool
+	}{
+		{"v0.4.18", "ipfs/go-ipfs:v0.4.18", false},
+		{testDigest, "ipfs/go-ipfs@" + testDigest, false},
+		{"", "", true},
+		{"not a version", "", true},
+		{"-v1", "", true},
+		{"v0.4.18:latest", "", true},
+		{"sha256:asdf", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			got, err := versionImage(tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("versionImage() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("versionImage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseImageRef(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    imageRef
+		wantErr bool
+	}{
+		{"ipfs/go-ipfs", imageRef{"ipfs/go-ipfs", "", ""}, false},
+		{"ipfs/go-ipfs:v0.4.18", imageRef{"ipfs/go-ipfs", "v0.4.18", ""}, false},
+		{"ipfs/go-ipfs@" + testDigest, imageRef{"ipfs/go-ipfs", "", testDigest}, false},
+		{"ipfs/go-ipfs:v0.4.18@" + testDigest, imageRef{"ipfs/go-ipfs", "v0.4.18", testDigest}, false},
+		{"localhost:5000/go-ipfs", imageRef{"localhost:5000/go-ipfs", "", ""}, false},
+		{"localhost:5000/go-ipfs:v0.4.18", imageRef{"localhost:5000/go-ipfs", "v0.4.18", ""}, false},
+		{"", imageRef{}, true},
+		{":v0.4.18", imageRef{}, true},
+		{"ipfs/go-ipfs@sha256:asdf", imageRef{}, true},
+		{"ipfs/go-ipfs:-v1", imageRef{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := parseImageRef(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseImageRef() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseImageRef() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_imageRef_matchesDigest(t *testing.T) {
+	var r = imageRef{repository: "ipfs/go-ipfs", digest: testDigest}
+	tests := []struct {
+		name    string
+		digests []string
+		want    bool
+	}{
+		{"no digests", nil, false},
+		{"match", []string{"ipfs/go-ipfs@" + testDigest}, true},
+		{"match with registry", []string{"docker.io/ipfs/go-ipfs@" + testDigest}, true},
+		{"different digest", []string{"ipfs/go-ipfs@sha256:" + strings.Repeat("b", 64)}, false},
+		{"different repository", []string{"ipfs/js-ipfs@" + testDigest}, false},
+		{"invalid", []string{testDigest}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.matchesDigest(tt.digests); got != tt.want {
+				t.Errorf("imageRef.matchesDigest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_readProgress(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		wantErr bool
+	}{
+		{"empty", "", false},
+		{"progress", `{"status":"Pulling"}` + "\n" + `{"status":"Downloaded"}`, false},
+		{"error", `{"status":"Pulling"}` + "\n" + `{"error":"manifest unknown"}`, true},
+		{"invalid", `asdf`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := readProgress(strings.NewReader(tt.output)); (err != nil) != tt.wantErr {
+				t.Errorf("readProgress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// imageRuntime is a Runtime that only implements image operations. Pulled and
+// loaded images are given the configured digests.
+type imageRuntime struct {
+	Runtime
+
+	local   map[string]types.ImageInspect
+	remote  map[string]types.ImageInspect
+	archive map[string]types.ImageInspect
+
+	pulls int
+	loads int
+}
+
+type errImageNotFound string
+
+func (e errImageNotFound) Error() string  { return "No such image: " + string(e) }
+func (e errImageNotFound) NotFound() bool { return true }
+
+func (r *imageRuntime) ImageInspectWithRaw(ctx context.Context, ref string) (types.ImageInspect, []byte, error) {
+	if info, found := r.local[ref]; found {
+		return info, nil, nil
+	}
+	return types.ImageInspect{}, nil, errImageNotFound(ref)
+}
+
+func (r *imageRuntime) ImagePull(ctx context.Context, ref string, opts types.ImagePullOptions) (io.ReadCloser, error) {
+	r.pulls++
+	info, found := r.remote[ref]
+	if !found {
+		return ioutil.NopCloser(strings.NewReader(`{"error":"manifest unknown"}`)), nil
+	}
+	r.local[ref] = info
+	return ioutil.NopCloser(strings.NewReader(`{"status":"Downloaded"}`)), nil
+}
+
+func (r *imageRuntime) ImageLoad(ctx context.Context, input io.Reader, quiet bool) (types.ImageLoadResponse, error) {
+	r.loads++
+	if _, err := ioutil.ReadAll(input); err != nil {
+		return types.ImageLoadResponse{}, err
+	}
+	if r.archive == nil {
+		return types.ImageLoadResponse{}, errors.New("invalid archive")
+	}
+	for ref, info := range r.archive {
+		r.local[ref] = info
+	}
+	return types.ImageLoadResponse{
+		Body: ioutil.NopCloser(strings.NewReader(`{"stream":"Loaded image"}`)),
+		JSON: true,
+	}, nil
+}
+
+func Test_imageManager_ensure(t *testing.T) {
+	l, _ := log.NewTestLogger()
+	tarball, err := ioutil.TempFile("", "nexus-images")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tarball.Close()
+	defer os.Remove(tarball.Name())
+
+	var (
+		tagged  = types.ImageInspect{ID: "sha256:tagged", RepoDigests: []string{"ipfs/go-ipfs@" + testDigest}}
+		loaded  = types.ImageInspect{ID: "sha256:loaded"}
+		digest  = "ipfs/go-ipfs@" + testDigest
+		other   = "ipfs/go-ipfs@sha256:" + strings.Repeat("b", 64)
+		archive = map[string]types.ImageInspect{"ipfs/go-ipfs:v0.4.19": loaded}
+	)
+	tests := []struct {
+		name      string
+		ref       string
+		local     map[string]types.ImageInspect
+		remote    map[string]types.ImageInspect
+		archive   map[string]types.ImageInspect
+		tarball   string
+		wantID    string
+		wantPulls int
+		wantLoads int
+		wantErr   bool
+	}{
+		{"invalid ref", "ipfs/go-ipfs:-v1", nil, nil, nil, "", "", 0, 0, true},
+		{"local", "ipfs/go-ipfs:v0.4.18",
+			map[string]types.ImageInspect{"ipfs/go-ipfs:v0.4.18": tagged}, nil, nil, "",
+			"sha256:tagged", 0, 0, false},
+		{"local by digest", digest,
+			map[string]types.ImageInspect{digest: tagged}, nil, nil, "",
+			"sha256:tagged", 0, 0, false},
+		{"local with wrong digest", other,
+			map[string]types.ImageInspect{other: tagged}, nil, nil, "",
+			"", 0, 0, true},
+		{"pulled", "ipfs/go-ipfs:v0.4.18",
+			nil, map[string]types.ImageInspect{"ipfs/go-ipfs:v0.4.18": tagged}, nil, "",
+			"sha256:tagged", 1, 0, false},
+		{"pulled by digest", digest,
+			nil, map[string]types.ImageInspect{digest: tagged}, nil, "",
+			"sha256:tagged", 1, 0, false},
+		{"pull failed", "ipfs/go-ipfs:v0.4.18", nil, nil, nil, "", "", 1, 0, true},
+		{"loaded", "ipfs/go-ipfs:v0.4.19", nil, nil, archive, tarball.Name(),
+			"sha256:loaded", 0, 1, false},
+		{"not in archive", "ipfs/go-ipfs:v0.4.18",
+			nil, map[string]types.ImageInspect{"ipfs/go-ipfs:v0.4.18": tagged}, archive, tarball.Name(),
+			"sha256:tagged", 1, 1, false},
+		{"invalid archive", "ipfs/go-ipfs:v0.4.18",
+			nil, map[string]types.ImageInspect{"ipfs/go-ipfs:v0.4.18": tagged}, nil, tarball.Name(),
+			"sha256:tagged", 1, 1, false},
+		{"missing archive", "ipfs/go-ipfs:v0.4.18",
+			nil, map[string]types.ImageInspect{"ipfs/go-ipfs:v0.4.18": tagged}, archive, "/nonexistent.tar",
+			"sha256:tagged", 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r = &imageRuntime{
+				local:   make(map[string]types.ImageInspect),
+				remote:  tt.remote,
+				archive: tt.archive,
+			}
+			for ref, info := range tt.local {
+				r.local[ref] = info
+			}
+			var m = newImageManager(l, r, tt.tarball)
+			id, err := m.ensure(context.Background(), tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("imageManager.ensure() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if id != tt.wantID {
+				t.Errorf("imageManager.ensure() = %v, want %v", id, tt.wantID)
+			}
+			if r.pulls != tt.wantPulls || r.loads != tt.wantLoads {
+				t.Errorf("expected %d pulls and %d loads, got %d and %d",
+					tt.wantPulls, tt.wantLoads, r.pulls, r.loads)
+			}
+
+			// available images should not be retrieved again
+			if !tt.wantErr {
+				if id, err := m.ensure(context.Background(), tt.ref); err != nil || id != tt.wantID {
+					t.Errorf("expected cached image %s, got %s (%v)", tt.wantID, id, err)
+				}
+				if r.pulls != tt.wantPulls {
+					t.Errorf("expected image not to be pulled again")
+				}
+			}
+		})
+	}
+}
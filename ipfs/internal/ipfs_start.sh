@@ -6,8 +6,9 @@
 
 set -e
 
-# arguments provided through string templates
-DISK_MAX=%dGB
+# arguments provided through templates
+DISK_MAX={{ .DiskMaxGB }}GB
+GC_WATERMARK={{ .GCWatermark }}
 
 # set variables
 user=ipfs
@@ -29,13 +30,34 @@ ipfs version
 if [ -e "$repo/config" ]; then
   echo "found IPFS fs-repo at $repo"
 else
-  ipfs init --profile server
+  ipfs init --profile {{ .InitProfile }}
   ipfs config Addresses.API /ip4/0.0.0.0/tcp/5001
   ipfs config Addresses.Gateway /ip4/0.0.0.0/tcp/8080
+
+  # replace generated identity with existing identity if one is provided - the
+  # private key cannot be set through "ipfs config"
+  if [ -e "$repo/identity" ]; then
+    echo "initializing IPFS fs-repo with existing identity"
+    peer_id=$(sed -n 1p "$repo/identity")
+    priv_key=$(sed -n 2p "$repo/identity")
+    sed -i \
+      -e "s|\"PeerID\": \".*\"|\"PeerID\": \"$peer_id\"|" \
+      -e "s|\"PrivKey\": \".*\"|\"PrivKey\": \"$priv_key\"|" \
+      "$repo/config"
+  fi
 fi
+rm -f "$repo/identity"
 
 # set datastore quota
 ipfs config Datastore.StorageMax $DISK_MAX
+ipfs config --json Datastore.StorageGCWatermark $GC_WATERMARK
+
+# apply node configuration - each line is a key followed by its JSON value
+if [ -e "$repo/nexus_config" ]; then
+  while read -r key value; do
+    ipfs config --json "$key" "$value"
+  done < "$repo/nexus_config"
+fi
 
 # release locks
 ipfs repo fsck
@@ -3,55 +3,83 @@ package ipfs
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
+	"time"
 
-	"github.com/docker/docker/api/types"
-	docker "github.com/docker/docker/client"
 	"go.uber.org/zap"
 
 	"github.com/RTradeLtd/Nexus/config"
+	"github.com/RTradeLtd/Nexus/network"
 )
 
 // NodeClient provides an interface to the base Docker client for controlling
 // IPFS nodes. It is implemented by ipfs.Client
 type NodeClient interface {
 	Nodes(ctx context.Context) (nodes []*NodeInfo, err error)
+	StoppedNodes(ctx context.Context) (nodes []*NodeInfo, err error)
 	CreateNode(ctx context.Context, n *NodeInfo, opts NodeOpts) (err error)
 	UpdateNode(ctx context.Context, n *NodeInfo) (err error)
 	StopNode(ctx context.Context, n *NodeInfo) (err error)
+	RestartNode(ctx context.Context, n *NodeInfo) (err error)
+	PauseNode(ctx context.Context, n *NodeInfo) (err error)
+	ResumeNode(ctx context.Context, n *NodeInfo) (err error)
 	RemoveNode(ctx context.Context, network string) (err error)
+	NodeAssetsExist(ctx context.Context, network string) (exists bool, err error)
 	NodeStats(ctx context.Context, n *NodeInfo) (stats NodeStats, err error)
+	StreamStats(ctx context.Context, n *NodeInfo, interval time.Duration) (samples <-chan ContainerStats, err error)
+	NodeLogs(ctx context.Context, n *NodeInfo, opts LogOptions) (lines <-chan LogLine, err error)
+	ProbeNode(ctx context.Context, n *NodeInfo) (err error)
+	UpgradeNode(ctx context.Context, n *NodeInfo, version string) (err error)
+	BackupNode(ctx context.Context, n *NodeInfo, w io.Writer) (manifest BackupManifest, err error)
+	RestoreNode(ctx context.Context, n *NodeInfo, r io.Reader) (manifest BackupManifest, err error)
+	RotateSwarmKey(ctx context.Context, n *NodeInfo, key []byte) (err error)
+	AddBootstrapPeers(ctx context.Context, n *NodeInfo, peers []string) (err error)
+	RemoveBootstrapPeers(ctx context.Context, n *NodeInfo, peers []string) (err error)
 	Watch(ctx context.Context) (<-chan Event, <-chan error)
 }
 
 // NewClient creates a new Docker Client from ENV values and negotiates the
 // correct API version to use
 func NewClient(logger *zap.SugaredLogger, ipfsOpts config.IPFS) (NodeClient, error) {
-	d, err := docker.NewEnvClient()
+	d, err := NewDockerRuntime()
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to dockerd: %s", err.Error())
+		return nil, err
 	}
-	d.NegotiateAPIVersion(context.Background())
+	return NewClientWithRuntime(logger, d, ipfsOpts)
+}
 
+// NewClientWithRuntime creates a new node client that manages node containers
+// using the given runtime
+func NewClientWithRuntime(logger *zap.SugaredLogger, d Runtime, ipfsOpts config.IPFS) (NodeClient, error) {
 	// parse file mode - 0 allows the stdlib to decide how to parse
 	mode, err := strconv.ParseUint(ipfsOpts.ModePerm, 0, 32)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse perm_mode %s: %s", ipfsOpts.ModePerm, err.Error())
 	}
 
-	// pull required images
-	ipfsImage := "ipfs/go-ipfs:" + ipfsOpts.Version
-	if _, err = d.ImagePull(context.Background(), ipfsImage, types.ImagePullOptions{}); err != nil {
-		return nil, fmt.Errorf("failed to download IPFS image: %s", err.Error())
+	c := &Client{
+		l:            logger.Named("ipfs"),
+		d:            d,
+		version:      ipfsOpts.Version,
+		defaultImage: ipfsOpts.Image,
+		dataDir:      ipfsOpts.DataDirectory,
+		fileMode:     os.FileMode(mode),
+		gcWatermark:  ipfsOpts.GCWatermark,
+		initProfile:  ipfsOpts.InitProfile,
+		apiHost:      network.Private,
 	}
 
-	c := &Client{
-		l:         logger.Named("ipfs"),
-		d:         d,
-		ipfsImage: ipfsImage,
-		dataDir:   ipfsOpts.DataDirectory,
-		fileMode:  os.FileMode(mode),
+	// make sure node startup scripts can be generated with these settings
+	if err := c.newStartScript(&NodeInfo{Resources: NodeResources{DiskGB: 1}}).Validate(); err != nil {
+		return nil, fmt.Errorf("invalid startup script configuration: %s", err.Error())
+	}
+	c.images = newImageManager(c.l, d, ipfsOpts.ImageTarball)
+
+	// make sure required images are available
+	if _, _, err = c.image(context.Background(), c.version); err != nil {
+		return nil, fmt.Errorf("failed to retrieve IPFS image: %s", err.Error())
 	}
 
 	// initialize directories
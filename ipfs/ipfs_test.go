@@ -3,15 +3,17 @@ package ipfs
 import (
 	"context"
 	"fmt"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/RTradeLtd/Nexus/config"
+	"github.com/RTradeLtd/Nexus/ipfs/emulator"
 	"github.com/RTradeLtd/Nexus/log"
+	"github.com/RTradeLtd/Nexus/network"
 	docker "github.com/docker/docker/client"
 )
 
 func newTestClient() (NodeClient, error) {
-	ipfsImage := "ipfs/go-ipfs:" + config.DefaultIPFSVersion
 	d, err := docker.NewEnvClient()
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to dockerd: %s", err.Error())
@@ -19,7 +21,34 @@ func newTestClient() (NodeClient, error) {
 	d.NegotiateAPIVersion(context.Background())
 
 	l, _ := log.NewLogger("", true)
-	return &Client{l, d, ipfsImage, "./tmp", 0755}, nil
+	return &Client{l: l, d: d, version: config.DefaultIPFSVersion, dataDir: "./tmp", fileMode: 0755,
+		images: newImageManager(l, d, ""), apiHost: network.Private}, nil
+}
+
+// newEmulatedTestClient creates a client backed by an emulated Engine API.
+// Callers should close the returned server when done.
+func newEmulatedTestClient() (NodeClient, *emulator.Engine, *httptest.Server, error) {
+	var (
+		e   = emulator.New()
+		srv = httptest.NewServer(e)
+	)
+	d, err := NewEmulatedRuntime(srv.Listener.Addr().String())
+	if err != nil {
+		srv.Close()
+		return nil, nil, nil, err
+	}
+
+	l, _ := log.NewTestLogger()
+	c, err := NewClientWithRuntime(l, d, config.IPFS{
+		Version:       config.DefaultIPFSVersion,
+		DataDirectory: "./tmp",
+		ModePerm:      "0700",
+	})
+	if err != nil {
+		srv.Close()
+		return nil, nil, nil, err
+	}
+	return c, e, srv, nil
 }
 
 func TestNewClient(t *testing.T) {
@@ -32,3 +61,12 @@ func TestNewClient(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestNewClientWithRuntime(t *testing.T) {
+	_, _, srv, err := newEmulatedTestClient()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	srv.Close()
+}
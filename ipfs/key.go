@@ -1,16 +1,221 @@
 package ipfs
 
 import (
+	"bytes"
+	"context"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/RTradeLtd/Nexus/log"
+)
+
+const (
+	// pskHeader is the first line of swarm keys in the PSK v1 format
+	pskHeader = "/key/swarm/psk/1.0.0/"
+	// pskLength is the length of decoded swarm keys in bytes
+	pskLength = 32
 )
 
-// SwarmKey generates a new swarm key
+// Encodings of swarm keys supported by the PSK v1 format
+const (
+	SwarmKeyBase16 = "/base16/"
+	SwarmKeyBase64 = "/base64/"
+	SwarmKeyBinary = "/bin/"
+)
+
+// PSK is a pre-shared key that restricts nodes to a private network. Its
+// String method returns the key's fingerprint, so that keys are never written
+// to logs by accident.
+type PSK struct {
+	key [pskLength]byte
+}
+
+// NewPSK generates a new random swarm key
+func NewPSK() (PSK, error) {
+	var k PSK
+	if _, err := rand.Read(k.key[:]); err != nil {
+		return k, fmt.Errorf("error generating key: %s", err.Error())
+	}
+	return k, nil
+}
+
+// ParseSwarmKey parses and validates a swarm key in the PSK v1 format used by
+// go-ipfs swarm.key files, in the /base16/, /base64/ or /bin/ encodings.
+// Errors never include key material.
+func ParseSwarmKey(b []byte) (PSK, error) {
+	var k PSK
+	header, rest := readKeyLine(b)
+	if header != pskHeader {
+		return k, fmt.Errorf("invalid swarm key: expected header '%s'", pskHeader)
+	}
+	encoding, data := readKeyLine(rest)
+
+	var decoded []byte
+	var err error
+	switch encoding {
+	case SwarmKeyBase16:
+		if decoded, err = hex.DecodeString(string(bytes.TrimSpace(data))); err != nil {
+			return k, errors.New("invalid swarm key: key is not valid base16")
+		}
+	case SwarmKeyBase64:
+		if decoded, err = base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data))); err != nil {
+			return k, errors.New("invalid swarm key: key is not valid base64")
+		}
+	case SwarmKeyBinary:
+		// binary keys may contain whitespace, so only a single trailing newline
+		// is allowed
+		decoded = data
+		if len(decoded) == pskLength+1 && decoded[pskLength] == '\n' {
+			decoded = decoded[:pskLength]
+		}
+	default:
+		// the unrecognized line is not included, since it may be key material
+		return k, fmt.Errorf("invalid swarm key: expected encoding '%s', '%s' or '%s'",
+			SwarmKeyBase16, SwarmKeyBase64, SwarmKeyBinary)
+	}
+	if len(decoded) != pskLength {
+		return k, fmt.Errorf("invalid swarm key: expected %d bytes, got %d", pskLength, len(decoded))
+	}
+	copy(k.key[:], decoded)
+	return k, nil
+}
+
+// readKeyLine splits the first line off the given swarm key data
+func readKeyLine(b []byte) (line string, rest []byte) {
+	var i = bytes.IndexByte(b, '\n')
+	if i < 0 {
+		return string(bytes.TrimSpace(b)), nil
+	}
+	return string(bytes.TrimSpace(b[:i])), b[i+1:]
+}
+
+// Encode formats the key in the PSK v1 format with the given encoding
+func (k PSK) Encode(encoding string) ([]byte, error) {
+	var data []byte
+	switch encoding {
+	case SwarmKeyBase16:
+		data = []byte(hex.EncodeToString(k.key[:]))
+	case SwarmKeyBase64:
+		data = []byte(base64.StdEncoding.EncodeToString(k.key[:]))
+	case SwarmKeyBinary:
+		data = append([]byte(nil), k.key[:]...)
+	default:
+		return nil, fmt.Errorf("unsupported swarm key encoding '%s'", encoding)
+	}
+	return append([]byte(pskHeader+"\n"+encoding+"\n"), data...), nil
+}
+
+// Bytes returns the normalized form of the key, which is /base16/ encoded with
+// lowercase characters and no trailing newline. Keys are stored and written to
+// nodes in this form.
+func (k PSK) Bytes() []byte {
+	b, _ := k.Encode(SwarmKeyBase16)
+	return b
+}
+
+// Fingerprint returns a short identifier of the key that can be safely logged
+// and displayed, since it does not reveal the key. Encodings of the same key
+// have the same fingerprint.
+func (k PSK) Fingerprint() string {
+	var sum = sha256.Sum256(k.key[:])
+	return hex.EncodeToString(sum[:8])
+}
+
+// String returns the key's fingerprint
+func (k PSK) String() string {
+	return k.Fingerprint()
+}
+
+// SwarmKey generates a new swarm key in its normalized form
 func SwarmKey() (string, error) {
-	key := make([]byte, 32)
-	if _, err := rand.Read(key); err != nil {
-		return "", fmt.Errorf("error generating key: %s", err.Error())
+	k, err := NewPSK()
+	if err != nil {
+		return "", err
+	}
+	return string(k.Bytes()), nil
+}
+
+// swarmKeyFingerprint returns the fingerprint of the given swarm key, or a
+// placeholder if the key is invalid
+func swarmKeyFingerprint(key []byte) string {
+	k, err := ParseSwarmKey(key)
+	if err != nil {
+		return "<invalid>"
+	}
+	return k.Fingerprint()
+}
+
+// RotateSwarmKey replaces the given node's swarm key with the given key and
+// restarts the node, so that it only accepts peers that have the new key. If the
+// node does not come back up with the same identity, the previous key is
+// written back and the node is restarted with it.
+func (c *Client) RotateSwarmKey(ctx context.Context, n *NodeInfo, key []byte) error {
+	if n == nil || n.DockerID == "" {
+		return errors.New("invalid node")
+	}
+	k, err := ParseSwarmKey(key)
+	if err != nil {
+		return err
+	}
+
+	var (
+		start = time.Now()
+		l     = log.NewProcessLogger(c.l, "rotate_swarm_key",
+			"network_id", n.NetworkID,
+			"docker_id", n.DockerID,
+			"key_fingerprint", k.Fingerprint())
+	)
+
+	// record the node's identity and current key before touching anything
+	probeCtx, cancel := context.WithTimeout(ctx, livenessTimeout)
+	id, err := c.probe(probeCtx, n)
+	cancel()
+	if err != nil {
+		l.Errorw("node is not healthy", "error", err)
+		return fmt.Errorf("node must be healthy for its swarm key to be rotated: %s", err.Error())
+	}
+	previous, err := ioutil.ReadFile(filepath.Join(c.getDataDir(n.NetworkID), "swarm.key"))
+	if err != nil {
+		l.Errorw("failed to read current swarm key", "error", err)
+		return fmt.Errorf("failed to read current swarm key: %s", err.Error())
+	}
+
+	// write new key and restart node with it
+	l.Info("writing new swarm key")
+	err = c.initNodeAssets(n, NodeOpts{SwarmKey: k.Bytes()})
+	if err == nil {
+		err = c.RestartNode(ctx, n)
+	}
+	if err == nil {
+		err = c.verifyIdentity(ctx, n, id.ID)
+	}
+	if err != nil {
+		l.Errorw("swarm key rotation failed - restoring previous key", "error", err)
+		if rerr := c.restoreSwarmKey(ctx, n, previous); rerr != nil {
+			l.Errorw("failed to restore previous swarm key", "error", rerr)
+			return fmt.Errorf("failed to rotate swarm key: %s, and failed to restore previous key: %s",
+				err.Error(), rerr.Error())
+		}
+		return fmt.Errorf("failed to rotate swarm key - restored previous key: %s", err.Error())
+	}
+
+	l.Infow("swarm key rotated",
+		"rotation.duration", time.Since(start))
+	return nil
+}
+
+// restoreSwarmKey writes the given key back to the node's data directory and
+// restarts the node with it
+func (c *Client) restoreSwarmKey(ctx context.Context, n *NodeInfo, key []byte) error {
+	if err := c.initNodeAssets(n, NodeOpts{SwarmKey: key}); err != nil {
+		return err
 	}
-	return "/key/swarm/psk/1.0.0/\n/base16/\n" + hex.EncodeToString(key), nil
+	return c.RestartNode(ctx, n)
 }
@@ -1,6 +1,7 @@
 package ipfs
 
 import (
+	"bytes"
 	"strings"
 	"testing"
 )
@@ -13,4 +14,100 @@ func TestSwarmKey(t *testing.T) {
 	if !strings.Contains(key, "/key/swarm/psk/1.0.0/") {
 		t.Error("key signature not found")
 	}
+	if _, err := ParseSwarmKey([]byte(key)); err != nil {
+		t.Errorf("expected generated key to be valid, got %v", err)
+	}
+}
+
+func TestParseSwarmKey(t *testing.T) {
+	var (
+		raw        = bytes.Repeat([]byte{0xab, '\n'}, 16)
+		normalized = "/key/swarm/psk/1.0.0/\n/base16/\n" + strings.Repeat("ab0a", 16)
+	)
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{"base16", normalized, false},
+		{"base16 uppercase", normalized[:30] + strings.ToUpper(normalized[30:]), false},
+		{"base16 with trailing newline", normalized + "\n", false},
+		{"base16 with carriage returns", strings.Replace(normalized, "\n", "\r\n", -1), false},
+		{"base64", "/key/swarm/psk/1.0.0/\n/base64/\n" + strings.Repeat("qwqrCqsKqwqrCqsK", 2) + "qwqrCqsKqwo=", false},
+		{"binary", "/key/swarm/psk/1.0.0/\n/bin/\n" + string(raw), false},
+		{"binary with trailing newline", "/key/swarm/psk/1.0.0/\n/bin/\n" + string(raw) + "\n", false},
+		{"empty", "", true},
+		{"no header", "/base16/\n" + strings.Repeat("ab0a", 16), true},
+		{"wrong version", "/key/swarm/psk/2.0.0/\n/base16/\n" + strings.Repeat("ab0a", 16), true},
+		{"no encoding", "/key/swarm/psk/1.0.0/\n" + strings.Repeat("ab0a", 16), true},
+		{"unknown encoding", "/key/swarm/psk/1.0.0/\n/base32/\n" + strings.Repeat("ab0a", 16), true},
+		{"invalid base16", "/key/swarm/psk/1.0.0/\n/base16/\n" + strings.Repeat("zz0a", 16), true},
+		{"invalid base64", "/key/swarm/psk/1.0.0/\n/base64/\n!!!!", true},
+		{"short", "/key/swarm/psk/1.0.0/\n/base16/\nab0a", true},
+		{"long", normalized + "ab", true},
+		{"short binary", "/key/swarm/psk/1.0.0/\n/bin/\n" + string(raw[1:]), true},
+		{"long binary", "/key/swarm/psk/1.0.0/\n/bin/\n" + string(raw) + "ab", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSwarmKey([]byte(tt.key))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseSwarmKey() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if string(got.Bytes()) != normalized {
+				t.Errorf("PSK.Bytes() = %q, want %q", got.Bytes(), normalized)
+			}
+		})
+	}
+}
+
+func TestPSK_Encode(t *testing.T) {
+	key, err := NewPSK()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, encoding := range []string{SwarmKeyBase16, SwarmKeyBase64, SwarmKeyBinary} {
+		t.Run(encoding, func(t *testing.T) {
+			b, err := key.Encode(encoding)
+			if err != nil {
+				t.Fatalf("PSK.Encode() error = %v", err)
+			}
+			got, err := ParseSwarmKey(b)
+			if err != nil {
+				t.Fatalf("ParseSwarmKey() error = %v", err)
+			}
+			if got != key || got.Fingerprint() != key.Fingerprint() {
+				t.Errorf("expected key %s to round trip, got %s", key, got)
+			}
+		})
+	}
+	if _, err := key.Encode("/base32/"); err == nil {
+		t.Error("expected error for unsupported encoding")
+	}
+}
+
+func TestPSK_Fingerprint(t *testing.T) {
+	key, err := NewPSK()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewPSK()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fingerprint = key.Fingerprint()
+	if len(fingerprint) != 16 || fingerprint == other.Fingerprint() {
+		t.Errorf("expected distinct fingerprints, got %s and %s", fingerprint, other.Fingerprint())
+	}
+	if key.String() != fingerprint {
+		t.Errorf("PSK.String() = %s, want fingerprint %s", key.String(), fingerprint)
+	}
+
+	if strings.Contains(string(key.Bytes()), fingerprint) {
+		t.Errorf("fingerprint %s appears in key", fingerprint)
+	}
 }
@@ -3,12 +3,42 @@ package mock
 
 import (
 	"context"
+	"io"
 	"sync"
+	"time"
 
 	"github.com/RTradeLtd/Nexus/ipfs"
 )
 
 type FakeNodeClient struct {
+	AddBootstrapPeersStub        func(context.Context, *ipfs.NodeInfo, []string) error
+	addBootstrapPeersMutex       sync.RWMutex
+	addBootstrapPeersArgsForCall []struct {
+		arg1 context.Context
+		arg2 *ipfs.NodeInfo
+		arg3 []string
+	}
+	addBootstrapPeersReturns struct {
+		result1 error
+	}
+	addBootstrapPeersReturnsOnCall map[int]struct {
+		result1 error
+	}
+	BackupNodeStub        func(context.Context, *ipfs.NodeInfo, io.Writer) (ipfs.BackupManifest, error)
+	backupNodeMutex       sync.RWMutex
+	backupNodeArgsForCall []struct {
+		arg1 context.Context
+		arg2 *ipfs.NodeInfo
+		arg3 io.Writer
+	}
+	backupNodeReturns struct {
+		result1 ipfs.BackupManifest
+		result2 error
+	}
+	backupNodeReturnsOnCall map[int]struct {
+		result1 ipfs.BackupManifest
+		result2 error
+	}
 	CreateNodeStub        func(context.Context, *ipfs.NodeInfo, ipfs.NodeOpts) error
 	createNodeMutex       sync.RWMutex
 	createNodeArgsForCall []struct {
@@ -22,6 +52,35 @@ type FakeNodeClient struct {
 	createNodeReturnsOnCall map[int]struct {
 		result1 error
 	}
+	NodeAssetsExistStub        func(context.Context, string) (bool, error)
+	nodeAssetsExistMutex       sync.RWMutex
+	nodeAssetsExistArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	nodeAssetsExistReturns struct {
+		result1 bool
+		result2 error
+	}
+	nodeAssetsExistReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	NodeLogsStub        func(context.Context, *ipfs.NodeInfo, ipfs.LogOptions) (<-chan ipfs.LogLine, error)
+	nodeLogsMutex       sync.RWMutex
+	nodeLogsArgsForCall []struct {
+		arg1 context.Context
+		arg2 *ipfs.NodeInfo
+		arg3 ipfs.LogOptions
+	}
+	nodeLogsReturns struct {
+		result1 <-chan ipfs.LogLine
+		result2 error
+	}
+	nodeLogsReturnsOnCall map[int]struct {
+		result1 <-chan ipfs.LogLine
+		result2 error
+	}
 	NodeStatsStub        func(context.Context, *ipfs.NodeInfo) (ipfs.NodeStats, error)
 	nodeStatsMutex       sync.RWMutex
 	nodeStatsArgsForCall []struct {
@@ -49,6 +108,43 @@ type FakeNodeClient struct {
 		result1 []*ipfs.NodeInfo
 		result2 error
 	}
+	PauseNodeStub        func(context.Context, *ipfs.NodeInfo) error
+	pauseNodeMutex       sync.RWMutex
+	pauseNodeArgsForCall []struct {
+		arg1 context.Context
+		arg2 *ipfs.NodeInfo
+	}
+	pauseNodeReturns struct {
+		result1 error
+	}
+	pauseNodeReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ProbeNodeStub        func(context.Context, *ipfs.NodeInfo) error
+	probeNodeMutex       sync.RWMutex
+	probeNodeArgsForCall []struct {
+		arg1 context.Context
+		arg2 *ipfs.NodeInfo
+	}
+	probeNodeReturns struct {
+		result1 error
+	}
+	probeNodeReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RemoveBootstrapPeersStub        func(context.Context, *ipfs.NodeInfo, []string) error
+	removeBootstrapPeersMutex       sync.RWMutex
+	removeBootstrapPeersArgsForCall []struct {
+		arg1 context.Context
+		arg2 *ipfs.NodeInfo
+		arg3 []string
+	}
+	removeBootstrapPeersReturns struct {
+		result1 error
+	}
+	removeBootstrapPeersReturnsOnCall map[int]struct {
+		result1 error
+	}
 	RemoveNodeStub        func(context.Context, string) error
 	removeNodeMutex       sync.RWMutex
 	removeNodeArgsForCall []struct {
@@ -61,6 +157,58 @@ type FakeNodeClient struct {
 	removeNodeReturnsOnCall map[int]struct {
 		result1 error
 	}
+	RestartNodeStub        func(context.Context, *ipfs.NodeInfo) error
+	restartNodeMutex       sync.RWMutex
+	restartNodeArgsForCall []struct {
+		arg1 context.Context
+		arg2 *ipfs.NodeInfo
+	}
+	restartNodeReturns struct {
+		result1 error
+	}
+	restartNodeReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RestoreNodeStub        func(context.Context, *ipfs.NodeInfo, io.Reader) (ipfs.BackupManifest, error)
+	restoreNodeMutex       sync.RWMutex
+	restoreNodeArgsForCall []struct {
+		arg1 context.Context
+		arg2 *ipfs.NodeInfo
+		arg3 io.Reader
+	}
+	restoreNodeReturns struct {
+		result1 ipfs.BackupManifest
+		result2 error
+	}
+	restoreNodeReturnsOnCall map[int]struct {
+		result1 ipfs.BackupManifest
+		result2 error
+	}
+	ResumeNodeStub        func(context.Context, *ipfs.NodeInfo) error
+	resumeNodeMutex       sync.RWMutex
+	resumeNodeArgsForCall []struct {
+		arg1 context.Context
+		arg2 *ipfs.NodeInfo
+	}
+	resumeNodeReturns struct {
+		result1 error
+	}
+	resumeNodeReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RotateSwarmKeyStub        func(context.Context, *ipfs.NodeInfo, []byte) error
+	rotateSwarmKeyMutex       sync.RWMutex
+	rotateSwarmKeyArgsForCall []struct {
+		arg1 context.Context
+		arg2 *ipfs.NodeInfo
+		arg3 []byte
+	}
+	rotateSwarmKeyReturns struct {
+		result1 error
+	}
+	rotateSwarmKeyReturnsOnCall map[int]struct {
+		result1 error
+	}
 	StopNodeStub        func(context.Context, *ipfs.NodeInfo) error
 	stopNodeMutex       sync.RWMutex
 	stopNodeArgsForCall []struct {
@@ -73,6 +221,34 @@ type FakeNodeClient struct {
 	stopNodeReturnsOnCall map[int]struct {
 		result1 error
 	}
+	StoppedNodesStub        func(context.Context) ([]*ipfs.NodeInfo, error)
+	stoppedNodesMutex       sync.RWMutex
+	stoppedNodesArgsForCall []struct {
+		arg1 context.Context
+	}
+	stoppedNodesReturns struct {
+		result1 []*ipfs.NodeInfo
+		result2 error
+	}
+	stoppedNodesReturnsOnCall map[int]struct {
+		result1 []*ipfs.NodeInfo
+		result2 error
+	}
+	StreamStatsStub        func(context.Context, *ipfs.NodeInfo, time.Duration) (<-chan ipfs.ContainerStats, error)
+	streamStatsMutex       sync.RWMutex
+	streamStatsArgsForCall []struct {
+		arg1 context.Context
+		arg2 *ipfs.NodeInfo
+		arg3 time.Duration
+	}
+	streamStatsReturns struct {
+		result1 <-chan ipfs.ContainerStats
+		result2 error
+	}
+	streamStatsReturnsOnCall map[int]struct {
+		result1 <-chan ipfs.ContainerStats
+		result2 error
+	}
 	UpdateNodeStub        func(context.Context, *ipfs.NodeInfo) error
 	updateNodeMutex       sync.RWMutex
 	updateNodeArgsForCall []struct {
@@ -85,6 +261,19 @@ type FakeNodeClient struct {
 	updateNodeReturnsOnCall map[int]struct {
 		result1 error
 	}
+	UpgradeNodeStub        func(context.Context, *ipfs.NodeInfo, string) error
+	upgradeNodeMutex       sync.RWMutex
+	upgradeNodeArgsForCall []struct {
+		arg1 context.Context
+		arg2 *ipfs.NodeInfo
+		arg3 string
+	}
+	upgradeNodeReturns struct {
+		result1 error
+	}
+	upgradeNodeReturnsOnCall map[int]struct {
+		result1 error
+	}
 	WatchStub        func(context.Context) (<-chan ipfs.Event, <-chan error)
 	watchMutex       sync.RWMutex
 	watchArgsForCall []struct {
@@ -102,6 +291,138 @@ type FakeNodeClient struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeNodeClient) AddBootstrapPeers(arg1 context.Context, arg2 *ipfs.NodeInfo, arg3 []string) error {
+	var arg3Copy []string
+	if arg3 != nil {
+		arg3Copy = make([]string, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	fake.addBootstrapPeersMutex.Lock()
+	ret, specificReturn := fake.addBootstrapPeersReturnsOnCall[len(fake.addBootstrapPeersArgsForCall)]
+	fake.addBootstrapPeersArgsForCall = append(fake.addBootstrapPeersArgsForCall, struct {
+		arg1 context.Context
+		arg2 *ipfs.NodeInfo
+		arg3 []string
+	}{arg1, arg2, arg3Copy})
+	fake.recordInvocation("AddBootstrapPeers", []interface{}{arg1, arg2, arg3Copy})
+	fake.addBootstrapPeersMutex.Unlock()
+	if fake.AddBootstrapPeersStub != nil {
+		return fake.AddBootstrapPeersStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.addBootstrapPeersReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeNodeClient) AddBootstrapPeersCallCount() int {
+	fake.addBootstrapPeersMutex.RLock()
+	defer fake.addBootstrapPeersMutex.RUnlock()
+	return len(fake.addBootstrapPeersArgsForCall)
+}
+
+func (fake *FakeNodeClient) AddBootstrapPeersCalls(stub func(context.Context, *ipfs.NodeInfo, []string) error) {
+	fake.addBootstrapPeersMutex.Lock()
+	defer fake.addBootstrapPeersMutex.Unlock()
+	fake.AddBootstrapPeersStub = stub
+}
+
+func (fake *FakeNodeClient) AddBootstrapPeersArgsForCall(i int) (context.Context, *ipfs.NodeInfo, []string) {
+	fake.addBootstrapPeersMutex.RLock()
+	defer fake.addBootstrapPeersMutex.RUnlock()
+	argsForCall := fake.addBootstrapPeersArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeNodeClient) AddBootstrapPeersReturns(result1 error) {
+	fake.addBootstrapPeersMutex.Lock()
+	defer fake.addBootstrapPeersMutex.Unlock()
+	fake.AddBootstrapPeersStub = nil
+	fake.addBootstrapPeersReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeNodeClient) AddBootstrapPeersReturnsOnCall(i int, result1 error) {
+	fake.addBootstrapPeersMutex.Lock()
+	defer fake.addBootstrapPeersMutex.Unlock()
+	fake.AddBootstrapPeersStub = nil
+	if fake.addBootstrapPeersReturnsOnCall == nil {
+		fake.addBootstrapPeersReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.addBootstrapPeersReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeNodeClient) BackupNode(arg1 context.Context, arg2 *ipfs.NodeInfo, arg3 io.Writer) (ipfs.BackupManifest, error) {
+	fake.backupNodeMutex.Lock()
+	ret, specificReturn := fake.backupNodeReturnsOnCall[len(fake.backupNodeArgsForCall)]
+	fake.backupNodeArgsForCall = append(fake.backupNodeArgsForCall, struct {
+		arg1 context.Context
+		arg2 *ipfs.NodeInfo
+		arg3 io.Writer
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("BackupNode", []interface{}{arg1, arg2, arg3})
+	fake.backupNodeMutex.Unlock()
+	if fake.BackupNodeStub != nil {
+		return fake.BackupNodeStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.backupNodeReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeNodeClient) BackupNodeCallCount() int {
+	fake.backupNodeMutex.RLock()
+	defer fake.backupNodeMutex.RUnlock()
+	return len(fake.backupNodeArgsForCall)
+}
+
+func (fake *FakeNodeClient) BackupNodeCalls(stub func(context.Context, *ipfs.NodeInfo, io.Writer) (ipfs.BackupManifest, error)) {
+	fake.backupNodeMutex.Lock()
+	defer fake.backupNodeMutex.Unlock()
+	fake.BackupNodeStub = stub
+}
+
+func (fake *FakeNodeClient) BackupNodeArgsForCall(i int) (context.Context, *ipfs.NodeInfo, io.Writer) {
+	fake.backupNodeMutex.RLock()
+	defer fake.backupNodeMutex.RUnlock()
+	argsForCall := fake.backupNodeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeNodeClient) BackupNodeReturns(result1 ipfs.BackupManifest, result2 error) {
+	fake.backupNodeMutex.Lock()
+	defer fake.backupNodeMutex.Unlock()
+	fake.BackupNodeStub = nil
+	fake.backupNodeReturns = struct {
+		result1 ipfs.BackupManifest
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeNodeClient) BackupNodeReturnsOnCall(i int, result1 ipfs.BackupManifest, result2 error) {
+	fake.backupNodeMutex.Lock()
+	defer fake.backupNodeMutex.Unlock()
+	fake.BackupNodeStub = nil
+	if fake.backupNodeReturnsOnCall == nil {
+		fake.backupNodeReturnsOnCall = make(map[int]struct {
+			result1 ipfs.BackupManifest
+			result2 error
+		})
+	}
+	fake.backupNodeReturnsOnCall[i] = struct {
+		result1 ipfs.BackupManifest
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeNodeClient) CreateNode(arg1 context.Context, arg2 *ipfs.NodeInfo, arg3 ipfs.NodeOpts) error {
 	fake.createNodeMutex.Lock()
 	ret, specificReturn := fake.createNodeReturnsOnCall[len(fake.createNodeArgsForCall)]
@@ -164,6 +485,135 @@ func (fake *FakeNodeClient) CreateNodeReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeNodeClient) NodeAssetsExist(arg1 context.Context, arg2 string) (bool, error) {
+	fake.nodeAssetsExistMutex.Lock()
+	ret, specificReturn := fake.nodeAssetsExistReturnsOnCall[len(fake.nodeAssetsExistArgsForCall)]
+	fake.nodeAssetsExistArgsForCall = append(fake.nodeAssetsExistArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("NodeAssetsExist", []interface{}{arg1, arg2})
+	fake.nodeAssetsExistMutex.Unlock()
+	if fake.NodeAssetsExistStub != nil {
+		return fake.NodeAssetsExistStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.nodeAssetsExistReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeNodeClient) NodeAssetsExistCallCount() int {
+	fake.nodeAssetsExistMutex.RLock()
+	defer fake.nodeAssetsExistMutex.RUnlock()
+	return len(fake.nodeAssetsExistArgsForCall)
+}
+
+func (fake *FakeNodeClient) NodeAssetsExistCalls(stub func(context.Context, string) (bool, error)) {
+	fake.nodeAssetsExistMutex.Lock()
+	defer fake.nodeAssetsExistMutex.Unlock()
+	fake.NodeAssetsExistStub = stub
+}
+
+func (fake *FakeNodeClient) NodeAssetsExistArgsForCall(i int) (context.Context, string) {
+	fake.nodeAssetsExistMutex.RLock()
+	defer fake.nodeAssetsExistMutex.RUnlock()
+	argsForCall := fake.nodeAssetsExistArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeNodeClient) NodeAssetsExistReturns(result1 bool, result2 error) {
+	fake.nodeAssetsExistMutex.Lock()
+	defer fake.nodeAssetsExistMutex.Unlock()
+	fake.NodeAssetsExistStub = nil
+	fake.nodeAssetsExistReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeNodeClient) NodeAssetsExistReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.nodeAssetsExistMutex.Lock()
+	defer fake.nodeAssetsExistMutex.Unlock()
+	fake.NodeAssetsExistStub = nil
+	if fake.nodeAssetsExistReturnsOnCall == nil {
+		fake.nodeAssetsExistReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.nodeAssetsExistReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeNodeClient) NodeLogs(arg1 context.Context, arg2 *ipfs.NodeInfo, arg3 ipfs.LogOptions) (<-chan ipfs.LogLine, error) {
+	fake.nodeLogsMutex.Lock()
+	ret, specificReturn := fake.nodeLogsReturnsOnCall[len(fake.nodeLogsArgsForCall)]
+	fake.nodeLogsArgsForCall = append(fake.nodeLogsArgsForCall, struct {
+		arg1 context.Context
+		arg2 *ipfs.NodeInfo
+		arg3 ipfs.LogOptions
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("NodeLogs", []interface{}{arg1, arg2, arg3})
+	fake.nodeLogsMutex.Unlock()
+	if fake.NodeLogsStub != nil {
+		return fake.NodeLogsStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.nodeLogsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeNodeClient) NodeLogsCallCount() int {
+	fake.nodeLogsMutex.RLock()
+	defer fake.nodeLogsMutex.RUnlock()
+	return len(fake.nodeLogsArgsForCall)
+}
+
+func (fake *FakeNodeClient) NodeLogsCalls(stub func(context.Context, *ipfs.NodeInfo, ipfs.LogOptions) (<-chan ipfs.LogLine, error)) {
+	fake.nodeLogsMutex.Lock()
+	defer fake.nodeLogsMutex.Unlock()
+	fake.NodeLogsStub = stub
+}
+
+func (fake *FakeNodeClient) NodeLogsArgsForCall(i int) (context.Context, *ipfs.NodeInfo, ipfs.LogOptions) {
+	fake.nodeLogsMutex.RLock()
+	defer fake.nodeLogsMutex.RUnlock()
+	argsForCall := fake.nodeLogsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeNodeClient) NodeLogsReturns(result1 <-chan ipfs.LogLine, result2 error) {
+	fake.nodeLogsMutex.Lock()
+	defer fake.nodeLogsMutex.Unlock()
+	fake.NodeLogsStub = nil
+	fake.nodeLogsReturns = struct {
+		result1 <-chan ipfs.LogLine
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeNodeClient) NodeLogsReturnsOnCall(i int, result1 <-chan ipfs.LogLine, result2 error) {
+	fake.nodeLogsMutex.Lock()
+	defer fake.nodeLogsMutex.Unlock()
+	fake.NodeLogsStub = nil
+	if fake.nodeLogsReturnsOnCall == nil {
+		fake.nodeLogsReturnsOnCall = make(map[int]struct {
+			result1 <-chan ipfs.LogLine
+			result2 error
+		})
+	}
+	fake.nodeLogsReturnsOnCall[i] = struct {
+		result1 <-chan ipfs.LogLine
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeNodeClient) NodeStats(arg1 context.Context, arg2 *ipfs.NodeInfo) (ipfs.NodeStats, error) {
 	fake.nodeStatsMutex.Lock()
 	ret, specificReturn := fake.nodeStatsReturnsOnCall[len(fake.nodeStatsArgsForCall)]
@@ -291,6 +741,195 @@ func (fake *FakeNodeClient) NodesReturnsOnCall(i int, result1 []*ipfs.NodeInfo,
 	}{result1, result2}
 }
 
+func (fake *FakeNodeClient) PauseNode(arg1 context.Context, arg2 *ipfs.NodeInfo) error {
+	fake.pauseNodeMutex.Lock()
+	ret, specificReturn := fake.pauseNodeReturnsOnCall[len(fake.pauseNodeArgsForCall)]
+	fake.pauseNodeArgsForCall = append(fake.pauseNodeArgsForCall, struct {
+		arg1 context.Context
+		arg2 *ipfs.NodeInfo
+	}{arg1, arg2})
+	fake.recordInvocation("PauseNode", []interface{}{arg1, arg2})
+	fake.pauseNodeMutex.Unlock()
+	if fake.PauseNodeStub != nil {
+		return fake.PauseNodeStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.pauseNodeReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeNodeClient) PauseNodeCallCount() int {
+	fake.pauseNodeMutex.RLock()
+	defer fake.pauseNodeMutex.RUnlock()
+	return len(fake.pauseNodeArgsForCall)
+}
+
+func (fake *FakeNodeClient) PauseNodeCalls(stub func(context.Context, *ipfs.NodeInfo) error) {
+	fake.pauseNodeMutex.Lock()
+	defer fake.pauseNodeMutex.Unlock()
+	fake.PauseNodeStub = stub
+}
+
+func (fake *FakeNodeClient) PauseNodeArgsForCall(i int) (context.Context, *ipfs.NodeInfo) {
+	fake.pauseNodeMutex.RLock()
+	defer fake.pauseNodeMutex.RUnlock()
+	argsForCall := fake.pauseNodeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeNodeClient) PauseNodeReturns(result1 error) {
+	fake.pauseNodeMutex.Lock()
+	defer fake.pauseNodeMutex.Unlock()
+	fake.PauseNodeStub = nil
+	fake.pauseNodeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeNodeClient) PauseNodeReturnsOnCall(i int, result1 error) {
+	fake.pauseNodeMutex.Lock()
+	defer fake.pauseNodeMutex.Unlock()
+	fake.PauseNodeStub = nil
+	if fake.pauseNodeReturnsOnCall == nil {
+		fake.pauseNodeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.pauseNodeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeNodeClient) ProbeNode(arg1 context.Context, arg2 *ipfs.NodeInfo) error {
+	fake.probeNodeMutex.Lock()
+	ret, specificReturn := fake.probeNodeReturnsOnCall[len(fake.probeNodeArgsForCall)]
+	fake.probeNodeArgsForCall = append(fake.probeNodeArgsForCall, struct {
+		arg1 context.Context
+		arg2 *ipfs.NodeInfo
+	}{arg1, arg2})
+	fake.recordInvocation("ProbeNode", []interface{}{arg1, arg2})
+	fake.probeNodeMutex.Unlock()
+	if fake.ProbeNodeStub != nil {
+		return fake.ProbeNodeStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.probeNodeReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeNodeClient) ProbeNodeCallCount() int {
+	fake.probeNodeMutex.RLock()
+	defer fake.probeNodeMutex.RUnlock()
+	return len(fake.probeNodeArgsForCall)
+}
+
+func (fake *FakeNodeClient) ProbeNodeCalls(stub func(context.Context, *ipfs.NodeInfo) error) {
+	fake.probeNodeMutex.Lock()
+	defer fake.probeNodeMutex.Unlock()
+	fake.ProbeNodeStub = stub
+}
+
+func (fake *FakeNodeClient) ProbeNodeArgsForCall(i int) (context.Context, *ipfs.NodeInfo) {
+	fake.probeNodeMutex.RLock()
+	defer fake.probeNodeMutex.RUnlock()
+	argsForCall := fake.probeNodeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeNodeClient) ProbeNodeReturns(result1 error) {
+	fake.probeNodeMutex.Lock()
+	defer fake.probeNodeMutex.Unlock()
+	fake.ProbeNodeStub = nil
+	fake.probeNodeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeNodeClient) ProbeNodeReturnsOnCall(i int, result1 error) {
+	fake.probeNodeMutex.Lock()
+	defer fake.probeNodeMutex.Unlock()
+	fake.ProbeNodeStub = nil
+	if fake.probeNodeReturnsOnCall == nil {
+		fake.probeNodeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.probeNodeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeNodeClient) RemoveBootstrapPeers(arg1 context.Context, arg2 *ipfs.NodeInfo, arg3 []string) error {
+	var arg3Copy []string
+	if arg3 != nil {
+		arg3Copy = make([]string, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	fake.removeBootstrapPeersMutex.Lock()
+	ret, specificReturn := fake.removeBootstrapPeersReturnsOnCall[len(fake.removeBootstrapPeersArgsForCall)]
+	fake.removeBootstrapPeersArgsForCall = append(fake.removeBootstrapPeersArgsForCall, struct {
+		arg1 context.Context
+		arg2 *ipfs.NodeInfo
+		arg3 []string
+	}{arg1, arg2, arg3Copy})
+	fake.recordInvocation("RemoveBootstrapPeers", []interface{}{arg1, arg2, arg3Copy})
+	fake.removeBootstrapPeersMutex.Unlock()
+	if fake.RemoveBootstrapPeersStub != nil {
+		return fake.RemoveBootstrapPeersStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.removeBootstrapPeersReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeNodeClient) RemoveBootstrapPeersCallCount() int {
+	fake.removeBootstrapPeersMutex.RLock()
+	defer fake.removeBootstrapPeersMutex.RUnlock()
+	return len(fake.removeBootstrapPeersArgsForCall)
+}
+
+func (fake *FakeNodeClient) RemoveBootstrapPeersCalls(stub func(context.Context, *ipfs.NodeInfo, []string) error) {
+	fake.removeBootstrapPeersMutex.Lock()
+	defer fake.removeBootstrapPeersMutex.Unlock()
+	fake.RemoveBootstrapPeersStub = stub
+}
+
+func (fake *FakeNodeClient) RemoveBootstrapPeersArgsForCall(i int) (context.Context, *ipfs.NodeInfo, []string) {
+	fake.removeBootstrapPeersMutex.RLock()
+	defer fake.removeBootstrapPeersMutex.RUnlock()
+	argsForCall := fake.removeBootstrapPeersArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeNodeClient) RemoveBootstrapPeersReturns(result1 error) {
+	fake.removeBootstrapPeersMutex.Lock()
+	defer fake.removeBootstrapPeersMutex.Unlock()
+	fake.RemoveBootstrapPeersStub = nil
+	fake.removeBootstrapPeersReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeNodeClient) RemoveBootstrapPeersReturnsOnCall(i int, result1 error) {
+	fake.removeBootstrapPeersMutex.Lock()
+	defer fake.removeBootstrapPeersMutex.Unlock()
+	fake.RemoveBootstrapPeersStub = nil
+	if fake.removeBootstrapPeersReturnsOnCall == nil {
+		fake.removeBootstrapPeersReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.removeBootstrapPeersReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeNodeClient) RemoveNode(arg1 context.Context, arg2 string) error {
 	fake.removeNodeMutex.Lock()
 	ret, specificReturn := fake.removeNodeReturnsOnCall[len(fake.removeNodeArgsForCall)]
@@ -352,6 +991,260 @@ func (fake *FakeNodeClient) RemoveNodeReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeNodeClient) RestartNode(arg1 context.Context, arg2 *ipfs.NodeInfo) error {
+	fake.restartNodeMutex.Lock()
+	ret, specificReturn := fake.restartNodeReturnsOnCall[len(fake.restartNodeArgsForCall)]
+	fake.restartNodeArgsForCall = append(fake.restartNodeArgsForCall, struct {
+		arg1 context.Context
+		arg2 *ipfs.NodeInfo
+	}{arg1, arg2})
+	fake.recordInvocation("RestartNode", []interface{}{arg1, arg2})
+	fake.restartNodeMutex.Unlock()
+	if fake.RestartNodeStub != nil {
+		return fake.RestartNodeStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.restartNodeReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeNodeClient) RestartNodeCallCount() int {
+	fake.restartNodeMutex.RLock()
+	defer fake.restartNodeMutex.RUnlock()
+	return len(fake.restartNodeArgsForCall)
+}
+
+func (fake *FakeNodeClient) RestartNodeCalls(stub func(context.Context, *ipfs.NodeInfo) error) {
+	fake.restartNodeMutex.Lock()
+	defer fake.restartNodeMutex.Unlock()
+	fake.RestartNodeStub = stub
+}
+
+func (fake *FakeNodeClient) RestartNodeArgsForCall(i int) (context.Context, *ipfs.NodeInfo) {
+	fake.restartNodeMutex.RLock()
+	defer fake.restartNodeMutex.RUnlock()
+	argsForCall := fake.restartNodeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeNodeClient) RestartNodeReturns(result1 error) {
+	fake.restartNodeMutex.Lock()
+	defer fake.restartNodeMutex.Unlock()
+	fake.RestartNodeStub = nil
+	fake.restartNodeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeNodeClient) RestartNodeReturnsOnCall(i int, result1 error) {
+	fake.restartNodeMutex.Lock()
+	defer fake.restartNodeMutex.Unlock()
+	fake.RestartNodeStub = nil
+	if fake.restartNodeReturnsOnCall == nil {
+		fake.restartNodeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.restartNodeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeNodeClient) RestoreNode(arg1 context.Context, arg2 *ipfs.NodeInfo, arg3 io.Reader) (ipfs.BackupManifest, error) {
+	fake.restoreNodeMutex.Lock()
+	ret, specificReturn := fake.restoreNodeReturnsOnCall[len(fake.restoreNodeArgsForCall)]
+	fake.restoreNodeArgsForCall = append(fake.restoreNodeArgsForCall, struct {
+		arg1 context.Context
+		arg2 *ipfs.NodeInfo
+		arg3 io.Reader
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("RestoreNode", []interface{}{arg1, arg2, arg3})
+	fake.restoreNodeMutex.Unlock()
+	if fake.RestoreNodeStub != nil {
+		return fake.RestoreNodeStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.restoreNodeReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeNodeClient) RestoreNodeCallCount() int {
+	fake.restoreNodeMutex.RLock()
+	defer fake.restoreNodeMutex.RUnlock()
+	return len(fake.restoreNodeArgsForCall)
+}
+
+func (fake *FakeNodeClient) RestoreNodeCalls(stub func(context.Context, *ipfs.NodeInfo, io.Reader) (ipfs.BackupManifest, error)) {
+	fake.restoreNodeMutex.Lock()
+	defer fake.restoreNodeMutex.Unlock()
+	fake.RestoreNodeStub = stub
+}
+
+func (fake *FakeNodeClient) RestoreNodeArgsForCall(i int) (context.Context, *ipfs.NodeInfo, io.Reader) {
+	fake.restoreNodeMutex.RLock()
+	defer fake.restoreNodeMutex.RUnlock()
+	argsForCall := fake.restoreNodeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeNodeClient) RestoreNodeReturns(result1 ipfs.BackupManifest, result2 error) {
+	fake.restoreNodeMutex.Lock()
+	defer fake.restoreNodeMutex.Unlock()
+	fake.RestoreNodeStub = nil
+	fake.restoreNodeReturns = struct {
+		result1 ipfs.BackupManifest
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeNodeClient) RestoreNodeReturnsOnCall(i int, result1 ipfs.BackupManifest, result2 error) {
+	fake.restoreNodeMutex.Lock()
+	defer fake.restoreNodeMutex.Unlock()
+	fake.RestoreNodeStub = nil
+	if fake.restoreNodeReturnsOnCall == nil {
+		fake.restoreNodeReturnsOnCall = make(map[int]struct {
+			result1 ipfs.BackupManifest
+			result2 error
+		})
+	}
+	fake.restoreNodeReturnsOnCall[i] = struct {
+		result1 ipfs.BackupManifest
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeNodeClient) ResumeNode(arg1 context.Context, arg2 *ipfs.NodeInfo) error {
+	fake.resumeNodeMutex.Lock()
+	ret, specificReturn := fake.resumeNodeReturnsOnCall[len(fake.resumeNodeArgsForCall)]
+	fake.resumeNodeArgsForCall = append(fake.resumeNodeArgsForCall, struct {
+		arg1 context.Context
+		arg2 *ipfs.NodeInfo
+	}{arg1, arg2})
+	fake.recordInvocation("ResumeNode", []interface{}{arg1, arg2})
+	fake.resumeNodeMutex.Unlock()
+	if fake.ResumeNodeStub != nil {
+		return fake.ResumeNodeStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.resumeNodeReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeNodeClient) ResumeNodeCallCount() int {
+	fake.resumeNodeMutex.RLock()
+	defer fake.resumeNodeMutex.RUnlock()
+	return len(fake.resumeNodeArgsForCall)
+}
+
+func (fake *FakeNodeClient) ResumeNodeCalls(stub func(context.Context, *ipfs.NodeInfo) error) {
+	fake.resumeNodeMutex.Lock()
+	defer fake.resumeNodeMutex.Unlock()
+	fake.ResumeNodeStub = stub
+}
+
+func (fake *FakeNodeClient) ResumeNodeArgsForCall(i int) (context.Context, *ipfs.NodeInfo) {
+	fake.resumeNodeMutex.RLock()
+	defer fake.resumeNodeMutex.RUnlock()
+	argsForCall := fake.resumeNodeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeNodeClient) ResumeNodeReturns(result1 error) {
+	fake.resumeNodeMutex.Lock()
+	defer fake.resumeNodeMutex.Unlock()
+	fake.ResumeNodeStub = nil
+	fake.resumeNodeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeNodeClient) ResumeNodeReturnsOnCall(i int, result1 error) {
+	fake.resumeNodeMutex.Lock()
+	defer fake.resumeNodeMutex.Unlock()
+	fake.ResumeNodeStub = nil
+	if fake.resumeNodeReturnsOnCall == nil {
+		fake.resumeNodeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.resumeNodeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeNodeClient) RotateSwarmKey(arg1 context.Context, arg2 *ipfs.NodeInfo, arg3 []byte) error {
+	var arg3Copy []byte
+	if arg3 != nil {
+		arg3Copy = make([]byte, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	fake.rotateSwarmKeyMutex.Lock()
+	ret, specificReturn := fake.rotateSwarmKeyReturnsOnCall[len(fake.rotateSwarmKeyArgsForCall)]
+	fake.rotateSwarmKeyArgsForCall = append(fake.rotateSwarmKeyArgsForCall, struct {
+		arg1 context.Context
+		arg2 *ipfs.NodeInfo
+		arg3 []byte
+	}{arg1, arg2, arg3Copy})
+	fake.recordInvocation("RotateSwarmKey", []interface{}{arg1, arg2, arg3Copy})
+	fake.rotateSwarmKeyMutex.Unlock()
+	if fake.RotateSwarmKeyStub != nil {
+		return fake.RotateSwarmKeyStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.rotateSwarmKeyReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeNodeClient) RotateSwarmKeyCallCount() int {
+	fake.rotateSwarmKeyMutex.RLock()
+	defer fake.rotateSwarmKeyMutex.RUnlock()
+	return len(fake.rotateSwarmKeyArgsForCall)
+}
+
+func (fake *FakeNodeClient) RotateSwarmKeyCalls(stub func(context.Context, *ipfs.NodeInfo, []byte) error) {
+	fake.rotateSwarmKeyMutex.Lock()
+	defer fake.rotateSwarmKeyMutex.Unlock()
+	fake.RotateSwarmKeyStub = stub
+}
+
+func (fake *FakeNodeClient) RotateSwarmKeyArgsForCall(i int) (context.Context, *ipfs.NodeInfo, []byte) {
+	fake.rotateSwarmKeyMutex.RLock()
+	defer fake.rotateSwarmKeyMutex.RUnlock()
+	argsForCall := fake.rotateSwarmKeyArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeNodeClient) RotateSwarmKeyReturns(result1 error) {
+	fake.rotateSwarmKeyMutex.Lock()
+	defer fake.rotateSwarmKeyMutex.Unlock()
+	fake.RotateSwarmKeyStub = nil
+	fake.rotateSwarmKeyReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeNodeClient) RotateSwarmKeyReturnsOnCall(i int, result1 error) {
+	fake.rotateSwarmKeyMutex.Lock()
+	defer fake.rotateSwarmKeyMutex.Unlock()
+	fake.RotateSwarmKeyStub = nil
+	if fake.rotateSwarmKeyReturnsOnCall == nil {
+		fake.rotateSwarmKeyReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.rotateSwarmKeyReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeNodeClient) StopNode(arg1 context.Context, arg2 *ipfs.NodeInfo) error {
 	fake.stopNodeMutex.Lock()
 	ret, specificReturn := fake.stopNodeReturnsOnCall[len(fake.stopNodeArgsForCall)]
@@ -413,6 +1306,134 @@ func (fake *FakeNodeClient) StopNodeReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeNodeClient) StoppedNodes(arg1 context.Context) ([]*ipfs.NodeInfo, error) {
+	fake.stoppedNodesMutex.Lock()
+	ret, specificReturn := fake.stoppedNodesReturnsOnCall[len(fake.stoppedNodesArgsForCall)]
+	fake.stoppedNodesArgsForCall = append(fake.stoppedNodesArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	fake.recordInvocation("StoppedNodes", []interface{}{arg1})
+	fake.stoppedNodesMutex.Unlock()
+	if fake.StoppedNodesStub != nil {
+		return fake.StoppedNodesStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.stoppedNodesReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeNodeClient) StoppedNodesCallCount() int {
+	fake.stoppedNodesMutex.RLock()
+	defer fake.stoppedNodesMutex.RUnlock()
+	return len(fake.stoppedNodesArgsForCall)
+}
+
+func (fake *FakeNodeClient) StoppedNodesCalls(stub func(context.Context) ([]*ipfs.NodeInfo, error)) {
+	fake.stoppedNodesMutex.Lock()
+	defer fake.stoppedNodesMutex.Unlock()
+	fake.StoppedNodesStub = stub
+}
+
+func (fake *FakeNodeClient) StoppedNodesArgsForCall(i int) context.Context {
+	fake.stoppedNodesMutex.RLock()
+	defer fake.stoppedNodesMutex.RUnlock()
+	argsForCall := fake.stoppedNodesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeNodeClient) StoppedNodesReturns(result1 []*ipfs.NodeInfo, result2 error) {
+	fake.stoppedNodesMutex.Lock()
+	defer fake.stoppedNodesMutex.Unlock()
+	fake.StoppedNodesStub = nil
+	fake.stoppedNodesReturns = struct {
+		result1 []*ipfs.NodeInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeNodeClient) StoppedNodesReturnsOnCall(i int, result1 []*ipfs.NodeInfo, result2 error) {
+	fake.stoppedNodesMutex.Lock()
+	defer fake.stoppedNodesMutex.Unlock()
+	fake.StoppedNodesStub = nil
+	if fake.stoppedNodesReturnsOnCall == nil {
+		fake.stoppedNodesReturnsOnCall = make(map[int]struct {
+			result1 []*ipfs.NodeInfo
+			result2 error
+		})
+	}
+	fake.stoppedNodesReturnsOnCall[i] = struct {
+		result1 []*ipfs.NodeInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeNodeClient) StreamStats(arg1 context.Context, arg2 *ipfs.NodeInfo, arg3 time.Duration) (<-chan ipfs.ContainerStats, error) {
+	fake.streamStatsMutex.Lock()
+	ret, specificReturn := fake.streamStatsReturnsOnCall[len(fake.streamStatsArgsForCall)]
+	fake.streamStatsArgsForCall = append(fake.streamStatsArgsForCall, struct {
+		arg1 context.Context
+		arg2 *ipfs.NodeInfo
+		arg3 time.Duration
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("StreamStats", []interface{}{arg1, arg2, arg3})
+	fake.streamStatsMutex.Unlock()
+	if fake.StreamStatsStub != nil {
+		return fake.StreamStatsStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.streamStatsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeNodeClient) StreamStatsCallCount() int {
+	fake.streamStatsMutex.RLock()
+	defer fake.streamStatsMutex.RUnlock()
+	return len(fake.streamStatsArgsForCall)
+}
+
+func (fake *FakeNodeClient) StreamStatsCalls(stub func(context.Context, *ipfs.NodeInfo, time.Duration) (<-chan ipfs.ContainerStats, error)) {
+	fake.streamStatsMutex.Lock()
+	defer fake.streamStatsMutex.Unlock()
+	fake.StreamStatsStub = stub
+}
+
+func (fake *FakeNodeClient) StreamStatsArgsForCall(i int) (context.Context, *ipfs.NodeInfo, time.Duration) {
+	fake.streamStatsMutex.RLock()
+	defer fake.streamStatsMutex.RUnlock()
+	argsForCall := fake.streamStatsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeNodeClient) StreamStatsReturns(result1 <-chan ipfs.ContainerStats, result2 error) {
+	fake.streamStatsMutex.Lock()
+	defer fake.streamStatsMutex.Unlock()
+	fake.StreamStatsStub = nil
+	fake.streamStatsReturns = struct {
+		result1 <-chan ipfs.ContainerStats
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeNodeClient) StreamStatsReturnsOnCall(i int, result1 <-chan ipfs.ContainerStats, result2 error) {
+	fake.streamStatsMutex.Lock()
+	defer fake.streamStatsMutex.Unlock()
+	fake.StreamStatsStub = nil
+	if fake.streamStatsReturnsOnCall == nil {
+		fake.streamStatsReturnsOnCall = make(map[int]struct {
+			result1 <-chan ipfs.ContainerStats
+			result2 error
+		})
+	}
+	fake.streamStatsReturnsOnCall[i] = struct {
+		result1 <-chan ipfs.ContainerStats
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeNodeClient) UpdateNode(arg1 context.Context, arg2 *ipfs.NodeInfo) error {
 	fake.updateNodeMutex.Lock()
 	ret, specificReturn := fake.updateNodeReturnsOnCall[len(fake.updateNodeArgsForCall)]
@@ -474,6 +1495,68 @@ func (fake *FakeNodeClient) UpdateNodeReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeNodeClient) UpgradeNode(arg1 context.Context, arg2 *ipfs.NodeInfo, arg3 string) error {
+	fake.upgradeNodeMutex.Lock()
+	ret, specificReturn := fake.upgradeNodeReturnsOnCall[len(fake.upgradeNodeArgsForCall)]
+	fake.upgradeNodeArgsForCall = append(fake.upgradeNodeArgsForCall, struct {
+		arg1 context.Context
+		arg2 *ipfs.NodeInfo
+		arg3 string
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("UpgradeNode", []interface{}{arg1, arg2, arg3})
+	fake.upgradeNodeMutex.Unlock()
+	if fake.UpgradeNodeStub != nil {
+		return fake.UpgradeNodeStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.upgradeNodeReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeNodeClient) UpgradeNodeCallCount() int {
+	fake.upgradeNodeMutex.RLock()
+	defer fake.upgradeNodeMutex.RUnlock()
+	return len(fake.upgradeNodeArgsForCall)
+}
+
+func (fake *FakeNodeClient) UpgradeNodeCalls(stub func(context.Context, *ipfs.NodeInfo, string) error) {
+	fake.upgradeNodeMutex.Lock()
+	defer fake.upgradeNodeMutex.Unlock()
+	fake.UpgradeNodeStub = stub
+}
+
+func (fake *FakeNodeClient) UpgradeNodeArgsForCall(i int) (context.Context, *ipfs.NodeInfo, string) {
+	fake.upgradeNodeMutex.RLock()
+	defer fake.upgradeNodeMutex.RUnlock()
+	argsForCall := fake.upgradeNodeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeNodeClient) UpgradeNodeReturns(result1 error) {
+	fake.upgradeNodeMutex.Lock()
+	defer fake.upgradeNodeMutex.Unlock()
+	fake.UpgradeNodeStub = nil
+	fake.upgradeNodeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeNodeClient) UpgradeNodeReturnsOnCall(i int, result1 error) {
+	fake.upgradeNodeMutex.Lock()
+	defer fake.upgradeNodeMutex.Unlock()
+	fake.UpgradeNodeStub = nil
+	if fake.upgradeNodeReturnsOnCall == nil {
+		fake.upgradeNodeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.upgradeNodeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeNodeClient) Watch(arg1 context.Context) (<-chan ipfs.Event, <-chan error) {
 	fake.watchMutex.Lock()
 	ret, specificReturn := fake.watchReturnsOnCall[len(fake.watchArgsForCall)]
@@ -540,18 +1623,46 @@ func (fake *FakeNodeClient) WatchReturnsOnCall(i int, result1 <-chan ipfs.Event,
 func (fake *FakeNodeClient) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.addBootstrapPeersMutex.RLock()
+	defer fake.addBootstrapPeersMutex.RUnlock()
+	fake.backupNodeMutex.RLock()
+	defer fake.backupNodeMutex.RUnlock()
 	fake.createNodeMutex.RLock()
 	defer fake.createNodeMutex.RUnlock()
+	fake.nodeAssetsExistMutex.RLock()
+	defer fake.nodeAssetsExistMutex.RUnlock()
+	fake.nodeLogsMutex.RLock()
+	defer fake.nodeLogsMutex.RUnlock()
 	fake.nodeStatsMutex.RLock()
 	defer fake.nodeStatsMutex.RUnlock()
 	fake.nodesMutex.RLock()
 	defer fake.nodesMutex.RUnlock()
+	fake.pauseNodeMutex.RLock()
+	defer fake.pauseNodeMutex.RUnlock()
+	fake.probeNodeMutex.RLock()
+	defer fake.probeNodeMutex.RUnlock()
+	fake.removeBootstrapPeersMutex.RLock()
+	defer fake.removeBootstrapPeersMutex.RUnlock()
 	fake.removeNodeMutex.RLock()
 	defer fake.removeNodeMutex.RUnlock()
+	fake.restartNodeMutex.RLock()
+	defer fake.restartNodeMutex.RUnlock()
+	fake.restoreNodeMutex.RLock()
+	defer fake.restoreNodeMutex.RUnlock()
+	fake.resumeNodeMutex.RLock()
+	defer fake.resumeNodeMutex.RUnlock()
+	fake.rotateSwarmKeyMutex.RLock()
+	defer fake.rotateSwarmKeyMutex.RUnlock()
 	fake.stopNodeMutex.RLock()
 	defer fake.stopNodeMutex.RUnlock()
+	fake.stoppedNodesMutex.RLock()
+	defer fake.stoppedNodesMutex.RUnlock()
+	fake.streamStatsMutex.RLock()
+	defer fake.streamStatsMutex.RUnlock()
 	fake.updateNodeMutex.RLock()
 	defer fake.updateNodeMutex.RUnlock()
+	fake.upgradeNodeMutex.RLock()
+	defer fake.upgradeNodeMutex.RUnlock()
 	fake.watchMutex.RLock()
 	defer fake.watchMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
@@ -0,0 +1,1228 @@
+package mock
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	crypto "github.com/libp2p/go-libp2p-crypto"
+
+	"github.com/RTradeLtd/Nexus/ipfs"
+)
+
+// Operation denotes a MemoryNodeClient operation that failures can be injected
+// into
+type Operation string
+
+const (
+	// OpNodes denotes MemoryNodeClient::Nodes
+	OpNodes Operation = "Nodes"
+	// OpStoppedNodes denotes MemoryNodeClient::StoppedNodes
+	OpStoppedNodes Operation = "StoppedNodes"
+	// OpCreateNode denotes MemoryNodeClient::CreateNode
+	OpCreateNode Operation = "CreateNode"
+	// OpUpdateNode denotes MemoryNodeClient::UpdateNode
+	OpUpdateNode Operation = "UpdateNode"
+	// OpStopNode denotes MemoryNodeClient::StopNode
+	OpStopNode Operation = "StopNode"
+	// OpRestartNode denotes MemoryNodeClient::RestartNode
+	OpRestartNode Operation = "RestartNode"
+	// OpPauseNode denotes MemoryNodeClient::PauseNode
+	OpPauseNode Operation = "PauseNode"
+	// OpResumeNode denotes MemoryNodeClient::ResumeNode
+	OpResumeNode Operation = "ResumeNode"
+	// OpRemoveNode denotes MemoryNodeClient::RemoveNode
+	OpRemoveNode Operation = "RemoveNode"
+	// OpNodeStats denotes MemoryNodeClient::NodeStats
+	OpNodeStats Operation = "NodeStats"
+	// OpStreamStats denotes MemoryNodeClient::StreamStats
+	OpStreamStats Operation = "StreamStats"
+	// OpNodeLogs denotes MemoryNodeClient::NodeLogs
+	OpNodeLogs Operation = "NodeLogs"
+	// OpProbeNode denotes MemoryNodeClient::ProbeNode
+	OpProbeNode Operation = "ProbeNode"
+	// OpUpgradeNode denotes MemoryNodeClient::UpgradeNode
+	OpUpgradeNode Operation = "UpgradeNode"
+	// OpBackupNode denotes MemoryNodeClient::BackupNode
+	OpBackupNode Operation = "BackupNode"
+	// OpRestoreNode denotes MemoryNodeClient::RestoreNode
+	OpRestoreNode Operation = "RestoreNode"
+	// OpRotateSwarmKey denotes MemoryNodeClient::RotateSwarmKey
+	OpRotateSwarmKey Operation = "RotateSwarmKey"
+	// OpAddBootstrapPeers denotes MemoryNodeClient::AddBootstrapPeers
+	OpAddBootstrapPeers Operation = "AddBootstrapPeers"
+	// OpRemoveBootstrapPeers denotes MemoryNodeClient::RemoveBootstrapPeers
+	OpRemoveBootstrapPeers Operation = "RemoveBootstrapPeers"
+	// OpNodeAssetsExist denotes MemoryNodeClient::NodeAssetsExist
+	OpNodeAssetsExist Operation = "NodeAssetsExist"
+)
+
+const (
+	// StateRunning denotes a running node container
+	StateRunning = "running"
+	// StatePaused denotes a node container that has been paused
+	StatePaused = "paused"
+	// StateExited denotes a node container that has stopped
+	StateExited = "exited"
+
+	// logPollInterval is the interval at which followed logs are checked for
+	// new output
+	logPollInterval = 10 * time.Millisecond
+
+	// daemonReady is the output of a node that has finished starting up
+	daemonReady = "Daemon is ready"
+
+	// eventBuffer is the number of events buffered per watcher before further
+	// events are dropped
+	eventBuffer = 128
+)
+
+// MemoryNodeClient is an in-memory implementation of ipfs.NodeClient that
+// simulates node containers, so that the orchestrator, registry and delegator
+// can run without a Docker daemon. Unlike FakeNodeClient, it tracks container
+// state, host ports, labels and node assets, and emits events to watchers.
+// Failures can be injected using Fail and Crash. Instantiate using
+// mock.NewMemoryNodeClient()
+type MemoryNodeClient struct {
+	// containers indexed by Docker ID - locked by MemoryNodeClient::mux
+	containers map[string]*memoryContainer
+	// simulated data directories indexed by network - locked by
+	// MemoryNodeClient::mux
+	assets map[string]*memoryAssets
+	// injected failures - locked by MemoryNodeClient::mux
+	failures []failure
+	// active watchers - locked by MemoryNodeClient::mux
+	watchers  map[int]chan ipfs.Event
+	watcherID int
+
+	mux sync.RWMutex
+}
+
+// memoryContainer simulates a node container. Labels are recorded upon creation
+// and never change, as is the case with Docker container labels.
+type memoryContainer struct {
+	id         string
+	name       string
+	labels     ipfs.NodeInfo
+	resources  ipfs.NodeResources
+	state      string
+	autoRemove bool
+	created    time.Time
+	logs       []ipfs.LogLine
+}
+
+// memoryAssets simulates the contents of a node's data directory, which
+// outlives the node's containers
+type memoryAssets struct {
+	swarmKey  []byte
+	peerID    string
+	peerKey   string
+	diskUsage int64
+	// version is the go-ipfs version the network is pinned to
+	version string
+	// config is the configuration the node runs with
+	config *ipfs.NodeConfig
+}
+
+type failure struct {
+	op      Operation
+	network string
+	err     error
+}
+
+// NewMemoryNodeClient instantiates a MemoryNodeClient with no nodes
+func NewMemoryNodeClient() *MemoryNodeClient {
+	return &MemoryNodeClient{
+		containers: make(map[string]*memoryContainer),
+		assets:     make(map[string]*memoryAssets),
+		watchers:   make(map[int]chan ipfs.Event),
+	}
+}
+
+// Fail causes the next call to the given operation for the given network to
+// return err. An empty network matches calls for any network.
+func (m *MemoryNodeClient) Fail(op Operation, network string, err error) {
+	m.mux.Lock()
+	m.failures = append(m.failures, failure{op, network, err})
+	m.mux.Unlock()
+}
+
+// Crash simulates an unexpected exit of the given network's node container
+func (m *MemoryNodeClient) Crash(network string) error {
+	m.mux.Lock()
+	var c = m.findNetwork(network)
+	if c == nil || c.state != StateRunning {
+		m.mux.Unlock()
+		return fmt.Errorf("no running node for network '%s'", network)
+	}
+	c.state = StateExited
+	c.log("Error: node crashed")
+	if c.autoRemove {
+		delete(m.containers, c.id)
+	}
+	var e = c.event("die")
+	m.mux.Unlock()
+
+	m.emit(e)
+	return nil
+}
+
+// State returns the state of the given network's node container, or an empty
+// string if no such container exists
+func (m *MemoryNodeClient) State(network string) string {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+	if c := m.findNetwork(network); c != nil {
+		return c.state
+	}
+	return ""
+}
+
+// SwarmKey returns the swarm key in the given network's simulated assets, or
+// nil if the network has no assets
+func (m *MemoryNodeClient) SwarmKey(network string) []byte {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+	if a, found := m.assets[network]; found {
+		return a.swarmKey
+	}
+	return nil
+}
+
+// NodeConfig returns the configuration in the given network's simulated assets,
+// or nil if the network has no assets
+func (m *MemoryNodeClient) NodeConfig(network string) *ipfs.NodeConfig {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+	if a, found := m.assets[network]; found && a.config != nil {
+		var cfg = *a.config
+		return &cfg
+	}
+	return nil
+}
+
+// SetDiskUsage sets the disk usage reported for the given network's node
+func (m *MemoryNodeClient) SetDiskUsage(network string, bytes int64) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	a, found := m.assets[network]
+	if !found {
+		return fmt.Errorf("no assets for network '%s'", network)
+	}
+	a.diskUsage = bytes
+	return nil
+}
+
+// Nodes retrieves a list of simulated running nodes
+func (m *MemoryNodeClient) Nodes(ctx context.Context) ([]*ipfs.NodeInfo, error) {
+	if err := m.failure(OpNodes, ""); err != nil {
+		return nil, err
+	}
+	return m.list(false), nil
+}
+
+// StoppedNodes retrieves a list of simulated nodes whose containers have
+// stopped
+func (m *MemoryNodeClient) StoppedNodes(ctx context.Context) ([]*ipfs.NodeInfo, error) {
+	if err := m.failure(OpStoppedNodes, ""); err != nil {
+		return nil, err
+	}
+	return m.list(true), nil
+}
+
+// CreateNode simulates the creation of a node container
+func (m *MemoryNodeClient) CreateNode(ctx context.Context, n *ipfs.NodeInfo, opts ipfs.NodeOpts) error {
+	if n == nil || n.NetworkID == "" {
+		return errors.New("invalid configuration provided")
+	}
+	// make sure important fields are all populated
+	n.Resources = n.Resources.WithDefaults()
+	if n.ContainerName == "" {
+		n.ContainerName = "ipfs-" + n.NetworkID
+	}
+
+	var peerID string
+	if opts.PeerKey != "" {
+		var err error
+		if peerID, err = ipfs.PeerIDFromKey(opts.PeerKey); err != nil {
+			return err
+		}
+	}
+	if opts.Config != nil {
+		if err := opts.Config.Validate(); err != nil {
+			return err
+		}
+	}
+	if _, err := ipfs.ParseBootstrapPeers(n.BootstrapPeers); err != nil {
+		return err
+	}
+
+	m.mux.Lock()
+
+	// initialize node assets
+	a, found := m.assets[n.NetworkID]
+	if opts.SwarmKey != nil {
+		if !found {
+			a = newMemoryAssets()
+			if peerID != "" {
+				a.peerID, a.peerKey = peerID, opts.PeerKey
+			}
+			m.assets[n.NetworkID] = a
+		}
+		a.swarmKey = opts.SwarmKey
+	} else if !found || a.swarmKey == nil {
+		m.mux.Unlock()
+		return errors.New("failed to set up filesystem for node: unable to find swarm key")
+	}
+	if peerID != "" && a.peerID != peerID {
+		m.mux.Unlock()
+		return fmt.Errorf("failed to set up filesystem for node: existing repo has peer ID '%s', expected '%s'",
+			a.peerID, peerID)
+	}
+	if opts.Config != nil {
+		var cfg = *opts.Config
+		a.config = &cfg
+	} else if a.config == nil {
+		var cfg = ipfs.DefaultNodeConfig()
+		a.config = &cfg
+	}
+	if n.Version == "" {
+		n.Version = a.version
+	}
+
+	// injected failures occur after assets are initialized, as is the case when
+	// container creation fails
+	if err := m.failureLocked(OpCreateNode, n.NetworkID); err != nil {
+		m.mux.Unlock()
+		return err
+	}
+
+	// check for conflicts with existing containers
+	for _, c := range m.containers {
+		if c.name == n.ContainerName {
+			m.mux.Unlock()
+			return fmt.Errorf("failed to instantiate node: container name '%s' is already in use",
+				n.ContainerName)
+		}
+		if c.state != StateExited && portsConflict(c.labels.Ports, n.Ports) {
+			m.mux.Unlock()
+			return errors.New("failed to start ipfs node: port is already allocated")
+		}
+	}
+
+	// create and start container
+	n.DockerID = newContainerID()
+	n.DataDir = filepath.Join("/data/ipfs", n.NetworkID)
+	var c = &memoryContainer{
+		id:         n.DockerID,
+		name:       n.ContainerName,
+		labels:     copyNode(n),
+		resources:  n.Resources,
+		state:      StateRunning,
+		autoRemove: opts.AutoRemove,
+		created:    time.Now(),
+	}
+	c.log(daemonReady)
+	m.containers[c.id] = c
+	var e = c.event("start")
+	m.mux.Unlock()
+
+	m.emit(e)
+	return nil
+}
+
+// UpdateNode simulates an update to a node's configuration, which restarts the
+// node container
+func (m *MemoryNodeClient) UpdateNode(ctx context.Context, n *ipfs.NodeInfo) error {
+	if n.NetworkID == "" && n.DockerID == "" {
+		return errors.New("network name or docker ID required")
+	}
+	if err := m.failure(OpUpdateNode, n.NetworkID); err != nil {
+		return err
+	}
+
+	// set defaults
+	n.Resources = n.Resources.WithDefaults()
+	if n.ContainerName == "" {
+		n.ContainerName = "ipfs-" + n.NetworkID
+	}
+	if n.DockerID == "" {
+		n.DockerID = n.ContainerName
+	}
+
+	m.mux.Lock()
+	var c = m.find(n.DockerID)
+	if c == nil {
+		m.mux.Unlock()
+		return fmt.Errorf("failed to update node configuration: no such container: %s", n.DockerID)
+	}
+	c.resources = n.Resources
+
+	// configuration changes are applied by restarting the node
+	var events = c.restart()
+	m.mux.Unlock()
+
+	m.emit(events...)
+	return nil
+}
+
+// StopNode simulates shutting down and removing a node container
+func (m *MemoryNodeClient) StopNode(ctx context.Context, n *ipfs.NodeInfo) error {
+	if n == nil || n.DockerID == "" {
+		return errors.New("invalid node")
+	}
+	if err := m.failure(OpStopNode, n.NetworkID); err != nil {
+		return err
+	}
+
+	m.mux.Lock()
+	var c = m.find(n.DockerID)
+	if c == nil {
+		m.mux.Unlock()
+		return fmt.Errorf(
+			"errors encountered: { ContainerStop: 'no such container: %s', ContainerRemove: 'no such container: %s' }",
+			n.DockerID, n.DockerID)
+	}
+	delete(m.containers, c.id)
+	var events = make([]ipfs.Event, 0, 1)
+	if c.state != StateExited {
+		events = append(events, c.event("die"))
+	}
+	m.mux.Unlock()
+
+	m.emit(events...)
+	return nil
+}
+
+// RestartNode simulates restarting a node container
+func (m *MemoryNodeClient) RestartNode(ctx context.Context, n *ipfs.NodeInfo) error {
+	if n == nil || n.DockerID == "" {
+		return errors.New("invalid node")
+	}
+	if err := m.failure(OpRestartNode, n.NetworkID); err != nil {
+		return err
+	}
+
+	m.mux.Lock()
+	var c = m.find(n.DockerID)
+	if c == nil {
+		m.mux.Unlock()
+		return fmt.Errorf("failed to restart node: no such container: %s", n.DockerID)
+	}
+	var events = c.restart()
+	m.mux.Unlock()
+
+	m.emit(events...)
+	return nil
+}
+
+// UpgradeNode simulates replacing a node container with one running the given
+// version. Injected failures simulate an upgrade that was rolled back, leaving
+// the node unchanged.
+func (m *MemoryNodeClient) UpgradeNode(ctx context.Context, n *ipfs.NodeInfo, version string) error {
+	if n == nil || n.DockerID == "" {
+		return errors.New("invalid node")
+	}
+
+	m.mux.Lock()
+	var c = m.find(n.DockerID)
+	if c == nil || c.state != StateRunning {
+		m.mux.Unlock()
+		return fmt.Errorf("node must be healthy to be upgraded: no running container %s", n.DockerID)
+	}
+	if err := m.failureLocked(OpUpgradeNode, n.NetworkID); err != nil {
+		m.mux.Unlock()
+		return fmt.Errorf("failed to upgrade node - rolled back to version '%s': %s",
+			c.labels.Version, err.Error())
+	}
+
+	// replace container
+	var upgraded = &memoryContainer{
+		id:         newContainerID(),
+		name:       c.name,
+		labels:     copyNode(&c.labels),
+		resources:  c.resources,
+		state:      StateRunning,
+		autoRemove: c.autoRemove,
+		created:    time.Now(),
+	}
+	upgraded.labels.Version = version
+	upgraded.log(daemonReady)
+	delete(m.containers, c.id)
+	m.containers[upgraded.id] = upgraded
+	if a, found := m.assets[n.NetworkID]; found {
+		a.version = version
+	}
+	var events = []ipfs.Event{c.event("die"), upgraded.event("start")}
+	*n = upgraded.info()
+	m.mux.Unlock()
+
+	m.emit(events...)
+	return nil
+}
+
+// BackupNode writes a backup archive of the given network's simulated assets
+// to w, in the format used by ipfs.Client. The archive's repo contains only the
+// node's configuration, swarm key, and version pin.
+func (m *MemoryNodeClient) BackupNode(ctx context.Context, n *ipfs.NodeInfo, w io.Writer) (ipfs.BackupManifest, error) {
+	if n == nil || n.NetworkID == "" {
+		return ipfs.BackupManifest{}, errors.New("invalid node")
+	}
+	if err := m.failure(OpBackupNode, n.NetworkID); err != nil {
+		return ipfs.BackupManifest{}, err
+	}
+
+	m.mux.RLock()
+	a, found := m.assets[n.NetworkID]
+	if !found {
+		m.mux.RUnlock()
+		return ipfs.BackupManifest{}, errors.New("failed to read node configuration: no assets")
+	}
+	var assets = *a
+	m.mux.RUnlock()
+
+	var version = n.Version
+	if version == "" {
+		version = assets.version
+	}
+	var manifest = ipfs.BackupManifest{
+		NetworkID:    n.NetworkID,
+		PeerID:       assets.peerID,
+		SwarmKeyHash: ipfs.SwarmKeyHash(assets.swarmKey),
+		Version:      version,
+		Created:      time.Now().UTC(),
+	}
+	var cfg ipfs.GoIPFSConfig
+	cfg.Identity.PeerID = assets.peerID
+	cfg.Identity.PrivKey = assets.peerKey
+
+	var (
+		gz    = gzip.NewWriter(w)
+		tw    = tar.NewWriter(gz)
+		mb, _ = json.Marshal(manifest)
+		cb, _ = json.Marshal(cfg)
+		files = []struct {
+			name string
+			data []byte
+		}{
+			{"manifest.json", mb},
+			{"repo/config", cb},
+			{"repo/swarm.key", assets.swarmKey},
+			{"repo/ipfs_version", []byte(assets.version)},
+		}
+	)
+	for _, f := range files {
+		if err := tw.WriteHeader(&tar.Header{
+			Name: f.name, Mode: 0600, Size: int64(len(f.data)), ModTime: manifest.Created,
+		}); err != nil {
+			return ipfs.BackupManifest{}, fmt.Errorf("failed to write backup archive: %s", err.Error())
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return ipfs.BackupManifest{}, fmt.Errorf("failed to write backup archive: %s", err.Error())
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return ipfs.BackupManifest{}, fmt.Errorf("failed to write backup archive: %s", err.Error())
+	}
+	if err := gz.Close(); err != nil {
+		return ipfs.BackupManifest{}, fmt.Errorf("failed to write backup archive: %s", err.Error())
+	}
+	return manifest, nil
+}
+
+// RestoreNode replaces the given network's simulated assets with those in a
+// backup archive, and starts a new node container for it. Any existing
+// container for the network is replaced.
+func (m *MemoryNodeClient) RestoreNode(ctx context.Context, n *ipfs.NodeInfo, r io.Reader) (ipfs.BackupManifest, error) {
+	if n == nil || n.NetworkID == "" {
+		return ipfs.BackupManifest{}, errors.New("invalid node")
+	}
+
+	// read archive
+	var (
+		manifest ipfs.BackupManifest
+		assets   = &memoryAssets{}
+		cfg      ipfs.GoIPFSConfig
+	)
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return manifest, fmt.Errorf("invalid backup archive: %s", err.Error())
+	}
+	var tr = tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return manifest, fmt.Errorf("invalid backup archive: %s", err.Error())
+		}
+		b, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return manifest, fmt.Errorf("invalid backup archive: %s", err.Error())
+		}
+		switch hdr.Name {
+		case "manifest.json":
+			err = json.Unmarshal(b, &manifest)
+		case "repo/config":
+			err = json.Unmarshal(b, &cfg)
+		case "repo/swarm.key":
+			assets.swarmKey = b
+		case "repo/ipfs_version":
+			assets.version = string(b)
+		}
+		if err != nil {
+			return manifest, fmt.Errorf("invalid backup archive: %s", err.Error())
+		}
+	}
+	switch {
+	case manifest.NetworkID != n.NetworkID:
+		return manifest, fmt.Errorf("backup is of network '%s'", manifest.NetworkID)
+	case cfg.Identity.PeerID == "" || cfg.Identity.PeerID != manifest.PeerID:
+		return manifest, fmt.Errorf("backup has peer ID '%s', manifest declares '%s'",
+			cfg.Identity.PeerID, manifest.PeerID)
+	case ipfs.SwarmKeyHash(assets.swarmKey) != manifest.SwarmKeyHash:
+		return manifest, errors.New("backup swarm key does not match manifest")
+	}
+	assets.peerID = cfg.Identity.PeerID
+	assets.peerKey = cfg.Identity.PrivKey
+	if err := m.failure(OpRestoreNode, n.NetworkID); err != nil {
+		return manifest, fmt.Errorf("failed to restore node: %s", err.Error())
+	}
+
+	// replace assets and container
+	m.mux.Lock()
+	var events = make([]ipfs.Event, 0, 2)
+	if c := m.findNetwork(n.NetworkID); c != nil {
+		delete(m.containers, c.id)
+		if c.state != StateExited {
+			events = append(events, c.event("die"))
+		}
+	}
+	m.assets[n.NetworkID] = assets
+	var restored = copyNode(n)
+	restored.Resources = restored.Resources.WithDefaults()
+	restored.Version = manifest.Version
+	restored.DockerID = newContainerID()
+	restored.ContainerName = "ipfs-" + n.NetworkID
+	restored.DataDir = filepath.Join("/data/ipfs", n.NetworkID)
+	var c = &memoryContainer{
+		id:        restored.DockerID,
+		name:      restored.ContainerName,
+		labels:    restored,
+		resources: restored.Resources,
+		state:     StateRunning,
+		created:   time.Now(),
+	}
+	c.log(daemonReady)
+	m.containers[c.id] = c
+	events = append(events, c.event("start"))
+	*n = c.info()
+	m.mux.Unlock()
+
+	m.emit(events...)
+	return manifest, nil
+}
+
+// RotateSwarmKey simulates replacing a node's swarm key and restarting it.
+// Injected failures simulate a rotation that was rolled back, which restarts
+// the node with its previous key.
+func (m *MemoryNodeClient) RotateSwarmKey(ctx context.Context, n *ipfs.NodeInfo, key []byte) error {
+	if n == nil || n.DockerID == "" {
+		return errors.New("invalid node")
+	}
+	if len(key) == 0 {
+		return errors.New("no swarm key provided")
+	}
+
+	m.mux.Lock()
+	var c = m.find(n.DockerID)
+	if c == nil || c.state != StateRunning {
+		m.mux.Unlock()
+		return fmt.Errorf("node must be healthy for its swarm key to be rotated: no running container %s",
+			n.DockerID)
+	}
+	var events = c.restart()
+	if err := m.failureLocked(OpRotateSwarmKey, n.NetworkID); err != nil {
+		events = append(events, c.restart()...)
+		m.mux.Unlock()
+		m.emit(events...)
+		return fmt.Errorf("failed to rotate swarm key - restored previous key: %s", err.Error())
+	}
+	if a, found := m.assets[n.NetworkID]; found {
+		a.swarmKey = append([]byte(nil), key...)
+	}
+	m.mux.Unlock()
+
+	m.emit(events...)
+	return nil
+}
+
+// AddBootstrapPeers simulates adding peers to a running node's bootstrap list.
+// The node's container is replaced by one labelled with the updated peers.
+func (m *MemoryNodeClient) AddBootstrapPeers(ctx context.Context, n *ipfs.NodeInfo, peers []string) error {
+	return m.changeBootstrapPeers(OpAddBootstrapPeers, n, peers, true)
+}
+
+// RemoveBootstrapPeers simulates removing peers from a running node's bootstrap
+// list. The node's container is replaced by one labelled with the updated
+// peers.
+func (m *MemoryNodeClient) RemoveBootstrapPeers(ctx context.Context, n *ipfs.NodeInfo, peers []string) error {
+	return m.changeBootstrapPeers(OpRemoveBootstrapPeers, n, peers, false)
+}
+
+func (m *MemoryNodeClient) changeBootstrapPeers(op Operation, n *ipfs.NodeInfo, peers []string, add bool) error {
+	if n == nil || n.DockerID == "" {
+		return errors.New("invalid node")
+	}
+	if len(peers) == 0 {
+		return errors.New("no peers provided")
+	}
+	parsed, err := ipfs.ParseBootstrapPeers(peers)
+	if err != nil {
+		return err
+	}
+
+	m.mux.Lock()
+	var c = m.find(n.DockerID)
+	if c == nil || c.state != StateRunning {
+		m.mux.Unlock()
+		return fmt.Errorf("node for network '%s' is not running", n.NetworkID)
+	}
+	if err := m.failureLocked(op, n.NetworkID); err != nil {
+		m.mux.Unlock()
+		return err
+	}
+
+	var (
+		candidates = n.BootstrapPeers
+		removed    = make(map[string]bool)
+		seen       = make(map[string]bool)
+		updated    = make([]string, 0, len(n.BootstrapPeers)+len(parsed))
+	)
+	if add {
+		candidates = append(append([]string{}, n.BootstrapPeers...), parsed...)
+	} else {
+		for _, p := range parsed {
+			removed[p] = true
+		}
+	}
+	for _, p := range candidates {
+		if !seen[p] && !removed[p] {
+			updated = append(updated, p)
+		}
+		seen[p] = true
+	}
+
+	// replace container
+	var recreated = &memoryContainer{
+		id:         newContainerID(),
+		name:       c.name,
+		labels:     copyNode(&c.labels),
+		resources:  c.resources,
+		state:      StateRunning,
+		autoRemove: c.autoRemove,
+		created:    time.Now(),
+	}
+	recreated.labels.BootstrapPeers = updated
+	recreated.log(daemonReady)
+	delete(m.containers, c.id)
+	m.containers[recreated.id] = recreated
+	var events = []ipfs.Event{c.event("die"), recreated.event("start")}
+	*n = recreated.info()
+	m.mux.Unlock()
+
+	m.emit(events...)
+	return nil
+}
+
+// PauseNode simulates pausing a running node container
+func (m *MemoryNodeClient) PauseNode(ctx context.Context, n *ipfs.NodeInfo) error {
+	return m.setPaused(OpPauseNode, n, true)
+}
+
+// ResumeNode simulates resuming a paused node container
+func (m *MemoryNodeClient) ResumeNode(ctx context.Context, n *ipfs.NodeInfo) error {
+	return m.setPaused(OpResumeNode, n, false)
+}
+
+// RemoveNode removes the simulated assets of the given network
+func (m *MemoryNodeClient) RemoveNode(ctx context.Context, network string) error {
+	if err := m.failure(OpRemoveNode, network); err != nil {
+		return err
+	}
+
+	m.mux.Lock()
+	delete(m.assets, network)
+	m.mux.Unlock()
+	return nil
+}
+
+// NodeAssetsExist checks if simulated assets exist for the given network
+func (m *MemoryNodeClient) NodeAssetsExist(ctx context.Context, network string) (bool, error) {
+	if network == "" {
+		return false, errors.New("invalid network name provided")
+	}
+	if err := m.failure(OpNodeAssetsExist, network); err != nil {
+		return false, err
+	}
+
+	m.mux.RLock()
+	_, found := m.assets[network]
+	m.mux.RUnlock()
+	return found, nil
+}
+
+// NodeStats retrieves simulated statistics about the provided node
+func (m *MemoryNodeClient) NodeStats(ctx context.Context, n *ipfs.NodeInfo) (ipfs.NodeStats, error) {
+	if err := m.failure(OpNodeStats, n.NetworkID); err != nil {
+		return ipfs.NodeStats{}, err
+	}
+
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+	var c = m.find(n.DockerID)
+	if c == nil {
+		return ipfs.NodeStats{}, errors.New("failed to get node stats")
+	}
+	a, found := m.assets[c.labels.NetworkID]
+	if !found {
+		return ipfs.NodeStats{}, errors.New("failed to get network node configuration")
+	}
+
+	var fingerprint string
+	if key, err := ipfs.ParseSwarmKey(a.swarmKey); err == nil {
+		fingerprint = key.Fingerprint()
+	}
+
+	return ipfs.NodeStats{
+		PeerID:    a.peerID,
+		PeerKey:   a.peerKey,
+		Uptime:    time.Since(c.created),
+		DiskUsage: a.diskUsage,
+		Stats:     c.stats(),
+
+		SwarmKeyFingerprint: fingerprint,
+	}, nil
+}
+
+// StreamStats emits simulated statistics about the provided node every
+// interval, until the given context is cancelled or the node's container is
+// stopped
+func (m *MemoryNodeClient) StreamStats(ctx context.Context, n *ipfs.NodeInfo, interval time.Duration) (<-chan ipfs.ContainerStats, error) {
+	if n == nil || n.DockerID == "" {
+		return nil, errors.New("invalid node")
+	}
+	if err := m.failure(OpStreamStats, n.NetworkID); err != nil {
+		return nil, err
+	}
+	m.mux.RLock()
+	var found = m.find(n.DockerID) != nil
+	m.mux.RUnlock()
+	if !found {
+		return nil, errors.New("failed to get node stats")
+	}
+
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	var samples = make(chan ipfs.ContainerStats)
+	go func() {
+		defer close(samples)
+		var ticker = time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			m.mux.RLock()
+			var c = m.find(n.DockerID)
+			if c == nil || c.state == StateExited {
+				m.mux.RUnlock()
+				return
+			}
+			var sample = c.stats()
+			m.mux.RUnlock()
+
+			select {
+			case samples <- sample:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return samples, nil
+}
+
+// NodeLogs retrieves simulated output from the provided node. If opts.Follow
+// is set, new output is delivered until the node's container is stopped,
+// opts.Until passes, or the given context is cancelled.
+func (m *MemoryNodeClient) NodeLogs(ctx context.Context, n *ipfs.NodeInfo, opts ipfs.LogOptions) (<-chan ipfs.LogLine, error) {
+	if n == nil || n.DockerID == "" {
+		return nil, errors.New("invalid node")
+	}
+	if err := m.failure(OpNodeLogs, n.NetworkID); err != nil {
+		return nil, err
+	}
+
+	m.mux.RLock()
+	var c = m.find(n.DockerID)
+	if c == nil {
+		m.mux.RUnlock()
+		return nil, fmt.Errorf("failed to get node logs: no such container: %s", n.DockerID)
+	}
+	var (
+		offset = len(c.logs)
+		output = c.logs
+	)
+	if opts.Tail > 0 && opts.Tail < len(output) {
+		output = output[len(output)-opts.Tail:]
+	}
+	output = append([]ipfs.LogLine{}, output...)
+	m.mux.RUnlock()
+
+	var include = func(l ipfs.LogLine) bool {
+		return (opts.Since.IsZero() || !l.Time.Before(opts.Since)) &&
+			(opts.Until.IsZero() || l.Time.Before(opts.Until))
+	}
+
+	var lines = make(chan ipfs.LogLine)
+	go func() {
+		defer close(lines)
+		var (
+			ticker  = time.NewTicker(logPollInterval)
+			stopped = false
+		)
+		defer ticker.Stop()
+		for {
+			for _, l := range output {
+				if !include(l) {
+					continue
+				}
+				select {
+				case lines <- l:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if !opts.Follow || stopped || (!opts.Until.IsZero() && time.Now().After(opts.Until)) {
+				return
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+
+			// collect new output - once the container has stopped, remaining
+			// output is delivered before returning
+			m.mux.RLock()
+			var c = m.find(n.DockerID)
+			if c == nil {
+				m.mux.RUnlock()
+				return
+			}
+			output = append([]ipfs.LogLine{}, c.logs[offset:]...)
+			offset = len(c.logs)
+			stopped = c.state == StateExited
+			m.mux.RUnlock()
+		}
+	}()
+	return lines, nil
+}
+
+// ProbeNode checks that the provided node is running. ipfs.ErrNodePaused is
+// returned if the node's container is paused.
+func (m *MemoryNodeClient) ProbeNode(ctx context.Context, n *ipfs.NodeInfo) error {
+	if n == nil || n.DockerID == "" {
+		return errors.New("invalid node")
+	}
+	if err := m.failure(OpProbeNode, n.NetworkID); err != nil {
+		return err
+	}
+
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+	var c = m.find(n.DockerID)
+	switch {
+	case c == nil:
+		return fmt.Errorf("node API is not responding: no such container: %s", n.DockerID)
+	case c.state == StatePaused:
+		return ipfs.ErrNodePaused
+	case c.state != StateRunning:
+		return ipfs.ErrNodeStopped
+	}
+	return nil
+}
+
+// Watch registers a watcher that receives simulated node events. Events are
+// buffered, and dropped if the watcher falls too far behind. Both channels are
+// closed when the given context is cancelled.
+func (m *MemoryNodeClient) Watch(ctx context.Context) (<-chan ipfs.Event, <-chan error) {
+	var (
+		events = make(chan ipfs.Event, eventBuffer)
+		errs   = make(chan error)
+	)
+
+	m.mux.Lock()
+	var id = m.watcherID
+	m.watcherID++
+	m.watchers[id] = events
+	m.mux.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		// events are only sent while holding a read lock, so the channel can be
+		// closed safely once the watcher is removed
+		m.mux.Lock()
+		delete(m.watchers, id)
+		close(events)
+		m.mux.Unlock()
+		close(errs)
+	}()
+
+	return events, errs
+}
+
+// setPaused pauses or resumes the given node's container. Docker does not
+// report pause events to ipfs.Client watchers, so none are emitted.
+func (m *MemoryNodeClient) setPaused(op Operation, n *ipfs.NodeInfo, pause bool) error {
+	if n == nil || n.DockerID == "" {
+		return errors.New("invalid node")
+	}
+	if err := m.failure(op, n.NetworkID); err != nil {
+		return err
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	var c = m.find(n.DockerID)
+	if c == nil {
+		return fmt.Errorf("no such container: %s", n.DockerID)
+	}
+	switch {
+	case pause && c.state == StatePaused:
+		return fmt.Errorf("failed to pause node: container %s is already paused", c.id)
+	case pause && c.state != StateRunning:
+		return fmt.Errorf("failed to pause node: container %s is not running", c.id)
+	case !pause && c.state != StatePaused:
+		return fmt.Errorf("failed to resume node: container %s is not paused", c.id)
+	}
+	if pause {
+		c.state = StatePaused
+	} else {
+		c.state = StateRunning
+	}
+	return nil
+}
+
+// list retrieves either stopped or running nodes
+func (m *MemoryNodeClient) list(stopped bool) []*ipfs.NodeInfo {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+	var nodes = make([]*ipfs.NodeInfo, 0, len(m.containers))
+	for _, c := range m.containers {
+		if (c.state == StateExited) != stopped {
+			continue
+		}
+		var n = c.info()
+		// Docker reports container names with a leading slash
+		n.ContainerName = "/" + c.name
+		nodes = append(nodes, &n)
+	}
+	return nodes
+}
+
+// failure pops the first injected failure that matches the given operation and
+// network, if there is one
+func (m *MemoryNodeClient) failure(op Operation, network string) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	return m.failureLocked(op, network)
+}
+
+// failureLocked is the same as failure, but the caller must hold
+// MemoryNodeClient::mux
+func (m *MemoryNodeClient) failureLocked(op Operation, network string) error {
+	for i, f := range m.failures {
+		if f.op == op && (f.network == "" || f.network == network) {
+			m.failures = append(m.failures[:i], m.failures[i+1:]...)
+			return f.err
+		}
+	}
+	return nil
+}
+
+// emit delivers events to all watchers without blocking
+func (m *MemoryNodeClient) emit(events ...ipfs.Event) {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+	for _, e := range events {
+		for _, w := range m.watchers {
+			select {
+			case w <- e:
+			default:
+			}
+		}
+	}
+}
+
+// find retrieves a container by ID, short ID, or name. The caller must hold
+// MemoryNodeClient::mux
+func (m *MemoryNodeClient) find(id string) *memoryContainer {
+	if id == "" {
+		return nil
+	}
+	id = strings.TrimPrefix(id, "/")
+	if c, found := m.containers[id]; found {
+		return c
+	}
+	for _, c := range m.containers {
+		if c.name == id || strings.HasPrefix(c.id, id) {
+			return c
+		}
+	}
+	return nil
+}
+
+// findNetwork retrieves the container of the given network. The caller must
+// hold MemoryNodeClient::mux
+func (m *MemoryNodeClient) findNetwork(network string) *memoryContainer {
+	for _, c := range m.containers {
+		if c.labels.NetworkID == network {
+			return c
+		}
+	}
+	return nil
+}
+
+// info generates node metadata from container details, as ipfs.Client does
+// with Docker container labels
+func (c *memoryContainer) info() ipfs.NodeInfo {
+	var n = copyNode(&c.labels)
+	n.DockerID = c.id
+	n.ContainerName = c.name
+	return n
+}
+
+// restart restarts the container and returns the resulting events. The caller
+// must hold MemoryNodeClient::mux
+func (c *memoryContainer) restart() []ipfs.Event {
+	var events = make([]ipfs.Event, 0, 2)
+	if c.state != StateExited {
+		c.log("Received interrupt signal, shutting down...")
+		events = append(events, c.event("die"))
+	}
+	c.state = StateRunning
+	c.log(daemonReady)
+	events = append(events, c.event("start"))
+	return events
+}
+
+// log records output from the container. The caller must hold
+// MemoryNodeClient::mux
+func (c *memoryContainer) log(lines ...string) {
+	var now = time.Now()
+	for _, l := range lines {
+		c.logs = append(c.logs, ipfs.LogLine{Time: now, Text: l})
+	}
+}
+
+// stats generates simulated resource usage statistics. The caller must hold
+// MemoryNodeClient::mux
+func (c *memoryContainer) stats() ipfs.ContainerStats {
+	var s = ipfs.ContainerStats{
+		Time:        time.Now(),
+		OnlineCPUs:  uint32(c.resources.CPUs),
+		MemoryUsage: 64 * 1048576,
+		MemoryLimit: uint64(c.resources.MemoryGB) * 1073741824,
+		PIDs:        8,
+	}
+	if c.state == StateRunning {
+		// simulate a node using 5% of a single core
+		s.CPUPercent = 5
+	}
+	if s.MemoryLimit > 0 {
+		s.MemoryPercent = float64(s.MemoryUsage) / float64(s.MemoryLimit) * 100
+	}
+	return s
+}
+
+// event generates a node event, as ipfs.Client does with Docker events
+func (c *memoryContainer) event(status string) ipfs.Event {
+	var n = c.info()
+	n.DockerID = c.id[:11]
+	return ipfs.Event{
+		Time:   time.Now().Unix(),
+		Status: status,
+		Node:   n,
+	}
+}
+
+func newMemoryAssets() *memoryAssets {
+	var a = &memoryAssets{}
+	a.peerID, a.peerKey = newIdentity()
+	return a
+}
+
+func copyNode(n *ipfs.NodeInfo) ipfs.NodeInfo {
+	var c = *n
+	if n.BootstrapPeers != nil {
+		c.BootstrapPeers = append([]string{}, n.BootstrapPeers...)
+	}
+	return c
+}
+
+func portsConflict(a, b ipfs.NodePorts) bool {
+	var used = map[string]bool{}
+	for _, p := range []string{a.Swarm, a.API, a.Gateway} {
+		if p != "" {
+			used[p] = true
+		}
+	}
+	for _, p := range []string{b.Swarm, b.API, b.Gateway} {
+		if used[p] {
+			return true
+		}
+	}
+	return false
+}
+
+func newContainerID() string {
+	var b = make([]byte, 32)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// newIdentity generates a peer ID and private key, so that node identities
+// can be provided to other nodes
+func newIdentity() (peerID, privKey string) {
+	sk, _, err := crypto.GenerateKeyPair(crypto.Ed25519, 0)
+	if err != nil {
+		panic(err)
+	}
+	b, err := crypto.MarshalPrivateKey(sk)
+	if err != nil {
+		panic(err)
+	}
+	privKey = crypto.ConfigEncodeKey(b)
+	if peerID, err = ipfs.PeerIDFromKey(privKey); err != nil {
+		panic(err)
+	}
+	return peerID, privKey
+}
+
+var _ ipfs.NodeClient = new(MemoryNodeClient)
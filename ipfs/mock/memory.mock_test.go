@@ -0,0 +1,748 @@
+package mock
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/RTradeLtd/Nexus/ipfs"
+)
+
+func TestMemoryNodeClient_lifecycle(t *testing.T) {
+	var (
+		c           = NewMemoryNodeClient()
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+	defer cancel()
+	events, _ := c.Watch(ctx)
+
+	var n = &ipfs.NodeInfo{
+		NetworkID: "test-network",
+		Ports:     ipfs.NodePorts{Swarm: "4001", API: "5001", Gateway: "8001"},
+	}
+
+	// node without assets should fail
+	if err := c.CreateNode(ctx, n, ipfs.NodeOpts{}); err == nil {
+		t.Fatal("expected error creating node without swarm key")
+	}
+
+	// create node
+	if err := c.CreateNode(ctx, n, ipfs.NodeOpts{SwarmKey: []byte("hello")}); err != nil {
+		t.Fatalf("CreateNode() error = %v", err)
+	}
+	if n.DockerID == "" || n.ContainerName != "ipfs-test-network" || n.DataDir == "" {
+		t.Errorf("CreateNode() did not populate node: %+v", n)
+	}
+	expectEvent(t, events, "start", n.NetworkID)
+
+	// name and port conflicts should fail
+	if err := c.CreateNode(ctx, &ipfs.NodeInfo{NetworkID: "test-network"},
+		ipfs.NodeOpts{SwarmKey: []byte("hello")}); err == nil {
+		t.Error("expected container name conflict")
+	}
+	if err := c.CreateNode(ctx, &ipfs.NodeInfo{
+		NetworkID: "test-network-2",
+		Ports:     ipfs.NodePorts{Swarm: "4001"},
+	}, ipfs.NodeOpts{SwarmKey: []byte("hello")}); err == nil {
+		t.Error("expected port conflict")
+	}
+
+	// list nodes
+	nodes, err := c.Nodes(ctx)
+	if err != nil {
+		t.Fatalf("Nodes() error = %v", err)
+	}
+	if len(nodes) != 1 || nodes[0].NetworkID != n.NetworkID || nodes[0].Ports != n.Ports {
+		t.Errorf("Nodes() = %+v", nodes)
+	}
+
+	// stats
+	if err := c.SetDiskUsage(n.NetworkID, 1024); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := c.NodeStats(ctx, n)
+	if err != nil {
+		t.Fatalf("NodeStats() error = %v", err)
+	}
+	if stats.PeerID == "" || stats.PeerKey == "" || stats.DiskUsage != 1024 {
+		t.Errorf("NodeStats() = %+v", stats)
+	}
+
+	// update restarts node
+	n.Resources.MemoryGB = 8
+	if err := c.UpdateNode(ctx, n); err != nil {
+		t.Fatalf("UpdateNode() error = %v", err)
+	}
+	expectEvent(t, events, "die", n.NetworkID)
+	expectEvent(t, events, "start", n.NetworkID)
+
+	// stop node
+	if err := c.StopNode(ctx, n); err != nil {
+		t.Fatalf("StopNode() error = %v", err)
+	}
+	expectEvent(t, events, "die", n.NetworkID)
+	if state := c.State(n.NetworkID); state != "" {
+		t.Errorf("State() = %s, expected container to be removed", state)
+	}
+
+	// node can be recreated from existing assets, with the same identity
+	if err := c.CreateNode(ctx, n, ipfs.NodeOpts{}); err != nil {
+		t.Fatalf("CreateNode() error = %v", err)
+	}
+	restarted, err := c.NodeStats(ctx, n)
+	if err != nil {
+		t.Fatalf("NodeStats() error = %v", err)
+	}
+	if restarted.PeerID != stats.PeerID {
+		t.Errorf("expected peer ID %s, got %s", stats.PeerID, restarted.PeerID)
+	}
+	if err := c.StopNode(ctx, n); err != nil {
+		t.Fatalf("StopNode() error = %v", err)
+	}
+
+	// remove assets
+	if err := c.RemoveNode(ctx, n.NetworkID); err != nil {
+		t.Fatalf("RemoveNode() error = %v", err)
+	}
+	if err := c.CreateNode(ctx, n, ipfs.NodeOpts{}); err == nil {
+		t.Error("expected error creating node after assets were removed")
+	}
+}
+
+func TestMemoryNodeClient_Fail(t *testing.T) {
+	var (
+		c        = NewMemoryNodeClient()
+		ctx      = context.Background()
+		injected = errors.New("oh no")
+	)
+
+	c.Fail(OpCreateNode, "other-network", injected)
+	c.Fail(OpCreateNode, "test-network", injected)
+
+	var n = &ipfs.NodeInfo{NetworkID: "test-network"}
+	if err := c.CreateNode(ctx, n, ipfs.NodeOpts{SwarmKey: []byte("hello")}); err != injected {
+		t.Errorf("expected injected error, got %v", err)
+	}
+	if exists, _ := c.NodeAssetsExist(ctx, n.NetworkID); !exists {
+		t.Error("expected assets to be created before injected failure")
+	}
+	if c.State(n.NetworkID) != "" {
+		t.Error("expected no container to be created")
+	}
+
+	// failures should only be triggered once
+	if err := c.CreateNode(ctx, n, ipfs.NodeOpts{SwarmKey: []byte("hello")}); err != nil {
+		t.Errorf("CreateNode() error = %v", err)
+	}
+
+	// empty network should match any network
+	c.Fail(OpNodeStats, "", injected)
+	if _, err := c.NodeStats(ctx, n); err != injected {
+		t.Errorf("expected injected error, got %v", err)
+	}
+}
+
+func TestMemoryNodeClient_Crash(t *testing.T) {
+	type args struct {
+		autoRemove bool
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantState   string
+		wantStopped int
+	}{
+		{"stopped", args{false}, StateExited, 1},
+		{"auto removed", args{true}, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				c           = NewMemoryNodeClient()
+				ctx, cancel = context.WithCancel(context.Background())
+			)
+			defer cancel()
+			events, _ := c.Watch(ctx)
+
+			if err := c.Crash("test-network"); err == nil {
+				t.Error("expected error crashing nonexistent node")
+			}
+
+			var n = &ipfs.NodeInfo{NetworkID: "test-network"}
+			if err := c.CreateNode(ctx, n, ipfs.NodeOpts{
+				SwarmKey:   []byte("hello"),
+				AutoRemove: tt.args.autoRemove,
+			}); err != nil {
+				t.Fatal(err)
+			}
+			expectEvent(t, events, "start", n.NetworkID)
+
+			if err := c.Crash(n.NetworkID); err != nil {
+				t.Fatalf("Crash() error = %v", err)
+			}
+			expectEvent(t, events, "die", n.NetworkID)
+
+			// listing nodes should not restart crashed nodes
+			if nodes, err := c.Nodes(ctx); err != nil || len(nodes) != 0 {
+				t.Errorf("Nodes() = %v, %v", nodes, err)
+			}
+			if state := c.State(n.NetworkID); state != tt.wantState {
+				t.Errorf("State() = %s, want %s", state, tt.wantState)
+			}
+			stopped, err := c.StoppedNodes(ctx)
+			if err != nil || len(stopped) != tt.wantStopped {
+				t.Errorf("StoppedNodes() = %v, %v", stopped, err)
+			}
+			if tt.wantStopped == 0 {
+				return
+			}
+
+			// stopped nodes can be restarted
+			if err := c.ProbeNode(ctx, stopped[0]); err != ipfs.ErrNodeStopped {
+				t.Errorf("expected stopped node to fail probe, got %v", err)
+			}
+			if err := c.RestartNode(ctx, stopped[0]); err != nil {
+				t.Fatalf("RestartNode() error = %v", err)
+			}
+			expectEvent(t, events, "start", n.NetworkID)
+			if err := c.ProbeNode(ctx, stopped[0]); err != nil {
+				t.Errorf("expected restarted node to pass probe, got %v", err)
+			}
+		})
+	}
+}
+
+func TestMemoryNodeClient_Watch(t *testing.T) {
+	var (
+		c           = NewMemoryNodeClient()
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+	events, errs := c.Watch(ctx)
+	cancel()
+
+	// both channels should be closed once the context is cancelled
+	for _, ch := range []interface{}{events, errs} {
+		var closed = make(chan bool)
+		go func(ch interface{}) {
+			switch ch := ch.(type) {
+			case <-chan ipfs.Event:
+				for range ch {
+				}
+			case <-chan error:
+				for range ch {
+				}
+			}
+			close(closed)
+		}(ch)
+		select {
+		case <-closed:
+		case <-time.After(time.Second):
+			t.Errorf("expected %T to be closed", ch)
+		}
+	}
+
+	// events should not be sent to closed watchers
+	if err := c.CreateNode(context.Background(), &ipfs.NodeInfo{NetworkID: "test-network"},
+		ipfs.NodeOpts{SwarmKey: []byte("hello")}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMemoryNodeClient_RestartNode(t *testing.T) {
+	var (
+		c           = NewMemoryNodeClient()
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+	defer cancel()
+	events, _ := c.Watch(ctx)
+
+	var n = &ipfs.NodeInfo{NetworkID: "test-network"}
+	if err := c.RestartNode(ctx, n); err == nil {
+		t.Error("expected error restarting invalid node")
+	}
+	if err := c.CreateNode(ctx, n, ipfs.NodeOpts{SwarmKey: []byte("hello")}); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, events, "start", n.NetworkID)
+
+	// restarts should retain the container
+	var id = n.DockerID
+	if err := c.RestartNode(ctx, n); err != nil {
+		t.Fatalf("RestartNode() error = %v", err)
+	}
+	expectEvent(t, events, "die", n.NetworkID)
+	expectEvent(t, events, "start", n.NetworkID)
+	if n.DockerID != id || c.State(n.NetworkID) != StateRunning {
+		t.Errorf("expected container %s to be running, got %s", id, c.State(n.NetworkID))
+	}
+}
+
+func TestMemoryNodeClient_UpgradeNode(t *testing.T) {
+	var (
+		c           = NewMemoryNodeClient()
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+	defer cancel()
+	events, _ := c.Watch(ctx)
+
+	var n = &ipfs.NodeInfo{NetworkID: "test-network"}
+	if err := c.UpgradeNode(ctx, n, "v0.4.19"); err == nil {
+		t.Error("expected error upgrading invalid node")
+	}
+	if err := c.CreateNode(ctx, n, ipfs.NodeOpts{SwarmKey: []byte("hello")}); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, events, "start", n.NetworkID)
+
+	// failed upgrades should leave the node unchanged
+	var id = n.DockerID
+	c.Fail(OpUpgradeNode, n.NetworkID, errors.New("migration failed"))
+	if err := c.UpgradeNode(ctx, n, "v0.4.19"); err == nil {
+		t.Error("expected upgrade to fail")
+	}
+	if n.DockerID != id || n.Version != "" {
+		t.Errorf("expected node to be unchanged, got %+v", n)
+	}
+
+	// upgrades should replace the container and pin the version
+	if err := c.UpgradeNode(ctx, n, "v0.4.19"); err != nil {
+		t.Fatalf("UpgradeNode() error = %v", err)
+	}
+	expectEvent(t, events, "die", n.NetworkID)
+	expectEvent(t, events, "start", n.NetworkID)
+	if n.DockerID == id || n.Version != "v0.4.19" || c.State(n.NetworkID) != StateRunning {
+		t.Errorf("expected node to be upgraded, got %+v", n)
+	}
+	if nodes, _ := c.Nodes(ctx); len(nodes) != 1 || nodes[0].Version != "v0.4.19" {
+		t.Errorf("expected upgraded node to be listed, got %+v", nodes)
+	}
+
+	// pinned version should be used when the node is recreated
+	if err := c.StopNode(ctx, n); err != nil {
+		t.Fatal(err)
+	}
+	var recreated = &ipfs.NodeInfo{NetworkID: n.NetworkID}
+	if err := c.CreateNode(ctx, recreated, ipfs.NodeOpts{}); err != nil {
+		t.Fatal(err)
+	}
+	if recreated.Version != "v0.4.19" {
+		t.Errorf("expected pinned version, got '%s'", recreated.Version)
+	}
+}
+
+func TestMemoryNodeClient_BootstrapPeers(t *testing.T) {
+	var (
+		c         = NewMemoryNodeClient()
+		ctx       = context.Background()
+		peerA, _  = newIdentity()
+		peerB, _  = newIdentity()
+		bootstrap = []string{"/ip4/1.2.3.4/tcp/4001/ipfs/" + peerA}
+		added     = "/ip4/5.6.7.8/tcp/4001/ipfs/" + peerB
+	)
+
+	// invalid peers should be rejected before nodes are created
+	var n = &ipfs.NodeInfo{NetworkID: "test-network", BootstrapPeers: []string{"1.2.3.4:4001"}}
+	if err := c.CreateNode(ctx, n, ipfs.NodeOpts{SwarmKey: []byte("hello")}); err == nil {
+		t.Fatal("expected error for invalid bootstrap peer")
+	}
+	n.BootstrapPeers = bootstrap
+	if err := c.CreateNode(ctx, n, ipfs.NodeOpts{SwarmKey: []byte("hello")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AddBootstrapPeers(ctx, n, []string{"/ip4/5.6.7.8/tcp/4001"}); err == nil {
+		t.Error("expected error for peer without ID")
+	}
+	c.Fail(OpAddBootstrapPeers, n.NetworkID, errors.New("oh no"))
+	if err := c.AddBootstrapPeers(ctx, n, []string{added}); err == nil {
+		t.Error("expected injected failure")
+	}
+
+	// changes should recreate the node's container, and be reflected in listed
+	// nodes
+	var listed = func() []string {
+		t.Helper()
+		nodes, err := c.Nodes(ctx)
+		if err != nil || len(nodes) != 1 {
+			t.Fatalf("expected 1 node, got %v (%v)", nodes, err)
+		}
+		return nodes[0].BootstrapPeers
+	}
+	var id = n.DockerID
+	if err := c.AddBootstrapPeers(ctx, n, []string{added, bootstrap[0]}); err != nil {
+		t.Fatalf("AddBootstrapPeers() error = %v", err)
+	}
+	if n.DockerID == id {
+		t.Error("expected node's container to be recreated")
+	}
+	var want = []string{bootstrap[0], added}
+	if !reflect.DeepEqual(n.BootstrapPeers, want) || !reflect.DeepEqual(listed(), want) {
+		t.Errorf("expected bootstrap peers %v, got %v and %v", want, n.BootstrapPeers, listed())
+	}
+	if err := c.RemoveBootstrapPeers(ctx, n, bootstrap); err != nil {
+		t.Fatalf("RemoveBootstrapPeers() error = %v", err)
+	}
+	want = []string{added}
+	if !reflect.DeepEqual(n.BootstrapPeers, want) || !reflect.DeepEqual(listed(), want) {
+		t.Errorf("expected bootstrap peers %v, got %v and %v", want, n.BootstrapPeers, listed())
+	}
+
+	// stopped nodes cannot be changed
+	if err := c.StopNode(ctx, n); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RemoveBootstrapPeers(ctx, n, want); err == nil {
+		t.Error("expected error for stopped node")
+	}
+}
+
+func TestMemoryNodeClient_RotateSwarmKey(t *testing.T) {
+	var (
+		c           = NewMemoryNodeClient()
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+	defer cancel()
+	events, _ := c.Watch(ctx)
+
+	var n = &ipfs.NodeInfo{NetworkID: "test-network"}
+	if err := c.RotateSwarmKey(ctx, n, []byte("goodbye")); err == nil {
+		t.Error("expected error rotating key of invalid node")
+	}
+	if err := c.CreateNode(ctx, n, ipfs.NodeOpts{SwarmKey: []byte("hello")}); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, events, "start", n.NetworkID)
+	if err := c.RotateSwarmKey(ctx, n, nil); err == nil {
+		t.Error("expected error for empty key")
+	}
+
+	// failed rotations should leave the previous key in place
+	c.Fail(OpRotateSwarmKey, n.NetworkID, errors.New("node failed to start"))
+	if err := c.RotateSwarmKey(ctx, n, []byte("goodbye")); err == nil {
+		t.Error("expected rotation to fail")
+	}
+	if key := string(c.SwarmKey(n.NetworkID)); key != "hello" {
+		t.Errorf("expected previous key to be restored, got '%s'", key)
+	}
+
+	// rotations should restart the node with the new key
+	for len(events) > 0 {
+		<-events
+	}
+	var id = n.DockerID
+	if err := c.RotateSwarmKey(ctx, n, []byte("goodbye")); err != nil {
+		t.Fatalf("RotateSwarmKey() error = %v", err)
+	}
+	expectEvent(t, events, "die", n.NetworkID)
+	expectEvent(t, events, "start", n.NetworkID)
+	if key := string(c.SwarmKey(n.NetworkID)); key != "goodbye" {
+		t.Errorf("expected key to be rotated, got '%s'", key)
+	}
+	if n.DockerID != id || c.State(n.NetworkID) != StateRunning {
+		t.Errorf("expected container %s to be running, got %s", id, c.State(n.NetworkID))
+	}
+}
+
+func TestMemoryNodeClient_CreateNodeWithPeerKey(t *testing.T) {
+	var (
+		c   = NewMemoryNodeClient()
+		ctx = context.Background()
+		n   = &ipfs.NodeInfo{NetworkID: "test-network"}
+	)
+	if err := c.CreateNode(ctx, n, ipfs.NodeOpts{SwarmKey: []byte("hello"), PeerKey: "bad"}); err == nil {
+		t.Error("expected error for invalid peer key")
+	}
+
+	// new nodes should start with the provided identity
+	peerID, peerKey := newIdentity()
+	if err := c.CreateNode(ctx, n, ipfs.NodeOpts{SwarmKey: []byte("hello"), PeerKey: peerKey}); err != nil {
+		t.Fatal(err)
+	}
+	s, err := c.NodeStats(ctx, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.PeerID != peerID || s.PeerKey != peerKey {
+		t.Errorf("expected peer ID %s, got %s", peerID, s.PeerID)
+	}
+
+	// existing assets must have the provided identity
+	if err := c.StopNode(ctx, n); err != nil {
+		t.Fatal(err)
+	}
+	if _, other := newIdentity(); c.CreateNode(ctx, n, ipfs.NodeOpts{PeerKey: other}) == nil {
+		t.Error("expected error for mismatched peer key")
+	}
+	if err := c.CreateNode(ctx, n, ipfs.NodeOpts{PeerKey: peerKey}); err != nil {
+		t.Errorf("CreateNode() error = %v", err)
+	}
+}
+
+func TestMemoryNodeClient_CreateNodeWithConfig(t *testing.T) {
+	var (
+		c   = NewMemoryNodeClient()
+		ctx = context.Background()
+		n   = &ipfs.NodeInfo{NetworkID: "test-network"}
+		cfg = ipfs.DefaultNodeConfig()
+	)
+	if c.NodeConfig(n.NetworkID) != nil {
+		t.Error("expected no configuration for network without assets")
+	}
+	cfg.Swarm.ConnMgr.LowWater = -1
+	if err := c.CreateNode(ctx, n, ipfs.NodeOpts{SwarmKey: []byte("hello"), Config: &cfg}); err == nil {
+		t.Error("expected error for invalid configuration")
+	}
+
+	// new nodes should use the default configuration
+	if err := c.CreateNode(ctx, n, ipfs.NodeOpts{SwarmKey: []byte("hello")}); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.NodeConfig(n.NetworkID); got == nil || !reflect.DeepEqual(*got, ipfs.DefaultNodeConfig()) {
+		t.Errorf("expected default configuration, got %+v", got)
+	}
+
+	// provided configuration should replace existing configuration, which is
+	// otherwise kept
+	cfg = ipfs.DefaultNodeConfig()
+	cfg.Experimental.FilestoreEnabled = true
+	for _, opts := range []ipfs.NodeOpts{{Config: &cfg}, {}} {
+		if err := c.StopNode(ctx, n); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.CreateNode(ctx, n, opts); err != nil {
+			t.Fatal(err)
+		}
+		if got := c.NodeConfig(n.NetworkID); got == nil || !got.Experimental.FilestoreEnabled {
+			t.Errorf("expected provided configuration, got %+v", got)
+		}
+	}
+}
+
+func TestMemoryNodeClient_BackupNode(t *testing.T) {
+	var (
+		c   = NewMemoryNodeClient()
+		ctx = context.Background()
+		n   = &ipfs.NodeInfo{NetworkID: "test-network"}
+	)
+	var archive bytes.Buffer
+	if _, err := c.BackupNode(ctx, n, &archive); err == nil {
+		t.Error("expected error backing up network without assets")
+	}
+	if err := c.CreateNode(ctx, n, ipfs.NodeOpts{SwarmKey: []byte("hello")}); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := c.NodeStats(ctx, n)
+
+	// backups should be described by their manifest
+	manifest, err := c.BackupNode(ctx, n, &archive)
+	if err != nil {
+		t.Fatalf("BackupNode() error = %v", err)
+	}
+	read, err := ipfs.ReadBackupManifest(bytes.NewReader(archive.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadBackupManifest() error = %v", err)
+	}
+	if read != manifest || manifest.PeerID != before.PeerID ||
+		manifest.SwarmKeyHash != ipfs.SwarmKeyHash([]byte("hello")) {
+		t.Errorf("unexpected manifest %+v", read)
+	}
+
+	// backups should only be restored to their own network
+	var other = &ipfs.NodeInfo{NetworkID: "other-network"}
+	if _, err := c.RestoreNode(ctx, other, bytes.NewReader(archive.Bytes())); err == nil {
+		t.Error("expected error restoring backup of another network")
+	}
+
+	// restored node should retain its identity after its assets are lost
+	c.StopNode(ctx, n)
+	c.RemoveNode(ctx, n.NetworkID)
+	var restored = &ipfs.NodeInfo{NetworkID: n.NetworkID}
+	if _, err := c.RestoreNode(ctx, restored, bytes.NewReader(archive.Bytes())); err != nil {
+		t.Fatalf("RestoreNode() error = %v", err)
+	}
+	if c.State(n.NetworkID) != StateRunning {
+		t.Error("expected restored node to be running")
+	}
+	if after, _ := c.NodeStats(ctx, restored); after.PeerID != before.PeerID || after.PeerKey != before.PeerKey {
+		t.Errorf("expected peer identity to be restored, got %+v", after)
+	}
+}
+
+func TestMemoryNodeClient_PauseNode(t *testing.T) {
+	var (
+		c   = NewMemoryNodeClient()
+		ctx = context.Background()
+		n   = &ipfs.NodeInfo{
+			NetworkID: "test-network",
+			Ports:     ipfs.NodePorts{Swarm: "4001", API: "5001", Gateway: "8001"},
+		}
+	)
+	if err := c.CreateNode(ctx, n, ipfs.NodeOpts{SwarmKey: []byte("hello")}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		op        func(context.Context, *ipfs.NodeInfo) error
+		wantErr   bool
+		wantState string
+	}{
+		{"resume running node", c.ResumeNode, true, StateRunning},
+		{"pause running node", c.PauseNode, false, StatePaused},
+		{"pause paused node", c.PauseNode, true, StatePaused},
+		{"resume paused node", c.ResumeNode, false, StateRunning},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.op(ctx, n); (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if state := c.State(n.NetworkID); state != tt.wantState {
+				t.Errorf("State() = %s, want %s", state, tt.wantState)
+			}
+		})
+	}
+
+	// paused nodes retain their ports
+	if err := c.PauseNode(ctx, n); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.CreateNode(ctx, &ipfs.NodeInfo{
+		NetworkID: "test-network-2",
+		Ports:     ipfs.NodePorts{Swarm: "4001"},
+	}, ipfs.NodeOpts{SwarmKey: []byte("hello")}); err == nil {
+		t.Error("expected port conflict")
+	}
+}
+
+func TestMemoryNodeClient_StreamStats(t *testing.T) {
+	var (
+		c           = NewMemoryNodeClient()
+		ctx, cancel = context.WithCancel(context.Background())
+		n           = &ipfs.NodeInfo{NetworkID: "test-network"}
+	)
+	defer cancel()
+	if _, err := c.StreamStats(ctx, n, time.Millisecond); err == nil {
+		t.Error("expected error for invalid node")
+	}
+	if err := c.CreateNode(ctx, n, ipfs.NodeOpts{SwarmKey: []byte("hello")}); err != nil {
+		t.Fatal(err)
+	}
+
+	samples, err := c.StreamStats(ctx, n, time.Millisecond)
+	if err != nil {
+		t.Fatalf("StreamStats() error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if s := <-samples; s.CPUPercent == 0 || s.MemoryLimit == 0 {
+			t.Errorf("unexpected sample %+v", s)
+		}
+	}
+
+	// stream should end when node stops
+	if err := c.StopNode(ctx, n); err != nil {
+		t.Fatal(err)
+	}
+	var done = time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-samples:
+			if !ok {
+				return
+			}
+		case <-done:
+			t.Fatal("expected stream to end after node stopped")
+		}
+	}
+}
+
+func TestMemoryNodeClient_NodeLogs(t *testing.T) {
+	var (
+		c           = NewMemoryNodeClient()
+		ctx, cancel = context.WithCancel(context.Background())
+		n           = &ipfs.NodeInfo{NetworkID: "test-network"}
+	)
+	defer cancel()
+	if _, err := c.NodeLogs(ctx, n, ipfs.LogOptions{}); err == nil {
+		t.Error("expected error for invalid node")
+	}
+	if err := c.CreateNode(ctx, n, ipfs.NodeOpts{SwarmKey: []byte("hello")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RestartNode(ctx, n); err != nil {
+		t.Fatal(err)
+	}
+
+	type args struct {
+		opts ipfs.LogOptions
+	}
+	tests := []struct {
+		name string
+		args args
+		want []string
+	}{
+		{"all output", args{ipfs.LogOptions{}},
+			[]string{"Daemon is ready", "Received interrupt signal, shutting down...", "Daemon is ready"}},
+		{"tail", args{ipfs.LogOptions{Tail: 1}}, []string{"Daemon is ready"}},
+		{"until", args{ipfs.LogOptions{Until: time.Now().Add(-time.Hour)}}, []string{}},
+		{"since", args{ipfs.LogOptions{Since: time.Now().Add(time.Hour)}}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := c.NodeLogs(ctx, n, tt.args.opts)
+			if err != nil {
+				t.Fatalf("NodeLogs() error = %v", err)
+			}
+			var got = make([]string, 0)
+			for l := range lines {
+				got = append(got, l.Text)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NodeLogs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// followed output should end when the node stops
+	lines, err := c.NodeLogs(ctx, n, ipfs.LogOptions{Tail: 1, Follow: true})
+	if err != nil {
+		t.Fatalf("NodeLogs() error = %v", err)
+	}
+	if l := <-lines; l.Text != "Daemon is ready" {
+		t.Errorf("unexpected output %+v", l)
+	}
+	if err := c.Crash(n.NetworkID); err != nil {
+		t.Fatal(err)
+	}
+	var done = time.After(time.Second)
+	select {
+	case l := <-lines:
+		if l.Text != "Error: node crashed" {
+			t.Errorf("unexpected output %+v", l)
+		}
+	case <-done:
+		t.Fatal("expected crash output to be followed")
+	}
+	select {
+	case _, ok := <-lines:
+		if ok {
+			t.Error("expected output to end after node stopped")
+		}
+	case <-done:
+		t.Fatal("expected output to end after node stopped")
+	}
+}
+
+func expectEvent(t *testing.T, events <-chan ipfs.Event, status, network string) {
+	t.Helper()
+	select {
+	case e := <-events:
+		if e.Status != status || e.Node.NetworkID != network {
+			t.Errorf("expected '%s' event for '%s', got %+v", status, network, e)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("timed out waiting for '%s' event for '%s'", status, network)
+	}
+}
@@ -12,6 +12,8 @@ const (
 	keyNetworkID = "network_id"
 	keyJobID     = "job_id"
 
+	keyVersion = "ipfs_version"
+
 	keyBootstrapPeers = "bootstrap_peers"
 	keyDataDir        = "data_dir"
 
@@ -42,6 +44,14 @@ type NodeInfo struct {
 	DataDir string `json:"data_dir"`
 	// BootstrapPeers lists the peers this node was bootstrapped onto upon init
 	BootstrapPeers []string `json:"bootstrap_peers"`
+	// Version is the go-ipfs version the node runs, either as an image tag or as
+	// an image digest of the form "sha256:<hex>". If unset at creation, the
+	// network's pinned version or the client's default version is used.
+	Version string `json:"version"`
+	// Image is the reference of the image the node's container runs, and
+	// ImageID is the ID of that image
+	Image   string `json:"image"`
+	ImageID string `json:"image_id"`
 }
 
 // NodePorts declares the exposed ports of an IPFS node
@@ -95,19 +105,27 @@ func newNode(id, name string, attributes map[string]string) (NodeInfo, error) {
 		ContainerName:  name,
 		DataDir:        attributes[keyDataDir],
 		BootstrapPeers: peers,
+		Version:        attributes[keyVersion],
 	}, nil
 }
 
-func (n *NodeInfo) withDefaults() {
-	if n.Resources.CPUs == 0 {
-		n.Resources.CPUs = 4
+// WithDefaults returns a copy of these resources with unset quotas replaced by
+// the defaults nodes are given at creation
+func (r NodeResources) WithDefaults() NodeResources {
+	if r.CPUs == 0 {
+		r.CPUs = 4
 	}
-	if n.Resources.DiskGB == 0 {
-		n.Resources.DiskGB = 100
+	if r.DiskGB == 0 {
+		r.DiskGB = 100
 	}
-	if n.Resources.MemoryGB == 0 {
-		n.Resources.MemoryGB = 4
+	if r.MemoryGB == 0 {
+		r.MemoryGB = 4
 	}
+	return r
+}
+
+func (n *NodeInfo) withDefaults() {
+	n.Resources = n.Resources.WithDefaults()
 
 	// set container name from network name
 	if n.ContainerName == "" {
@@ -126,6 +144,7 @@ func (n *NodeInfo) labels(peers []string, dataDir string) map[string]string {
 	return map[string]string{
 		keyNetworkID: n.NetworkID,
 		keyJobID:     n.JobID,
+		keyVersion:   n.Version,
 
 		keyPortSwarm:   n.Ports.Swarm,
 		keyPortAPI:     n.Ports.API,
@@ -145,8 +164,10 @@ func (n *NodeInfo) updateFromContainerDetails(c *types.Container) {
 		return
 	}
 
-	// check container ID
+	// check container ID and image
 	n.DockerID = c.ID
+	n.Image = c.Image
+	n.ImageID = c.ImageID
 
 	// check ports
 	if len(c.Ports) > 0 {
@@ -46,6 +46,10 @@ func Test_newNode(t *testing.T) {
 				MemoryGB: 4,
 			}},
 			false},
+		{"parse version",
+			args{"1", "ipfs-node1", map[string]string{keyVersion: "v0.4.19"}},
+			NodeInfo{DockerID: "1", ContainerName: "ipfs-node1", Version: "v0.4.19"},
+			false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -72,7 +76,9 @@ func TestNodeInfo_updateFromContainerDetails(t *testing.T) {
 	}{
 		{"nil container", args{nil}, NodeInfo{}},
 		{"with container", args{&types.Container{
-			ID: "abcde",
+			ID:      "abcde",
+			Image:   "ipfs/go-ipfs:v0.4.18",
+			ImageID: "sha256:fghij",
 			Ports: []types.Port{
 				{PrivatePort: 4001, PublicPort: 3456},
 				{PrivatePort: 5001, PublicPort: 2345},
@@ -80,6 +86,8 @@ func TestNodeInfo_updateFromContainerDetails(t *testing.T) {
 			},
 		}}, NodeInfo{
 			DockerID: "abcde",
+			Image:    "ipfs/go-ipfs:v0.4.18",
+			ImageID:  "sha256:fghij",
 			Ports: NodePorts{
 				Swarm:   "3456",
 				API:     "2345",
@@ -0,0 +1,136 @@
+package ipfs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/docker/docker/api/types"
+)
+
+const (
+	// readinessTimeout is the maximum duration to wait for a started node's API
+	// to respond
+	readinessTimeout = 2 * time.Minute
+	// livenessTimeout is the maximum duration to wait for a single probe of a
+	// node's API
+	livenessTimeout = 10 * time.Second
+	// readinessInterval is the interval between probes of a starting node
+	readinessInterval = 250 * time.Millisecond
+)
+
+var (
+	// ErrNodePaused is returned when a node cannot be probed because its
+	// container is paused
+	ErrNodePaused = errors.New("node is paused")
+	// ErrNodeStopped is returned when a node cannot be probed because its
+	// container has stopped
+	ErrNodeStopped = errors.New("node is not running")
+)
+
+// nodeIdentity is the response of the IPFS API's /api/v0/id endpoint
+type nodeIdentity struct {
+	ID           string `json:"ID"`
+	AgentVersion string `json:"AgentVersion"`
+}
+
+// ProbeNode checks that the given node's API is responsive. If it is not, the
+// node's container is inspected - ErrNodePaused or ErrNodeStopped is returned
+// if the container is paused or has stopped.
+func (c *Client) ProbeNode(ctx context.Context, n *NodeInfo) error {
+	if n == nil || n.DockerID == "" {
+		return errors.New("invalid node")
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, livenessTimeout)
+	_, err := c.probe(probeCtx, n)
+	cancel()
+	if err == nil {
+		return nil
+	}
+	if state, ierr := c.containerState(ctx, n.DockerID); ierr == nil {
+		if state.Paused {
+			return ErrNodePaused
+		}
+		if !state.Running {
+			return ErrNodeStopped
+		}
+	}
+	return fmt.Errorf("node API is not responding: %s", err.Error())
+}
+
+// waitForNode blocks until the given node's API responds. It returns an error
+// if the node's container stops, or if the node is not ready within
+// readinessTimeout.
+func (c *Client) waitForNode(ctx context.Context, n *NodeInfo) error {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	var ticker = time.NewTicker(readinessInterval)
+	defer ticker.Stop()
+	for {
+		probeCtx, cancelProbe := context.WithTimeout(ctx, livenessTimeout)
+		_, err := c.probe(probeCtx, n)
+		cancelProbe()
+		if err == nil {
+			return nil
+		}
+
+		// stop waiting if the daemon has exited
+		if state, ierr := c.containerState(ctx, n.DockerID); ierr == nil &&
+			!state.Running && !state.Restarting {
+			return fmt.Errorf("node exited during startup with code %d", state.ExitCode)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("node did not become ready: %s", err.Error())
+		case <-ticker.C:
+		}
+	}
+}
+
+// probe requests the identity of the given node from its API
+func (c *Client) probe(ctx context.Context, n *NodeInfo) (nodeIdentity, error) {
+	var id nodeIdentity
+	if n.Ports.API == "" {
+		return id, errors.New("node has no API port")
+	}
+
+	req, err := http.NewRequest(http.MethodPost,
+		"http://"+net.JoinHostPort(c.apiHost, n.Ports.API)+"/api/v0/id", nil)
+	if err != nil {
+		return id, err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return id, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return id, fmt.Errorf("unexpected response from node API: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&id); err != nil {
+		return id, fmt.Errorf("invalid response from node API: %s", err.Error())
+	}
+	if id.ID == "" {
+		return id, errors.New("node API did not report a peer ID")
+	}
+	return id, nil
+}
+
+// containerState retrieves the state of the given container
+func (c *Client) containerState(ctx context.Context, dockerID string) (types.ContainerState, error) {
+	info, err := c.d.ContainerInspect(ctx, dockerID)
+	if err != nil {
+		return types.ContainerState{}, err
+	}
+	if info.ContainerJSONBase == nil || info.State == nil {
+		return types.ContainerState{}, errors.New("container state unavailable")
+	}
+	return *info.State, nil
+}
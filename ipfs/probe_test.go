@@ -0,0 +1,59 @@
+package ipfs
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_client_probe(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		noPort  bool
+		wantID  string
+		wantErr bool
+	}{
+		{"ok", http.StatusOK, `{"ID":"QmPeer","AgentVersion":"go-ipfs/0.4.18/"}`, false, "QmPeer", false},
+		{"no api port", http.StatusOK, `{"ID":"QmPeer"}`, true, "", true},
+		{"error status", http.StatusInternalServerError, `{"Message":"oh no"}`, false, "", true},
+		{"invalid response", http.StatusOK, `asdf`, false, "", true},
+		{"no peer ID", http.StatusOK, `{}`, false, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/api/v0/id" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+			host, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+			var (
+				c = &Client{apiHost: host}
+				n = &NodeInfo{Ports: NodePorts{API: port}}
+			)
+			if tt.noPort {
+				n.Ports.API = ""
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			id, err := c.probe(ctx, n)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("client.probe() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if id.ID != tt.wantID {
+				t.Errorf("client.probe() = %v, want %v", id.ID, tt.wantID)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package ipfs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/network"
+	docker "github.com/docker/docker/client"
+)
+
+// emulatedAPIVersion is the Engine API version used to communicate with
+// emulated runtimes, which do not support version negotiation
+const emulatedAPIVersion = "1.38"
+
+// Runtime is the subset of the Docker Engine API that the node client uses to
+// manage node containers. It is implemented by the Docker client, which can be
+// connected to a real Docker daemon using ipfs.NewDockerRuntime(), or to an
+// emulated Engine API using ipfs.NewEmulatedRuntime()
+type Runtime interface {
+	ContainerList(ctx context.Context, options types.ContainerListOptions) ([]types.Container, error)
+	ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig,
+		networkingConfig *network.NetworkingConfig, containerName string) (container.ContainerCreateCreatedBody, error)
+	ContainerStart(ctx context.Context, containerID string, options types.ContainerStartOptions) error
+	ContainerStop(ctx context.Context, containerID string, timeout *time.Duration) error
+	ContainerRestart(ctx context.Context, containerID string, timeout *time.Duration) error
+	ContainerRemove(ctx context.Context, containerID string, options types.ContainerRemoveOptions) error
+	ContainerPause(ctx context.Context, containerID string) error
+	ContainerUnpause(ctx context.Context, containerID string) error
+	ContainerUpdate(ctx context.Context, containerID string, updateConfig container.UpdateConfig) (container.ContainerUpdateOKBody, error)
+	ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error)
+	ContainerStats(ctx context.Context, containerID string, stream bool) (types.ContainerStats, error)
+	ContainerLogs(ctx context.Context, containerID string, options types.ContainerLogsOptions) (io.ReadCloser, error)
+
+	ContainerExecCreate(ctx context.Context, containerID string, config types.ExecConfig) (types.IDResponse, error)
+	ContainerExecAttach(ctx context.Context, execID string, config types.ExecConfig) (types.HijackedResponse, error)
+	ContainerExecInspect(ctx context.Context, execID string) (types.ContainerExecInspect, error)
+
+	Events(ctx context.Context, options types.EventsOptions) (<-chan events.Message, <-chan error)
+
+	ImagePull(ctx context.Context, ref string, options types.ImagePullOptions) (io.ReadCloser, error)
+	ImageInspectWithRaw(ctx context.Context, imageID string) (types.ImageInspect, []byte, error)
+	ImageLoad(ctx context.Context, input io.Reader, quiet bool) (types.ImageLoadResponse, error)
+}
+
+// NewDockerRuntime creates a new Docker client from ENV values and negotiates
+// the correct API version to use
+func NewDockerRuntime() (Runtime, error) {
+	d, err := docker.NewEnvClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to dockerd: %s", err.Error())
+	}
+	d.NegotiateAPIVersion(context.Background())
+	return d, nil
+}
+
+// NewEmulatedRuntime creates a new Docker client that talks to an emulated
+// Engine API, such as the one provided by package ipfs/emulator, listening on
+// the given TCP address (for example "127.0.0.1:2375")
+func NewEmulatedRuntime(addr string) (Runtime, error) {
+	d, err := docker.NewClientWithOpts(
+		docker.WithHost("tcp://"+addr),
+		docker.WithVersion(emulatedAPIVersion))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to emulated runtime: %s", err.Error())
+	}
+	return d, nil
+}
@@ -0,0 +1,85 @@
+package ipfs
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"text/template"
+
+	internal "github.com/RTradeLtd/Nexus/ipfs/internal"
+)
+
+const (
+	// defaultGCWatermark is the percentage of a node's storage limit at which
+	// garbage collection is triggered
+	defaultGCWatermark = 90
+	// defaultInitProfile is the configuration profile new repos are
+	// initialized with
+	defaultInitProfile = "server"
+)
+
+// initProfilePattern matches comma-separated go-ipfs configuration profiles
+var initProfilePattern = regexp.MustCompile(`^[a-z0-9-]+(,[a-z0-9-]+)*$`)
+
+// startScript declares the parameters of a node's startup script
+type startScript struct {
+	// DiskMaxGB is the storage limit of the node's datastore
+	DiskMaxGB int
+	// GCWatermark is the percentage of the storage limit at which garbage
+	// collection is triggered
+	GCWatermark int
+	// InitProfile is the comma-separated list of configuration profiles that
+	// new repos are initialized with
+	InitProfile string
+}
+
+// newStartScript returns the startup script parameters for the given node,
+// using the client's settings or their defaults if they are unset
+func (c *Client) newStartScript(n *NodeInfo) startScript {
+	var s = startScript{
+		DiskMaxGB:   n.Resources.DiskGB,
+		GCWatermark: c.gcWatermark,
+		InitProfile: c.initProfile,
+	}
+	if s.GCWatermark == 0 {
+		s.GCWatermark = defaultGCWatermark
+	}
+	if s.InitProfile == "" {
+		s.InitProfile = defaultInitProfile
+	}
+	return s
+}
+
+// Validate checks that the parameters produce a working script
+func (s startScript) Validate() error {
+	if s.DiskMaxGB < 1 {
+		return fmt.Errorf("invalid disk max %dGB", s.DiskMaxGB)
+	}
+	if s.GCWatermark < 1 || s.GCWatermark > 100 {
+		return fmt.Errorf("invalid GC watermark %d - must be a percentage", s.GCWatermark)
+	}
+	if !initProfilePattern.MatchString(s.InitProfile) {
+		return fmt.Errorf("invalid init profile '%s'", s.InitProfile)
+	}
+	return nil
+}
+
+// Render validates the parameters and generates the script
+func (s startScript) Render() (string, error) {
+	if err := s.Validate(); err != nil {
+		return "", fmt.Errorf("failed to generate startup script: %s", err.Error())
+	}
+	f, err := internal.ReadFile("ipfs/internal/ipfs_start.sh")
+	if err != nil {
+		return "", fmt.Errorf("failed to generate startup script: %s", err.Error())
+	}
+	tmpl, err := template.New("ipfs_start.sh").Parse(string(f))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate startup script: %s", err.Error())
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, s); err != nil {
+		return "", fmt.Errorf("failed to generate startup script: %s", err.Error())
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,52 @@
+package ipfs
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func Test_startScript_Render(t *testing.T) {
+	var c = &Client{gcWatermark: 75, initProfile: "server,badgerds"}
+	tests := []struct {
+		name    string
+		script  startScript
+		wantErr bool
+	}{
+		{"defaults", (&Client{}).newStartScript(&NodeInfo{Resources: NodeResources{DiskGB: 10}}), false},
+		{"all parameters", c.newStartScript(&NodeInfo{Resources: NodeResources{DiskGB: 250}}), false},
+		{"no disk", startScript{GCWatermark: 90, InitProfile: "server"}, true},
+		{"invalid watermark", startScript{DiskMaxGB: 10, GCWatermark: 101, InitProfile: "server"}, true},
+		{"no profile", startScript{DiskMaxGB: 10, GCWatermark: 90}, true},
+		{"invalid profile", startScript{DiskMaxGB: 10, GCWatermark: 90, InitProfile: "server; rm -rf /"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.script.Render()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("startScript.Render() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			var golden = filepath.Join("testdata", "start_script_"+strings.Replace(tt.name, " ", "_", -1)+".golden")
+			if *update {
+				if err := ioutil.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("startScript.Render() = %s, want %s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package ipfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+
+	"github.com/RTradeLtd/Nexus/log"
+)
+
+// versionFile is the file in a node's data directory that pins the go-ipfs
+// version the node runs
+const versionFile = "ipfs_version"
+
+// pinnedVersion retrieves the go-ipfs version the given network is pinned to,
+// or the client's default version if it is not pinned
+func (c *Client) pinnedVersion(network string) string {
+	b, err := ioutil.ReadFile(filepath.Join(c.getDataDir(network), versionFile))
+	if err != nil {
+		return c.version
+	}
+	if v := strings.TrimSpace(string(b)); v != "" {
+		return v
+	}
+	return c.version
+}
+
+// pinVersion pins the given network to the given go-ipfs version. An empty
+// version removes the pin.
+func (c *Client) pinVersion(network, version string) error {
+	var path = filepath.Join(c.getDataDir(network), versionFile)
+	if version == "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return ioutil.WriteFile(path, []byte(version), c.fileMode)
+}
+
+func (c *Client) getSnapshotDir(network string) string {
+	p, _ := filepath.Abs(filepath.Join(c.dataDir, fmt.Sprintf("/data/snapshots/%s", network)))
+	return p
+}
+
+// UpgradeNode replaces the given node's container with one running the given
+// go-ipfs version, which migrates the node's repo. An empty version upgrades the
+// node to the client's default version. The repo is snapshotted beforehand, and
+// if the upgraded node does not become healthy with the same identity, the
+// snapshot is restored and the node is started with its previous version. The
+// network is pinned to the given version if the upgrade succeeds.
+func (c *Client) UpgradeNode(ctx context.Context, n *NodeInfo, version string) error {
+	if n == nil || n.DockerID == "" {
+		return errors.New("invalid node")
+	}
+	var target = version
+	if target == "" {
+		target = c.version
+	}
+	if n.Version == "" {
+		n.Version = c.pinnedVersion(n.NetworkID)
+	}
+
+	var (
+		start = time.Now()
+		l     = log.NewProcessLogger(c.l, "upgrade_node",
+			"network_id", n.NetworkID,
+			"docker_id", n.DockerID,
+			"version.current", n.Version,
+			"version.target", target)
+	)
+
+	// make sure the upgrade can proceed before stopping the node
+	if _, _, err := c.image(ctx, target); err != nil {
+		l.Errorw("failed to retrieve image", "error", err)
+		return fmt.Errorf("failed to retrieve image for version '%s': %s", target, err.Error())
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, livenessTimeout)
+	id, err := c.probe(probeCtx, n)
+	cancel()
+	if err != nil {
+		l.Errorw("node is not healthy", "error", err)
+		return fmt.Errorf("node must be healthy to be upgraded: %s", err.Error())
+	}
+
+	// stop node and snapshot its repo
+	var previous = *n
+	l.Info("stopping node")
+	if err := c.StopNode(ctx, n); err != nil {
+		l.Errorw("failed to stop node", "error", err)
+		return fmt.Errorf("failed to stop node: %s", err.Error())
+	}
+	l.Info("creating repo snapshot")
+	var snapshot = c.getSnapshotDir(n.NetworkID)
+	os.RemoveAll(snapshot)
+	if err := copyDir(c.getDataDir(n.NetworkID), snapshot); err != nil {
+		l.Errorw("failed to snapshot repo - restarting node", "error", err)
+		os.RemoveAll(snapshot)
+		if rerr := c.recreateNode(ctx, n, previous); rerr != nil {
+			return fmt.Errorf("failed to snapshot repo: %s, and failed to restart node: %s",
+				err.Error(), rerr.Error())
+		}
+		return fmt.Errorf("failed to snapshot repo: %s", err.Error())
+	}
+	defer os.RemoveAll(snapshot)
+
+	// start node with new version, which migrates its repo
+	var upgraded = previous
+	upgraded.Version = target
+	l.Info("starting upgraded node")
+	err = c.recreateNode(ctx, n, upgraded)
+	if err == nil {
+		err = c.verifyIdentity(ctx, n, id.ID)
+	}
+	if err == nil {
+		if err = c.pinVersion(n.NetworkID, version); err != nil {
+			err = fmt.Errorf("failed to pin version: %s", err.Error())
+		}
+	}
+	if err != nil {
+		l.Errorw("upgrade failed - rolling back", "error", err)
+		if rerr := c.rollbackUpgrade(ctx, n, previous, snapshot); rerr != nil {
+			l.Errorw("rollback failed", "error", rerr)
+			return fmt.Errorf("failed to upgrade node: %s, and failed to roll back: %s",
+				err.Error(), rerr.Error())
+		}
+		l.Infow("upgrade rolled back",
+			"upgrade.duration", time.Since(start))
+		return fmt.Errorf("failed to upgrade node - rolled back to version '%s': %s",
+			previous.Version, err.Error())
+	}
+
+	l.Infow("node upgraded",
+		"upgrade.duration", time.Since(start))
+	return nil
+}
+
+// recreateNode replaces the given node with a new container created from the
+// given configuration. Any existing container is removed.
+func (c *Client) recreateNode(ctx context.Context, n *NodeInfo, from NodeInfo) error {
+	c.d.ContainerRemove(ctx, toNodeContainerName(from.NetworkID),
+		types.ContainerRemoveOptions{Force: true})
+	*n = from
+	n.DockerID = ""
+	n.ContainerName = ""
+	return c.CreateNode(ctx, n, NodeOpts{})
+}
+
+// verifyIdentity checks that the given recreated node is healthy and has the
+// given identity
+func (c *Client) verifyIdentity(ctx context.Context, n *NodeInfo, peerID string) error {
+	probeCtx, cancel := context.WithTimeout(ctx, livenessTimeout)
+	id, err := c.probe(probeCtx, n)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("node is not healthy: %s", err.Error())
+	}
+	if id.ID != peerID {
+		return fmt.Errorf("node reported peer ID '%s', expected '%s'", id.ID, peerID)
+	}
+	c.l.Infow("recreated node is healthy",
+		"network_id", n.NetworkID,
+		"agent_version", id.AgentVersion)
+	return nil
+}
+
+// rollbackUpgrade restores the given repo snapshot and starts the node with its
+// previous configuration
+func (c *Client) rollbackUpgrade(ctx context.Context, n *NodeInfo, previous NodeInfo,
+	snapshot string) error {
+	c.d.ContainerRemove(ctx, toNodeContainerName(previous.NetworkID),
+		types.ContainerRemoveOptions{Force: true})
+	var dir = c.getDataDir(previous.NetworkID)
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to remove migrated repo: %s", err.Error())
+	}
+	if err := os.Rename(snapshot, dir); err != nil {
+		return fmt.Errorf("failed to restore repo snapshot: %s", err.Error())
+	}
+	return c.recreateNode(ctx, n, previous)
+}
+
+// copyDir recursively copies the contents of the given directory, preserving
+// file modes and symlinks
+func copyDir(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		var target = filepath.Join(dst, rel)
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			return copyFile(path, target, info.Mode().Perm())
+		}
+	})
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
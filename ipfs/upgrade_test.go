@@ -0,0 +1,74 @@
+package ipfs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_client_pinVersion(t *testing.T) {
+	var c = &Client{version: "v0.4.18", dataDir: "./tmp", fileMode: 0755}
+	var network = "test_pin_version"
+	os.MkdirAll(c.getDataDir(network), 0755)
+	defer os.RemoveAll(c.getDataDir(network))
+
+	if v := c.pinnedVersion(network); v != "v0.4.18" {
+		t.Errorf("expected default version, got %s", v)
+	}
+	if err := c.pinVersion(network, "v0.4.19"); err != nil {
+		t.Fatal(err)
+	}
+	if v := c.pinnedVersion(network); v != "v0.4.19" {
+		t.Errorf("expected pinned version, got %s", v)
+	}
+	if err := c.pinVersion(network, ""); err != nil {
+		t.Fatal(err)
+	}
+	if v := c.pinnedVersion(network); v != "v0.4.18" {
+		t.Errorf("expected pin to be removed, got %s", v)
+	}
+	if err := c.pinVersion(network, ""); err != nil {
+		t.Errorf("expected removing missing pin to succeed, got %v", err)
+	}
+}
+
+func Test_copyDir(t *testing.T) {
+	src, err := ioutil.TempDir("", "nexus-copy-src")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+	dst, err := ioutil.TempDir("", "nexus-copy-dst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dst)
+	dst = filepath.Join(dst, "snapshot")
+
+	os.MkdirAll(filepath.Join(src, "blocks", "CIQA"), 0700)
+	ioutil.WriteFile(filepath.Join(src, "config"), []byte("config"), 0600)
+	ioutil.WriteFile(filepath.Join(src, "blocks", "CIQA", "block.data"), []byte("block"), 0644)
+	os.Symlink("config", filepath.Join(src, "config.link"))
+
+	if err := copyDir(src, dst); err != nil {
+		t.Fatalf("copyDir() error = %v", err)
+	}
+	for path, want := range map[string]string{
+		"config":                 "config",
+		"blocks/CIQA/block.data": "block",
+		"config.link":            "config",
+	} {
+		if b, err := ioutil.ReadFile(filepath.Join(dst, path)); err != nil || string(b) != want {
+			t.Errorf("expected '%s' to contain '%s', got '%s' (%v)", path, want, b, err)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(dst, "config")); err != nil {
+		t.Error(err)
+	} else if info.Mode().Perm() != 0600 {
+		t.Errorf("expected file mode to be preserved, got %v", info.Mode())
+	}
+	if link, err := os.Readlink(filepath.Join(dst, "config.link")); err != nil || link != "config" {
+		t.Errorf("expected symlink to be preserved, got '%s' (%v)", link, err)
+	}
+}
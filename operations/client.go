@@ -0,0 +1,186 @@
+package operations
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// OperationsClient is the client API for the operations service
+type OperationsClient interface {
+	GetJob(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*JobStatusResponse, error)
+	RestartNetwork(ctx context.Context, in *NetworkRequest, opts ...grpc.CallOption) (*Empty, error)
+	PauseNetwork(ctx context.Context, in *NetworkRequest, opts ...grpc.CallOption) (*Empty, error)
+	ResumeNetwork(ctx context.Context, in *NetworkRequest, opts ...grpc.CallOption) (*Empty, error)
+	StreamNetworkStats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (StatsStreamClient, error)
+	NetworkLogs(ctx context.Context, in *LogsRequest, opts ...grpc.CallOption) (LogsStreamClient, error)
+	UpgradeNetwork(ctx context.Context, in *UpgradeRequest, opts ...grpc.CallOption) (*Empty, error)
+	BackupNetwork(ctx context.Context, in *NetworkRequest, opts ...grpc.CallOption) (*BackupResponse, error)
+	RestoreNetwork(ctx context.Context, in *RestoreRequest, opts ...grpc.CallOption) (*Empty, error)
+	RotateSwarmKey(ctx context.Context, in *NetworkRequest, opts ...grpc.CallOption) (*SwarmKeyResponse, error)
+	AddBootstrapPeers(ctx context.Context, in *BootstrapPeersRequest, opts ...grpc.CallOption) (*BootstrapPeersResponse, error)
+	RemoveBootstrapPeers(ctx context.Context, in *BootstrapPeersRequest, opts ...grpc.CallOption) (*BootstrapPeersResponse, error)
+}
+
+// StatsStreamClient is the client side of a StreamNetworkStats stream
+type StatsStreamClient interface {
+	Recv() (*StatsSample, error)
+	grpc.ClientStream
+}
+
+// LogsStreamClient is the client side of a NetworkLogs stream
+type LogsStreamClient interface {
+	Recv() (*LogLine, error)
+	grpc.ClientStream
+}
+
+type operationsClient struct {
+	cc *grpc.ClientConn
+}
+
+// NewOperationsClient creates a client for the operations service on the given
+// connection
+func NewOperationsClient(cc *grpc.ClientConn) OperationsClient {
+	return &operationsClient{cc}
+}
+
+func (c *operationsClient) GetJob(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*JobStatusResponse, error) {
+	var out = new(JobStatusResponse)
+	if err := c.invoke(ctx, "GetJob", in, out, opts); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *operationsClient) RestartNetwork(ctx context.Context, in *NetworkRequest, opts ...grpc.CallOption) (*Empty, error) {
+	var out = new(Empty)
+	if err := c.invoke(ctx, "RestartNetwork", in, out, opts); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *operationsClient) PauseNetwork(ctx context.Context, in *NetworkRequest, opts ...grpc.CallOption) (*Empty, error) {
+	var out = new(Empty)
+	if err := c.invoke(ctx, "PauseNetwork", in, out, opts); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *operationsClient) ResumeNetwork(ctx context.Context, in *NetworkRequest, opts ...grpc.CallOption) (*Empty, error) {
+	var out = new(Empty)
+	if err := c.invoke(ctx, "ResumeNetwork", in, out, opts); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *operationsClient) StreamNetworkStats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (StatsStreamClient, error) {
+	stream, err := c.stream(ctx, &statsStreamDesc, in, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &statsStreamClient{stream}, nil
+}
+
+func (c *operationsClient) NetworkLogs(ctx context.Context, in *LogsRequest, opts ...grpc.CallOption) (LogsStreamClient, error) {
+	stream, err := c.stream(ctx, &logsStreamDesc, in, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &logsStreamClient{stream}, nil
+}
+
+func (c *operationsClient) UpgradeNetwork(ctx context.Context, in *UpgradeRequest, opts ...grpc.CallOption) (*Empty, error) {
+	var out = new(Empty)
+	if err := c.invoke(ctx, "UpgradeNetwork", in, out, opts); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *operationsClient) BackupNetwork(ctx context.Context, in *NetworkRequest, opts ...grpc.CallOption) (*BackupResponse, error) {
+	var out = new(BackupResponse)
+	if err := c.invoke(ctx, "BackupNetwork", in, out, opts); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *operationsClient) RestoreNetwork(ctx context.Context, in *RestoreRequest, opts ...grpc.CallOption) (*Empty, error) {
+	var out = new(Empty)
+	if err := c.invoke(ctx, "RestoreNetwork", in, out, opts); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *operationsClient) RotateSwarmKey(ctx context.Context, in *NetworkRequest, opts ...grpc.CallOption) (*SwarmKeyResponse, error) {
+	var out = new(SwarmKeyResponse)
+	if err := c.invoke(ctx, "RotateSwarmKey", in, out, opts); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *operationsClient) AddBootstrapPeers(ctx context.Context, in *BootstrapPeersRequest, opts ...grpc.CallOption) (*BootstrapPeersResponse, error) {
+	var out = new(BootstrapPeersResponse)
+	if err := c.invoke(ctx, "AddBootstrapPeers", in, out, opts); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *operationsClient) RemoveBootstrapPeers(ctx context.Context, in *BootstrapPeersRequest, opts ...grpc.CallOption) (*BootstrapPeersResponse, error) {
+	var out = new(BootstrapPeersResponse)
+	if err := c.invoke(ctx, "RemoveBootstrapPeers", in, out, opts); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// invoke calls the given unary method
+func (c *operationsClient) invoke(ctx context.Context, method string, in, out interface{}, opts []grpc.CallOption) error {
+	return c.cc.Invoke(ctx, "/"+ServiceName+"/"+method, in, out, callOptions(opts)...)
+}
+
+// stream opens the given server streaming method and sends the request
+func (c *operationsClient) stream(ctx context.Context, desc *grpc.StreamDesc, in interface{}, opts []grpc.CallOption) (grpc.ClientStream, error) {
+	stream, err := c.cc.NewStream(ctx, desc, "/"+ServiceName+"/"+desc.StreamName, callOptions(opts)...)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+type statsStreamClient struct{ grpc.ClientStream }
+
+func (s *statsStreamClient) Recv() (*StatsSample, error) {
+	var m = new(StatsSample)
+	if err := s.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+type logsStreamClient struct{ grpc.ClientStream }
+
+func (s *logsStreamClient) Recv() (*LogLine, error) {
+	var m = new(LogLine)
+	if err := s.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// callOptions selects the operations service's codec ahead of the given options
+func callOptions(opts []grpc.CallOption) []grpc.CallOption {
+	return append([]grpc.CallOption{grpc.CallContentSubtype(Codec)}, opts...)
+}
@@ -0,0 +1,23 @@
+package operations
+
+import (
+	"encoding/json"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// Codec is the name of the gRPC codec used by the operations service, which
+// encodes messages as JSON. Clients created with NewOperationsClient request it
+// for all calls.
+const Codec = "json"
+
+func init() { encoding.RegisterCodec(jsonCodec{}) }
+
+// jsonCodec implements encoding.Codec using encoding/json
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+func (jsonCodec) Name() string { return Codec }
@@ -0,0 +1,38 @@
+package operations
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc/encoding"
+)
+
+func TestCodec(t *testing.T) {
+	var codec = encoding.GetCodec(Codec)
+	if codec == nil {
+		t.Fatalf("expected codec '%s' to be registered", Codec)
+	}
+
+	var req = &JobStatusResponse{
+		JobID:    "asdf",
+		Network:  "bobheadxi",
+		State:    "succeeded",
+		Result:   []byte(`{"peer_id":"1234"}`),
+		QueuedAt: 1547078400,
+	}
+	b, err := codec.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got JobStatusResponse
+	if err := codec.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(req, &got) {
+		t.Errorf("expected %+v, got %+v", req, got)
+	}
+
+	if err := codec.Unmarshal([]byte("not json"), &got); err == nil {
+		t.Error("expected error for invalid message")
+	}
+}
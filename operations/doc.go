@@ -0,0 +1,5 @@
+// Package operations provides the Nexus daemon's operations service, a gRPC
+// service that complements the nexus service defined in RTradeLtd/grpc with
+// network operations that it does not provide. Messages are encoded as JSON,
+// so the service does not require generated protobuf types.
+package operations
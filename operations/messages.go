@@ -0,0 +1,109 @@
+package operations
+
+// Empty is a message without content
+type Empty struct{}
+
+// NetworkRequest identifies the network an operation applies to
+type NetworkRequest struct {
+	Network string `json:"network"`
+}
+
+// JobRequest identifies a job
+type JobRequest struct {
+	JobID string `json:"job_id"`
+}
+
+// JobStatusResponse describes the status of a job. Times are Unix timestamps,
+// and are 0 if unset.
+type JobStatusResponse struct {
+	JobID     string `json:"job_id"`
+	Network   string `json:"network"`
+	Operation string `json:"operation"`
+	State     string `json:"state"`
+	Error     string `json:"error,omitempty"`
+	// Result is the JSON-encoded result of the job, if it provides one
+	Result []byte `json:"result,omitempty"`
+
+	QueuedAt   int64 `json:"queued_at"`
+	StartedAt  int64 `json:"started_at"`
+	FinishedAt int64 `json:"finished_at"`
+}
+
+// StatsRequest requests resource usage samples of a network's node
+type StatsRequest struct {
+	Network         string `json:"network"`
+	IntervalSeconds int64  `json:"interval_seconds"`
+}
+
+// StatsSample is a resource usage sample of a network's node. Network and
+// block I/O are cumulative since the node's container started.
+type StatsSample struct {
+	Network string `json:"network"`
+	Time    int64  `json:"time"`
+
+	CPUPercent    float64 `json:"cpu_percent"`
+	OnlineCPUs    uint32  `json:"online_cpus"`
+	MemoryUsage   uint64  `json:"memory_usage"`
+	MemoryLimit   uint64  `json:"memory_limit"`
+	MemoryPercent float64 `json:"memory_percent"`
+
+	NetworkRxBytes  uint64 `json:"network_rx_bytes"`
+	NetworkTxBytes  uint64 `json:"network_tx_bytes"`
+	BlockReadBytes  uint64 `json:"block_read_bytes"`
+	BlockWriteBytes uint64 `json:"block_write_bytes"`
+
+	PIDs uint64 `json:"pids"`
+}
+
+// LogsRequest requests output of a network's node. Since and Until are Unix
+// timestamps, and Tail is a number of lines - each is ignored if 0.
+type LogsRequest struct {
+	Network string `json:"network"`
+	Since   int64  `json:"since"`
+	Until   int64  `json:"until"`
+	Tail    int64  `json:"tail"`
+	Follow  bool   `json:"follow"`
+}
+
+// LogLine is a line of output of a network's node
+type LogLine struct {
+	Network string `json:"network"`
+	Time    int64  `json:"time"`
+	Text    string `json:"text"`
+}
+
+// UpgradeRequest requests that a network's node run the given go-ipfs version.
+// An empty version denotes the default version.
+type UpgradeRequest struct {
+	Network string `json:"network"`
+	Version string `json:"version"`
+}
+
+// BackupResponse identifies a backup archive. Manifest is the archive's
+// JSON-encoded manifest.
+type BackupResponse struct {
+	Archive  string `json:"archive"`
+	Manifest []byte `json:"manifest"`
+}
+
+// RestoreRequest requests that a network's node be rebuilt from an archive
+type RestoreRequest struct {
+	Network string `json:"network"`
+	Archive string `json:"archive"`
+}
+
+// SwarmKeyResponse provides a network's swarm key
+type SwarmKeyResponse struct {
+	SwarmKey string `json:"swarm_key"`
+}
+
+// BootstrapPeersRequest requests a change to a network's bootstrap peers
+type BootstrapPeersRequest struct {
+	Network string   `json:"network"`
+	Peers   []string `json:"peers"`
+}
+
+// BootstrapPeersResponse provides a network's bootstrap peers
+type BootstrapPeersResponse struct {
+	Peers []string `json:"peers"`
+}
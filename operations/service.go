@@ -0,0 +1,177 @@
+package operations
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// ServiceName is the name under which the operations service is registered
+const ServiceName = "nexus.Operations"
+
+// JobIDHeader is the response header that provides the ID of the job queued by
+// a request. The job can be polled using the operations service's GetJob.
+const JobIDHeader = "job_id"
+
+// OperationsServer is the server API for the operations service
+type OperationsServer interface {
+	// GetJob retrieves the status of a job
+	GetJob(context.Context, *JobRequest) (*JobStatusResponse, error)
+	// RestartNetwork queues a job to restart a network's node
+	RestartNetwork(context.Context, *NetworkRequest) (*Empty, error)
+	// PauseNetwork suspends a network's node
+	PauseNetwork(context.Context, *NetworkRequest) (*Empty, error)
+	// ResumeNetwork resumes a network's paused node
+	ResumeNetwork(context.Context, *NetworkRequest) (*Empty, error)
+	// StreamNetworkStats streams resource usage samples of a network's node
+	StreamNetworkStats(*StatsRequest, StatsStreamServer) error
+	// NetworkLogs streams output of a network's node
+	NetworkLogs(*LogsRequest, LogsStreamServer) error
+	// UpgradeNetwork moves a network's node to a go-ipfs version
+	UpgradeNetwork(context.Context, *UpgradeRequest) (*Empty, error)
+	// BackupNetwork archives a network's node data
+	BackupNetwork(context.Context, *NetworkRequest) (*BackupResponse, error)
+	// RestoreNetwork rebuilds a network's node from an archive
+	RestoreNetwork(context.Context, *RestoreRequest) (*Empty, error)
+	// RotateSwarmKey replaces a network's swarm key
+	RotateSwarmKey(context.Context, *NetworkRequest) (*SwarmKeyResponse, error)
+	// AddBootstrapPeers adds bootstrap peers to a network's node
+	AddBootstrapPeers(context.Context, *BootstrapPeersRequest) (*BootstrapPeersResponse, error)
+	// RemoveBootstrapPeers removes bootstrap peers from a network's node
+	RemoveBootstrapPeers(context.Context, *BootstrapPeersRequest) (*BootstrapPeersResponse, error)
+}
+
+// StatsStreamServer is the server side of a StreamNetworkStats stream
+type StatsStreamServer interface {
+	Send(*StatsSample) error
+	grpc.ServerStream
+}
+
+// LogsStreamServer is the server side of a NetworkLogs stream
+type LogsStreamServer interface {
+	Send(*LogLine) error
+	grpc.ServerStream
+}
+
+// RegisterOperationsServer registers the given implementation of the
+// operations service with the given gRPC server
+func RegisterOperationsServer(s *grpc.Server, srv OperationsServer) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*OperationsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		unaryMethod("GetJob", func() interface{} { return new(JobRequest) },
+			func(srv OperationsServer, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.GetJob(ctx, req.(*JobRequest))
+			}),
+		unaryMethod("RestartNetwork", func() interface{} { return new(NetworkRequest) },
+			func(srv OperationsServer, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.RestartNetwork(ctx, req.(*NetworkRequest))
+			}),
+		unaryMethod("PauseNetwork", func() interface{} { return new(NetworkRequest) },
+			func(srv OperationsServer, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.PauseNetwork(ctx, req.(*NetworkRequest))
+			}),
+		unaryMethod("ResumeNetwork", func() interface{} { return new(NetworkRequest) },
+			func(srv OperationsServer, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.ResumeNetwork(ctx, req.(*NetworkRequest))
+			}),
+		unaryMethod("UpgradeNetwork", func() interface{} { return new(UpgradeRequest) },
+			func(srv OperationsServer, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.UpgradeNetwork(ctx, req.(*UpgradeRequest))
+			}),
+		unaryMethod("BackupNetwork", func() interface{} { return new(NetworkRequest) },
+			func(srv OperationsServer, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.BackupNetwork(ctx, req.(*NetworkRequest))
+			}),
+		unaryMethod("RestoreNetwork", func() interface{} { return new(RestoreRequest) },
+			func(srv OperationsServer, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.RestoreNetwork(ctx, req.(*RestoreRequest))
+			}),
+		unaryMethod("RotateSwarmKey", func() interface{} { return new(NetworkRequest) },
+			func(srv OperationsServer, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.RotateSwarmKey(ctx, req.(*NetworkRequest))
+			}),
+		unaryMethod("AddBootstrapPeers", func() interface{} { return new(BootstrapPeersRequest) },
+			func(srv OperationsServer, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.AddBootstrapPeers(ctx, req.(*BootstrapPeersRequest))
+			}),
+		unaryMethod("RemoveBootstrapPeers", func() interface{} { return new(BootstrapPeersRequest) },
+			func(srv OperationsServer, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.RemoveBootstrapPeers(ctx, req.(*BootstrapPeersRequest))
+			}),
+	},
+	Streams: []grpc.StreamDesc{
+		statsStreamDesc,
+		logsStreamDesc,
+	},
+}
+
+var statsStreamDesc = grpc.StreamDesc{
+	StreamName:    "StreamNetworkStats",
+	ServerStreams: true,
+	Handler: func(srv interface{}, stream grpc.ServerStream) error {
+		var req StatsRequest
+		if err := stream.RecvMsg(&req); err != nil {
+			return err
+		}
+		return srv.(OperationsServer).StreamNetworkStats(&req, &statsStreamServer{stream})
+	},
+}
+
+type statsStreamServer struct{ grpc.ServerStream }
+
+func (s *statsStreamServer) Send(m *StatsSample) error { return s.SendMsg(m) }
+
+var logsStreamDesc = grpc.StreamDesc{
+	StreamName:    "NetworkLogs",
+	ServerStreams: true,
+	Handler: func(srv interface{}, stream grpc.ServerStream) error {
+		var req LogsRequest
+		if err := stream.RecvMsg(&req); err != nil {
+			return err
+		}
+		return srv.(OperationsServer).NetworkLogs(&req, &logsStreamServer{stream})
+	},
+}
+
+type logsStreamServer struct{ grpc.ServerStream }
+
+func (s *logsStreamServer) Send(m *LogLine) error { return s.SendMsg(m) }
+
+// unaryMethod creates the handler of a unary method, which decodes the request
+// into the message allocated by newRequest and calls the server with it
+func unaryMethod(
+	name string,
+	newRequest func() interface{},
+	call func(srv OperationsServer, ctx context.Context, req interface{}) (interface{}, error),
+) grpc.MethodDesc {
+	var fullMethod = "/" + ServiceName + "/" + name
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(
+			srv interface{},
+			ctx context.Context,
+			dec func(interface{}) error,
+			interceptor grpc.UnaryServerInterceptor,
+		) (interface{}, error) {
+			var req = newRequest()
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			var handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return call(srv.(OperationsServer), ctx, req)
+			}
+			if interceptor == nil {
+				return handler(ctx, req)
+			}
+			return interceptor(ctx, req, &grpc.UnaryServerInfo{
+				Server:     srv,
+				FullMethod: fullMethod,
+			}, handler)
+		},
+	}
+}
@@ -0,0 +1,29 @@
+package operations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestServiceDesc(t *testing.T) {
+	var handled = make(map[string]bool)
+	for _, m := range serviceDesc.Methods {
+		handled[m.MethodName] = true
+	}
+	for _, s := range serviceDesc.Streams {
+		if !s.ServerStreams || s.ClientStreams {
+			t.Errorf("expected %s to be a server stream", s.StreamName)
+		}
+		handled[s.StreamName] = true
+	}
+
+	var server = reflect.TypeOf((*OperationsServer)(nil)).Elem()
+	for i := 0; i < server.NumMethod(); i++ {
+		if name := server.Method(i).Name; !handled[name] {
+			t.Errorf("no handler for %s", name)
+		}
+	}
+	if len(handled) != server.NumMethod() {
+		t.Errorf("expected %d handlers, got %d", server.NumMethod(), len(handled))
+	}
+}
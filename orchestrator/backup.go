@@ -0,0 +1,211 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/RTradeLtd/Nexus/ipfs"
+	"github.com/RTradeLtd/Nexus/log"
+)
+
+// backupTimeFormat is the format of timestamps in backup archive names
+const backupTimeFormat = "20060102T150405Z"
+
+// NetworkBackup describes a backup of a network's node
+type NetworkBackup struct {
+	// Archive is the name of the backup archive in the orchestrator's backup
+	// directory
+	Archive  string
+	Manifest ipfs.BackupManifest
+}
+
+// NetworkBackup archives the given network's node data into the orchestrator's
+// backup directory. Running nodes are paused while they are backed up. Networks
+// that are offline can be backed up as long as their assets exist.
+func (o *Orchestrator) NetworkBackup(ctx context.Context, network string) (NetworkBackup, error) {
+	if network == "" {
+		return NetworkBackup{}, errors.New("invalid network name provided")
+	}
+
+	unlock, err := o.locks.tryLock(network)
+	if err != nil {
+		return NetworkBackup{}, err
+	}
+	defer unlock()
+
+	var start = time.Now()
+	var l = log.NewProcessLogger(o.l, "network_backup",
+		"job_id", generateID(),
+		"network", network)
+	l.Info("network backup process started")
+
+	// hibernated and offline nodes have no running container to pause
+	node, err := o.Registry.Get(network)
+	if err == nil && o.Registry.Hibernated(network) {
+		node.DockerID = ""
+	} else if err != nil {
+		var crashLooping bool
+		if node, crashLooping = o.crashLoopingNode(network); !crashLooping {
+			exists, err := o.client.NodeAssetsExist(ctx, network)
+			if err != nil {
+				return NetworkBackup{}, fmt.Errorf("failed to check assets for network '%s': %s",
+					network, err.Error())
+			}
+			if !exists {
+				return NetworkBackup{}, fmt.Errorf("no assets found for network '%s'", network)
+			}
+			node = ipfs.NodeInfo{NetworkID: network}
+		}
+	}
+
+	// write archive to a temporary file so that incomplete backups are never
+	// mistaken for complete ones
+	if err := os.MkdirAll(o.backupDir, 0700); err != nil {
+		l.Errorw("failed to create backup directory", "error", err)
+		return NetworkBackup{}, fmt.Errorf("failed to create backup directory: %s", err.Error())
+	}
+	var (
+		archive = fmt.Sprintf("%s-%s.tar.gz", network, start.UTC().Format(backupTimeFormat))
+		path    = filepath.Join(o.backupDir, archive)
+		tmp     = path + ".tmp"
+	)
+	l = l.With("node", node, "archive", archive)
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		l.Errorw("failed to create backup archive", "error", err)
+		return NetworkBackup{}, fmt.Errorf("failed to create backup archive: %s", err.Error())
+	}
+	manifest, err := o.client.BackupNode(ctx, &node, f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		l.Errorw("failed to back up node", "error", err)
+		return NetworkBackup{}, fmt.Errorf("failed to back up network '%s': %s", network, err.Error())
+	}
+
+	l.Infow("network backup process completed",
+		"peer_id", manifest.PeerID,
+		"network_backup.duration", time.Since(start))
+	return NetworkBackup{Archive: archive, Manifest: manifest}, nil
+}
+
+// NetworkRestore rebuilds the given network's node from the given archive in
+// the orchestrator's backup directory. The archive must be a backup of the
+// network, and its swarm key must match the network's. Online nodes are
+// replaced, and offline networks are brought online with the restored node.
+func (o *Orchestrator) NetworkRestore(ctx context.Context, network, archive string) error {
+	if network == "" {
+		return errors.New("invalid network name provided")
+	}
+	if archive == "" || archive != filepath.Base(archive) || strings.HasPrefix(archive, ".") {
+		return fmt.Errorf("invalid backup archive '%s'", archive)
+	}
+
+	unlock, err := o.locks.tryLock(network)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	var (
+		start = time.Now()
+		jobID = generateID()
+		l     = log.NewProcessLogger(o.l, "network_restore",
+			"job_id", jobID,
+			"network", network,
+			"archive", archive)
+	)
+	l.Info("network restore process started")
+
+	// check that the archive belongs to the network
+	f, err := os.Open(filepath.Join(o.backupDir, archive))
+	if err != nil {
+		return fmt.Errorf("failed to open backup archive: %s", err.Error())
+	}
+	defer f.Close()
+	manifest, err := ipfs.ReadBackupManifest(f)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		return fmt.Errorf("failed to read backup archive: %s", err.Error())
+	}
+	if manifest.NetworkID != network {
+		return fmt.Errorf("archive '%s' is a backup of network '%s'", archive, manifest.NetworkID)
+	}
+	n, err := o.nm.GetNetworkByName(network)
+	if err != nil {
+		l.Infow("failed to fetch network from database",
+			"error", err)
+		return fmt.Errorf("no network with name '%s' found", network)
+	}
+	if n.SwarmKey == "" || ipfs.SwarmKeyHash([]byte(n.SwarmKey)) != manifest.SwarmKeyHash {
+		l.Warnw("backup swarm key does not match network")
+		return fmt.Errorf("archive '%s' does not match the swarm key of network '%s'", archive, network)
+	}
+
+	// restore online nodes in place, otherwise allocate resources for the node
+	node, err := o.Registry.Get(network)
+	var registered = err == nil
+	if registered && o.Registry.Hibernated(network) {
+		return fmt.Errorf("network '%s' is hibernated", network)
+	}
+	if !registered {
+		if _, crashLooping := o.crashLoopingNode(network); crashLooping {
+			return fmt.Errorf("network '%s' is crash-looping - it must be stopped or restarted first", network)
+		}
+		node = *getNodeFromDatabaseEntry(jobID, n)
+		if err := o.Registry.Register(&node); err != nil {
+			l.Errorw("failed to allocate resources for node", "error", err)
+			return fmt.Errorf("failed to allocate resources for network '%s': %s", network, err.Error())
+		}
+	}
+
+	l = l.With("node", node, "peer_id", manifest.PeerID)
+	l.Info("restoring node")
+	if _, err := o.client.RestoreNode(ctx, &node, f); err != nil {
+		l.Errorw("failed to restore node", "error", err)
+		if registered {
+			if uerr := o.Registry.Update(&node); uerr != nil {
+				l.Errorw("failed to update registry", "error", uerr)
+			}
+		} else {
+			o.Registry.Deregister(network)
+		}
+		return fmt.Errorf("failed to restore network '%s': %s", network, err.Error())
+	}
+	if err := o.Registry.Update(&node); err != nil {
+		l.Errorw("failed to update registry", "error", err)
+	}
+
+	// the restored node may have a different identity than the previous one
+	var attrs = map[string]interface{}{
+		"activated":  time.Now(),
+		"swarm_addr": o.swarmAddr(node.Ports.Swarm),
+	}
+	if s, err := o.client.NodeStats(ctx, &node); err != nil {
+		l.Errorw("failed to get node stats after restore", "error", err)
+	} else {
+		attrs["peer_key"] = s.PeerKey
+		attrs["swarm_addr"] = o.bootstrapAddr(node.Ports.Swarm, s.PeerID)
+	}
+	if err := o.nm.UpdateNetworkByName(network, attrs); err != nil {
+		l.Errorw("failed to update network in database", "error", err)
+		return fmt.Errorf("failed to update network '%s': %s", network, err.Error())
+	}
+	o.clearRestarts(network)
+
+	l.Infow("network restore process completed",
+		"network_restore.duration", time.Since(start))
+	return nil
+}
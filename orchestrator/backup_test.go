@@ -0,0 +1,120 @@
+package orchestrator
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/RTradeLtd/database/models"
+
+	"github.com/RTradeLtd/Nexus/config"
+	"github.com/RTradeLtd/Nexus/ipfs"
+	"github.com/RTradeLtd/Nexus/ipfs/mock"
+	"github.com/RTradeLtd/Nexus/log"
+	"github.com/RTradeLtd/Nexus/registry"
+	tmock "github.com/RTradeLtd/Nexus/temporal/mock"
+)
+
+func TestOrchestrator_NetworkBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nexus-backups")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	l, _ := log.NewTestLogger()
+	var (
+		ctx      = context.Background()
+		client   = mock.NewMemoryNodeClient()
+		networks = &tmock.FakePrivateNetworks{}
+		o        = &Orchestrator{
+			Registry:  registry.New(l, config.New().Ports),
+			l:         l,
+			nm:        networks,
+			client:    client,
+			address:   "127.0.0.1",
+			backupDir: dir,
+		}
+		swarmKey = testSwarmKey
+	)
+	defer o.Registry.Close()
+	networks.GetNetworkByNameStub = func(name string) (*models.HostedIPFSPrivateNetwork, error) {
+		return &models.HostedIPFSPrivateNetwork{Name: name, SwarmKey: swarmKey}, nil
+	}
+	if _, err := o.NetworkBackup(ctx, "bobheadxi"); err == nil {
+		t.Error("expected error backing up network without assets")
+	}
+	if _, err := o.NetworkUp(ctx, "bobheadxi"); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := o.Registry.Get("bobheadxi")
+
+	// backups should be written to the backup directory
+	b, err := o.NetworkBackup(ctx, "bobheadxi")
+	if err != nil {
+		t.Fatalf("Orchestrator.NetworkBackup() error = %v", err)
+	}
+	if b.Manifest.NetworkID != "bobheadxi" || b.Manifest.PeerID == "" {
+		t.Errorf("unexpected manifest %+v", b.Manifest)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 1 ||
+		filepath.Base(files[0]) != b.Archive {
+		t.Errorf("expected only archive '%s' in backup directory, got %v", b.Archive, files)
+	}
+	if client.State("bobheadxi") != mock.StateRunning {
+		t.Error("expected node to be running after backup")
+	}
+
+	// invalid restores
+	for _, archive := range []string{"", "../" + b.Archive, ".hidden", "missing.tar.gz"} {
+		if err := o.NetworkRestore(ctx, "bobheadxi", archive); err == nil {
+			t.Errorf("expected error restoring archive '%s'", archive)
+		}
+	}
+	if err := o.NetworkRestore(ctx, "postables", b.Archive); err == nil {
+		t.Error("expected error restoring backup of another network")
+	}
+	if swarmKey, err = ipfs.SwarmKey(); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.NetworkRestore(ctx, "bobheadxi", b.Archive); err == nil {
+		t.Error("expected error restoring backup with different swarm key")
+	}
+	swarmKey = testSwarmKey
+
+	// online nodes should be restored in place
+	if err := o.NetworkRestore(ctx, "bobheadxi", b.Archive); err != nil {
+		t.Fatalf("Orchestrator.NetworkRestore() error = %v", err)
+	}
+	after, err := o.Registry.Get("bobheadxi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.DockerID == before.DockerID || after.Ports != before.Ports {
+		t.Errorf("expected node %+v to be replaced in place, got %+v", before, after)
+	}
+
+	// offline networks should be brought online after their assets are lost
+	if err := o.NetworkDown(ctx, "bobheadxi"); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.NetworkRemove(ctx, "bobheadxi"); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.NetworkRestore(ctx, "bobheadxi", b.Archive); err != nil {
+		t.Fatalf("Orchestrator.NetworkRestore() error = %v", err)
+	}
+	restored, err := o.Registry.Get("bobheadxi")
+	if err != nil {
+		t.Fatalf("expected restored node to be registered: %v", err)
+	}
+	if s, _ := client.NodeStats(ctx, &restored); s.PeerID != b.Manifest.PeerID {
+		t.Errorf("expected peer ID '%s' to be restored, got '%s'", b.Manifest.PeerID, s.PeerID)
+	}
+	var _, attrs = networks.UpdateNetworkByNameArgsForCall(networks.UpdateNetworkByNameCallCount() - 1)
+	if attrs["peer_key"] == "" || attrs["swarm_addr"] == "" {
+		t.Errorf("expected network to be marked as active, got %v", attrs)
+	}
+}
@@ -0,0 +1,130 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/RTradeLtd/Nexus/ipfs"
+	"github.com/RTradeLtd/Nexus/log"
+)
+
+// AddBootstrapPeers adds the given peers to the bootstrap list of the given
+// network's node, recreating its container, and saves the network's updated
+// bootstrap peers, which are returned. If the peers cannot be saved, the change
+// is reverted.
+func (o *Orchestrator) AddBootstrapPeers(ctx context.Context, network string, peers []string) ([]string, error) {
+	return o.changeBootstrapPeers(ctx, network, peers, true)
+}
+
+// RemoveBootstrapPeers removes the given peers from the bootstrap list of the
+// given network's node, recreating its container, and saves the network's
+// updated bootstrap peers, which are returned. If the peers cannot be saved, the
+// change is reverted.
+func (o *Orchestrator) RemoveBootstrapPeers(ctx context.Context, network string, peers []string) ([]string, error) {
+	return o.changeBootstrapPeers(ctx, network, peers, false)
+}
+
+func (o *Orchestrator) changeBootstrapPeers(ctx context.Context, network string, peers []string,
+	add bool) ([]string, error) {
+	if network == "" {
+		return nil, errors.New("invalid network name provided")
+	}
+	if len(peers) == 0 {
+		return nil, errors.New("no bootstrap peers provided")
+	}
+	if _, err := ipfs.ParseBootstrapPeers(peers); err != nil {
+		return nil, err
+	}
+
+	unlock, err := o.locks.tryLock(network)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var (
+		process        = "bootstrap_peers_add"
+		change, revert = o.client.AddBootstrapPeers, o.client.RemoveBootstrapPeers
+	)
+	if !add {
+		process = "bootstrap_peers_remove"
+		change, revert = revert, change
+	}
+	var start = time.Now()
+	var l = log.NewProcessLogger(o.l, process,
+		"job_id", generateID(),
+		"network", network,
+		"peers", peers)
+	l.Info("bootstrap peers update process started")
+
+	node, err := o.Registry.Get(network)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find node for network '%s': %s", network, err.Error())
+	}
+	if o.Registry.Hibernated(network) {
+		return nil, fmt.Errorf("network '%s' is hibernated", network)
+	}
+	n, err := o.nm.GetNetworkByName(network)
+	if err != nil {
+		l.Infow("failed to fetch network from database",
+			"error", err)
+		return nil, fmt.Errorf("no network with name '%s' found", network)
+	}
+
+	l = l.With("node", node)
+	var previous = append([]string{}, node.BootstrapPeers...)
+	if err := change(ctx, &node, peers); err != nil {
+		l.Errorw("failed to update bootstrap peers", "error", err)
+		return nil, fmt.Errorf("failed to update bootstrap peers for network '%s': %s", network, err.Error())
+	}
+	if err := o.Registry.Update(&node); err != nil {
+		l.Errorw("failed to update registry", "error", err)
+	}
+
+	// redeployed nodes are bootstrapped with the peers in the database, so the
+	// node must not be left with peers that are not saved
+	n.BootstrapPeerAddresses = node.BootstrapPeers
+	if err := o.nm.SaveNetwork(n); err != nil {
+		l.Errorw("failed to save bootstrap peers - reverting change",
+			"error", err)
+		var changed = missingPeers(node.BootstrapPeers, previous)
+		if !add {
+			changed = missingPeers(previous, node.BootstrapPeers)
+		}
+		if len(changed) > 0 {
+			if rerr := revert(ctx, &node, changed); rerr != nil {
+				l.Errorw("failed to revert bootstrap peers", "error", rerr)
+				return nil, fmt.Errorf("failed to save bootstrap peers for network '%s': %s, and failed to revert change: %s",
+					network, err.Error(), rerr.Error())
+			}
+			if uerr := o.Registry.Update(&node); uerr != nil {
+				l.Errorw("failed to update registry", "error", uerr)
+			}
+		}
+		return nil, fmt.Errorf("failed to save bootstrap peers for network '%s': %s", network, err.Error())
+	}
+
+	l.Infow("bootstrap peers update process completed",
+		"bootstrap_peers", node.BootstrapPeers,
+		"bootstrap_peers_update.duration", time.Since(start))
+	return node.BootstrapPeers, nil
+}
+
+// missingPeers returns the peers in a that are not in b
+func missingPeers(a, b []string) []string {
+	var (
+		missing = make([]string, 0)
+		found   = make(map[string]bool)
+	)
+	for _, p := range b {
+		found[p] = true
+	}
+	for _, p := range a {
+		if !found[p] {
+			missing = append(missing, p)
+		}
+	}
+	return missing
+}
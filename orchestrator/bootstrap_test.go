@@ -0,0 +1,116 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/RTradeLtd/database/models"
+
+	"github.com/RTradeLtd/Nexus/config"
+	"github.com/RTradeLtd/Nexus/ipfs/mock"
+	"github.com/RTradeLtd/Nexus/log"
+	"github.com/RTradeLtd/Nexus/registry"
+	tmock "github.com/RTradeLtd/Nexus/temporal/mock"
+)
+
+func TestOrchestrator_BootstrapPeers(t *testing.T) {
+	l, _ := log.NewTestLogger()
+	var (
+		ctx      = context.Background()
+		client   = mock.NewMemoryNodeClient()
+		networks = &tmock.FakePrivateNetworks{}
+		o        = &Orchestrator{
+			Registry: registry.New(l, config.New().Ports),
+			l:        l,
+			nm:       networks,
+			client:   client,
+			address:  "127.0.0.1",
+		}
+		peerA, _ = newTestIdentity()
+		peerB, _ = newTestIdentity()
+		initial  = "/ip4/1.2.3.4/tcp/4001/ipfs/" + peerA
+		added    = "/ip4/5.6.7.8/tcp/4001/ipfs/" + peerB
+		saved    = []string{initial}
+		saveErr  error
+	)
+	defer o.Registry.Close()
+	networks.GetNetworkByNameStub = func(name string) (*models.HostedIPFSPrivateNetwork, error) {
+		return &models.HostedIPFSPrivateNetwork{
+			Name:                   name,
+			SwarmKey:               testSwarmKey,
+			BootstrapPeerAddresses: append([]string{}, saved...),
+		}, nil
+	}
+	networks.SaveNetworkStub = func(n *models.HostedIPFSPrivateNetwork) error {
+		if saveErr != nil {
+			return saveErr
+		}
+		saved = append([]string{}, n.BootstrapPeerAddresses...)
+		return nil
+	}
+
+	// invalid requests
+	if _, err := o.AddBootstrapPeers(ctx, "", []string{added}); err == nil {
+		t.Error("expected error for empty network")
+	}
+	if _, err := o.AddBootstrapPeers(ctx, "bobheadxi", nil); err == nil {
+		t.Error("expected error for no peers")
+	}
+	if _, err := o.AddBootstrapPeers(ctx, "bobheadxi", []string{"5.6.7.8:4001"}); err == nil {
+		t.Error("expected error for invalid peer")
+	}
+	if _, err := o.AddBootstrapPeers(ctx, "bobheadxi", []string{added}); err == nil {
+		t.Error("expected error for network without node")
+	}
+
+	if _, err := o.NetworkUp(ctx, "bobheadxi"); err != nil {
+		t.Fatal(err)
+	}
+
+	// peers should be added to running node, registry and database
+	peers, err := o.AddBootstrapPeers(ctx, "bobheadxi", []string{added})
+	if err != nil {
+		t.Fatalf("Orchestrator.AddBootstrapPeers() error = %v", err)
+	}
+	var want = []string{initial, added}
+	if !reflect.DeepEqual(peers, want) || !reflect.DeepEqual(saved, want) {
+		t.Errorf("expected peers %v, got %v (saved %v)", want, peers, saved)
+	}
+	if node, _ := o.Registry.Get("bobheadxi"); !reflect.DeepEqual(node.BootstrapPeers, want) {
+		t.Errorf("expected registered peers %v, got %v", want, node.BootstrapPeers)
+	}
+	nodes, err := client.Nodes(ctx)
+	if err != nil || len(nodes) != 1 || !reflect.DeepEqual(nodes[0].BootstrapPeers, want) {
+		t.Errorf("expected node with peers %v, got %+v (%v)", want, nodes, err)
+	}
+
+	// failure to save should revert change
+	saveErr = errors.New("oh no")
+	if _, err := o.RemoveBootstrapPeers(ctx, "bobheadxi", []string{initial}); err == nil {
+		t.Error("expected error saving peers")
+	}
+	if node, _ := o.Registry.Get("bobheadxi"); len(node.BootstrapPeers) != 2 {
+		t.Errorf("expected change to be reverted, got %v", node.BootstrapPeers)
+	}
+	saveErr = nil
+
+	// peers should be removed
+	if peers, err = o.RemoveBootstrapPeers(ctx, "bobheadxi", []string{initial}); err != nil {
+		t.Fatalf("Orchestrator.RemoveBootstrapPeers() error = %v", err)
+	}
+	want = []string{added}
+	if !reflect.DeepEqual(peers, want) || !reflect.DeepEqual(saved, want) {
+		t.Errorf("expected peers %v, got %v (saved %v)", want, peers, saved)
+	}
+
+	// failed changes should be reported
+	client.Fail(mock.OpAddBootstrapPeers, "bobheadxi", errors.New("oh no"))
+	if _, err := o.AddBootstrapPeers(ctx, "bobheadxi", []string{initial}); err == nil {
+		t.Error("expected error for failed change")
+	}
+	if !reflect.DeepEqual(saved, want) {
+		t.Errorf("expected saved peers to be unchanged, got %v", saved)
+	}
+}
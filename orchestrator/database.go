@@ -26,9 +26,14 @@ func getOptionsFromDatabaseEntry(network *models.HostedIPFSPrivateNetwork) (ipfs
 		return opts, errors.New("invalid network entry")
 	}
 
-	// set swarm key
+	// set swarm key - existing keys are validated and normalized, so that the
+	// node never starts with a malformed key
 	if network.SwarmKey != "" {
-		opts.SwarmKey = []byte(network.SwarmKey)
+		key, err := ipfs.ParseSwarmKey([]byte(network.SwarmKey))
+		if err != nil {
+			return opts, err
+		}
+		opts.SwarmKey = key.Bytes()
 	} else {
 		key, err := ipfs.SwarmKey()
 		if err != nil {
@@ -37,5 +42,18 @@ func getOptionsFromDatabaseEntry(network *models.HostedIPFSPrivateNetwork) (ipfs
 		opts.SwarmKey = []byte(key)
 	}
 
+	// set peer key, so that redeployed nodes keep their identity
+	if network.PeerKey != "" {
+		if _, err := ipfs.PeerIDFromKey(network.PeerKey); err != nil {
+			return ipfs.NodeOpts{}, err
+		}
+		opts.PeerKey = network.PeerKey
+	}
+
+	// reject bootstrap peers before any resources are allocated for the node
+	if _, err := ipfs.ParseBootstrapPeers(network.BootstrapPeerAddresses); err != nil {
+		return ipfs.NodeOpts{}, err
+	}
+
 	return opts, nil
 }
@@ -2,6 +2,7 @@ package orchestrator
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/RTradeLtd/Nexus/ipfs"
@@ -9,6 +10,7 @@ import (
 	tcfg "github.com/RTradeLtd/config"
 	"github.com/RTradeLtd/database"
 	"github.com/RTradeLtd/database/models"
+	crypto "github.com/libp2p/go-libp2p-crypto"
 )
 
 var dbDefaults = tcfg.Database{
@@ -19,6 +21,28 @@ var dbDefaults = tcfg.Database{
 	Password: "password123",
 }
 
+// testSwarmKey is a valid swarm key in its normalized form
+var testSwarmKey = "/key/swarm/psk/1.0.0/\n/base16/\n" + strings.Repeat("ab", 32)
+
+// testPeerKey is a valid peer key, and testPeerID is its peer ID
+var testPeerID, testPeerKey = newTestIdentity()
+
+func newTestIdentity() (peerID, privKey string) {
+	sk, _, err := crypto.GenerateKeyPair(crypto.Ed25519, 0)
+	if err != nil {
+		panic(err)
+	}
+	b, err := crypto.MarshalPrivateKey(sk)
+	if err != nil {
+		panic(err)
+	}
+	privKey = crypto.ConfigEncodeKey(b)
+	if peerID, err = ipfs.PeerIDFromKey(privKey); err != nil {
+		panic(err)
+	}
+	return peerID, privKey
+}
+
 func newTestDB() (*database.Manager, error) {
 	return database.Initialize(&tcfg.TemporalConfig{
 		Database: dbDefaults,
@@ -40,13 +64,42 @@ func Test_getOptionsFromDatabaseEntry(t *testing.T) {
 	}{
 		{"invalid network", args{nil}, ipfs.NodeOpts{}, true},
 		{"with swarm key", args{&models.HostedIPFSPrivateNetwork{
-			SwarmKey: "helloworld",
+			SwarmKey: testSwarmKey,
 		}}, ipfs.NodeOpts{
-			SwarmKey: []byte("helloworld"),
+			SwarmKey: []byte(testSwarmKey),
 		}, false},
+		{"with swarm key to normalize", args{&models.HostedIPFSPrivateNetwork{
+			SwarmKey: "/key/swarm/psk/1.0.0/\n/base64/\n" + strings.Repeat("q6ur", 10) + "q6s=\n",
+		}}, ipfs.NodeOpts{
+			SwarmKey: []byte(testSwarmKey),
+		}, false},
+		{"with invalid swarm key", args{&models.HostedIPFSPrivateNetwork{
+			SwarmKey: "helloworld",
+		}}, ipfs.NodeOpts{}, true},
 		{"without swarm key", args{&models.HostedIPFSPrivateNetwork{}}, ipfs.NodeOpts{
 			SwarmKey: []byte("generated"),
 		}, false},
+		{"with peer key", args{&models.HostedIPFSPrivateNetwork{
+			SwarmKey: testSwarmKey,
+			PeerKey:  testPeerKey,
+		}}, ipfs.NodeOpts{
+			SwarmKey: []byte(testSwarmKey),
+			PeerKey:  testPeerKey,
+		}, false},
+		{"with invalid peer key", args{&models.HostedIPFSPrivateNetwork{
+			SwarmKey: testSwarmKey,
+			PeerKey:  "helloworld",
+		}}, ipfs.NodeOpts{}, true},
+		{"with bootstrap peers", args{&models.HostedIPFSPrivateNetwork{
+			SwarmKey:               testSwarmKey,
+			BootstrapPeerAddresses: []string{"/ip4/1.2.3.4/tcp/4001/ipfs/" + testPeerID},
+		}}, ipfs.NodeOpts{
+			SwarmKey: []byte(testSwarmKey),
+		}, false},
+		{"with invalid bootstrap peers", args{&models.HostedIPFSPrivateNetwork{
+			SwarmKey:               testSwarmKey,
+			BootstrapPeerAddresses: []string{"1.2.3.4:4001"},
+		}}, ipfs.NodeOpts{}, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,96 @@
+package orchestrator
+
+import (
+	"context"
+	"time"
+
+	"github.com/RTradeLtd/Nexus/ipfs"
+)
+
+const (
+	eventDie   = "die"
+	eventStart = "start"
+)
+
+// watchEvents consumes node events and updates orchestrator state accordingly
+// until the given context is cancelled
+func (o *Orchestrator) watchEvents(ctx context.Context) {
+	events, errs := o.client.Watch(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err, ok := <-errs:
+			if !ok {
+				// watcher has shut down
+				errs = nil
+				continue
+			}
+			o.l.Warnw("error encountered watching node events", "error", err)
+		case e, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			o.handleEvent(ctx, e)
+		}
+	}
+}
+
+// handleEvent updates the registry and database based on the given event.
+// Events for networks with operations in progress are ignored, since they are
+// expected results of those operations.
+func (o *Orchestrator) handleEvent(ctx context.Context, e ipfs.Event) {
+	var network = e.Node.NetworkID
+	if network == "" {
+		return
+	}
+	var l = o.l.With("event", e)
+	unlock, err := o.locks.tryLock(network)
+	if err != nil {
+		l.Debugw("ignoring event for network with operation in progress")
+		return
+	}
+	defer unlock()
+
+	switch e.Status {
+	case eventDie:
+		// node died unexpectedly - hibernated nodes are stopped deliberately, and
+		// their events may arrive after hibernation completes. The network is
+		// inactive until the restart policy brings the node back, but the node
+		// keeps its registration and ports so that the same container can be
+		// restarted, and is only deregistered once it is declared crash-looping.
+		if _, err := o.Registry.Get(network); err != nil || o.Registry.Hibernated(network) {
+			return
+		}
+		l.Warnw("node died unexpectedly - marking node as unhealthy and network as inactive")
+		if _, err := o.Registry.RecordProbe(network, ipfs.ErrNodeStopped); err != nil {
+			l.Errorw("failed to mark node as unhealthy", "error", err)
+		}
+		if err := o.nm.UpdateNetworkByName(network, map[string]interface{}{
+			"activated":  time.Time{},
+			"swarm_addr": "",
+		}); err != nil {
+			l.Errorw("failed to mark network as inactive", "error", err)
+		}
+
+	case eventStart:
+		// node was started outside an orchestrator operation, for example by an
+		// operator using the container runtime directly
+		if _, err := o.Registry.Get(network); err == nil {
+			return
+		}
+		var node = e.Node
+		l.Infow("node started - registering network")
+		if err := o.Registry.Adopt(&node); err != nil {
+			l.Errorw("failed to register node", "error", err)
+			return
+		}
+		if err := o.nm.UpdateNetworkByName(network, map[string]interface{}{
+			"activated":  time.Now(),
+			"swarm_addr": o.nodeBootstrapAddr(ctx, l, &node),
+		}); err != nil {
+			l.Errorw("failed to mark network as active", "error", err)
+		}
+	}
+}
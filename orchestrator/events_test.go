@@ -0,0 +1,170 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/RTradeLtd/Nexus/config"
+	"github.com/RTradeLtd/Nexus/ipfs"
+	"github.com/RTradeLtd/Nexus/ipfs/mock"
+	"github.com/RTradeLtd/Nexus/log"
+	"github.com/RTradeLtd/Nexus/registry"
+	tmock "github.com/RTradeLtd/Nexus/temporal/mock"
+)
+
+func TestOrchestrator_handleEvent(t *testing.T) {
+	var node = ipfs.NodeInfo{
+		NetworkID: "test-network",
+		Ports:     ipfs.NodePorts{Swarm: "4001", API: "5001", Gateway: "8080"},
+	}
+	type fields struct {
+		registered bool
+		hibernated bool
+		inProgress bool
+	}
+	type args struct {
+		e ipfs.Event
+	}
+	tests := []struct {
+		name           string
+		fields         fields
+		args           args
+		wantRegistered bool
+		wantDBUpdate   bool
+	}{
+		{"unknown network",
+			fields{false, false, false}, args{ipfs.Event{Status: "die"}}, false, false},
+		{"registered node died",
+			fields{true, false, false}, args{ipfs.Event{Status: "die", Node: node}}, true, true},
+		{"unregistered node died",
+			fields{false, false, false}, args{ipfs.Event{Status: "die", Node: node}}, false, false},
+		{"hibernated node died",
+			fields{true, true, false}, args{ipfs.Event{Status: "die", Node: node}}, true, false},
+		{"node died during operation",
+			fields{true, false, true}, args{ipfs.Event{Status: "die", Node: node}}, true, false},
+		{"unregistered node started",
+			fields{false, false, false}, args{ipfs.Event{Status: "start", Node: node}}, true, true},
+		{"registered node started",
+			fields{true, false, false}, args{ipfs.Event{Status: "start", Node: node}}, true, false},
+		{"node started during operation",
+			fields{false, false, true}, args{ipfs.Event{Status: "start", Node: node}}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _ := log.NewTestLogger()
+			var (
+				networks = &tmock.FakePrivateNetworks{}
+				reg      = registry.New(l, config.New().Ports)
+				o        = &Orchestrator{
+					Registry: reg,
+					l:        l,
+					nm:       networks,
+					client:   &mock.FakeNodeClient{},
+					address:  "127.0.0.1",
+				}
+			)
+			defer reg.Close()
+			if tt.fields.registered {
+				var n = node
+				reg.Register(&n)
+			}
+			if tt.fields.hibernated {
+				reg.Hibernate(node.NetworkID)
+			}
+			if tt.fields.inProgress {
+				unlock, err := o.locks.tryLock(node.NetworkID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer unlock()
+			}
+
+			o.handleEvent(context.Background(), tt.args.e)
+
+			if _, err := reg.Get(node.NetworkID); (err == nil) != tt.wantRegistered {
+				t.Errorf("expected registered = %v, got error %v", tt.wantRegistered, err)
+			}
+			if tt.args.e.Status == "die" && tt.wantRegistered && !tt.fields.hibernated &&
+				!tt.fields.inProgress {
+				if h, _ := reg.Health(node.NetworkID); h.Healthy {
+					t.Error("expected dead node to be marked unhealthy")
+				}
+			}
+			if (networks.UpdateNetworkByNameCallCount() > 0) != tt.wantDBUpdate {
+				t.Errorf("expected database update = %v, got %d calls",
+					tt.wantDBUpdate, networks.UpdateNetworkByNameCallCount())
+			}
+			if tt.wantDBUpdate && tt.args.e.Status == "die" {
+				if _, attrs := networks.UpdateNetworkByNameArgsForCall(0); attrs["swarm_addr"] != "" {
+					t.Errorf("expected network to be marked inactive, got %v", attrs)
+				}
+			}
+		})
+	}
+}
+
+func TestOrchestrator_watchEvents(t *testing.T) {
+	l, _ := log.NewTestLogger()
+	var (
+		client   = mock.NewMemoryNodeClient()
+		networks = &tmock.FakePrivateNetworks{}
+		node     = &ipfs.NodeInfo{
+			NetworkID: "test-network",
+			Ports:     ipfs.NodePorts{Swarm: "4001", API: "5001", Gateway: "8080"},
+		}
+	)
+	if err := client.CreateNode(context.Background(), node, ipfs.NodeOpts{
+		SwarmKey: []byte(testSwarmKey),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	o, err := New(l, "127.0.0.1", config.IPFS{
+		Ports:         config.New().Ports,
+		DataDirectory: "./tmp",
+	}, true, client, networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o.reconcileInterval = 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	o.Run(ctx)
+	time.Sleep(10 * time.Millisecond)
+
+	// crashed node should keep its registration, and be marked unhealthy and
+	// its network inactive
+	if err := client.Crash(node.NetworkID); err != nil {
+		t.Fatal(err)
+	}
+	var deadline = time.Now().Add(time.Second)
+	for networks.UpdateNetworkByNameCallCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if networks.UpdateNetworkByNameCallCount() != 1 {
+		t.Fatalf("expected network to be marked inactive")
+	}
+	network, attrs := networks.UpdateNetworkByNameArgsForCall(0)
+	if network != node.NetworkID || attrs["swarm_addr"] != "" {
+		t.Errorf("unexpected database update for '%s': %v", network, attrs)
+	}
+	health, err := o.Registry.Health(node.NetworkID)
+	if err != nil {
+		t.Fatalf("expected node to remain registered: %v", err)
+	}
+	if health.Healthy || health.LastError != ipfs.ErrNodeStopped.Error() {
+		t.Errorf("expected node to be marked unhealthy, got %+v", health)
+	}
+
+	// ports of the crashed node should remain reserved
+	var other = &ipfs.NodeInfo{NetworkID: "other-network"}
+	if err := o.Registry.Register(other); err != nil {
+		t.Fatal(err)
+	}
+	if other.Ports.Swarm == node.Ports.Swarm || other.Ports.API == node.Ports.API ||
+		other.Ports.Gateway == node.Ports.Gateway {
+		t.Errorf("expected ports of crashed node to remain reserved, got %+v", other.Ports)
+	}
+}
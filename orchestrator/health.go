@@ -0,0 +1,82 @@
+package orchestrator
+
+import (
+	"context"
+	"time"
+
+	"github.com/RTradeLtd/Nexus/ipfs"
+)
+
+const (
+	// defaultLivenessInterval is the default interval between liveness checks
+	defaultLivenessInterval = 30 * time.Second
+	// livenessFailureThreshold is the number of consecutive failed liveness
+	// probes after which a node is restarted
+	livenessFailureThreshold = 3
+)
+
+// runLivenessChecks restarts stopped nodes on startup, and then periodically
+// probes all nodes and restarts stopped ones until the given context is
+// cancelled
+func (o *Orchestrator) runLivenessChecks(ctx context.Context, interval time.Duration) {
+	var ticker = time.NewTicker(interval)
+	defer ticker.Stop()
+	o.healStopped(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			o.checkLiveness(ctx)
+			o.healStopped(ctx)
+		}
+	}
+}
+
+// checkLiveness probes all registered nodes and records the results in the
+// registry. Nodes that have stopped, or that fail livenessFailureThreshold
+// consecutive probes, are restarted according to the restart policy. Paused and
+// hibernated nodes are not probed. The restarted networks are returned.
+func (o *Orchestrator) checkLiveness(ctx context.Context) []string {
+	var restarted = make([]string, 0)
+	for _, n := range o.Registry.List() {
+		var network = n.NetworkID
+		if o.Registry.Hibernated(network) {
+			continue
+		}
+		var probeErr = o.client.ProbeNode(ctx, &n)
+		if probeErr == ipfs.ErrNodePaused {
+			continue
+		}
+		health, err := o.Registry.RecordProbe(network, probeErr)
+		if err != nil {
+			// node was deregistered while it was being probed
+			continue
+		}
+		if probeErr == nil {
+			o.resetRestarts(network)
+			continue
+		}
+
+		var l = o.l.With("network", network, "health", health)
+		l.Warnw("node failed liveness probe", "error", probeErr)
+		if probeErr != ipfs.ErrNodeStopped &&
+			health.ConsecutiveFailures < livenessFailureThreshold {
+			continue
+		}
+		ok, err := o.restartNode(ctx, &n, probeErr.Error())
+		if err != nil {
+			if err != ErrOperationInProgress {
+				l.Errorw("failed to restart unhealthy node", "error", err)
+			}
+			continue
+		}
+		if !ok {
+			l.Debugw("unhealthy node restart is backing off or node is crash-looping")
+			continue
+		}
+		o.Registry.RecordProbe(network, nil)
+		restarted = append(restarted, network)
+	}
+	return restarted
+}
@@ -0,0 +1,106 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/RTradeLtd/database/models"
+
+	"github.com/RTradeLtd/Nexus/config"
+	"github.com/RTradeLtd/Nexus/ipfs/mock"
+	"github.com/RTradeLtd/Nexus/log"
+	"github.com/RTradeLtd/Nexus/registry"
+	tmock "github.com/RTradeLtd/Nexus/temporal/mock"
+)
+
+func TestOrchestrator_checkLiveness(t *testing.T) {
+	l, _ := log.NewTestLogger()
+	var (
+		ctx      = context.Background()
+		client   = mock.NewMemoryNodeClient()
+		networks = &tmock.FakePrivateNetworks{}
+		o        = &Orchestrator{
+			Registry: registry.New(l, config.New().Ports),
+			l:        l,
+			nm:       networks,
+			client:   client,
+			address:  "127.0.0.1",
+			restart: restartPolicy{
+				minBackoff:  time.Minute,
+				maxBackoff:  10 * time.Minute,
+				maxRestarts: 5,
+				window:      time.Hour,
+			},
+		}
+	)
+	defer o.Registry.Close()
+	networks.GetNetworkByNameStub = func(name string) (*models.HostedIPFSPrivateNetwork, error) {
+		return &models.HostedIPFSPrivateNetwork{Name: name, SwarmKey: testSwarmKey}, nil
+	}
+	for _, network := range []string{"bobheadxi", "postables"} {
+		if _, err := o.NetworkUp(ctx, network); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := o.NetworkPause(ctx, "postables"); err != nil {
+		t.Fatal(err)
+	}
+
+	// probes of bobheadxi fail until it is restarted
+	var unresponsive = errors.New("node API is not responding")
+	for i := 0; i < livenessFailureThreshold; i++ {
+		client.Fail(mock.OpProbeNode, "bobheadxi", unresponsive)
+	}
+	for i := 1; i < livenessFailureThreshold; i++ {
+		if restarted := o.checkLiveness(ctx); len(restarted) != 0 {
+			t.Errorf("expected no restarts before threshold, got %v", restarted)
+		}
+		h, _ := o.Registry.Health("bobheadxi")
+		if h.Healthy || h.ConsecutiveFailures != i || h.LastError != unresponsive.Error() {
+			t.Errorf("expected node to be unhealthy, got %+v", h)
+		}
+	}
+	if restarted := o.checkLiveness(ctx); !reflect.DeepEqual(restarted, []string{"bobheadxi"}) {
+		t.Errorf("expected unhealthy node to be restarted, got %v", restarted)
+	}
+	if h, _ := o.Registry.Health("bobheadxi"); !h.Healthy {
+		t.Errorf("expected restarted node to be healthy, got %+v", h)
+	}
+
+	// paused nodes should not be probed
+	if h, _ := o.Registry.Health("postables"); !h.Healthy || !h.LastProbe.IsZero() {
+		t.Errorf("expected paused node to be skipped, got %+v", h)
+	}
+
+	// restarts should back off
+	for i := 0; i < livenessFailureThreshold; i++ {
+		client.Fail(mock.OpProbeNode, "bobheadxi", unresponsive)
+		if restarted := o.checkLiveness(ctx); len(restarted) != 0 {
+			t.Errorf("expected restart to back off, got %v", restarted)
+		}
+	}
+	if h, _ := o.Registry.Health("bobheadxi"); h.Healthy {
+		t.Errorf("expected node to remain unhealthy while backing off, got %+v", h)
+	}
+
+	// restart history should be reset once the node stays healthy
+	o.restarts["bobheadxi"].next = time.Now().Add(-time.Second)
+	o.checkLiveness(ctx)
+	if _, found := o.restarts["bobheadxi"]; found {
+		t.Error("expected restart history to be reset")
+	}
+
+	// stopped nodes should be restarted without waiting for the threshold
+	if err := client.Crash("bobheadxi"); err != nil {
+		t.Fatal(err)
+	}
+	if restarted := o.checkLiveness(ctx); !reflect.DeepEqual(restarted, []string{"bobheadxi"}) {
+		t.Errorf("expected stopped node to be restarted, got %v", restarted)
+	}
+	if client.State("bobheadxi") != mock.StateRunning {
+		t.Error("expected stopped node to be running")
+	}
+}
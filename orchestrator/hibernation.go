@@ -0,0 +1,339 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/RTradeLtd/Nexus/config"
+	"github.com/RTradeLtd/Nexus/ipfs"
+	"github.com/RTradeLtd/Nexus/log"
+	"github.com/RTradeLtd/Nexus/registry"
+)
+
+const (
+	// hibernationInterval is the interval between checks for idle nodes
+	hibernationInterval = time.Minute
+	// wakeTimeout is the maximum duration allowed for starting a hibernated
+	// node, regardless of how long callers are willing to wait
+	wakeTimeout = 5 * time.Minute
+)
+
+// idleTimeouts denotes how long nodes can be idle before they are hibernated.
+// A zero timeout disables hibernation.
+type idleTimeouts struct {
+	fallback time.Duration
+	networks map[string]time.Duration
+}
+
+func parseIdleTimeouts(cfg config.Hibernation) (idleTimeouts, error) {
+	var (
+		t   = idleTimeouts{networks: make(map[string]time.Duration)}
+		err error
+	)
+	if cfg.IdleTimeout != "" {
+		if t.fallback, err = time.ParseDuration(cfg.IdleTimeout); err != nil {
+			return t, fmt.Errorf("invalid idle timeout '%s': %s", cfg.IdleTimeout, err.Error())
+		}
+	}
+	for network, timeout := range cfg.NetworkIdleTimeouts {
+		if t.networks[network], err = time.ParseDuration(timeout); err != nil {
+			return t, fmt.Errorf("invalid idle timeout '%s' for network '%s': %s",
+				timeout, network, err.Error())
+		}
+	}
+	return t, nil
+}
+
+// get retrieves the idle timeout for the given network
+func (t idleTimeouts) get(network string) time.Duration {
+	if timeout, found := t.networks[network]; found {
+		return timeout
+	}
+	return t.fallback
+}
+
+// enabled checks if any network can be hibernated
+func (t idleTimeouts) enabled() bool {
+	if t.fallback > 0 {
+		return true
+	}
+	for _, timeout := range t.networks {
+		if timeout > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// wakeCall tracks an in-progress wake of a hibernated node
+type wakeCall struct {
+	done chan struct{}
+	err  error
+}
+
+// runHibernator periodically hibernates idle nodes until the given context is
+// cancelled
+func (o *Orchestrator) runHibernator(ctx context.Context, interval time.Duration) {
+	var ticker = time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			o.hibernateIdle(ctx)
+		}
+	}
+}
+
+// hibernateIdle hibernates all nodes that have been idle for longer than their
+// network's idle timeout, and returns the networks that were hibernated
+func (o *Orchestrator) hibernateIdle(ctx context.Context) []string {
+	var hibernated = make([]string, 0)
+	for _, n := range o.Registry.List() {
+		var timeout = o.idle.get(n.NetworkID)
+		if timeout <= 0 || o.Registry.Hibernated(n.NetworkID) {
+			continue
+		}
+		last, err := o.Registry.LastActive(n.NetworkID)
+		if err != nil || time.Since(last) < timeout {
+			continue
+		}
+		if err := o.hibernate(ctx, n.NetworkID); err != nil {
+			if err != ErrOperationInProgress {
+				o.l.Errorw("failed to hibernate idle node",
+					"error", err, "network", n.NetworkID)
+			}
+			continue
+		}
+		hibernated = append(hibernated, n.NetworkID)
+	}
+	return hibernated
+}
+
+// hibernate stops the given network's node while retaining its assets and
+// registry entry, so that it can be woken later
+func (o *Orchestrator) hibernate(ctx context.Context, network string) error {
+	unlock, err := o.locks.tryLock(network)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	var l = log.NewProcessLogger(o.l, "network_hibernate",
+		"job_id", generateID(),
+		"network", network)
+
+	node, err := o.Registry.Get(network)
+	if err != nil {
+		return fmt.Errorf("failed to find node for network '%s': %s", network, err.Error())
+	}
+	if o.Registry.Hibernated(network) {
+		return nil
+	}
+
+	l.Infow("hibernating idle node", "node", node)
+	if err := o.client.StopNode(ctx, &node); err != nil {
+		l.Errorw("failed to stop node", "error", err)
+		return fmt.Errorf("failed to stop node for network '%s': %s", network, err.Error())
+	}
+	if err := o.Registry.Hibernate(network); err != nil {
+		l.Errorw("failed to mark node as hibernated", "error", err)
+		return err
+	}
+	if err := o.saveHibernated(); err != nil {
+		l.Errorw("failed to persist hibernation", "error", err)
+	}
+	l.Info("node hibernated")
+	return nil
+}
+
+// Wake starts the given network's node if it is hibernated, and blocks until
+// the node is ready or the given context is cancelled. Concurrent calls for the
+// same network share a single wake, which continues even if callers stop
+// waiting.
+func (o *Orchestrator) Wake(ctx context.Context, network string) error {
+	if network == "" {
+		return errors.New("invalid network name provided")
+	}
+	if !o.Registry.Hibernated(network) {
+		return nil
+	}
+
+	o.wakeMux.Lock()
+	if o.wakes == nil {
+		o.wakes = make(map[string]*wakeCall)
+	}
+	c, found := o.wakes[network]
+	if !found {
+		c = &wakeCall{done: make(chan struct{})}
+		o.wakes[network] = c
+		go func() {
+			wakeCtx, cancel := context.WithTimeout(context.Background(), wakeTimeout)
+			c.err = o.wake(wakeCtx, network)
+			cancel()
+
+			o.wakeMux.Lock()
+			delete(o.wakes, network)
+			o.wakeMux.Unlock()
+			close(c.done)
+		}()
+	}
+	o.wakeMux.Unlock()
+
+	select {
+	case <-c.done:
+		return c.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// wake starts the given network's hibernated node with its current
+// configuration and reserved ports, once any operation in progress on the
+// network has completed
+func (o *Orchestrator) wake(ctx context.Context, network string) error {
+	unlock, err := o.locks.lock(ctx, network)
+	if err != nil {
+		return fmt.Errorf("failed to wake network '%s': %s", network, err.Error())
+	}
+	defer unlock()
+
+	var (
+		start = time.Now()
+		jobID = generateID()
+		l     = log.NewProcessLogger(o.l, "network_wake",
+			"job_id", jobID,
+			"network", network)
+	)
+
+	node, err := o.Registry.Get(network)
+	if err != nil {
+		return fmt.Errorf("failed to find node for network '%s': %s", network, err.Error())
+	}
+	if !o.Registry.Hibernated(network) {
+		return nil
+	}
+
+	// retrieve current configuration
+	n, err := o.nm.GetNetworkByName(network)
+	if err != nil {
+		l.Errorw("failed to fetch network from database", "error", err)
+		return fmt.Errorf("no network with name '%s' found", network)
+	}
+	opts, err := getOptionsFromDatabaseEntry(n)
+	if err != nil {
+		l.Warnw("invalid database entry", "error", err)
+		return fmt.Errorf("failed to configure network: %s", err.Error())
+	}
+	var woken = getNodeFromDatabaseEntry(jobID, n)
+	woken.Ports = node.Ports
+	woken.Version = node.Version
+	opts.Config = o.nodeConfig(network, woken.Ports.Swarm)
+
+	// allocate resources and start node
+	if err := o.Registry.Wake(network); err != nil {
+		if capErr, ok := err.(*registry.CapacityError); ok {
+			l.Warnw("insufficient host capacity",
+				"error", err,
+				"node.resources", woken.Resources,
+				"host.utilization", o.Registry.Utilization())
+			return capErr
+		}
+		return err
+	}
+	l.Infow("waking hibernated node", "node", woken)
+	if err := o.client.CreateNode(ctx, woken, opts); err != nil {
+		l.Errorw("failed to start node - returning to hibernation", "error", err)
+		if woken.DockerID != "" {
+			o.client.StopNode(ctx, woken)
+		}
+		if err := o.Registry.Hibernate(network); err != nil {
+			l.Errorw("failed to return node to hibernation", "error", err)
+		}
+		return fmt.Errorf("failed to start node for network '%s': %s", network, err.Error())
+	}
+	if err := o.Registry.Update(woken); err != nil {
+		l.Errorw("failed to update registry", "error", err)
+	}
+	if err := o.saveHibernated(); err != nil {
+		l.Errorw("failed to persist wake", "error", err)
+	}
+
+	l.Infow("node woken",
+		"wake.duration", time.Since(start))
+	return nil
+}
+
+// loadHibernated registers the hibernated nodes persisted at the given path, so
+// that they remain hibernated and retain their ports across restarts. Nodes
+// that are already registered because they were started in the meantime are
+// left running.
+func loadHibernated(l *zap.SugaredLogger, reg *registry.NodeRegistry, path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read hibernated nodes from '%s': %s", path, err.Error())
+	}
+	if len(b) == 0 {
+		return nil
+	}
+	var nodes []ipfs.NodeInfo
+	if err := json.Unmarshal(b, &nodes); err != nil {
+		return fmt.Errorf("failed to read hibernated nodes from '%s': %s", path, err.Error())
+	}
+	var restored = 0
+	for i := range nodes {
+		var n = &nodes[i]
+		if _, err := reg.Get(n.NetworkID); err == nil {
+			l.Infow("hibernated node is running - leaving it awake", "node", n)
+			continue
+		}
+		if err := reg.AdoptHibernated(n); err != nil {
+			l.Warnw("failed to restore hibernated node", "error", err, "node", n)
+			continue
+		}
+		restored++
+	}
+	l.Infow("hibernated nodes restored",
+		"path", path,
+		"nodes", restored)
+	return nil
+}
+
+// saveHibernated persists the registry's hibernated nodes. It should be called
+// whenever a node is hibernated, woken, or a hibernated node is changed or
+// deregistered.
+func (o *Orchestrator) saveHibernated() error {
+	if o.hibernatedPath == "" {
+		return nil
+	}
+	var nodes = o.Registry.HibernatedNodes()
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].NetworkID < nodes[j].NetworkID })
+	b, err := json.Marshal(nodes)
+	if err != nil {
+		return fmt.Errorf("failed to persist hibernated nodes: %s", err.Error())
+	}
+
+	o.hibernatedMux.Lock()
+	defer o.hibernatedMux.Unlock()
+	if err := os.MkdirAll(filepath.Dir(o.hibernatedPath), 0700); err != nil {
+		return fmt.Errorf("failed to persist hibernated nodes: %s", err.Error())
+	}
+	var tmp = o.hibernatedPath + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return fmt.Errorf("failed to persist hibernated nodes: %s", err.Error())
+	}
+	if err := os.Rename(tmp, o.hibernatedPath); err != nil {
+		return fmt.Errorf("failed to persist hibernated nodes: %s", err.Error())
+	}
+	return nil
+}
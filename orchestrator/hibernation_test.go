@@ -0,0 +1,294 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RTradeLtd/database/models"
+
+	"github.com/RTradeLtd/Nexus/config"
+	"github.com/RTradeLtd/Nexus/ipfs/mock"
+	"github.com/RTradeLtd/Nexus/log"
+	"github.com/RTradeLtd/Nexus/registry"
+	tmock "github.com/RTradeLtd/Nexus/temporal/mock"
+)
+
+func Test_parseIdleTimeouts(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         config.Hibernation
+		wantErr     bool
+		wantEnabled bool
+		want        map[string]time.Duration
+	}{
+		{"disabled", config.Hibernation{}, false, false,
+			map[string]time.Duration{"bobheadxi": 0}},
+		{"invalid timeout", config.Hibernation{IdleTimeout: "asdf"}, true, false, nil},
+		{"invalid network timeout", config.Hibernation{
+			NetworkIdleTimeouts: map[string]string{"bobheadxi": "asdf"},
+		}, true, false, nil},
+		{"default timeout", config.Hibernation{IdleTimeout: "1h"}, false, true,
+			map[string]time.Duration{"bobheadxi": time.Hour}},
+		{"network overrides", config.Hibernation{
+			IdleTimeout:         "1h",
+			NetworkIdleTimeouts: map[string]string{"bobheadxi": "0", "postables": "5m"},
+		}, false, true, map[string]time.Duration{
+			"bobheadxi":  0,
+			"postables":  5 * time.Minute,
+			"timhortons": time.Hour,
+		}},
+		{"only network timeouts", config.Hibernation{
+			NetworkIdleTimeouts: map[string]string{"postables": "5m"},
+		}, false, true, map[string]time.Duration{
+			"postables":  5 * time.Minute,
+			"timhortons": 0,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseIdleTimeouts(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseIdleTimeouts() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.enabled() != tt.wantEnabled {
+				t.Errorf("expected enabled = %v", tt.wantEnabled)
+			}
+			for network, want := range tt.want {
+				if timeout := got.get(network); timeout != want {
+					t.Errorf("expected timeout %v for '%s', got %v", want, network, timeout)
+				}
+			}
+		})
+	}
+}
+
+func newHibernationTestOrchestrator(t *testing.T, idle idleTimeouts) (*Orchestrator, *mock.MemoryNodeClient) {
+	l, _ := log.NewTestLogger()
+	var (
+		client   = mock.NewMemoryNodeClient()
+		networks = &tmock.FakePrivateNetworks{}
+		o        = &Orchestrator{
+			Registry: registry.NewWithCapacity(l, config.New().Ports, config.Capacity{CPUs: 8}),
+			l:        l,
+			nm:       networks,
+			client:   client,
+			address:  "127.0.0.1",
+			idle:     idle,
+		}
+	)
+	networks.GetNetworkByNameStub = func(name string) (*models.HostedIPFSPrivateNetwork, error) {
+		return &models.HostedIPFSPrivateNetwork{Name: name, SwarmKey: testSwarmKey}, nil
+	}
+	for _, network := range []string{"bobheadxi", "postables"} {
+		if _, err := o.NetworkUp(context.Background(), network); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return o, client
+}
+
+func TestOrchestrator_hibernateIdle(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		o, node = newHibernationTestOrchestrator(t, idleTimeouts{
+			fallback: time.Hour,
+			networks: map[string]time.Duration{"postables": 0},
+		})
+	)
+	defer o.Registry.Close()
+
+	// recently active nodes should not be hibernated
+	if hibernated := o.hibernateIdle(ctx); len(hibernated) != 0 {
+		t.Errorf("expected no nodes to be hibernated, got %v", hibernated)
+	}
+
+	// idle nodes should be hibernated, unless disabled for the network
+	o.idle.fallback = time.Nanosecond
+	hibernated := o.hibernateIdle(ctx)
+	if len(hibernated) != 1 || hibernated[0] != "bobheadxi" {
+		t.Errorf("expected only 'bobheadxi' to be hibernated, got %v", hibernated)
+	}
+	if state := node.State("bobheadxi"); state != "" {
+		t.Errorf("expected container to be removed, got state '%s'", state)
+	}
+	if exists, _ := node.NodeAssetsExist(ctx, "bobheadxi"); !exists {
+		t.Error("expected assets to be retained")
+	}
+	if !o.Registry.Hibernated("bobheadxi") {
+		t.Error("expected node to be marked as hibernated")
+	}
+	if u := o.Registry.Utilization(); u.Allocated.CPUs != 4 {
+		t.Errorf("expected resources to be released, got %+v", u.Allocated)
+	}
+
+	// hibernated nodes should not be hibernated again, or touched by reconciler
+	if hibernated := o.hibernateIdle(ctx); len(hibernated) != 0 {
+		t.Errorf("expected no nodes to be hibernated, got %v", hibernated)
+	}
+	o.nm.(*tmock.FakePrivateNetworks).GetActiveNetworksReturns([]*models.HostedIPFSPrivateNetwork{
+		{Name: "bobheadxi", SwarmAddr: o.swarmAddr("")},
+	}, nil)
+	report, err := o.Reconcile(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Deregistered) != 0 || len(report.Started) != 0 || len(report.Deactivated) != 0 {
+		t.Errorf("expected hibernated node to be left alone, got %+v", report)
+	}
+
+	// diagnostics should report hibernation
+	d, err := o.NetworkDiagnostics(ctx, "bobheadxi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Hibernated || d.LastActive.IsZero() {
+		t.Errorf("unexpected diagnostics %+v", d)
+	}
+}
+
+func TestOrchestrator_Wake(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		o, node = newHibernationTestOrchestrator(t, idleTimeouts{})
+	)
+	defer o.Registry.Close()
+	before, _ := o.Registry.Get("bobheadxi")
+	if err := o.hibernate(ctx, "bobheadxi"); err != nil {
+		t.Fatal(err)
+	}
+
+	// waking running nodes should do nothing
+	if err := o.Wake(ctx, "postables"); err != nil {
+		t.Errorf("Orchestrator.Wake() error = %v", err)
+	}
+	if err := o.Wake(ctx, ""); err == nil {
+		t.Error("expected error for invalid network")
+	}
+
+	// failed wakes should return node to hibernation
+	node.Fail(mock.OpCreateNode, "bobheadxi", errors.New("oh no"))
+	if err := o.Wake(ctx, "bobheadxi"); err == nil {
+		t.Error("expected wake to fail")
+	}
+	if !o.Registry.Hibernated("bobheadxi") {
+		t.Error("expected node to remain hibernated")
+	}
+	if u := o.Registry.Utilization(); u.Allocated.CPUs != 4 {
+		t.Errorf("expected resources to be released, got %+v", u.Allocated)
+	}
+
+	// concurrent wakes should all succeed with a single node start
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := o.Wake(ctx, "bobheadxi"); err != nil {
+				t.Errorf("Orchestrator.Wake() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if o.Registry.Hibernated("bobheadxi") {
+		t.Error("expected node to be woken")
+	}
+	if state := node.State("bobheadxi"); state != mock.StateRunning {
+		t.Errorf("expected node to be running, got state '%s'", state)
+	}
+	after, _ := o.Registry.Get("bobheadxi")
+	if after.Ports != before.Ports {
+		t.Errorf("expected ports %+v to be retained, got %+v", before.Ports, after.Ports)
+	}
+	if after.DockerID == before.DockerID {
+		t.Error("expected registry to be updated with new container")
+	}
+	if u := o.Registry.Utilization(); u.Allocated.CPUs != 8 {
+		t.Errorf("expected resources to be allocated, got %+v", u.Allocated)
+	}
+}
+
+func TestOrchestrator_hibernation_restart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hibernation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	l, _ := log.NewTestLogger()
+	var (
+		ctx      = context.Background()
+		client   = mock.NewMemoryNodeClient()
+		networks = &tmock.FakePrivateNetworks{}
+		opts     = config.IPFS{Ports: config.New().Ports, DataDirectory: dir}
+	)
+	networks.GetNetworkByNameStub = func(name string) (*models.HostedIPFSPrivateNetwork, error) {
+		return &models.HostedIPFSPrivateNetwork{Name: name, SwarmKey: testSwarmKey}, nil
+	}
+	o, err := New(l, "127.0.0.1", opts, true, client, networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, network := range []string{"bobheadxi", "postables"} {
+		if _, err := o.NetworkUp(ctx, network); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before, _ := o.Registry.Get("bobheadxi")
+	if err := o.hibernate(ctx, "bobheadxi"); err != nil {
+		t.Fatal(err)
+	}
+	o.Registry.Close()
+	networks.GetActiveNetworksReturns([]*models.HostedIPFSPrivateNetwork{
+		{Name: "bobheadxi", SwarmAddr: o.swarmAddr(before.Ports.Swarm)},
+	}, nil)
+
+	// hibernated nodes should remain hibernated with their ports after restart
+	restarted, err := New(l, "127.0.0.1", opts, true, client, networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !restarted.Registry.Hibernated("bobheadxi") || restarted.Registry.Hibernated("postables") {
+		t.Errorf("expected only 'bobheadxi' to be hibernated, got %+v",
+			restarted.Registry.HibernatedNodes())
+	}
+	if after, err := restarted.Registry.Get("bobheadxi"); err != nil || after.Ports != before.Ports {
+		t.Errorf("expected ports %+v to be retained, got %+v (%v)", before.Ports, after.Ports, err)
+	}
+	if report, err := restarted.Reconcile(ctx); err != nil {
+		t.Fatal(err)
+	} else if len(report.Started) != 0 || len(report.Deregistered) != 0 {
+		t.Errorf("expected hibernated node to be left alone, got %+v", report)
+	}
+	if healed := restarted.healStopped(ctx); len(healed) != 0 {
+		t.Errorf("expected no nodes to be restarted, got %v", healed)
+	}
+	if state := client.State("bobheadxi"); state != "" {
+		t.Errorf("expected hibernated node to remain stopped, got state '%s'", state)
+	}
+
+	// woken nodes should no longer be hibernated after restart
+	if err := restarted.Wake(ctx, "bobheadxi"); err != nil {
+		t.Fatal(err)
+	}
+	restarted.Registry.Close()
+	woken, err := New(l, "127.0.0.1", opts, true, client, networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer woken.Registry.Close()
+	if woken.Registry.Hibernated("bobheadxi") {
+		t.Error("expected woken node to not be hibernated")
+	}
+	if after, err := woken.Registry.Get("bobheadxi"); err != nil || after.Ports != before.Ports {
+		t.Errorf("expected ports %+v to be retained, got %+v (%v)", before.Ports, after.Ports, err)
+	}
+}
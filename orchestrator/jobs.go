@@ -0,0 +1,361 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// jobQueueSize is the maximum number of jobs that can be queued at once
+	jobQueueSize = 1024
+	// jobWorkers is the number of jobs that can be executed concurrently
+	jobWorkers = 8
+	// jobRetention is how long finished jobs are kept for
+	jobRetention = 7 * 24 * time.Hour
+)
+
+// ErrJobNotFound is returned when a requested job does not exist
+var ErrJobNotFound = errors.New("job not found")
+
+// JobState denotes the progress of a job
+type JobState string
+
+const (
+	// JobQueued indicates a job is waiting to be executed
+	JobQueued JobState = "queued"
+	// JobRunning indicates a job is being executed
+	JobRunning JobState = "running"
+	// JobSucceeded indicates a job completed successfully
+	JobSucceeded JobState = "succeeded"
+	// JobFailed indicates a job completed with an error
+	JobFailed JobState = "failed"
+)
+
+// JobOperation denotes the network operation a job executes
+type JobOperation string
+
+const (
+	// OperationNetworkUp executes Orchestrator::NetworkUp
+	OperationNetworkUp JobOperation = "network_up"
+	// OperationNetworkUpdate executes Orchestrator::NetworkUpdate
+	OperationNetworkUpdate JobOperation = "network_update"
+	// OperationNetworkDown executes Orchestrator::NetworkDown
+	OperationNetworkDown JobOperation = "network_down"
+	// OperationNetworkRestart executes Orchestrator::NetworkRestart
+	OperationNetworkRestart JobOperation = "network_restart"
+)
+
+// Job tracks an asynchronous network operation
+type Job struct {
+	ID        string       `json:"id"`
+	Network   string       `json:"network"`
+	Operation JobOperation `json:"operation"`
+	State     JobState     `json:"state"`
+
+	// Error is set if the job failed
+	Error string `json:"error,omitempty"`
+	// Result is set if a network up job succeeded
+	Result *NetworkDetails `json:"result,omitempty"`
+
+	QueuedAt   time.Time `json:"queued_at"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// Finished indicates whether the job has completed
+func (j *Job) Finished() bool {
+	return j.State == JobSucceeded || j.State == JobFailed
+}
+
+// jobManager tracks jobs and persists them to disk, so that job state survives
+// restarts
+type jobManager struct {
+	l    *zap.SugaredLogger
+	path string
+
+	// jobs indexed by ID - locked by jobManager::mux
+	jobs map[string]*Job
+	mux  sync.RWMutex
+
+	queue chan string
+}
+
+// newJobManager loads jobs persisted at the given path. Jobs that were queued
+// before a restart are queued again, and jobs that were running are marked as
+// failed.
+func newJobManager(logger *zap.SugaredLogger, path string) (*jobManager, error) {
+	var m = &jobManager{
+		l:     logger.Named("jobs"),
+		path:  path,
+		jobs:  make(map[string]*Job),
+		queue: make(chan string, jobQueueSize),
+	}
+
+	// load existing jobs
+	b, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read jobs from '%s': %s", path, err.Error())
+	}
+	if len(b) > 0 {
+		var jobs []*Job
+		if err := json.Unmarshal(b, &jobs); err != nil {
+			return nil, fmt.Errorf("failed to read jobs from '%s': %s", path, err.Error())
+		}
+		sort.Slice(jobs, func(i, j int) bool { return jobs[i].QueuedAt.Before(jobs[j].QueuedAt) })
+		for _, j := range jobs {
+			switch j.State {
+			case JobQueued:
+				if len(m.queue) == cap(m.queue) {
+					j.fail(errors.New("job queue is full"))
+				} else {
+					m.queue <- j.ID
+				}
+			case JobRunning:
+				j.fail(errors.New("job interrupted by daemon restart"))
+			}
+			m.jobs[j.ID] = j
+		}
+		m.l.Infow("jobs restored",
+			"path", path,
+			"jobs", len(jobs),
+			"queued", len(m.queue))
+	}
+
+	// make sure jobs can be persisted
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	if err := m.save(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// enqueue creates and queues a new job
+func (m *jobManager) enqueue(op JobOperation, network string) (Job, error) {
+	var j = &Job{
+		ID:        generateID(),
+		Network:   network,
+		Operation: op,
+		State:     JobQueued,
+		QueuedAt:  time.Now(),
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	for _, existing := range m.jobs {
+		if existing.Network == network && !existing.Finished() {
+			return Job{}, ErrOperationInProgress
+		}
+	}
+	m.jobs[j.ID] = j
+	if err := m.save(); err != nil {
+		delete(m.jobs, j.ID)
+		return Job{}, err
+	}
+	select {
+	case m.queue <- j.ID:
+	default:
+		delete(m.jobs, j.ID)
+		m.save()
+		return Job{}, errors.New("job queue is full")
+	}
+	return *j, nil
+}
+
+// get retrieves the job with the given ID
+func (m *jobManager) get(id string) (Job, error) {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+	j, found := m.jobs[id]
+	if !found {
+		return Job{}, ErrJobNotFound
+	}
+	return *j, nil
+}
+
+// start marks the job with the given ID as running
+func (m *jobManager) start(id string) (Job, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	j, found := m.jobs[id]
+	if !found {
+		return Job{}, ErrJobNotFound
+	}
+	j.State = JobRunning
+	j.StartedAt = time.Now()
+	if err := m.save(); err != nil {
+		m.l.Warnw("failed to persist job", "error", err, "job", j)
+	}
+	return *j, nil
+}
+
+// finish records the outcome of the job with the given ID
+func (m *jobManager) finish(id string, result *NetworkDetails, err error) (Job, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	j, found := m.jobs[id]
+	if !found {
+		return Job{}, ErrJobNotFound
+	}
+	if err != nil {
+		j.fail(err)
+	} else {
+		j.State = JobSucceeded
+		j.Result = result
+		j.FinishedAt = time.Now()
+	}
+	if err := m.save(); err != nil {
+		m.l.Warnw("failed to persist job", "error", err, "job", j)
+	}
+	return *j, nil
+}
+
+// save prunes expired jobs and writes the remaining jobs to disk. The caller
+// must hold jobManager::mux
+func (m *jobManager) save() error {
+	var (
+		expiry = time.Now().Add(-jobRetention)
+		jobs   = make([]*Job, 0, len(m.jobs))
+	)
+	for id, j := range m.jobs {
+		if j.Finished() && j.FinishedAt.Before(expiry) {
+			delete(m.jobs, id)
+			continue
+		}
+		jobs = append(jobs, j)
+	}
+	b, err := json.Marshal(jobs)
+	if err != nil {
+		return fmt.Errorf("failed to persist jobs: %s", err.Error())
+	}
+
+	// write atomically, since job results may contain swarm keys the file is
+	// only readable by the daemon
+	if err := os.MkdirAll(filepath.Dir(m.path), 0700); err != nil {
+		return fmt.Errorf("failed to persist jobs: %s", err.Error())
+	}
+	var tmp = m.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return fmt.Errorf("failed to persist jobs: %s", err.Error())
+	}
+	if err := os.Rename(tmp, m.path); err != nil {
+		return fmt.Errorf("failed to persist jobs: %s", err.Error())
+	}
+	return nil
+}
+
+func (j *Job) fail(err error) {
+	j.State = JobFailed
+	j.Error = err.Error()
+	j.FinishedAt = time.Now()
+}
+
+// SubmitJob queues the given operation on the given network for execution in
+// the background, and returns the new job. Only one job can be pending for
+// each network - ErrOperationInProgress is returned otherwise.
+func (o *Orchestrator) SubmitJob(op JobOperation, network string) (Job, error) {
+	if network == "" {
+		return Job{}, errors.New("invalid network name provided")
+	}
+	switch op {
+	case OperationNetworkUp, OperationNetworkUpdate, OperationNetworkDown, OperationNetworkRestart:
+	default:
+		return Job{}, fmt.Errorf("unknown operation '%s'", op)
+	}
+
+	j, err := o.jobs.enqueue(op, network)
+	if err == ErrOperationInProgress {
+		return Job{}, err
+	} else if err != nil {
+		o.l.Errorw("failed to queue job",
+			"error", err, "network", network, "operation", op)
+		return Job{}, fmt.Errorf("failed to queue job: %s", err.Error())
+	}
+	o.l.Infow("job queued",
+		"job_id", j.ID, "network", network, "operation", op)
+	return j, nil
+}
+
+// GetJob retrieves the job with the given ID
+func (o *Orchestrator) GetJob(id string) (Job, error) {
+	return o.jobs.get(id)
+}
+
+// runJobs executes queued jobs until the given context is cancelled
+func (o *Orchestrator) runJobs(ctx context.Context, workers int) {
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case id := <-o.jobs.queue:
+					o.runJob(ctx, id)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// runJob executes the job with the given ID once the lock for its network can
+// be acquired. If the given context is cancelled first, the job is left queued
+// so that it is queued again after a restart.
+func (o *Orchestrator) runJob(ctx context.Context, id string) {
+	j, err := o.jobs.get(id)
+	if err != nil {
+		o.l.Errorw("failed to start job", "error", err, "job_id", id)
+		return
+	}
+	unlock, err := o.locks.lock(ctx, j.Network)
+	if err != nil {
+		o.l.Warnw("job not started", "error", err, "job_id", id)
+		return
+	}
+	defer unlock()
+	if j, err = o.jobs.start(id); err != nil {
+		o.l.Errorw("failed to start job", "error", err, "job_id", id)
+		return
+	}
+
+	var result *NetworkDetails
+	switch j.Operation {
+	case OperationNetworkUp:
+		var details NetworkDetails
+		if details, err = o.networkUp(ctx, j.ID, j.Network); err == nil {
+			result = &details
+		}
+	case OperationNetworkUpdate:
+		err = o.networkUpdate(ctx, j.ID, j.Network)
+	case OperationNetworkDown:
+		err = o.networkDown(ctx, j.ID, j.Network)
+	case OperationNetworkRestart:
+		err = o.networkRestart(ctx, j.ID, j.Network)
+	default:
+		err = fmt.Errorf("unknown operation '%s'", j.Operation)
+	}
+
+	if j, err = o.jobs.finish(id, result, err); err != nil {
+		o.l.Errorw("failed to finish job", "error", err, "job_id", id)
+		return
+	}
+	o.l.Infow("job finished",
+		"job_id", j.ID,
+		"network", j.Network,
+		"operation", j.Operation,
+		"state", j.State,
+		"job.duration", j.FinishedAt.Sub(j.StartedAt))
+}
@@ -0,0 +1,242 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/RTradeLtd/database/models"
+
+	"github.com/RTradeLtd/Nexus/config"
+	"github.com/RTradeLtd/Nexus/ipfs/mock"
+	"github.com/RTradeLtd/Nexus/log"
+	"github.com/RTradeLtd/Nexus/registry"
+	tmock "github.com/RTradeLtd/Nexus/temporal/mock"
+)
+
+func Test_jobManager_persistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var path = filepath.Join(dir, "jobs.json")
+
+	l, _ := log.NewTestLogger()
+	m, err := newJobManager(l, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// set up jobs in each state
+	var ids = map[JobState]string{}
+	for _, state := range []JobState{JobQueued, JobRunning, JobSucceeded, JobFailed} {
+		j, err := m.enqueue(OperationNetworkUp, string(state))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if j.State != JobQueued || j.QueuedAt.IsZero() {
+			t.Errorf("unexpected new job %+v", j)
+		}
+		ids[state] = j.ID
+	}
+	m.start(ids[JobRunning])
+	m.start(ids[JobSucceeded])
+	m.finish(ids[JobSucceeded], &NetworkDetails{PeerID: "peer"}, nil)
+	m.start(ids[JobFailed])
+	m.finish(ids[JobFailed], nil, errors.New("oh no"))
+
+	// only one job should be pending per network
+	if _, err := m.enqueue(OperationNetworkDown, string(JobRunning)); err != ErrOperationInProgress {
+		t.Errorf("expected ErrOperationInProgress, got %v", err)
+	}
+	if _, err := m.enqueue(OperationNetworkDown, string(JobSucceeded)); err != nil {
+		t.Errorf("expected job to be queued for network without pending jobs, got %v", err)
+	}
+	if _, err := m.get("asdf"); err != ErrJobNotFound {
+		t.Errorf("expected ErrJobNotFound, got %v", err)
+	}
+
+	// simulate restart
+	restored, err := newJobManager(l, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		id        string
+		wantState JobState
+	}{
+		{"queued jobs should be requeued", ids[JobQueued], JobQueued},
+		{"running jobs should be failed", ids[JobRunning], JobFailed},
+		{"succeeded jobs should be kept", ids[JobSucceeded], JobSucceeded},
+		{"failed jobs should be kept", ids[JobFailed], JobFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j, err := restored.get(tt.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if j.State != tt.wantState {
+				t.Errorf("expected state %s, got %s", tt.wantState, j.State)
+			}
+			if j.Finished() && (j.FinishedAt.IsZero() || (j.State == JobFailed && j.Error == "")) {
+				t.Errorf("finished job missing details: %+v", j)
+			}
+		})
+	}
+	if len(restored.queue) != 2 || <-restored.queue != ids[JobQueued] {
+		t.Error("expected queued job to be requeued")
+	}
+	if j, _ := restored.get(ids[JobSucceeded]); j.Result == nil || j.Result.PeerID != "peer" {
+		t.Errorf("expected job result to be restored, got %+v", j.Result)
+	}
+}
+
+func TestOrchestrator_SubmitJob(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	l, _ := log.NewTestLogger()
+	jobs, err := newJobManager(l, filepath.Join(dir, "jobs.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		networks = &tmock.FakePrivateNetworks{}
+		o        = &Orchestrator{
+			Registry: registry.New(l, config.New().Ports),
+			l:        l,
+			nm:       networks,
+			client:   mock.NewMemoryNodeClient(),
+			address:  "127.0.0.1",
+			jobs:     jobs,
+		}
+	)
+	defer o.Registry.Close()
+	networks.GetNetworkByNameStub = func(name string) (*models.HostedIPFSPrivateNetwork, error) {
+		if name != "test-network" {
+			return nil, errors.New("not found")
+		}
+		return &models.HostedIPFSPrivateNetwork{Name: name}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go o.runJobs(ctx, 2)
+
+	type args struct {
+		op      JobOperation
+		network string
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantErr   bool
+		wantState JobState
+	}{
+		{"invalid network name", args{OperationNetworkUp, ""}, true, ""},
+		{"invalid operation", args{"asdf", "test-network"}, true, ""},
+		{"nonexistent network", args{OperationNetworkUp, "asdf"}, false, JobFailed},
+		{"network up", args{OperationNetworkUp, "test-network"}, false, JobSucceeded},
+		{"network update", args{OperationNetworkUpdate, "test-network"}, false, JobSucceeded},
+		{"network down", args{OperationNetworkDown, "test-network"}, false, JobSucceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j, err := o.SubmitJob(tt.args.op, tt.args.network)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Orchestrator.SubmitJob() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			// poll job until completion
+			var deadline = time.Now().Add(5 * time.Second)
+			for !j.Finished() && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+				if j, err = o.GetJob(j.ID); err != nil {
+					t.Fatalf("Orchestrator.GetJob() error = %v", err)
+				}
+			}
+			if j.State != tt.wantState {
+				t.Errorf("expected state %s, got %s (%s)", tt.wantState, j.State, j.Error)
+			}
+			if j.StartedAt.IsZero() || j.FinishedAt.Before(j.StartedAt) {
+				t.Errorf("unexpected timestamps %+v", j)
+			}
+			if tt.args.op == OperationNetworkUp && j.State == JobSucceeded &&
+				(j.Result == nil || j.Result.PeerID == "") {
+				t.Errorf("expected network details in result, got %+v", j.Result)
+			}
+		})
+	}
+}
+
+func TestOrchestrator_runJob_locked(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	l, _ := log.NewTestLogger()
+	jobs, err := newJobManager(l, filepath.Join(dir, "jobs.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		networks = &tmock.FakePrivateNetworks{}
+		o        = &Orchestrator{
+			Registry: registry.New(l, config.New().Ports),
+			l:        l,
+			nm:       networks,
+			client:   mock.NewMemoryNodeClient(),
+			address:  "127.0.0.1",
+			jobs:     jobs,
+		}
+	)
+	defer o.Registry.Close()
+	networks.GetNetworkByNameReturns(&models.HostedIPFSPrivateNetwork{Name: "test-network"}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go o.runJobs(ctx, 1)
+
+	// jobs should wait for operations in progress instead of failing
+	unlock, err := o.locks.tryLock("test-network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	j, err := o.SubmitJob(OperationNetworkUp, "test-network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got, err := o.GetJob(j.ID); err != nil || got.State != JobQueued {
+		t.Errorf("expected job to remain queued, got %+v, %v", got, err)
+	}
+	unlock()
+
+	// poll job until completion
+	var deadline = time.Now().Add(5 * time.Second)
+	for !j.Finished() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		if j, err = o.GetJob(j.ID); err != nil {
+			t.Fatalf("Orchestrator.GetJob() error = %v", err)
+		}
+	}
+	if j.State != JobSucceeded {
+		t.Errorf("expected state %s, got %s (%s)", JobSucceeded, j.State, j.Error)
+	}
+}
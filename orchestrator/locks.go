@@ -0,0 +1,89 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrOperationInProgress is returned when an operation is requested for a
+// network that already has an operation in progress
+var ErrOperationInProgress = errors.New("another operation is already in progress for this network")
+
+// networkLocks provides exclusive access to individual networks, so that
+// operations on the same network are serialized while operations on
+// different networks can run in parallel
+type networkLocks struct {
+	// locked networks, each with a channel that is closed when the lock is
+	// released - locked by networkLocks::mux
+	held map[string]chan struct{}
+	// number of callers blocked in networkLocks::lock for each network - locked
+	// by networkLocks::mux
+	waiting map[string]int
+	mux     sync.Mutex
+}
+
+// tryLock attempts to acquire the lock for the given network. It does not
+// block, and returns ErrOperationInProgress if the lock is already held or
+// another caller is waiting for it, so that callers of tryLock yield to queued
+// operations. The returned function releases the lock.
+func (n *networkLocks) tryLock(network string) (unlock func(), err error) {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+	if _, locked := n.held[network]; locked || n.waiting[network] > 0 {
+		return nil, ErrOperationInProgress
+	}
+	return n.acquire(network), nil
+}
+
+// lock acquires the lock for the given network, blocking until it is released
+// by its current holder or the given context is cancelled. The returned
+// function releases the lock.
+func (n *networkLocks) lock(ctx context.Context, network string) (unlock func(), err error) {
+	n.mux.Lock()
+	if n.waiting == nil {
+		n.waiting = make(map[string]int)
+	}
+	n.waiting[network]++
+	defer func() {
+		if n.waiting[network]--; n.waiting[network] == 0 {
+			delete(n.waiting, network)
+		}
+		n.mux.Unlock()
+	}()
+
+	for {
+		released, locked := n.held[network]
+		if !locked {
+			return n.acquire(network), nil
+		}
+		n.mux.Unlock()
+		select {
+		case <-ctx.Done():
+			n.mux.Lock()
+			return nil, ctx.Err()
+		case <-released:
+			n.mux.Lock()
+		}
+	}
+}
+
+// acquire marks the given network as locked. The caller must hold
+// networkLocks::mux
+func (n *networkLocks) acquire(network string) (unlock func()) {
+	if n.held == nil {
+		n.held = make(map[string]chan struct{})
+	}
+	var released = make(chan struct{})
+	n.held[network] = released
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			n.mux.Lock()
+			delete(n.held, network)
+			close(released)
+			n.mux.Unlock()
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package orchestrator
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RTradeLtd/Nexus/config"
+	"github.com/RTradeLtd/Nexus/ipfs/mock"
+	"github.com/RTradeLtd/Nexus/log"
+	"github.com/RTradeLtd/Nexus/registry"
+	tmock "github.com/RTradeLtd/Nexus/temporal/mock"
+)
+
+func Test_networkLocks(t *testing.T) {
+	var locks networkLocks
+
+	unlock, err := locks.tryLock("bobheadxi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := locks.tryLock("bobheadxi"); err != ErrOperationInProgress {
+		t.Errorf("expected ErrOperationInProgress, got %v", err)
+	}
+
+	// other networks should not be affected
+	unlockOther, err := locks.tryLock("postables")
+	if err != nil {
+		t.Errorf("expected lock on other network to succeed, got %v", err)
+	} else {
+		unlockOther()
+	}
+
+	// releasing lock multiple times should be safe
+	unlock()
+	unlock()
+	unlock, err = locks.tryLock("bobheadxi")
+	if err != nil {
+		t.Errorf("expected lock to be released, got %v", err)
+	} else {
+		unlock()
+	}
+}
+
+func Test_networkLocks_concurrent(t *testing.T) {
+	var (
+		locks    networkLocks
+		acquired = make(chan func(), 100)
+		wg       sync.WaitGroup
+	)
+	for i := 0; i < cap(acquired); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if unlock, err := locks.tryLock("bobheadxi"); err == nil {
+				acquired <- unlock
+			}
+		}()
+	}
+	wg.Wait()
+	close(acquired)
+	if len(acquired) != 1 {
+		t.Errorf("expected exactly one lock to be acquired, got %d", len(acquired))
+	}
+	for unlock := range acquired {
+		unlock()
+	}
+}
+
+func Test_networkLocks_lock(t *testing.T) {
+	var locks networkLocks
+	unlock, err := locks.tryLock("bobheadxi")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// lock should block until context is cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := locks.lock(ctx, "bobheadxi"); err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	// lock should be acquired once released, and tryLock should yield to it
+	var acquired = make(chan func())
+	go func() {
+		unlock, err := locks.lock(context.Background(), "bobheadxi")
+		if err != nil {
+			t.Error(err)
+			close(acquired)
+			return
+		}
+		acquired <- unlock
+	}()
+	for {
+		locks.mux.Lock()
+		var waiting = locks.waiting["bobheadxi"]
+		locks.mux.Unlock()
+		if waiting > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	unlock()
+	if _, err := locks.tryLock("bobheadxi"); err != ErrOperationInProgress {
+		t.Errorf("expected ErrOperationInProgress, got %v", err)
+	}
+	select {
+	case unlock, ok := <-acquired:
+		if ok {
+			unlock()
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected lock to be acquired")
+	}
+	if unlock, err := locks.tryLock("bobheadxi"); err != nil {
+		t.Errorf("expected lock to be released, got %v", err)
+	} else {
+		unlock()
+	}
+}
+
+func TestOrchestrator_operationInProgress(t *testing.T) {
+	l, _ := log.NewTestLogger()
+	var o = &Orchestrator{
+		Registry: registry.New(l, config.New().Ports),
+		l:        l,
+		nm:       &tmock.FakePrivateNetworks{},
+		client:   mock.NewMemoryNodeClient(),
+		address:  "127.0.0.1",
+	}
+	defer o.Registry.Close()
+
+	unlock, err := o.locks.tryLock("bobheadxi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+
+	var ctx = context.Background()
+	tests := []struct {
+		name string
+		op   func() error
+	}{
+		{"network up", func() error { _, err := o.NetworkUp(ctx, "bobheadxi"); return err }},
+		{"network update", func() error { return o.NetworkUpdate(ctx, "bobheadxi") }},
+		{"network down", func() error { return o.NetworkDown(ctx, "bobheadxi") }},
+		{"network remove", func() error { return o.NetworkRemove(ctx, "bobheadxi") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.op(); err != ErrOperationInProgress {
+				t.Errorf("expected ErrOperationInProgress, got %v", err)
+			}
+		})
+	}
+}
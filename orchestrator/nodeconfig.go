@@ -0,0 +1,42 @@
+package orchestrator
+
+import (
+	"fmt"
+
+	"github.com/RTradeLtd/Nexus/config"
+	"github.com/RTradeLtd/Nexus/ipfs"
+)
+
+// nodeConfigs denotes the go-ipfs configuration nodes run with
+type nodeConfigs struct {
+	fallback *ipfs.NodeConfig
+	networks map[string]ipfs.NodeConfig
+}
+
+func parseNodeConfigs(cfg config.IPFS) (nodeConfigs, error) {
+	var c = nodeConfigs{networks: make(map[string]ipfs.NodeConfig)}
+	fallback, err := ipfs.DefaultNodeConfig().WithOverrides(cfg.NodeConfig)
+	if err != nil {
+		return c, err
+	}
+	c.fallback = &fallback
+	for network, overrides := range cfg.NetworkNodeConfigs {
+		if c.networks[network], err = fallback.WithOverrides(overrides); err != nil {
+			return c, fmt.Errorf("invalid configuration for network '%s': %s", network, err.Error())
+		}
+	}
+	return c, nil
+}
+
+// get retrieves the node configuration for the given network
+func (c nodeConfigs) get(network string) *ipfs.NodeConfig {
+	if cfg, found := c.networks[network]; found {
+		return &cfg
+	}
+	if c.fallback != nil {
+		var cfg = *c.fallback
+		return &cfg
+	}
+	var cfg = ipfs.DefaultNodeConfig()
+	return &cfg
+}
@@ -0,0 +1,92 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/RTradeLtd/database/models"
+
+	"github.com/RTradeLtd/Nexus/config"
+	"github.com/RTradeLtd/Nexus/ipfs/mock"
+	"github.com/RTradeLtd/Nexus/log"
+	"github.com/RTradeLtd/Nexus/registry"
+	tmock "github.com/RTradeLtd/Nexus/temporal/mock"
+)
+
+func Test_parseNodeConfigs(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.IPFS
+		wantErr bool
+		want    map[string]int
+	}{
+		{"defaults", config.IPFS{}, false, map[string]int{"bobheadxi": 900}},
+		{"invalid default", config.IPFS{
+			NodeConfig: json.RawMessage(`{"Swarm": {"ConnMgr": {"HighWater": -1}}}`),
+		}, true, nil},
+		{"invalid network override", config.IPFS{
+			NetworkNodeConfigs: map[string]json.RawMessage{"bobheadxi": json.RawMessage(`{"Swarm": 1}`)},
+		}, true, nil},
+		{"network overrides", config.IPFS{
+			NodeConfig: json.RawMessage(`{"Swarm": {"ConnMgr": {"HighWater": 1200}}}`),
+			NetworkNodeConfigs: map[string]json.RawMessage{
+				"bobheadxi": json.RawMessage(`{"Swarm": {"ConnMgr": {"HighWater": 2000}}}`),
+			},
+		}, false, map[string]int{"bobheadxi": 2000, "postables": 1200}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseNodeConfigs(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseNodeConfigs() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			for network, want := range tt.want {
+				if hw := got.get(network).Swarm.ConnMgr.HighWater; hw != want {
+					t.Errorf("expected high water %d for '%s', got %d", want, network, hw)
+				}
+			}
+		})
+	}
+}
+
+func TestOrchestrator_NetworkUpNodeConfig(t *testing.T) {
+	l, _ := log.NewTestLogger()
+	configs, err := parseNodeConfigs(config.IPFS{
+		NetworkNodeConfigs: map[string]json.RawMessage{
+			"bobheadxi": json.RawMessage(`{"Experimental": {"FilestoreEnabled": true}}`),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		ctx      = context.Background()
+		client   = mock.NewMemoryNodeClient()
+		networks = &tmock.FakePrivateNetworks{}
+		o        = &Orchestrator{
+			Registry:    registry.New(l, config.New().Ports),
+			l:           l,
+			nm:          networks,
+			client:      client,
+			address:     "127.0.0.1",
+			nodeConfigs: configs,
+		}
+	)
+	defer o.Registry.Close()
+	networks.GetNetworkByNameStub = func(name string) (*models.HostedIPFSPrivateNetwork, error) {
+		return &models.HostedIPFSPrivateNetwork{Name: name, SwarmKey: testSwarmKey}, nil
+	}
+
+	// only networks with overrides should have them applied
+	for network, want := range map[string]bool{"bobheadxi": true, "postables": false} {
+		if _, err := o.NetworkUp(ctx, network); err != nil {
+			t.Fatal(err)
+		}
+		cfg := client.NodeConfig(network)
+		if cfg == nil || cfg.Experimental.FilestoreEnabled != want {
+			t.Errorf("expected filestore enabled = %v for '%s', got %+v", want, network, cfg)
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/RTradeLtd/Nexus/temporal"
@@ -26,15 +28,54 @@ type Orchestrator struct {
 
 	client  ipfs.NodeClient
 	address string
+	jobs    *jobManager
+
+	// backupDir is the directory node backups are written to and restored from
+	backupDir string
+
+	reconcileInterval time.Duration
+	livenessInterval  time.Duration
+	idle              idleTimeouts
+	restart           restartPolicy
+	nodeConfigs       nodeConfigs
+
+	// locks serializes operations on each network
+	locks networkLocks
+
+	// hibernatedPath is the file hibernated nodes are persisted to - written
+	// under Orchestrator::hibernatedMux
+	hibernatedPath string
+	hibernatedMux  sync.Mutex
+
+	// in-progress wakes of hibernated nodes - locked by Orchestrator::wakeMux
+	wakes   map[string]*wakeCall
+	wakeMux sync.Mutex
+
+	// automatic restarts of stopped and unhealthy nodes - locked by
+	// Orchestrator::restartMux
+	restarts   map[string]*restartHistory
+	restartMux sync.Mutex
 }
 
 // New instantiates and bootstraps a new Orchestrator
-func New(logger *zap.SugaredLogger, address string, ports config.Ports, dev bool,
+func New(logger *zap.SugaredLogger, address string, opts config.IPFS, dev bool,
 	c ipfs.NodeClient, networks temporal.PrivateNetworks) (*Orchestrator, error) {
 	var l = logger.Named("orchestrator")
 	if address == "" {
 		l.Warn("host address not set")
 	}
+	idle, err := parseIdleTimeouts(opts.Hibernation)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hibernation configuration: %s", err.Error())
+	}
+	restart, err := parseRestartPolicy(opts.RestartPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid restart policy: %s", err.Error())
+	}
+	nodeConfigs, err := parseNodeConfigs(opts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node configuration: %s", err.Error())
+	}
 
 	// bootstrap registry
 	l.Info("checking for existing nodes")
@@ -46,7 +87,22 @@ func New(logger *zap.SugaredLogger, address string, ports config.Ports, dev bool
 	if len(nodes) > 0 {
 		l.Infow("bootstrapping with found nodes", "nodes", nodes)
 	}
-	reg := registry.New(l, ports, nodes...)
+	reg := registry.NewWithCapacity(l, opts.Ports, opts.Capacity, nodes...)
+
+	// load jobs
+	jobs, err := newJobManager(l, filepath.Join(opts.DataDirectory, "/data/nexus/jobs.json"))
+	if err != nil {
+		l.Errorw("failed to load jobs", "error", err)
+		return nil, fmt.Errorf("unable to load jobs: %s", err.Error())
+	}
+
+	// restore hibernated nodes, so that they are not started by the reconciler
+	// or restart policy
+	var hibernatedPath = filepath.Join(opts.DataDirectory, "/data/nexus/hibernated.json")
+	if err := loadHibernated(l, reg, hibernatedPath); err != nil {
+		l.Errorw("failed to load hibernated nodes", "error", err)
+		return nil, fmt.Errorf("unable to load hibernated nodes: %s", err.Error())
+	}
 
 	return &Orchestrator{
 		Registry: reg,
@@ -55,13 +111,33 @@ func New(logger *zap.SugaredLogger, address string, ports config.Ports, dev bool
 		nm:      networks,
 		client:  c,
 		address: address,
+		jobs:    jobs,
+
+		backupDir:      filepath.Join(opts.DataDirectory, "/data/backups"),
+		hibernatedPath: hibernatedPath,
+
+		reconcileInterval: defaultReconcileInterval,
+		livenessInterval:  defaultLivenessInterval,
+		idle:              idle,
+		restart:           restart,
+		nodeConfigs:       nodeConfigs,
 	}, nil
 }
 
 // Run initializes the orchestrator's background tasks. Cancelling the context
 // will end the tasks and release the orchestrator's resources.
 func (o *Orchestrator) Run(ctx context.Context) error {
-	go o.client.Watch(ctx)
+	go o.watchEvents(ctx)
+	go o.runJobs(ctx, jobWorkers)
+	if o.reconcileInterval > 0 {
+		go o.runReconciler(ctx, o.reconcileInterval)
+	}
+	if o.livenessInterval > 0 {
+		go o.runLivenessChecks(ctx, o.livenessInterval)
+	}
+	if o.idle.enabled() {
+		go o.runHibernator(ctx, hibernationInterval)
+	}
 	go func() {
 		select {
 		case <-ctx.Done():
@@ -79,22 +155,47 @@ type NetworkDetails struct {
 	NetworkID string
 	PeerID    string
 	SwarmPort string
+	// SwarmAddr is the multiaddr peers can bootstrap from to reach the node
+	SwarmAddr string
 	SwarmKey  string
 }
 
-// NetworkUp intializes a node for given network
+// NetworkUp intializes a node for given network. If the process fails, any
+// resources allocated for the node are released.
 func (o *Orchestrator) NetworkUp(ctx context.Context, network string) (NetworkDetails, error) {
+	unlock, err := o.locks.tryLock(network)
+	if err != nil {
+		return NetworkDetails{}, err
+	}
+	defer unlock()
+	return o.networkUp(ctx, generateID(), network)
+}
+
+// networkUp executes the network up process as a saga - if any step fails, the
+// steps that have already taken effect are undone in reverse order, so that a
+// failed process leaves the host as it was before. The caller must hold the
+// lock for the network.
+func (o *Orchestrator) networkUp(ctx context.Context, jobID, network string) (details NetworkDetails, err error) {
 	if network == "" {
 		return NetworkDetails{}, errors.New("invalid network name provided")
 	}
 
 	var start = time.Now()
-	var jobID = generateID()
 	var l = log.NewProcessLogger(o.l, "network_up",
 		"job_id", jobID,
 		"network", network)
 	l.Info("network up process started")
 
+	// undo completed steps if process fails
+	var tx = newSaga(l)
+	defer func() {
+		if err != nil {
+			l.Warnw("network up process failed - rolling back",
+				"error", err)
+			tx.rollback()
+		}
+	}()
+
 	// check if request is valid
 	n, err := o.nm.GetNetworkByName(network)
 	if err != nil {
@@ -113,21 +214,52 @@ func (o *Orchestrator) NetworkUp(ctx context.Context, network string) (NetworkDe
 		return NetworkDetails{}, fmt.Errorf("failed to configure network: %s", err.Error())
 	}
 
-	// register node for network
+	// register node for network, leasing ports and resources for it
 	newNode := getNodeFromDatabaseEntry(jobID, n)
 	if err := o.Registry.Register(newNode); err != nil {
+		if capErr, ok := err.(*registry.CapacityError); ok {
+			l.Warnw("insufficient host capacity",
+				"error", err,
+				"node.resources", newNode.Resources,
+				"host.utilization", o.Registry.Utilization())
+			return NetworkDetails{}, capErr
+		}
 		l.Errorw("no available ports",
 			"error", err)
 		return NetworkDetails{}, fmt.Errorf("failed to allocate resources for network '%s': %s", network, err)
 	}
+	tx.completed("port_lease", func(context.Context) error {
+		return o.Registry.Deregister(network)
+	})
+	opts.Config = o.nodeConfig(network, newNode.Ports.Swarm)
+
+	// node assets are created alongside the node, but existing assets belong to
+	// a previous instance of this network and must be preserved
+	exists, err := o.client.NodeAssetsExist(ctx, network)
+	if err != nil {
+		l.Errorw("unable to check for existing node assets",
+			"error", err)
+		return NetworkDetails{}, fmt.Errorf("failed to check assets for network '%s': %s", network, err)
+	}
+	if !exists {
+		tx.completed("node_assets", func(ctx context.Context) error {
+			return o.client.RemoveNode(ctx, network)
+		})
+	}
 
-	// instantiate node
+	// instantiate node - the container may have been created even if an error
+	// is returned, in which case the node's Docker ID is set
 	l = l.With("node", newNode)
 	l.Info("network registered, creating node")
+	tx.completed("node_container", func(ctx context.Context) error {
+		if newNode.DockerID == "" {
+			return nil
+		}
+		return o.client.StopNode(ctx, newNode)
+	})
 	if err := o.client.CreateNode(ctx, newNode, opts); err != nil {
-		l.Errorw("unable to create node - deregistering",
+		l.Errorw("unable to create node",
 			"error", err)
-		o.Registry.Deregister(newNode.NetworkID)
 		return NetworkDetails{}, fmt.Errorf("failed to instantiate node for network '%s': %s", network, err)
 	}
 	l.Info("node created")
@@ -135,24 +267,41 @@ func (o *Orchestrator) NetworkUp(ctx context.Context, network string) (NetworkDe
 	s, err := o.client.NodeStats(ctx, newNode)
 	if err != nil {
 		l.Errorw("failed to get node stats after node started up successfully", "error", err)
-		return NetworkDetails{NetworkID: network}, fmt.Errorf("failed to get stats about network '%s': %s",
+		return NetworkDetails{}, fmt.Errorf("failed to get stats about network '%s': %s",
 			network, err)
 	}
 
+	// make sure nodes with an existing identity came up with it
+	if opts.PeerKey != "" {
+		expected, err := ipfs.PeerIDFromKey(opts.PeerKey)
+		if err != nil {
+			return NetworkDetails{}, err
+		}
+		if s.PeerID != expected {
+			l.Errorw("node started with unexpected identity",
+				"peer_id", s.PeerID,
+				"expected_peer_id", expected)
+			return NetworkDetails{}, fmt.Errorf("node for network '%s' has peer ID '%s', expected '%s'",
+				network, s.PeerID, expected)
+		}
+	}
+
 	// update network in database
+	var previous = *n
 	n.PeerKey = s.PeerKey
 	n.SwarmKey = string(opts.SwarmKey)
-	n.SwarmAddr = fmt.Sprintf("%s:%s", o.address, newNode.Ports.Swarm)
+	n.SwarmAddr = o.bootstrapAddr(newNode.Ports.Swarm, s.PeerID)
 	n.Activated = time.Now()
 	if err := o.nm.SaveNetwork(n); err != nil {
-		l.Errorw("failed to update database - removing node",
-			"error", err,
-			"entry", n)
-		o.Registry.Deregister(newNode.NetworkID)
-		o.client.RemoveNode(ctx, newNode.NetworkID)
-		return NetworkDetails{NetworkID: network}, fmt.Errorf("failed to update network '%s': %s", network, err)
+		l.Errorw("failed to update database",
+			"error", err)
+		return NetworkDetails{}, fmt.Errorf("failed to update network '%s': %s", network, err)
 	}
+	tx.completed("database_record", func(context.Context) error {
+		return o.nm.SaveNetwork(&previous)
+	})
 
+	o.clearRestarts(network)
 	l.Infow("network up process completed",
 		"network_up.duration", time.Since(start))
 
@@ -160,12 +309,24 @@ func (o *Orchestrator) NetworkUp(ctx context.Context, network string) (NetworkDe
 		NetworkID: network,
 		PeerID:    s.PeerID,
 		SwarmPort: newNode.Ports.Swarm,
+		SwarmAddr: n.SwarmAddr,
 		SwarmKey:  n.SwarmKey,
 	}, nil
 }
 
 // NetworkUpdate updates given network's configuration from database
 func (o *Orchestrator) NetworkUpdate(ctx context.Context, network string) error {
+	unlock, err := o.locks.tryLock(network)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return o.networkUpdate(ctx, generateID(), network)
+}
+
+// networkUpdate executes NetworkUpdate for the given job. The caller must hold
+// the lock for the network.
+func (o *Orchestrator) networkUpdate(ctx context.Context, jobID, network string) error {
 	if network == "" {
 		return errors.New("invalid network name provided")
 	}
@@ -177,7 +338,6 @@ func (o *Orchestrator) NetworkUpdate(ctx context.Context, network string) error
 	}
 
 	var start = time.Now()
-	var jobID = generateID()
 	var l = log.NewProcessLogger(o.l, "network_update",
 		"job_id", jobID,
 		"network", network)
@@ -200,22 +360,41 @@ func (o *Orchestrator) NetworkUpdate(ctx context.Context, network string) error
 	new.Ports = node.Ports
 	new.DataDir = node.DataDir
 
+	// update registry, allocating resources for the new configuration
+	l.Info("updating registry")
+	if err := o.Registry.Update(new); err != nil {
+		if capErr, ok := err.(*registry.CapacityError); ok {
+			l.Warnw("insufficient host capacity",
+				"error", err,
+				"node.resources", new.Resources,
+				"host.utilization", o.Registry.Utilization())
+			return capErr
+		}
+		l.Errorw("failed to register updated network", "error", err)
+		return fmt.Errorf("error updating registry: %s", err.Error())
+	}
+
+	// hibernated nodes are started with the latest configuration when woken
+	if o.Registry.Hibernated(network) {
+		if err := o.saveHibernated(); err != nil {
+			l.Errorw("failed to persist hibernated node", "error", err)
+		}
+		l.Infow("node is hibernated - update will be applied when it is woken",
+			"network_update.duration", time.Since(start))
+		return nil
+	}
+
 	// execute update
 	l.Info("updating node",
 		"node.config", new)
 	if err = o.client.UpdateNode(ctx, new); err != nil {
-		l.Errorw("failed to update network", "error", err)
+		l.Errorw("failed to update network - restoring registry entry", "error", err)
+		if err := o.Registry.Update(&node); err != nil {
+			l.Errorw("failed to restore registry entry", "error", err)
+		}
 		return fmt.Errorf("failed to update network '%s': %s", network, err.Error())
 	}
 
-	// update registry
-	l.Info("updating registry")
-	o.Registry.Deregister(network)
-	if err := o.Registry.Register(new); err != nil {
-		l.Errorw("failed to register updated network", "error", err)
-		return fmt.Errorf("error updating registry: %s", err.Error())
-	}
-
 	l.Infow("network update process completed",
 		"network_update.duration", time.Since(start))
 	return nil
@@ -223,22 +402,36 @@ func (o *Orchestrator) NetworkUpdate(ctx context.Context, network string) error
 
 // NetworkDown brings a network offline
 func (o *Orchestrator) NetworkDown(ctx context.Context, network string) error {
+	unlock, err := o.locks.tryLock(network)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return o.networkDown(ctx, generateID(), network)
+}
+
+// networkDown executes NetworkDown for the given job. The caller must hold the
+// lock for the network.
+func (o *Orchestrator) networkDown(ctx context.Context, jobID, network string) error {
 	if network == "" {
 		return errors.New("invalid network name provided")
 	}
 
 	start := time.Now()
-	jobID := generateID()
 	l := log.NewProcessLogger(o.l, "network_down",
 		"job_id", jobID,
 		"network", network)
 	l.Info("network up process started")
 
-	// retrieve node from registry
+	// retrieve node from registry, or the stopped node if it is crash-looping
 	node, err := o.Registry.Get(network)
-	if err != nil {
-		l.Info("could not find node in registry")
-		return fmt.Errorf("failed to get node for network %s from registry: %s", network, err.Error())
+	var registered = err == nil
+	if !registered {
+		var crashLooping bool
+		if node, crashLooping = o.crashLoopingNode(network); !crashLooping {
+			l.Info("could not find node in registry")
+			return fmt.Errorf("failed to get node for network %s from registry: %s", network, err.Error())
+		}
 	}
 
 	// shut down node
@@ -251,10 +444,19 @@ func (o *Orchestrator) NetworkDown(ctx context.Context, network string) error {
 	l.Info("node stopped")
 
 	// deregister node
-	if err := o.Registry.Deregister(network); err != nil {
-		l.Errorw("error occurred while deregistering node",
-			"error", err)
+	if registered {
+		var hibernated = o.Registry.Hibernated(network)
+		if err := o.Registry.Deregister(network); err != nil {
+			l.Errorw("error occurred while deregistering node",
+				"error", err)
+		}
+		if hibernated {
+			if err := o.saveHibernated(); err != nil {
+				l.Errorw("failed to persist hibernated nodes", "error", err)
+			}
+		}
 	}
+	o.clearRestarts(network)
 
 	// update network in database to indicate it is no longer active
 	var t time.Time
@@ -279,6 +481,12 @@ func (o *Orchestrator) NetworkRemove(ctx context.Context, network string) error
 		return errors.New("invalid network name provided")
 	}
 
+	unlock, err := o.locks.tryLock(network)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	if _, err := o.Registry.Get(network); err == nil {
 		return errors.New("network is still online and in registry - must be offline for removal")
 	}
@@ -286,6 +494,235 @@ func (o *Orchestrator) NetworkRemove(ctx context.Context, network string) error
 	return o.client.RemoveNode(ctx, network)
 }
 
+// NetworkRestart restarts the given network's node in place and bootstraps it
+// with its configured peers. The node's ports, assets and database state are
+// retained. Crash-looping nodes can be restarted even if they have been
+// deregistered, and their restart history is reset.
+func (o *Orchestrator) NetworkRestart(ctx context.Context, network string) error {
+	unlock, err := o.locks.tryLock(network)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return o.networkRestart(ctx, generateID(), network)
+}
+
+// networkRestart executes NetworkRestart for the given job. The caller must
+// hold the lock for the network.
+func (o *Orchestrator) networkRestart(ctx context.Context, jobID, network string) error {
+	if network == "" {
+		return errors.New("invalid network name provided")
+	}
+
+	var start = time.Now()
+	var l = log.NewProcessLogger(o.l, "network_restart",
+		"job_id", jobID,
+		"network", network)
+	l.Info("network restart process started")
+
+	node, err := o.Registry.Get(network)
+	if err != nil {
+		var crashLooping bool
+		if node, crashLooping = o.crashLoopingNode(network); !crashLooping {
+			return fmt.Errorf("failed to find node for network '%s': %s", network, err.Error())
+		}
+	}
+	if o.Registry.Hibernated(network) {
+		return fmt.Errorf("network '%s' is hibernated", network)
+	}
+
+	l = l.With("node", node)
+	l.Info("restarting node")
+	if err := o.client.RestartNode(ctx, &node); err != nil {
+		l.Errorw("failed to restart node", "error", err)
+		return fmt.Errorf("failed to restart network '%s': %s", network, err.Error())
+	}
+	o.registerRestarted(ctx, l, &node)
+	o.clearRestarts(network)
+
+	l.Infow("network restart process completed",
+		"network_restart.duration", time.Since(start))
+	return nil
+}
+
+// NetworkUpgrade upgrades the given network's node to the given go-ipfs version
+// and pins the network to it, so that new releases can be rolled out to
+// individual networks. An empty version upgrades the node to the default
+// version and removes the pin. The node's repo is migrated by the new version,
+// and if the upgraded node is unhealthy, the node is rolled back to its previous
+// version and repo.
+func (o *Orchestrator) NetworkUpgrade(ctx context.Context, network, version string) error {
+	if network == "" {
+		return errors.New("invalid network name provided")
+	}
+
+	unlock, err := o.locks.tryLock(network)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	var start = time.Now()
+	var l = log.NewProcessLogger(o.l, "network_upgrade",
+		"job_id", generateID(),
+		"network", network,
+		"version", version)
+	l.Info("network upgrade process started")
+
+	node, err := o.Registry.Get(network)
+	if err != nil {
+		return fmt.Errorf("failed to find node for network '%s': %s", network, err.Error())
+	}
+	if o.Registry.Hibernated(network) {
+		return fmt.Errorf("network '%s' is hibernated", network)
+	}
+
+	l = l.With("node", node)
+	l.Info("upgrading node")
+	err = o.client.UpgradeNode(ctx, &node, version)
+
+	// the node's container is replaced even if the upgrade is rolled back
+	if uerr := o.Registry.Update(&node); uerr != nil {
+		l.Errorw("failed to update registry", "error", uerr)
+	}
+	if err != nil {
+		l.Errorw("failed to upgrade node", "error", err)
+		return fmt.Errorf("failed to upgrade network '%s': %s", network, err.Error())
+	}
+
+	l.Infow("network upgrade process completed",
+		"network_upgrade.duration", time.Since(start))
+	return nil
+}
+
+// RotateSwarmKey generates a new swarm key for the given network and restarts
+// the network's node with it, revoking access from peers that only have the
+// previous key. The new key is returned so that it can be distributed to the
+// network's members. If the node fails to restart with the new key, or the new
+// key cannot be saved, the node is restarted with its previous key.
+func (o *Orchestrator) RotateSwarmKey(ctx context.Context, network string) (string, error) {
+	if network == "" {
+		return "", errors.New("invalid network name provided")
+	}
+
+	unlock, err := o.locks.tryLock(network)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	var start = time.Now()
+	var l = log.NewProcessLogger(o.l, "swarm_key_rotation",
+		"job_id", generateID(),
+		"network", network)
+	l.Info("swarm key rotation process started")
+
+	node, err := o.Registry.Get(network)
+	if err != nil {
+		return "", fmt.Errorf("failed to find node for network '%s': %s", network, err.Error())
+	}
+	if o.Registry.Hibernated(network) {
+		return "", fmt.Errorf("network '%s' is hibernated", network)
+	}
+	n, err := o.nm.GetNetworkByName(network)
+	if err != nil {
+		l.Infow("failed to fetch network from database",
+			"error", err)
+		return "", fmt.Errorf("no network with name '%s' found", network)
+	}
+	if n.SwarmKey == "" {
+		return "", fmt.Errorf("network '%s' has no swarm key to rotate", network)
+	}
+	key, err := ipfs.SwarmKey()
+	if err != nil {
+		l.Errorw("failed to generate swarm key", "error", err)
+		return "", fmt.Errorf("failed to generate swarm key: %s", err.Error())
+	}
+
+	l = l.With("node", node)
+	l.Info("restarting node with new swarm key")
+	if err := o.client.RotateSwarmKey(ctx, &node, []byte(key)); err != nil {
+		l.Errorw("failed to rotate swarm key", "error", err)
+		return "", fmt.Errorf("failed to rotate swarm key for network '%s': %s", network, err.Error())
+	}
+
+	// the node must not be left with a key that nobody else has
+	var previous = n.SwarmKey
+	n.SwarmKey = key
+	if err := o.nm.SaveNetwork(n); err != nil {
+		l.Errorw("failed to save swarm key - restoring previous key",
+			"error", err)
+		if rerr := o.client.RotateSwarmKey(ctx, &node, []byte(previous)); rerr != nil {
+			l.Errorw("failed to restore previous swarm key", "error", rerr)
+			return "", fmt.Errorf("failed to save swarm key for network '%s': %s, and failed to restore previous key: %s",
+				network, err.Error(), rerr.Error())
+		}
+		return "", fmt.Errorf("failed to save swarm key for network '%s': %s", network, err.Error())
+	}
+
+	l.Infow("swarm key rotation process completed",
+		"swarm_key_rotation.duration", time.Since(start))
+	return key, nil
+}
+
+// NetworkPause suspends the given network's node without releasing its
+// resources, ports or database state. Paused nodes do not respond to requests
+// until they are resumed with NetworkResume.
+func (o *Orchestrator) NetworkPause(ctx context.Context, network string) error {
+	if network == "" {
+		return errors.New("invalid network name provided")
+	}
+
+	unlock, err := o.locks.tryLock(network)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	node, err := o.Registry.Get(network)
+	if err != nil {
+		return fmt.Errorf("failed to find node for network '%s': %s", network, err.Error())
+	}
+	if o.Registry.Hibernated(network) {
+		return fmt.Errorf("network '%s' is hibernated", network)
+	}
+
+	if err := o.client.PauseNode(ctx, &node); err != nil {
+		o.l.Errorw("failed to pause node",
+			"error", err, "network", network)
+		return fmt.Errorf("failed to pause network '%s': %s", network, err.Error())
+	}
+	o.l.Infow("network paused", "network", network)
+	return nil
+}
+
+// NetworkResume resumes the given network's node after a NetworkPause
+func (o *Orchestrator) NetworkResume(ctx context.Context, network string) error {
+	if network == "" {
+		return errors.New("invalid network name provided")
+	}
+
+	unlock, err := o.locks.tryLock(network)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	node, err := o.Registry.Get(network)
+	if err != nil {
+		return fmt.Errorf("failed to find node for network '%s': %s", network, err.Error())
+	}
+
+	if err := o.client.ResumeNode(ctx, &node); err != nil {
+		o.l.Errorw("failed to resume node",
+			"error", err, "network", network)
+		return fmt.Errorf("failed to resume network '%s': %s", network, err.Error())
+	}
+	o.Registry.Touch(network)
+	o.l.Infow("network resumed", "network", network)
+	return nil
+}
+
 // NetworkStatus denotes high-level details about requested network, intended
 // for consumer use
 type NetworkStatus struct {
@@ -312,8 +749,9 @@ func (o *Orchestrator) NetworkStatus(ctx context.Context, network string) (Netwo
 	return NetworkStatus{
 		NetworkDetails: NetworkDetails{
 			NetworkID: network,
-			PeerID:    n.NetworkID,
+			PeerID:    stats.PeerID,
 			SwarmPort: n.Ports.Swarm,
+			SwarmAddr: o.bootstrapAddr(n.Ports.Swarm, stats.PeerID),
 			SwarmKey:  "<OMITTED>",
 		},
 		Uptime:    stats.Uptime,
@@ -325,26 +763,108 @@ func (o *Orchestrator) NetworkStatus(ctx context.Context, network string) (Netwo
 type NetworkDiagnostics struct {
 	ipfs.NodeInfo
 	ipfs.NodeStats
+
+	// HostUtilization describes resource allocation on the node's host
+	HostUtilization registry.Utilization
+
+	// Hibernated indicates whether the node has been stopped due to inactivity
+	Hibernated bool
+	// LastActive is the time of the most recent request to the node
+	LastActive time.Time
+	// Health describes the results of liveness probes against the node
+	Health registry.Health
+	// CrashLooping indicates that the node has stopped too many times to be
+	// restarted automatically, and requires an operator to intervene
+	CrashLooping bool
+	// Restarts is the number of recent automatic restarts of the node
+	Restarts int
 }
 
-// NetworkDiagnostics retrieves detailed statistics and information about a node
+// NetworkDiagnostics retrieves detailed statistics and information about a
+// node. Crash-looping nodes are reported even if they have been deregistered.
 func (o *Orchestrator) NetworkDiagnostics(ctx context.Context, network string) (NetworkDiagnostics, error) {
 	o.l.Info("diagnostics requested for network", "network.id", network)
 	n, err := o.Registry.Get(network)
 	if err != nil {
-		return NetworkDiagnostics{}, fmt.Errorf("failed to retrieve network details: %s", err.Error())
+		stopped, crashLooping := o.crashLoopingNode(network)
+		if !crashLooping {
+			return NetworkDiagnostics{}, fmt.Errorf("failed to retrieve network details: %s", err.Error())
+		}
+		return NetworkDiagnostics{
+			NodeInfo:        stopped,
+			HostUtilization: o.Registry.Utilization(),
+			CrashLooping:    true,
+			Restarts:        o.recentRestarts(network),
+		}, nil
 	}
 
 	// attempt to retrieve live network stats, return what's possible
-	stats, err := o.client.NodeStats(ctx, &n)
+	var (
+		hibernated = o.Registry.Hibernated(network)
+		stats      ipfs.NodeStats
+	)
+	if !hibernated {
+		if stats, err = o.client.NodeStats(ctx, &n); err != nil {
+			o.l.Errorw("error occurred while attempting to acess registered node",
+				"error", err,
+				"node", n)
+		}
+	}
+	lastActive, _ := o.Registry.LastActive(network)
+	health, _ := o.Registry.Health(network)
+	_, crashLooping := o.crashLoopingNode(network)
+
+	return NetworkDiagnostics{
+		NodeInfo:        n,
+		NodeStats:       stats,
+		HostUtilization: o.Registry.Utilization(),
+		Hibernated:      hibernated,
+		LastActive:      lastActive,
+		Health:          health,
+		CrashLooping:    crashLooping,
+		Restarts:        o.recentRestarts(network),
+	}, nil
+}
+
+// NetworkStats streams resource usage samples of the given network's node every
+// interval, until the given context is cancelled or the node stops
+func (o *Orchestrator) NetworkStats(ctx context.Context, network string, interval time.Duration) (<-chan ipfs.ContainerStats, error) {
+	n, err := o.Registry.Get(network)
 	if err != nil {
-		o.l.Errorw("error occurred while attempting to acess registered node",
+		return nil, fmt.Errorf("failed to retrieve network details: %s", err.Error())
+	}
+	if o.Registry.Hibernated(network) {
+		return nil, fmt.Errorf("network '%s' is hibernated", network)
+	}
+
+	samples, err := o.client.StreamStats(ctx, &n, interval)
+	if err != nil {
+		o.l.Errorw("error occurred while attempting to stream node stats",
 			"error", err,
 			"node", n)
+		return nil, err
 	}
+	return samples, nil
+}
 
-	return NetworkDiagnostics{
-		NodeInfo:  n,
-		NodeStats: stats,
-	}, nil
+// NetworkLogs retrieves output from the given network's node. If opts.Follow is
+// set, output is streamed until the given context is cancelled or the node
+// stops
+func (o *Orchestrator) NetworkLogs(ctx context.Context, network string, opts ipfs.LogOptions) (<-chan ipfs.LogLine, error) {
+	n, err := o.Registry.Get(network)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve network details: %s", err.Error())
+	}
+	if o.Registry.Hibernated(network) {
+		return nil, fmt.Errorf("network '%s' is hibernated", network)
+	}
+
+	lines, err := o.client.NodeLogs(ctx, &n, opts)
+	if err != nil {
+		o.l.Errorw("error occurred while attempting to retrieve node logs",
+			"error", err,
+			"node", n)
+		return nil, err
+	}
+	return lines, nil
 }
@@ -15,6 +15,8 @@ import (
 	"github.com/RTradeLtd/Nexus/ipfs/mock"
 	"github.com/RTradeLtd/Nexus/log"
 	"github.com/RTradeLtd/Nexus/registry"
+	"github.com/RTradeLtd/Nexus/temporal"
+	tmock "github.com/RTradeLtd/Nexus/temporal/mock"
 )
 
 func TestNew(t *testing.T) {
@@ -45,7 +47,8 @@ func TestNew(t *testing.T) {
 				t.Fatalf("failed to reach database: %s\n", err.Error())
 			}
 
-			_, err = New(l, "", config.Ports{}, true, client, models.NewHostedIPFSNetworkManager(dbm.DB))
+			_, err = New(l, "", config.IPFS{DataDirectory: "./tmp"}, true, client,
+				temporal.NewNetworkManager(models.NewHostedIPFSNetworkManager(dbm.DB)))
 			if (err != nil) != tt.wantErr {
 				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -61,7 +64,8 @@ func TestOrchestrator_Run(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to reach database: %s\n", err.Error())
 	}
-	o, err := New(l, "", config.Ports{}, true, client, models.NewHostedIPFSNetworkManager(dbm.DB))
+	o, err := New(l, "", config.IPFS{DataDirectory: "./tmp"}, true, client,
+		temporal.NewNetworkManager(models.NewHostedIPFSNetworkManager(dbm.DB)))
 	if err != nil {
 		t.Error(err)
 		return
@@ -120,7 +124,7 @@ func TestOrchestrator_NetworkUp(t *testing.T) {
 			o := &Orchestrator{
 				Registry: registry.New(l, tt.fields.regPorts),
 				l:        l,
-				nm:       nm,
+				nm:       temporal.NewNetworkManager(nm),
 				client:   client,
 				address:  "127.0.0.1",
 			}
@@ -185,7 +189,7 @@ func TestOrchestrator_NetworkDown(t *testing.T) {
 			o := &Orchestrator{
 				Registry: registry.New(l, config.New().Ports, &tt.fields.node),
 				l:        l,
-				nm:       nm,
+				nm:       temporal.NewNetworkManager(nm),
 				client:   client,
 				address:  "127.0.0.1",
 			}
@@ -295,7 +299,7 @@ func TestOrchestrator_NetworkUpdate(t *testing.T) {
 				Registry: registry.New(l, config.New().Ports, &tt.fields.node),
 				l:        l,
 				client:   client,
-				nm:       nm,
+				nm:       temporal.NewNetworkManager(nm),
 				address:  "127.0.0.1",
 			}
 
@@ -391,3 +395,500 @@ func TestOrchestrator_NetworkDiagnostics(t *testing.T) {
 		})
 	}
 }
+
+func TestOrchestrator_capacity(t *testing.T) {
+	l, _ := log.NewTestLogger()
+	var (
+		ctx      = context.Background()
+		networks = &tmock.FakePrivateNetworks{}
+		o        = &Orchestrator{
+			Registry: registry.NewWithCapacity(l, config.New().Ports, config.Capacity{
+				CPUs: 4, MemoryGB: 8, DiskGB: 150,
+			}),
+			l:       l,
+			nm:      networks,
+			client:  mock.NewMemoryNodeClient(),
+			address: "127.0.0.1",
+		}
+	)
+	defer o.Registry.Close()
+	networks.GetNetworkByNameStub = func(name string) (*models.HostedIPFSPrivateNetwork, error) {
+		return &models.HostedIPFSPrivateNetwork{
+			Name:              name,
+			ResourcesCPUs:     2,
+			ResourcesMemoryGB: 2,
+			ResourcesDiskGB:   50,
+		}, nil
+	}
+
+	// first two networks fit on host
+	for _, network := range []string{"bobheadxi", "postables"} {
+		if _, err := o.NetworkUp(ctx, network); err != nil {
+			t.Fatalf("Orchestrator.NetworkUp() error = %v", err)
+		}
+	}
+
+	// third should be rejected without side effects
+	_, err := o.NetworkUp(ctx, "timhortons")
+	if capErr, ok := err.(*registry.CapacityError); !ok || capErr.Resource != registry.ResourceCPUs {
+		t.Errorf("expected cpu *registry.CapacityError, got %v", err)
+	}
+	if _, err := o.Registry.Get("timhortons"); err == nil {
+		t.Error("expected rejected network to not be registered")
+	}
+
+	// utilization should be reported in diagnostics
+	d, err := o.NetworkDiagnostics(ctx, "bobheadxi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.HostUtilization.Allocated != (ipfs.NodeResources{CPUs: 4, MemoryGB: 4, DiskGB: 100}) ||
+		d.HostUtilization.Limits != (ipfs.NodeResources{CPUs: 4, MemoryGB: 8, DiskGB: 150}) {
+		t.Errorf("unexpected host utilization %+v", d.HostUtilization)
+	}
+
+	// capacity should be released when networks go down
+	if err := o.NetworkDown(ctx, "postables"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.NetworkUp(ctx, "timhortons"); err != nil {
+		t.Errorf("expected network to fit after capacity was released, got %v", err)
+	}
+}
+
+func TestOrchestrator_NetworkRestart(t *testing.T) {
+	l, _ := log.NewTestLogger()
+	var (
+		ctx      = context.Background()
+		client   = mock.NewMemoryNodeClient()
+		networks = &tmock.FakePrivateNetworks{}
+		o        = &Orchestrator{
+			Registry: registry.New(l, config.New().Ports),
+			l:        l,
+			nm:       networks,
+			client:   client,
+			address:  "127.0.0.1",
+		}
+	)
+	defer o.Registry.Close()
+	networks.GetNetworkByNameStub = func(name string) (*models.HostedIPFSPrivateNetwork, error) {
+		return &models.HostedIPFSPrivateNetwork{Name: name, SwarmKey: testSwarmKey,
+			Activated: time.Now()}, nil
+	}
+	if _, err := o.NetworkUp(ctx, "bobheadxi"); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := o.Registry.Get("bobheadxi")
+	var dbUpdates = networks.UpdateNetworkByNameCallCount()
+
+	// invalid and unknown networks
+	if err := o.NetworkRestart(ctx, ""); err == nil {
+		t.Error("expected error for invalid network")
+	}
+	if err := o.NetworkRestart(ctx, "postables"); err == nil {
+		t.Error("expected error for unknown network")
+	}
+
+	// failed restarts are reported
+	client.Fail(mock.OpRestartNode, "bobheadxi", errors.New("oh no"))
+	if err := o.NetworkRestart(ctx, "bobheadxi"); err == nil {
+		t.Error("expected restart to fail")
+	}
+
+	// restarts should retain node configuration
+	if err := o.NetworkRestart(ctx, "bobheadxi"); err != nil {
+		t.Errorf("Orchestrator.NetworkRestart() error = %v", err)
+	}
+	after, err := o.Registry.Get("bobheadxi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.DockerID != before.DockerID || after.Ports != before.Ports {
+		t.Errorf("expected node %+v to be retained, got %+v", before, after)
+	}
+	if state := client.State("bobheadxi"); state != mock.StateRunning {
+		t.Errorf("expected node to be running, got state '%s'", state)
+	}
+	if networks.UpdateNetworkByNameCallCount() != dbUpdates {
+		t.Error("expected database to be left alone")
+	}
+}
+
+func TestOrchestrator_NetworkUpgrade(t *testing.T) {
+	l, _ := log.NewTestLogger()
+	var (
+		ctx      = context.Background()
+		client   = mock.NewMemoryNodeClient()
+		networks = &tmock.FakePrivateNetworks{}
+		o        = &Orchestrator{
+			Registry: registry.New(l, config.New().Ports),
+			l:        l,
+			nm:       networks,
+			client:   client,
+			address:  "127.0.0.1",
+		}
+	)
+	defer o.Registry.Close()
+	networks.GetNetworkByNameStub = func(name string) (*models.HostedIPFSPrivateNetwork, error) {
+		return &models.HostedIPFSPrivateNetwork{Name: name, SwarmKey: testSwarmKey}, nil
+	}
+	if _, err := o.NetworkUp(ctx, "bobheadxi"); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := o.Registry.Get("bobheadxi")
+
+	// invalid and unknown networks
+	if err := o.NetworkUpgrade(ctx, "", "v0.4.19"); err == nil {
+		t.Error("expected error for invalid network")
+	}
+	if err := o.NetworkUpgrade(ctx, "postables", "v0.4.19"); err == nil {
+		t.Error("expected error for unknown network")
+	}
+
+	// failed upgrades are rolled back
+	client.Fail(mock.OpUpgradeNode, "bobheadxi", errors.New("migration failed"))
+	if err := o.NetworkUpgrade(ctx, "bobheadxi", "v0.4.19"); err == nil {
+		t.Error("expected upgrade to fail")
+	}
+	if n, _ := o.Registry.Get("bobheadxi"); n.DockerID != before.DockerID || n.Version != before.Version {
+		t.Errorf("expected node %+v to be retained, got %+v", before, n)
+	}
+
+	// upgraded node should replace the registered node
+	if err := o.NetworkUpgrade(ctx, "bobheadxi", "v0.4.19"); err != nil {
+		t.Errorf("Orchestrator.NetworkUpgrade() error = %v", err)
+	}
+	after, err := o.Registry.Get("bobheadxi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Version != "v0.4.19" || after.DockerID == before.DockerID || after.Ports != before.Ports {
+		t.Errorf("expected node %+v to be upgraded, got %+v", before, after)
+	}
+	if d, _ := o.NetworkDiagnostics(ctx, "bobheadxi"); d.Version != "v0.4.19" {
+		t.Errorf("expected version in diagnostics, got %+v", d.NodeInfo)
+	}
+}
+
+func TestOrchestrator_RotateSwarmKey(t *testing.T) {
+	l, _ := log.NewTestLogger()
+	var (
+		ctx      = context.Background()
+		client   = mock.NewMemoryNodeClient()
+		networks = &tmock.FakePrivateNetworks{}
+		o        = &Orchestrator{
+			Registry: registry.New(l, config.New().Ports),
+			l:        l,
+			nm:       networks,
+			client:   client,
+			address:  "127.0.0.1",
+		}
+		swarmKey = testSwarmKey
+		saveErr  error
+	)
+	defer o.Registry.Close()
+	networks.GetNetworkByNameStub = func(name string) (*models.HostedIPFSPrivateNetwork, error) {
+		return &models.HostedIPFSPrivateNetwork{Name: name, SwarmKey: swarmKey}, nil
+	}
+	networks.SaveNetworkStub = func(n *models.HostedIPFSPrivateNetwork) error {
+		if saveErr != nil {
+			return saveErr
+		}
+		swarmKey = n.SwarmKey
+		return nil
+	}
+	if _, err := o.NetworkUp(ctx, "bobheadxi"); err != nil {
+		t.Fatal(err)
+	}
+
+	// invalid and unknown networks
+	if _, err := o.RotateSwarmKey(ctx, ""); err == nil {
+		t.Error("expected error for invalid network")
+	}
+	if _, err := o.RotateSwarmKey(ctx, "postables"); err == nil {
+		t.Error("expected error for unknown network")
+	}
+
+	// failed rotations should retain the previous key
+	client.Fail(mock.OpRotateSwarmKey, "bobheadxi", errors.New("node failed to start"))
+	if _, err := o.RotateSwarmKey(ctx, "bobheadxi"); err == nil {
+		t.Error("expected rotation to fail")
+	}
+	if swarmKey != testSwarmKey || string(client.SwarmKey("bobheadxi")) != testSwarmKey {
+		t.Errorf("expected previous key to be retained, got '%s'", swarmKey)
+	}
+
+	// keys that cannot be saved should be rolled back on the node
+	saveErr = errors.New("database unavailable")
+	if _, err := o.RotateSwarmKey(ctx, "bobheadxi"); err == nil {
+		t.Error("expected rotation to fail")
+	}
+	saveErr = nil
+	if string(client.SwarmKey("bobheadxi")) != testSwarmKey {
+		t.Errorf("expected previous key to be restored, got '%s'", client.SwarmKey("bobheadxi"))
+	}
+
+	// rotated key should be written to the node and saved
+	key, err := o.RotateSwarmKey(ctx, "bobheadxi")
+	if err != nil {
+		t.Fatalf("Orchestrator.RotateSwarmKey() error = %v", err)
+	}
+	if key == testSwarmKey || swarmKey != key || string(client.SwarmKey("bobheadxi")) != key {
+		t.Errorf("expected key '%s' to be saved and written to node, got '%s' and '%s'",
+			key, swarmKey, client.SwarmKey("bobheadxi"))
+	}
+	if client.State("bobheadxi") != mock.StateRunning {
+		t.Error("expected node to be running after rotation")
+	}
+}
+
+func TestOrchestrator_NetworkUpPeerKey(t *testing.T) {
+	l, _ := log.NewTestLogger()
+	var (
+		ctx      = context.Background()
+		client   = mock.NewMemoryNodeClient()
+		networks = &tmock.FakePrivateNetworks{}
+		o        = &Orchestrator{
+			Registry: registry.New(l, config.New().Ports),
+			l:        l,
+			nm:       networks,
+			client:   client,
+			address:  "127.0.0.1",
+		}
+		peerKey = testPeerKey
+	)
+	defer o.Registry.Close()
+	networks.GetNetworkByNameStub = func(name string) (*models.HostedIPFSPrivateNetwork, error) {
+		return &models.HostedIPFSPrivateNetwork{Name: name, SwarmKey: testSwarmKey, PeerKey: peerKey}, nil
+	}
+	networks.SaveNetworkStub = func(n *models.HostedIPFSPrivateNetwork) error {
+		peerKey = n.PeerKey
+		return nil
+	}
+
+	// node should start with identity from database
+	details, err := o.NetworkUp(ctx, "bobheadxi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if details.PeerID != testPeerID || peerKey != testPeerKey {
+		t.Errorf("expected peer ID '%s', got '%s'", testPeerID, details.PeerID)
+	}
+
+	// redeployed node should keep identity, even if its assets are gone
+	if err := o.NetworkDown(ctx, "bobheadxi"); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.NetworkRemove(ctx, "bobheadxi"); err != nil {
+		t.Fatal(err)
+	}
+	if details, err = o.NetworkUp(ctx, "bobheadxi"); err != nil {
+		t.Fatal(err)
+	}
+	if details.PeerID != testPeerID {
+		t.Errorf("expected peer ID '%s' after redeploy, got '%s'", testPeerID, details.PeerID)
+	}
+
+	// nodes with a different identity should not be brought up
+	if err := o.NetworkDown(ctx, "bobheadxi"); err != nil {
+		t.Fatal(err)
+	}
+	_, peerKey = newTestIdentity()
+	if _, err := o.NetworkUp(ctx, "bobheadxi"); err == nil {
+		t.Error("expected error for mismatched peer key")
+	}
+	if _, err := o.Registry.Get("bobheadxi"); err == nil {
+		t.Error("expected network to be rolled back")
+	}
+}
+
+func TestOrchestrator_NetworkPause(t *testing.T) {
+	l, _ := log.NewTestLogger()
+	var (
+		ctx      = context.Background()
+		client   = mock.NewMemoryNodeClient()
+		networks = &tmock.FakePrivateNetworks{}
+		o        = &Orchestrator{
+			Registry: registry.New(l, config.New().Ports),
+			l:        l,
+			nm:       networks,
+			client:   client,
+			address:  "127.0.0.1",
+		}
+	)
+	defer o.Registry.Close()
+	networks.GetNetworkByNameStub = func(name string) (*models.HostedIPFSPrivateNetwork, error) {
+		return &models.HostedIPFSPrivateNetwork{Name: name, SwarmKey: testSwarmKey}, nil
+	}
+	if _, err := o.NetworkUp(ctx, "bobheadxi"); err != nil {
+		t.Fatal(err)
+	}
+	var dbUpdates = networks.UpdateNetworkByNameCallCount()
+
+	tests := []struct {
+		name      string
+		op        func(context.Context, string) error
+		network   string
+		wantErr   bool
+		wantState string
+	}{
+		{"pause invalid network", o.NetworkPause, "", true, mock.StateRunning},
+		{"pause unknown network", o.NetworkPause, "postables", true, mock.StateRunning},
+		{"resume running network", o.NetworkResume, "bobheadxi", true, mock.StateRunning},
+		{"pause running network", o.NetworkPause, "bobheadxi", false, mock.StatePaused},
+		{"pause paused network", o.NetworkPause, "bobheadxi", true, mock.StatePaused},
+		{"resume unknown network", o.NetworkResume, "postables", true, mock.StatePaused},
+		{"resume paused network", o.NetworkResume, "bobheadxi", false, mock.StateRunning},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.op(ctx, tt.network); (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if state := client.State("bobheadxi"); state != tt.wantState {
+				t.Errorf("expected state '%s', got '%s'", tt.wantState, state)
+			}
+			if _, err := o.Registry.Get("bobheadxi"); err != nil {
+				t.Errorf("expected node to remain registered, got %v", err)
+			}
+		})
+	}
+	if networks.UpdateNetworkByNameCallCount() != dbUpdates {
+		t.Error("expected database to be left alone")
+	}
+}
+
+func TestOrchestrator_NetworkStats(t *testing.T) {
+	l, _ := log.NewTestLogger()
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		networks    = &tmock.FakePrivateNetworks{}
+		o           = &Orchestrator{
+			Registry: registry.New(l, config.New().Ports),
+			l:        l,
+			nm:       networks,
+			client:   mock.NewMemoryNodeClient(),
+			address:  "127.0.0.1",
+		}
+	)
+	defer cancel()
+	defer o.Registry.Close()
+	networks.GetNetworkByNameStub = func(name string) (*models.HostedIPFSPrivateNetwork, error) {
+		return &models.HostedIPFSPrivateNetwork{Name: name, SwarmKey: testSwarmKey}, nil
+	}
+	if _, err := o.NetworkUp(ctx, "bobheadxi"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := o.NetworkStats(ctx, "postables", time.Millisecond); err == nil {
+		t.Error("expected error for unknown network")
+	}
+	samples, err := o.NetworkStats(ctx, "bobheadxi", time.Millisecond)
+	if err != nil {
+		t.Fatalf("Orchestrator.NetworkStats() error = %v", err)
+	}
+	if s := <-samples; s.MemoryLimit == 0 || s.Time.IsZero() {
+		t.Errorf("unexpected sample %+v", s)
+	}
+
+	// stream should end when context is cancelled
+	cancel()
+	for range samples {
+	}
+}
+
+func TestOrchestrator_NetworkLogs(t *testing.T) {
+	l, _ := log.NewTestLogger()
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		networks    = &tmock.FakePrivateNetworks{}
+		o           = &Orchestrator{
+			Registry: registry.New(l, config.New().Ports),
+			l:        l,
+			nm:       networks,
+			client:   mock.NewMemoryNodeClient(),
+			address:  "127.0.0.1",
+		}
+	)
+	defer cancel()
+	defer o.Registry.Close()
+	networks.GetNetworkByNameStub = func(name string) (*models.HostedIPFSPrivateNetwork, error) {
+		return &models.HostedIPFSPrivateNetwork{Name: name, SwarmKey: testSwarmKey}, nil
+	}
+	if _, err := o.NetworkUp(ctx, "bobheadxi"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := o.NetworkLogs(ctx, "postables", ipfs.LogOptions{}); err == nil {
+		t.Error("expected error for unknown network")
+	}
+	lines, err := o.NetworkLogs(ctx, "bobheadxi", ipfs.LogOptions{Tail: 1})
+	if err != nil {
+		t.Fatalf("Orchestrator.NetworkLogs() error = %v", err)
+	}
+	var got = make([]ipfs.LogLine, 0)
+	for l := range lines {
+		got = append(got, l)
+	}
+	if len(got) != 1 || got[0].Text == "" || got[0].Time.IsZero() {
+		t.Errorf("unexpected output %+v", got)
+	}
+
+	// followed output should end when context is cancelled
+	lines, err = o.NetworkLogs(ctx, "bobheadxi", ipfs.LogOptions{Follow: true})
+	if err != nil {
+		t.Fatalf("Orchestrator.NetworkLogs() error = %v", err)
+	}
+	cancel()
+	for range lines {
+	}
+}
+
+func TestOrchestrator_NetworkUpAnnounce(t *testing.T) {
+	l, _ := log.NewTestLogger()
+	var (
+		ctx      = context.Background()
+		client   = mock.NewMemoryNodeClient()
+		networks = &tmock.FakePrivateNetworks{}
+		o        = &Orchestrator{
+			Registry: registry.New(l, config.New().Ports),
+			l:        l,
+			nm:       networks,
+			client:   client,
+			address:  "192.168.1.4",
+		}
+	)
+	defer o.Registry.Close()
+	networks.GetNetworkByNameStub = func(name string) (*models.HostedIPFSPrivateNetwork, error) {
+		return &models.HostedIPFSPrivateNetwork{Name: name, SwarmKey: testSwarmKey}, nil
+	}
+
+	details, err := o.NetworkUp(ctx, "bobheadxi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		swarmAddr = "/ip4/192.168.1.4/tcp/" + details.SwarmPort
+		want      = swarmAddr + "/ipfs/" + details.PeerID
+	)
+	if details.SwarmAddr != want {
+		t.Errorf("expected swarm address '%s', got '%s'", want, details.SwarmAddr)
+	}
+	if got := networks.SaveNetworkArgsForCall(0).SwarmAddr; got != want {
+		t.Errorf("expected database swarm address '%s', got '%s'", want, got)
+	}
+
+	// node should announce its public address, even though it is private
+	var cfg = client.NodeConfig("bobheadxi")
+	if cfg == nil {
+		t.Fatal("expected node configuration")
+	}
+	if len(cfg.Addresses.Announce) != 1 || cfg.Addresses.Announce[0] != swarmAddr {
+		t.Errorf("expected node to announce '%s', got %v", swarmAddr, cfg.Addresses.Announce)
+	}
+	for _, r := range cfg.Addresses.NoAnnounce {
+		if r == "/ip4/192.168.0.0/ipcidr/16" {
+			t.Error("expected host's range to be announced")
+		}
+	}
+}
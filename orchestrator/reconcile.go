@@ -0,0 +1,216 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/RTradeLtd/Nexus/ipfs"
+	"github.com/RTradeLtd/Nexus/log"
+)
+
+// defaultReconcileInterval is the default interval between reconciliation
+// passes
+const defaultReconcileInterval = 5 * time.Minute
+
+// ReconcileReport summarizes the changes made by a reconciliation pass
+type ReconcileReport struct {
+	// Registered lists networks with running nodes that were missing from the
+	// registry
+	Registered []string
+	// Deregistered lists registered networks without running nodes
+	Deregistered []string
+	// Started lists active networks without nodes that were started
+	Started []string
+	// Deactivated lists active networks without nodes that failed to start
+	Deactivated []string
+	// Stopped lists active networks with stopped nodes, which are left to be
+	// restarted according to the restart policy
+	Stopped []string
+	// Updated lists networks whose database entries were out of date
+	Updated []string
+	// Skipped lists networks that were not reconciled because operations were
+	// in progress
+	Skipped []string
+}
+
+// runReconciler reconciles orchestrator state on startup, and then
+// periodically until the given context is cancelled
+func (o *Orchestrator) runReconciler(ctx context.Context, interval time.Duration) {
+	var ticker = time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := o.Reconcile(ctx); err != nil {
+			o.l.Errorw("reconciliation failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reconcile compares node containers, registry entries, and active networks in
+// the database, and fixes any drift between them: running nodes missing from
+// the registry are registered, registry entries without nodes are
+// deregistered, active networks without nodes are started, and stale database
+// entries are updated. Networks with operations in progress are skipped,
+// hibernated networks are left stopped, and stopped nodes are left to the
+// restart policy.
+func (o *Orchestrator) Reconcile(ctx context.Context) (ReconcileReport, error) {
+	var (
+		start  = time.Now()
+		report ReconcileReport
+		l      = log.NewProcessLogger(o.l, "reconcile",
+			"job_id", generateID())
+	)
+
+	// retrieve state from all sources
+	nodes, err := o.client.Nodes(ctx)
+	if err != nil {
+		l.Errorw("failed to fetch nodes", "error", err)
+		return report, fmt.Errorf("unable to fetch nodes: %s", err.Error())
+	}
+	stopped, err := o.client.StoppedNodes(ctx)
+	if err != nil {
+		l.Errorw("failed to fetch stopped nodes", "error", err)
+		return report, fmt.Errorf("unable to fetch stopped nodes: %s", err.Error())
+	}
+	active, err := o.nm.GetActiveNetworks()
+	if err != nil {
+		l.Errorw("failed to fetch active networks", "error", err)
+		return report, fmt.Errorf("unable to fetch active networks: %s", err.Error())
+	}
+	var (
+		running   = make(map[string]*ipfs.NodeInfo)
+		halted    = make(map[string]bool)
+		activated = make(map[string]string)
+	)
+	for _, n := range nodes {
+		running[n.NetworkID] = n
+	}
+	for _, n := range stopped {
+		halted[n.NetworkID] = true
+	}
+	for _, n := range active {
+		activated[n.Name] = n.SwarmAddr
+	}
+
+	// deregister ghost entries - hibernated nodes are expected to have no
+	// running containers, and stopped nodes keep their registrations until the
+	// restart policy declares them crash-looping
+	for _, n := range o.Registry.List() {
+		if _, found := running[n.NetworkID]; found || o.Registry.Hibernated(n.NetworkID) {
+			continue
+		}
+		if _, crashLooping := o.crashLoopingNode(n.NetworkID); halted[n.NetworkID] && !crashLooping {
+			continue
+		}
+		unlock, err := o.locks.tryLock(n.NetworkID)
+		if err != nil {
+			report.Skipped = append(report.Skipped, n.NetworkID)
+			continue
+		}
+		l.Warnw("deregistering node that is no longer running", "node", n)
+		err = o.Registry.Deregister(n.NetworkID)
+		unlock()
+		if err != nil {
+			l.Errorw("failed to deregister node", "error", err, "node", n)
+			continue
+		}
+		report.Deregistered = append(report.Deregistered, n.NetworkID)
+	}
+
+	// track running nodes
+	for network, n := range running {
+		unlock, err := o.locks.tryLock(network)
+		if err != nil {
+			report.Skipped = append(report.Skipped, network)
+			continue
+		}
+		registered, updated := o.reconcileNode(ctx, l, n, activated)
+		unlock()
+		if registered {
+			report.Registered = append(report.Registered, network)
+		}
+		if updated {
+			report.Updated = append(report.Updated, network)
+		}
+	}
+
+	// start nodes for active networks
+	for network := range activated {
+		if _, found := running[network]; found || o.Registry.Hibernated(network) {
+			continue
+		}
+		if halted[network] {
+			report.Stopped = append(report.Stopped, network)
+			continue
+		}
+		l.Warnw("starting node for active network", "network", network)
+		if _, err := o.NetworkUp(ctx, network); err != nil {
+			if err == ErrOperationInProgress {
+				report.Skipped = append(report.Skipped, network)
+				continue
+			}
+			l.Errorw("failed to start node - deactivating network",
+				"error", err, "network", network)
+			if err := o.nm.UpdateNetworkByName(network, map[string]interface{}{
+				"activated":  time.Time{},
+				"swarm_addr": "",
+			}); err != nil {
+				l.Errorw("failed to deactivate network", "error", err, "network", network)
+				continue
+			}
+			report.Deactivated = append(report.Deactivated, network)
+			continue
+		}
+		report.Started = append(report.Started, network)
+	}
+
+	l.Infow("reconciliation completed",
+		"report", report,
+		"reconcile.duration", time.Since(start))
+	return report, nil
+}
+
+// reconcileNode registers the given running node if it is missing from the
+// registry, and updates its database entry if it is out of date. The caller
+// must hold the lock for the node's network.
+func (o *Orchestrator) reconcileNode(ctx context.Context, l *zap.SugaredLogger, n *ipfs.NodeInfo,
+	activated map[string]string) (registered, updated bool) {
+	var network = n.NetworkID
+	if _, err := o.Registry.Get(network); err != nil {
+		l.Warnw("registering running node missing from registry", "node", n)
+		if err := o.Registry.Adopt(n); err != nil {
+			l.Errorw("failed to register node", "error", err, "node", n)
+			return false, false
+		}
+		registered = true
+	}
+
+	// make sure database reflects node state - entries are up to date if they
+	// hold a bootstrap address for the node's swarm port
+	var (
+		addr           = o.swarmAddr(n.Ports.Swarm)
+		current, found = activated[network]
+	)
+	if found && ((addr == "" && current == "") || strings.HasPrefix(current, addr+"/ipfs/")) {
+		return registered, false
+	}
+	var attrs = map[string]interface{}{"swarm_addr": o.nodeBootstrapAddr(ctx, l, n)}
+	if !found {
+		attrs["activated"] = time.Now()
+	}
+	l.Warnw("updating stale database entry for running node",
+		"network", network, "entry.swarm_addr", current, "entry.activated", found)
+	if err := o.nm.UpdateNetworkByName(network, attrs); err != nil {
+		l.Errorw("failed to update network", "error", err, "network", network)
+		return registered, false
+	}
+	return registered, true
+}
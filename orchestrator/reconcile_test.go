@@ -0,0 +1,168 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/RTradeLtd/database/models"
+
+	"github.com/RTradeLtd/Nexus/config"
+	"github.com/RTradeLtd/Nexus/ipfs"
+	"github.com/RTradeLtd/Nexus/ipfs/mock"
+	"github.com/RTradeLtd/Nexus/log"
+	"github.com/RTradeLtd/Nexus/registry"
+	tmock "github.com/RTradeLtd/Nexus/temporal/mock"
+)
+
+func TestOrchestrator_Reconcile(t *testing.T) {
+	l, _ := log.NewTestLogger()
+	var (
+		ctx      = context.Background()
+		client   = mock.NewMemoryNodeClient()
+		networks = &tmock.FakePrivateNetworks{}
+		reg      = registry.New(l, config.New().Ports)
+		o        = &Orchestrator{
+			Registry: reg,
+			l:        l,
+			nm:       networks,
+			client:   client,
+			address:  "127.0.0.1",
+		}
+	)
+	defer reg.Close()
+
+	// running node that is missing from registry and database
+	var unregistered = &ipfs.NodeInfo{
+		NetworkID: "unregistered",
+		Ports:     ipfs.NodePorts{Swarm: "4001", API: "5001", Gateway: "8001"},
+	}
+	if err := client.CreateNode(ctx, unregistered, ipfs.NodeOpts{SwarmKey: []byte(testSwarmKey)}); err != nil {
+		t.Fatal(err)
+	}
+
+	// running node with stale database entry
+	var stale = &ipfs.NodeInfo{
+		NetworkID: "stale",
+		Ports:     ipfs.NodePorts{Swarm: "4002", API: "5002", Gateway: "8002"},
+	}
+	if err := client.CreateNode(ctx, stale, ipfs.NodeOpts{SwarmKey: []byte(testSwarmKey)}); err != nil {
+		t.Fatal(err)
+	}
+	reg.Register(&ipfs.NodeInfo{NetworkID: "stale", Ports: stale.Ports})
+
+	// node that stopped unexpectedly should be left to the restart policy
+	var crashed = &ipfs.NodeInfo{
+		NetworkID: "crashed",
+		Ports:     ipfs.NodePorts{Swarm: "4005", API: "5005", Gateway: "8005"},
+	}
+	if err := client.CreateNode(ctx, crashed, ipfs.NodeOpts{SwarmKey: []byte(testSwarmKey)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Crash("crashed"); err != nil {
+		t.Fatal(err)
+	}
+	reg.Register(&ipfs.NodeInfo{NetworkID: "crashed", Ports: crashed.Ports})
+
+	// registered node without a container
+	reg.Register(&ipfs.NodeInfo{NetworkID: "ghost"})
+
+	// network in progress should not be touched
+	reg.Register(&ipfs.NodeInfo{NetworkID: "busy"})
+	unlock, err := o.locks.tryLock("busy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+
+	// active networks without nodes
+	networks.GetActiveNetworksReturns([]*models.HostedIPFSPrivateNetwork{
+		{Name: "stale", SwarmAddr: "/ip4/127.0.0.1/tcp/1234"},
+		{Name: "missing", SwarmAddr: "/ip4/127.0.0.1/tcp/4003"},
+		{Name: "broken", SwarmAddr: "/ip4/127.0.0.1/tcp/4004"},
+		{Name: "crashed", SwarmAddr: "/ip4/127.0.0.1/tcp/4005"},
+	}, nil)
+	networks.GetNetworkByNameStub = func(name string) (*models.HostedIPFSPrivateNetwork, error) {
+		if name == "broken" {
+			return nil, errors.New("oh no")
+		}
+		return &models.HostedIPFSPrivateNetwork{Name: name}, nil
+	}
+
+	report, err := o.Reconcile(ctx)
+	if err != nil {
+		t.Fatalf("Orchestrator.Reconcile() error = %v", err)
+	}
+
+	var expect = func(name string, got []string, want ...string) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+			return
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s: expected %v, got %v", name, want, got)
+				return
+			}
+		}
+	}
+	expect("registered", report.Registered, "unregistered")
+	expect("deregistered", report.Deregistered, "ghost")
+	expect("started", report.Started, "missing")
+	expect("deactivated", report.Deactivated, "broken")
+	expect("skipped", report.Skipped, "busy")
+	expect("stopped", report.Stopped, "crashed")
+	if len(report.Updated) != 2 {
+		t.Errorf("expected 'stale' and 'unregistered' to be updated, got %v", report.Updated)
+	}
+
+	// check resulting state
+	for _, network := range []string{"unregistered", "stale", "missing", "busy", "crashed"} {
+		if _, err := reg.Get(network); err != nil {
+			t.Errorf("expected '%s' to be registered", network)
+		}
+	}
+	for _, network := range []string{"ghost", "broken"} {
+		if _, err := reg.Get(network); err == nil {
+			t.Errorf("expected '%s' to be deregistered", network)
+		}
+	}
+	if client.State("missing") != mock.StateRunning {
+		t.Error("expected node for 'missing' to be started")
+	}
+	if client.State("crashed") != mock.StateExited {
+		t.Error("expected node for 'crashed' to be left stopped")
+	}
+
+	// database entries should be updated with bootstrap addresses
+	var written = make(map[string]string)
+	for i := 0; i < networks.UpdateNetworkByNameCallCount(); i++ {
+		network, attrs := networks.UpdateNetworkByNameArgsForCall(i)
+		if addr, ok := attrs["swarm_addr"].(string); ok {
+			written[network] = addr
+		}
+	}
+	for network, port := range map[string]string{"stale": "4002", "unregistered": "4001"} {
+		var prefix = "/ip4/127.0.0.1/tcp/" + port + "/ipfs/"
+		if addr := written[network]; !strings.HasPrefix(addr, prefix) || addr == prefix {
+			t.Errorf("expected bootstrap address for '%s', got '%s'", network, addr)
+		}
+	}
+
+	// second pass should find nothing to do
+	networks.GetActiveNetworksReturns([]*models.HostedIPFSPrivateNetwork{
+		{Name: "stale", SwarmAddr: written["stale"]},
+		{Name: "unregistered", SwarmAddr: written["unregistered"]},
+		{Name: "missing", SwarmAddr: networks.SaveNetworkArgsForCall(0).SwarmAddr},
+	}, nil)
+	report, err = o.Reconcile(ctx)
+	if err != nil {
+		t.Fatalf("Orchestrator.Reconcile() error = %v", err)
+	}
+	if len(report.Registered)+len(report.Deregistered)+len(report.Started)+
+		len(report.Deactivated)+len(report.Updated) != 0 {
+		t.Errorf("expected no changes, got %+v", report)
+	}
+}
@@ -0,0 +1,314 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/RTradeLtd/Nexus/config"
+	"github.com/RTradeLtd/Nexus/ipfs"
+	"github.com/RTradeLtd/Nexus/log"
+)
+
+// restartPolicy denotes how nodes that stop unexpectedly or fail liveness
+// probes are automatically restarted. A non-positive maxRestarts or window
+// allows unlimited restarts.
+type restartPolicy struct {
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	maxRestarts int
+	window      time.Duration
+}
+
+func parseRestartPolicy(cfg config.RestartPolicy) (restartPolicy, error) {
+	var (
+		p   = restartPolicy{maxRestarts: cfg.MaxRestarts}
+		err error
+	)
+	for _, d := range []struct {
+		name  string
+		value string
+		dest  *time.Duration
+	}{
+		{"min backoff", cfg.MinBackoff, &p.minBackoff},
+		{"max backoff", cfg.MaxBackoff, &p.maxBackoff},
+		{"window", cfg.Window, &p.window},
+	} {
+		if d.value == "" {
+			continue
+		}
+		if *d.dest, err = time.ParseDuration(d.value); err != nil {
+			return p, fmt.Errorf("invalid %s '%s': %s", d.name, d.value, err.Error())
+		}
+	}
+	if p.maxBackoff > 0 && p.maxBackoff < p.minBackoff {
+		return p, fmt.Errorf("max backoff '%s' is less than min backoff '%s'",
+			cfg.MaxBackoff, cfg.MinBackoff)
+	}
+	return p, nil
+}
+
+// delay calculates the backoff after the given number of recent restarts
+func (p restartPolicy) delay(restarts int) time.Duration {
+	var d = p.minBackoff
+	for i := 1; i < restarts && (p.maxBackoff <= 0 || d < p.maxBackoff); i++ {
+		d *= 2
+	}
+	if p.maxBackoff > 0 && d > p.maxBackoff {
+		return p.maxBackoff
+	}
+	return d
+}
+
+// limited checks if the given number of recent restarts exhausts the policy
+func (p restartPolicy) limited(restarts int) bool {
+	return p.maxRestarts > 0 && p.window > 0 && restarts >= p.maxRestarts
+}
+
+// restartHistory tracks automatic restarts of a node
+type restartHistory struct {
+	// node is the most recently seen state of the node, retained so that
+	// crash-looping nodes can be inspected and restarted after they have been
+	// deregistered
+	node ipfs.NodeInfo
+	// restarts are the times of restarts within the policy's window
+	restarts []time.Time
+	// next is the earliest time at which the node can be restarted again
+	next time.Time
+	// crashLooping indicates that the node has exhausted its restarts, and will
+	// not be restarted again until an operator intervenes
+	crashLooping bool
+}
+
+// prune discards restarts that are outside the given window
+func (h *restartHistory) prune(window time.Duration) {
+	if window <= 0 {
+		return
+	}
+	var (
+		cutoff = time.Now().Add(-window)
+		recent = h.restarts[:0]
+	)
+	for _, t := range h.restarts {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	h.restarts = recent
+}
+
+// healStopped restarts nodes whose containers have stopped unexpectedly, as
+// allowed by the restart policy, and returns the restarted networks
+func (o *Orchestrator) healStopped(ctx context.Context) []string {
+	var restarted = make([]string, 0)
+	stopped, err := o.client.StoppedNodes(ctx)
+	if err != nil {
+		o.l.Warnw("failed to fetch stopped nodes", "error", err)
+		return restarted
+	}
+
+	// discard histories of nodes that are neither running nor stopped
+	var known = make(map[string]bool)
+	for _, n := range o.Registry.List() {
+		known[n.NetworkID] = true
+	}
+	for _, n := range stopped {
+		known[n.NetworkID] = true
+	}
+	o.pruneRestarts(known)
+
+	for _, n := range stopped {
+		ok, err := o.restartNode(ctx, n, "node stopped unexpectedly")
+		if err != nil {
+			if err != ErrOperationInProgress {
+				o.l.Errorw("failed to restart stopped node",
+					"error", err, "network", n.NetworkID)
+			}
+			continue
+		}
+		if ok {
+			restarted = append(restarted, n.NetworkID)
+			continue
+		}
+		if _, crashLooping := o.crashLoopingNode(n.NetworkID); crashLooping {
+			o.releaseCrashLooping(n.NetworkID)
+		}
+	}
+	return restarted
+}
+
+// releaseCrashLooping deregisters the given crash-looping network's stopped
+// node, releasing its ports and resources, and marks the network as inactive.
+// Registrations are kept while a stopped node can still be restarted, so that
+// its ports are not assigned to other networks.
+func (o *Orchestrator) releaseCrashLooping(network string) {
+	unlock, err := o.locks.tryLock(network)
+	if err != nil {
+		return
+	}
+	defer unlock()
+	if _, err := o.Registry.Get(network); err != nil {
+		return
+	}
+
+	var l = o.l.With("network", network)
+	l.Warnw("deregistering crash-looping node")
+	if err := o.Registry.Deregister(network); err != nil {
+		l.Errorw("failed to deregister node", "error", err)
+	}
+	if err := o.nm.UpdateNetworkByName(network, map[string]interface{}{
+		"activated":  time.Time{},
+		"swarm_addr": "",
+	}); err != nil {
+		l.Errorw("failed to mark network as inactive", "error", err)
+	}
+}
+
+// restartNode restarts the given node if the restart policy allows it, and
+// registers it if it was deregistered when it was declared crash-looping. It
+// returns false if the restart is backing off or the node is crash-looping.
+func (o *Orchestrator) restartNode(ctx context.Context, n *ipfs.NodeInfo, reason string) (bool, error) {
+	var network = n.NetworkID
+	unlock, err := o.locks.tryLock(network)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+	if o.Registry.Hibernated(network) || !o.allowRestart(*n) {
+		return false, nil
+	}
+
+	var (
+		start = time.Now()
+		l     = log.NewProcessLogger(o.l, "node_restart",
+			"job_id", generateID(),
+			"network", network)
+	)
+	l.Warnw("automatically restarting node",
+		"reason", reason,
+		"node", n,
+		"restarts", o.recentRestarts(network))
+	if err := o.client.RestartNode(ctx, n); err != nil {
+		l.Errorw("failed to restart node", "error", err)
+		return false, fmt.Errorf("failed to restart network '%s': %s", network, err.Error())
+	}
+	o.registerRestarted(ctx, l, n)
+
+	l.Infow("node restarted",
+		"node_restart.duration", time.Since(start))
+	return true, nil
+}
+
+// registerRestarted registers the given restarted node if it is not registered,
+// and marks its network as active if it is not, for example because the node
+// died. The caller must hold the lock for the node's network.
+func (o *Orchestrator) registerRestarted(ctx context.Context, l *zap.SugaredLogger, n *ipfs.NodeInfo) {
+	var network = n.NetworkID
+	if _, err := o.Registry.Get(network); err == nil {
+		if entry, err := o.nm.GetNetworkByName(network); err == nil && !entry.Activated.IsZero() {
+			return
+		}
+	} else if err := o.Registry.Adopt(n); err != nil {
+		l.Errorw("failed to register restarted node", "error", err)
+		return
+	}
+	if err := o.nm.UpdateNetworkByName(network, map[string]interface{}{
+		"activated":  time.Now(),
+		"swarm_addr": o.nodeBootstrapAddr(ctx, l, n),
+	}); err != nil {
+		l.Errorw("failed to mark network as active", "error", err)
+	}
+}
+
+// allowRestart checks if the given node can be automatically restarted, and if
+// so, records the restart. Nodes that exhaust the restart policy are marked as
+// crash-looping.
+func (o *Orchestrator) allowRestart(n ipfs.NodeInfo) bool {
+	o.restartMux.Lock()
+	defer o.restartMux.Unlock()
+	if o.restarts == nil {
+		o.restarts = make(map[string]*restartHistory)
+	}
+	h, found := o.restarts[n.NetworkID]
+	if !found {
+		h = &restartHistory{}
+		o.restarts[n.NetworkID] = h
+	}
+	h.node = n
+	if h.crashLooping {
+		return false
+	}
+
+	h.prune(o.restart.window)
+	if o.restart.limited(len(h.restarts)) {
+		h.crashLooping = true
+		o.l.Errorw("node is crash-looping - automatic restarts disabled",
+			"network", n.NetworkID,
+			"restarts", len(h.restarts),
+			"window", o.restart.window)
+		return false
+	}
+	if time.Now().Before(h.next) {
+		return false
+	}
+
+	var now = time.Now()
+	h.restarts = append(h.restarts, now)
+	h.next = now.Add(o.restart.delay(len(h.restarts)))
+	return true
+}
+
+// resetRestarts discards the restart history of the given network's node once
+// it has stayed healthy for longer than its current backoff
+func (o *Orchestrator) resetRestarts(network string) {
+	o.restartMux.Lock()
+	defer o.restartMux.Unlock()
+	if h, found := o.restarts[network]; found &&
+		(h.crashLooping || time.Now().After(h.next)) {
+		delete(o.restarts, network)
+	}
+}
+
+// clearRestarts discards the restart history of the given network's node, for
+// example after an operator has restarted it
+func (o *Orchestrator) clearRestarts(network string) {
+	o.restartMux.Lock()
+	delete(o.restarts, network)
+	o.restartMux.Unlock()
+}
+
+// pruneRestarts discards restart histories of networks that are not in the
+// given set
+func (o *Orchestrator) pruneRestarts(known map[string]bool) {
+	o.restartMux.Lock()
+	for network := range o.restarts {
+		if !known[network] {
+			delete(o.restarts, network)
+		}
+	}
+	o.restartMux.Unlock()
+}
+
+// recentRestarts returns the number of automatic restarts of the given
+// network's node within the restart policy's window
+func (o *Orchestrator) recentRestarts(network string) int {
+	o.restartMux.Lock()
+	defer o.restartMux.Unlock()
+	if h, found := o.restarts[network]; found {
+		h.prune(o.restart.window)
+		return len(h.restarts)
+	}
+	return 0
+}
+
+// crashLoopingNode retrieves the given network's node if it is crash-looping
+func (o *Orchestrator) crashLoopingNode(network string) (ipfs.NodeInfo, bool) {
+	o.restartMux.Lock()
+	defer o.restartMux.Unlock()
+	if h, found := o.restarts[network]; found && h.crashLooping {
+		return h.node, true
+	}
+	return ipfs.NodeInfo{}, false
+}
@@ -0,0 +1,175 @@
+package orchestrator
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/RTradeLtd/database/models"
+
+	"github.com/RTradeLtd/Nexus/config"
+	"github.com/RTradeLtd/Nexus/ipfs"
+	"github.com/RTradeLtd/Nexus/ipfs/mock"
+	"github.com/RTradeLtd/Nexus/log"
+	"github.com/RTradeLtd/Nexus/registry"
+	tmock "github.com/RTradeLtd/Nexus/temporal/mock"
+)
+
+func Test_parseRestartPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.RestartPolicy
+		want    restartPolicy
+		wantErr bool
+	}{
+		{"empty", config.RestartPolicy{}, restartPolicy{}, false},
+		{"defaults", config.New().IPFS.RestartPolicy, restartPolicy{
+			minBackoff:  10 * time.Second,
+			maxBackoff:  5 * time.Minute,
+			maxRestarts: 5,
+			window:      time.Hour,
+		}, false},
+		{"invalid min backoff", config.RestartPolicy{MinBackoff: "asdf"}, restartPolicy{}, true},
+		{"invalid window", config.RestartPolicy{Window: "asdf"}, restartPolicy{}, true},
+		{"max less than min", config.RestartPolicy{MinBackoff: "1m", MaxBackoff: "1s"}, restartPolicy{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRestartPolicy(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseRestartPolicy() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseRestartPolicy() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_restartPolicy_delay(t *testing.T) {
+	var p = restartPolicy{minBackoff: 10 * time.Second, maxBackoff: time.Minute}
+	tests := []struct {
+		name     string
+		restarts int
+		want     time.Duration
+	}{
+		{"first restart", 1, 10 * time.Second},
+		{"second restart", 2, 20 * time.Second},
+		{"third restart", 3, 40 * time.Second},
+		{"capped", 100, time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.delay(tt.restarts); got != tt.want {
+				t.Errorf("restartPolicy.delay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrchestrator_healStopped(t *testing.T) {
+	l, _ := log.NewTestLogger()
+	var (
+		ctx      = context.Background()
+		client   = mock.NewMemoryNodeClient()
+		networks = &tmock.FakePrivateNetworks{}
+		o        = &Orchestrator{
+			Registry: registry.New(l, config.New().Ports),
+			l:        l,
+			nm:       networks,
+			client:   client,
+			address:  "127.0.0.1",
+			restart:  restartPolicy{maxRestarts: 2, window: time.Hour},
+		}
+	)
+	defer o.Registry.Close()
+	networks.GetNetworkByNameStub = func(name string) (*models.HostedIPFSPrivateNetwork, error) {
+		return &models.HostedIPFSPrivateNetwork{Name: name, SwarmKey: testSwarmKey}, nil
+	}
+	if _, err := o.NetworkUp(ctx, "bobheadxi"); err != nil {
+		t.Fatal(err)
+	}
+
+	// crash node and notify the event handler
+	var crash = func() {
+		t.Helper()
+		node, err := o.Registry.Get("bobheadxi")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := client.Crash("bobheadxi"); err != nil {
+			t.Fatal(err)
+		}
+		o.handleEvent(ctx, ipfs.Event{Status: eventDie, Node: node})
+	}
+
+	// stopped node should keep its registration, and be restarted - its network
+	// is inactive until then
+	for i := 1; i <= o.restart.maxRestarts; i++ {
+		crash()
+		var updates = networks.UpdateNetworkByNameCallCount()
+		if _, attrs := networks.UpdateNetworkByNameArgsForCall(updates - 1); attrs["swarm_addr"] != "" {
+			t.Errorf("expected network to be marked inactive, got %v", attrs)
+		}
+		if restarted := o.healStopped(ctx); !reflect.DeepEqual(restarted, []string{"bobheadxi"}) {
+			t.Fatalf("expected stopped node to be restarted, got %v", restarted)
+		}
+		if networks.UpdateNetworkByNameCallCount() != updates+1 {
+			t.Error("expected restarted network to be marked active")
+		} else if _, attrs := networks.UpdateNetworkByNameArgsForCall(updates); attrs["activated"] == (time.Time{}) {
+			t.Errorf("unexpected database update %v", attrs)
+		}
+		if _, err := o.Registry.Get("bobheadxi"); err != nil {
+			t.Errorf("expected restarted node to remain registered: %v", err)
+		}
+		if got := o.recentRestarts("bobheadxi"); got != i {
+			t.Errorf("expected %d recent restarts, got %d", i, got)
+		}
+	}
+
+	// node should be marked as crash-looping once restarts are exhausted, and
+	// only then deregistered and marked inactive
+	crash()
+	var updates = networks.UpdateNetworkByNameCallCount()
+	if restarted := o.healStopped(ctx); len(restarted) != 0 {
+		t.Errorf("expected crash-looping node not to be restarted, got %v", restarted)
+	}
+	if client.State("bobheadxi") != mock.StateExited {
+		t.Error("expected crash-looping node to remain stopped")
+	}
+	if _, err := o.Registry.Get("bobheadxi"); err == nil {
+		t.Error("expected crash-looping node to be deregistered")
+	}
+	if networks.UpdateNetworkByNameCallCount() != updates+1 {
+		t.Error("expected crash-looping network to be marked inactive")
+	} else if _, attrs := networks.UpdateNetworkByNameArgsForCall(updates); attrs["swarm_addr"] != "" {
+		t.Errorf("unexpected database update %v", attrs)
+	}
+	d, err := o.NetworkDiagnostics(ctx, "bobheadxi")
+	if err != nil {
+		t.Fatalf("expected diagnostics for crash-looping node: %v", err)
+	}
+	if !d.CrashLooping || d.Restarts != o.restart.maxRestarts || d.NetworkID != "bobheadxi" {
+		t.Errorf("expected node to be reported as crash-looping, got %+v", d)
+	}
+
+	// operator restart should register the node and reset its history
+	if err := o.NetworkRestart(ctx, "bobheadxi"); err != nil {
+		t.Fatalf("expected crash-looping node to be restartable: %v", err)
+	}
+	if _, err := o.Registry.Get("bobheadxi"); err != nil {
+		t.Errorf("expected restarted node to be registered: %v", err)
+	}
+	if d, _ := o.NetworkDiagnostics(ctx, "bobheadxi"); d.CrashLooping || d.Restarts != 0 {
+		t.Errorf("expected restart history to be reset, got %+v", d)
+	}
+
+	// histories of nodes that no longer exist should be discarded
+	o.allowRestart(ipfs.NodeInfo{NetworkID: "timhortons"})
+	o.healStopped(ctx)
+	if _, found := o.restarts["timhortons"]; found {
+		t.Error("expected history of removed node to be discarded")
+	}
+}
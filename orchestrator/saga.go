@@ -0,0 +1,52 @@
+package orchestrator
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// rollbackTimeout is the maximum duration allowed for undoing a failed saga
+const rollbackTimeout = time.Minute
+
+// saga tracks the completed steps of a multi-step process, so that their
+// effects can be undone in reverse order if a later step fails
+type saga struct {
+	l     *zap.SugaredLogger
+	steps []sagaStep
+}
+
+type sagaStep struct {
+	name string
+	undo func(ctx context.Context) error
+}
+
+func newSaga(l *zap.SugaredLogger) *saga {
+	return &saga{l: l}
+}
+
+// completed records a step that has taken effect, along with the action that
+// compensates for it
+func (s *saga) completed(name string, undo func(ctx context.Context) error) {
+	s.steps = append(s.steps, sagaStep{name, undo})
+}
+
+// rollback undoes all completed steps in reverse order. A separate context is
+// used, since the original context may have been the cause of the failure.
+// Failed compensations are logged, and do not stop remaining steps from being
+// undone.
+func (s *saga) rollback() {
+	ctx, cancel := context.WithTimeout(context.Background(), rollbackTimeout)
+	defer cancel()
+	for i := len(s.steps) - 1; i >= 0; i-- {
+		var step = s.steps[i]
+		if err := step.undo(ctx); err != nil {
+			s.l.Errorw("failed to roll back step",
+				"error", err, "step", step.name)
+			continue
+		}
+		s.l.Infow("rolled back step", "step", step.name)
+	}
+	s.steps = nil
+}
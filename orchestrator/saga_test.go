@@ -0,0 +1,166 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/RTradeLtd/database/models"
+
+	"github.com/RTradeLtd/Nexus/config"
+	"github.com/RTradeLtd/Nexus/ipfs"
+	"github.com/RTradeLtd/Nexus/ipfs/mock"
+	"github.com/RTradeLtd/Nexus/log"
+	"github.com/RTradeLtd/Nexus/registry"
+	tmock "github.com/RTradeLtd/Nexus/temporal/mock"
+)
+
+func Test_saga_rollback(t *testing.T) {
+	l, _ := log.NewTestLogger()
+	var (
+		tx     = newSaga(l)
+		undone []string
+	)
+	for _, step := range []string{"first", "second", "third"} {
+		var name = step
+		tx.completed(name, func(ctx context.Context) error {
+			if ctx.Err() != nil {
+				t.Errorf("expected rollback context to be usable, got %v", ctx.Err())
+			}
+			undone = append(undone, name)
+			if name == "second" {
+				return errors.New("oh no")
+			}
+			return nil
+		})
+	}
+
+	tx.rollback()
+
+	// steps should be undone in reverse order, and failures should not stop
+	// remaining steps from being undone
+	if len(undone) != 3 || undone[0] != "third" || undone[1] != "second" || undone[2] != "first" {
+		t.Errorf("unexpected rollback order %v", undone)
+	}
+
+	// steps should not be undone twice
+	tx.rollback()
+	if len(undone) != 3 {
+		t.Errorf("expected steps to only be undone once, got %v", undone)
+	}
+}
+
+func TestOrchestrator_NetworkUp_rollback(t *testing.T) {
+	const network = "test-network"
+	var injected = errors.New("oh no")
+	type fields struct {
+		ports          config.Ports
+		existingAssets bool
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		fail   func(*mock.MemoryNodeClient, *tmock.FakePrivateNetworks)
+	}{
+		{"port lease fails",
+			fields{config.Ports{}, false},
+			func(*mock.MemoryNodeClient, *tmock.FakePrivateNetworks) {}},
+		{"asset check fails",
+			fields{config.New().Ports, false},
+			func(c *mock.MemoryNodeClient, _ *tmock.FakePrivateNetworks) {
+				c.Fail(mock.OpNodeAssetsExist, network, injected)
+			}},
+		{"container creation fails",
+			fields{config.New().Ports, false},
+			func(c *mock.MemoryNodeClient, _ *tmock.FakePrivateNetworks) {
+				c.Fail(mock.OpCreateNode, network, injected)
+			}},
+		{"container creation fails with existing assets",
+			fields{config.New().Ports, true},
+			func(c *mock.MemoryNodeClient, _ *tmock.FakePrivateNetworks) {
+				c.Fail(mock.OpCreateNode, network, injected)
+			}},
+		{"node stats fails",
+			fields{config.New().Ports, false},
+			func(c *mock.MemoryNodeClient, _ *tmock.FakePrivateNetworks) {
+				c.Fail(mock.OpNodeStats, network, injected)
+			}},
+		{"database update fails",
+			fields{config.New().Ports, false},
+			func(_ *mock.MemoryNodeClient, n *tmock.FakePrivateNetworks) {
+				n.SaveNetworkReturns(injected)
+			}},
+		{"database update fails with existing assets",
+			fields{config.New().Ports, true},
+			func(_ *mock.MemoryNodeClient, n *tmock.FakePrivateNetworks) {
+				n.SaveNetworkReturns(injected)
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _ := log.NewTestLogger()
+			var (
+				ctx      = context.Background()
+				client   = mock.NewMemoryNodeClient()
+				networks = &tmock.FakePrivateNetworks{}
+				o        = &Orchestrator{
+					Registry: registry.New(l, tt.fields.ports),
+					l:        l,
+					nm:       networks,
+					client:   client,
+					address:  "127.0.0.1",
+				}
+			)
+			defer o.Registry.Close()
+			networks.GetNetworkByNameReturns(&models.HostedIPFSPrivateNetwork{
+				Name:     network,
+				SwarmKey: testSwarmKey,
+			}, nil)
+
+			// assets left behind by a previous instance of the network
+			if tt.fields.existingAssets {
+				var n = &ipfs.NodeInfo{
+					NetworkID: network,
+					Ports:     ipfs.NodePorts{Swarm: "4001", API: "5001", Gateway: "8080"},
+				}
+				if err := client.CreateNode(ctx, n, ipfs.NodeOpts{SwarmKey: []byte(testSwarmKey)}); err != nil {
+					t.Fatal(err)
+				}
+				if err := client.StopNode(ctx, n); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			tt.fail(client, networks)
+			if _, err := o.NetworkUp(ctx, network); err == nil {
+				t.Fatal("expected error")
+			}
+
+			// host should be left as it was before
+			if _, err := o.Registry.Get(network); err == nil {
+				t.Error("expected node to be deregistered")
+			}
+			if state := client.State(network); state != "" {
+				t.Errorf("expected container to be removed, got state '%s'", state)
+			}
+			exists, err := client.NodeAssetsExist(ctx, network)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if exists != tt.fields.existingAssets {
+				t.Errorf("expected assets to exist = %v, got %v", tt.fields.existingAssets, exists)
+			}
+			if networks.SaveNetworkCallCount() > 1 {
+				t.Errorf("unexpected database updates: %d", networks.SaveNetworkCallCount())
+			}
+
+			// network should be able to come up once the failure is resolved
+			networks.SaveNetworkReturns(nil)
+			if len(tt.fields.ports.Swarm) > 0 {
+				if _, err := o.NetworkUp(ctx, network); err != nil {
+					t.Errorf("expected retry to succeed, got %v", err)
+				}
+			}
+		})
+	}
+}
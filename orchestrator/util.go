@@ -1,9 +1,16 @@
 package orchestrator
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"fmt"
 	"io"
+	"net"
+
+	"go.uber.org/zap"
+
+	"github.com/RTradeLtd/Nexus/ipfs"
 )
 
 func generateID() string {
@@ -11,3 +18,57 @@ func generateID() string {
 	io.ReadFull(rand.Reader, b)
 	return base64.URLEncoding.EncodeToString(b)
 }
+
+// swarmAddr generates the public swarm multiaddr of a node on this host, or an
+// empty string if the host's public address is not configured
+func (o *Orchestrator) swarmAddr(port string) string {
+	if o.address == "" {
+		return ""
+	}
+	var proto = "dns4"
+	if ip := net.ParseIP(o.address); ip != nil {
+		if ip.To4() != nil {
+			proto = "ip4"
+		} else {
+			proto = "ip6"
+		}
+	}
+	return fmt.Sprintf("/%s/%s/tcp/%s", proto, o.address, port)
+}
+
+// bootstrapAddr generates the multiaddr peers can bootstrap from to reach the
+// node with the given peer ID. If the peer ID is unknown, the node's swarm
+// address is returned.
+func (o *Orchestrator) bootstrapAddr(port, peerID string) string {
+	var addr = o.swarmAddr(port)
+	if addr == "" || peerID == "" {
+		return addr
+	}
+	return addr + "/ipfs/" + peerID
+}
+
+// nodeBootstrapAddr retrieves the bootstrap address of the given running node,
+// falling back to its swarm address if the node's peer ID is unavailable
+func (o *Orchestrator) nodeBootstrapAddr(ctx context.Context, l *zap.SugaredLogger,
+	n *ipfs.NodeInfo) string {
+	if o.address == "" {
+		return ""
+	}
+	s, err := o.client.NodeStats(ctx, n)
+	if err != nil {
+		l.Warnw("failed to get node peer ID", "error", err)
+		return o.swarmAddr(n.Ports.Swarm)
+	}
+	return o.bootstrapAddr(n.Ports.Swarm, s.PeerID)
+}
+
+// nodeConfig retrieves the configuration for the given network's node, which
+// announces the node's public swarm address unless configured otherwise
+func (o *Orchestrator) nodeConfig(network, swarmPort string) *ipfs.NodeConfig {
+	var cfg = o.nodeConfigs.get(network)
+	if addr := o.swarmAddr(swarmPort); addr != "" {
+		var announced = cfg.WithAnnounce(addr)
+		cfg = &announced
+	}
+	return cfg
+}
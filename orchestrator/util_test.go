@@ -7,3 +7,26 @@ func Test_generateID(t *testing.T) {
 		t.Errorf("invalid ID generated")
 	}
 }
+
+func TestOrchestrator_bootstrapAddr(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		peerID  string
+		want    string
+	}{
+		{"no address", "", "QmPeer", ""},
+		{"ip4", "1.2.3.4", "QmPeer", "/ip4/1.2.3.4/tcp/4001/ipfs/QmPeer"},
+		{"ip6", "::1", "QmPeer", "/ip6/::1/tcp/4001/ipfs/QmPeer"},
+		{"hostname", "nexus.temporal.cloud", "QmPeer", "/dns4/nexus.temporal.cloud/tcp/4001/ipfs/QmPeer"},
+		{"unknown peer ID", "1.2.3.4", "", "/ip4/1.2.3.4/tcp/4001"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var o = &Orchestrator{address: tt.address}
+			if got := o.bootstrapAddr("4001", tt.peerID); got != tt.want {
+				t.Errorf("Orchestrator.bootstrapAddr() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
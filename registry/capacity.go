@@ -0,0 +1,96 @@
+package registry
+
+import (
+	"fmt"
+
+	"github.com/RTradeLtd/Nexus/config"
+	"github.com/RTradeLtd/Nexus/ipfs"
+)
+
+const (
+	// ResourceCPUs denotes CPU allocation
+	ResourceCPUs = "cpus"
+	// ResourceMemory denotes memory allocation, in gigabytes
+	ResourceMemory = "memory_gb"
+	// ResourceDisk denotes disk allocation, in gigabytes
+	ResourceDisk = "disk_gb"
+)
+
+// CapacityError is returned when a node cannot be registered because the host
+// does not have enough of a resource available
+type CapacityError struct {
+	Resource  string
+	Requested int
+	Available int
+}
+
+func (c *CapacityError) Error() string {
+	return fmt.Sprintf("insufficient host capacity: requested %d %s, but only %d available",
+		c.Requested, c.Resource, c.Available)
+}
+
+// Utilization describes the resources allocated to registered nodes, as well as
+// the resources that can be allocated in total. A zero limit means the resource
+// is not limited.
+type Utilization struct {
+	Allocated ipfs.NodeResources `json:"allocated"`
+	Limits    ipfs.NodeResources `json:"limits"`
+}
+
+// limits computes the maximum allocatable resources for the given capacity
+func limits(c config.Capacity) ipfs.NodeResources {
+	return ipfs.NodeResources{
+		CPUs:     overcommit(c.CPUs, c.CPUOvercommit),
+		MemoryGB: overcommit(c.MemoryGB, c.MemoryOvercommit),
+		DiskGB:   overcommit(c.DiskGB, c.DiskOvercommit),
+	}
+}
+
+func overcommit(capacity int, ratio float64) int {
+	if ratio <= 0 {
+		ratio = 1
+	}
+	return int(float64(capacity) * ratio)
+}
+
+// admit checks if the given resources can be allocated in addition to the
+// given allocated resources within the given limits
+func admit(allocated, requested, limits ipfs.NodeResources) error {
+	for _, r := range []struct {
+		name                        string
+		allocated, requested, limit int
+	}{
+		{ResourceCPUs, allocated.CPUs, requested.CPUs, limits.CPUs},
+		{ResourceMemory, allocated.MemoryGB, requested.MemoryGB, limits.MemoryGB},
+		{ResourceDisk, allocated.DiskGB, requested.DiskGB, limits.DiskGB},
+	} {
+		if r.limit > 0 && r.allocated+r.requested > r.limit {
+			var available = r.limit - r.allocated
+			if available < 0 {
+				available = 0
+			}
+			return &CapacityError{
+				Resource:  r.name,
+				Requested: r.requested,
+				Available: available,
+			}
+		}
+	}
+	return nil
+}
+
+func add(a, b ipfs.NodeResources) ipfs.NodeResources {
+	return ipfs.NodeResources{
+		CPUs:     a.CPUs + b.CPUs,
+		MemoryGB: a.MemoryGB + b.MemoryGB,
+		DiskGB:   a.DiskGB + b.DiskGB,
+	}
+}
+
+func subtract(a, b ipfs.NodeResources) ipfs.NodeResources {
+	return ipfs.NodeResources{
+		CPUs:     a.CPUs - b.CPUs,
+		MemoryGB: a.MemoryGB - b.MemoryGB,
+		DiskGB:   a.DiskGB - b.DiskGB,
+	}
+}
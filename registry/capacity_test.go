@@ -0,0 +1,135 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/RTradeLtd/Nexus/config"
+	"github.com/RTradeLtd/Nexus/ipfs"
+	"github.com/RTradeLtd/Nexus/log"
+)
+
+func newTestCapacityRegistry(capacity config.Capacity) *NodeRegistry {
+	// create a registry with a mock node using default resources for testing
+	n := defaultNode
+	l, _ := log.NewTestLogger()
+	return NewWithCapacity(l, config.New().Ports, capacity, &n)
+}
+
+func TestNodeRegistry_Register_capacity(t *testing.T) {
+	type args struct {
+		resources ipfs.NodeResources
+	}
+	tests := []struct {
+		name         string
+		capacity     config.Capacity
+		args         args
+		wantResource string
+	}{
+		{"unlimited",
+			config.Capacity{}, args{ipfs.NodeResources{CPUs: 1000}}, ""},
+		{"within capacity",
+			config.Capacity{CPUs: 8, MemoryGB: 8, DiskGB: 200}, args{ipfs.NodeResources{}}, ""},
+		{"exceeds cpus",
+			config.Capacity{CPUs: 6}, args{ipfs.NodeResources{}}, ResourceCPUs},
+		{"exceeds memory",
+			config.Capacity{MemoryGB: 6}, args{ipfs.NodeResources{}}, ResourceMemory},
+		{"exceeds disk",
+			config.Capacity{DiskGB: 150}, args{ipfs.NodeResources{}}, ResourceDisk},
+		{"within overcommitted capacity",
+			config.Capacity{CPUs: 4, CPUOvercommit: 2}, args{ipfs.NodeResources{}}, ""},
+		{"exceeds overcommitted capacity",
+			config.Capacity{CPUs: 4, CPUOvercommit: 1.5}, args{ipfs.NodeResources{}}, ResourceCPUs},
+		{"explicit resources within capacity",
+			config.Capacity{CPUs: 6}, args{ipfs.NodeResources{CPUs: 2}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestCapacityRegistry(tt.capacity)
+			defer r.Close()
+
+			err := r.Register(&ipfs.NodeInfo{NetworkID: "postables", Resources: tt.args.resources})
+			if tt.wantResource == "" {
+				if err != nil {
+					t.Errorf("NodeRegistry.Register() error = %v", err)
+				}
+				return
+			}
+			capErr, ok := err.(*CapacityError)
+			if !ok {
+				t.Errorf("expected *CapacityError, got %v", err)
+				return
+			}
+			if capErr.Resource != tt.wantResource {
+				t.Errorf("expected %s to be exceeded, got %s", tt.wantResource, capErr.Resource)
+			}
+
+			// rejected nodes should not be allocated anything
+			if _, err := r.Get("postables"); err == nil {
+				t.Error("expected node to not be registered")
+			}
+			if u := r.Utilization(); u.Allocated != defaultNode.Resources.WithDefaults() {
+				t.Errorf("unexpected allocation %+v", u.Allocated)
+			}
+		})
+	}
+}
+
+func TestNodeRegistry_Utilization(t *testing.T) {
+	r := newTestCapacityRegistry(config.Capacity{CPUs: 10, MemoryGB: 10, DiskGB: 100, DiskOvercommit: 3})
+	defer r.Close()
+
+	var base = ipfs.NodeResources{}.WithDefaults()
+	tests := []struct {
+		name string
+		op   func() error
+		want ipfs.NodeResources
+	}{
+		{"existing nodes are allocated", func() error { return nil }, base},
+		{"register allocates resources", func() error {
+			return r.Register(&ipfs.NodeInfo{
+				NetworkID: "postables",
+				Resources: ipfs.NodeResources{CPUs: 2, MemoryGB: 2, DiskGB: 50},
+			})
+		}, ipfs.NodeResources{CPUs: base.CPUs + 2, MemoryGB: base.MemoryGB + 2, DiskGB: base.DiskGB + 50}},
+		{"update reallocates resources", func() error {
+			n, _ := r.Get("postables")
+			n.Resources = ipfs.NodeResources{CPUs: 1, MemoryGB: 1, DiskGB: 10}
+			return r.Update(&n)
+		}, ipfs.NodeResources{CPUs: base.CPUs + 1, MemoryGB: base.MemoryGB + 1, DiskGB: base.DiskGB + 10}},
+		{"update beyond capacity is rejected", func() error {
+			n, _ := r.Get("postables")
+			n.Resources = ipfs.NodeResources{CPUs: 100, MemoryGB: 1, DiskGB: 10}
+			if err := r.Update(&n); err == nil {
+				t.Error("expected update to be rejected")
+			}
+			return nil
+		}, ipfs.NodeResources{CPUs: base.CPUs + 1, MemoryGB: base.MemoryGB + 1, DiskGB: base.DiskGB + 10}},
+		{"adopt ignores capacity", func() error {
+			return r.Adopt(&ipfs.NodeInfo{
+				NetworkID: "timhortons",
+				Ports:     ipfs.NodePorts{Swarm: "4002", API: "5002", Gateway: "8002"},
+				Resources: ipfs.NodeResources{CPUs: 100, MemoryGB: 1, DiskGB: 1},
+			})
+		}, ipfs.NodeResources{CPUs: base.CPUs + 101, MemoryGB: base.MemoryGB + 2, DiskGB: base.DiskGB + 11}},
+		{"deregister releases resources", func() error {
+			if err := r.Deregister("timhortons"); err != nil {
+				return err
+			}
+			return r.Deregister("postables")
+		}, base},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.op(); err != nil {
+				t.Fatal(err)
+			}
+			var u = r.Utilization()
+			if u.Allocated != tt.want {
+				t.Errorf("expected allocation %+v, got %+v", tt.want, u.Allocated)
+			}
+			if u.Limits != (ipfs.NodeResources{CPUs: 10, MemoryGB: 10, DiskGB: 300}) {
+				t.Errorf("unexpected limits %+v", u.Limits)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Health describes the results of liveness probes against a node. Nodes that
+// have not been probed are considered healthy.
+type Health struct {
+	Healthy bool `json:"healthy"`
+	// ConsecutiveFailures is the number of probes that have failed since the
+	// last successful probe
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastProbe           time.Time `json:"last_probe"`
+	LastError           string    `json:"last_error,omitempty"`
+}
+
+// RecordProbe records the result of a liveness probe against the given
+// network's node. A failed probe marks the node as unhealthy, and a successful
+// probe marks it as healthy again. The node's updated health is returned.
+func (r *NodeRegistry) RecordProbe(network string, probeErr error) (Health, error) {
+	if network == "" {
+		return Health{}, errors.New(ErrInvalidNetwork)
+	}
+
+	r.nm.Lock()
+	defer r.nm.Unlock()
+	if _, found := r.nodes[network]; !found {
+		return Health{}, fmt.Errorf("node for network '%s' not found", network)
+	}
+
+	var h = Health{Healthy: true, LastProbe: time.Now()}
+	if probeErr != nil {
+		h.Healthy = false
+		h.ConsecutiveFailures = r.health[network].ConsecutiveFailures + 1
+		h.LastError = probeErr.Error()
+	}
+	r.health[network] = h
+	return h, nil
+}
+
+// Health retrieves the health of the given network's node
+func (r *NodeRegistry) Health(network string) (Health, error) {
+	if network == "" {
+		return Health{}, errors.New(ErrInvalidNetwork)
+	}
+
+	r.nm.RLock()
+	defer r.nm.RUnlock()
+	if _, found := r.nodes[network]; !found {
+		return Health{}, fmt.Errorf("node for network '%s' not found", network)
+	}
+	if h, found := r.health[network]; found {
+		return h, nil
+	}
+	return Health{Healthy: true}, nil
+}
@@ -0,0 +1,65 @@
+package registry
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNodeRegistry_RecordProbe(t *testing.T) {
+	r := newTestRegistry()
+	defer r.Close()
+	var network = defaultNode.NetworkID
+
+	if _, err := r.RecordProbe("", nil); err == nil {
+		t.Error("expected error for invalid network")
+	}
+	if _, err := r.RecordProbe("timhortons", nil); err == nil {
+		t.Error("expected error for unknown network")
+	}
+	if _, err := r.Health("timhortons"); err == nil {
+		t.Error("expected error for unknown network")
+	}
+	if h, err := r.Health(network); err != nil || !h.Healthy {
+		t.Errorf("expected unprobed node to be healthy, got %+v (%v)", h, err)
+	}
+
+	type args struct {
+		err error
+	}
+	tests := []struct {
+		name         string
+		args         args
+		wantHealthy  bool
+		wantFailures int
+	}{
+		{"first failure", args{errors.New("timed out")}, false, 1},
+		{"second failure", args{errors.New("timed out")}, false, 2},
+		{"recovered", args{nil}, true, 0},
+		{"failure after recovery", args{errors.New("timed out")}, false, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := r.RecordProbe(network, tt.args.err)
+			if err != nil {
+				t.Fatalf("NodeRegistry.RecordProbe() error = %v", err)
+			}
+			if h.Healthy != tt.wantHealthy || h.ConsecutiveFailures != tt.wantFailures {
+				t.Errorf("NodeRegistry.RecordProbe() = %+v", h)
+			}
+			if h.LastProbe.IsZero() || (tt.args.err != nil && h.LastError != tt.args.err.Error()) {
+				t.Errorf("expected probe to be recorded, got %+v", h)
+			}
+			if got, _ := r.Health(network); got != h {
+				t.Errorf("NodeRegistry.Health() = %+v, want %+v", got, h)
+			}
+		})
+	}
+
+	// health should be reset when the node is hibernated
+	if err := r.Hibernate(network); err != nil {
+		t.Fatal(err)
+	}
+	if h, _ := r.Health(network); !h.Healthy || h.ConsecutiveFailures != 0 {
+		t.Errorf("expected hibernated node's health to be reset, got %+v", h)
+	}
+}
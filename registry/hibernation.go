@@ -0,0 +1,136 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/RTradeLtd/Nexus/ipfs"
+)
+
+// Touch records activity on the given network's node
+func (r *NodeRegistry) Touch(network string) {
+	r.nm.Lock()
+	if _, found := r.nodes[network]; found {
+		r.activity[network] = time.Now()
+	}
+	r.nm.Unlock()
+}
+
+// LastActive retrieves the time of the most recent activity on the given
+// network's node, or the time it was registered if there has been no activity
+func (r *NodeRegistry) LastActive(network string) (time.Time, error) {
+	if network == "" {
+		return time.Time{}, errors.New(ErrInvalidNetwork)
+	}
+
+	r.nm.RLock()
+	defer r.nm.RUnlock()
+	if _, found := r.nodes[network]; !found {
+		return time.Time{}, fmt.Errorf("node for network '%s' not found", network)
+	}
+	return r.activity[network], nil
+}
+
+// Hibernated checks if the given network's node is hibernated
+func (r *NodeRegistry) Hibernated(network string) bool {
+	r.nm.RLock()
+	defer r.nm.RUnlock()
+	return r.hibernated[network]
+}
+
+// Hibernate marks the given network's node as hibernated, releasing its
+// resources. The node remains registered and retains its ports.
+func (r *NodeRegistry) Hibernate(network string) error {
+	if network == "" {
+		return errors.New(ErrInvalidNetwork)
+	}
+
+	r.nm.Lock()
+	defer r.nm.Unlock()
+
+	n, found := r.nodes[network]
+	if !found {
+		return fmt.Errorf("node for network '%s' not found", network)
+	}
+	if r.hibernated[network] {
+		return fmt.Errorf("node for network '%s' is already hibernated", network)
+	}
+
+	r.hibernated[network] = true
+	delete(r.health, network)
+	r.allocated = subtract(r.allocated, n.Resources.WithDefaults())
+	return nil
+}
+
+// Wake marks the given network's node as no longer hibernated, allocating its
+// resources again. A *CapacityError is returned if the host does not have
+// enough resources available for the node.
+func (r *NodeRegistry) Wake(network string) error {
+	if network == "" {
+		return errors.New(ErrInvalidNetwork)
+	}
+
+	r.nm.Lock()
+	defer r.nm.Unlock()
+
+	n, found := r.nodes[network]
+	if !found {
+		return fmt.Errorf("node for network '%s' not found", network)
+	}
+	if !r.hibernated[network] {
+		return fmt.Errorf("node for network '%s' is not hibernated", network)
+	}
+
+	var resources = n.Resources.WithDefaults()
+	if err := admit(r.allocated, resources, r.limits); err != nil {
+		return err
+	}
+	delete(r.hibernated, network)
+	r.activity[network] = time.Now()
+	r.allocated = add(r.allocated, resources)
+	return nil
+}
+
+// AdoptHibernated registers a hibernated node that was stopped before a
+// restart, reserving its ports without allocating its resources. The node must
+// already have ports assigned.
+func (r *NodeRegistry) AdoptHibernated(node *ipfs.NodeInfo) error {
+	if node.NetworkID == "" {
+		return errors.New(ErrInvalidNetwork)
+	}
+	if node.Ports.Swarm == "" || node.Ports.API == "" || node.Ports.Gateway == "" {
+		return fmt.Errorf("node for network '%s' has no ports assigned", node.NetworkID)
+	}
+
+	r.nm.Lock()
+	defer r.nm.Unlock()
+
+	if _, found := r.nodes[node.NetworkID]; found {
+		return errors.New(ErrNetworkExists)
+	}
+	for _, p := range []string{node.Ports.Swarm, node.Ports.API, node.Ports.Gateway} {
+		if r.reserved(p) {
+			return fmt.Errorf("port '%s' of node for network '%s' is already in use",
+				p, node.NetworkID)
+		}
+	}
+
+	r.nodes[node.NetworkID] = node
+	r.activity[node.NetworkID] = time.Now()
+	r.hibernated[node.NetworkID] = true
+	return nil
+}
+
+// HibernatedNodes retrieves a list of all hibernated nodes
+func (r *NodeRegistry) HibernatedNodes() []ipfs.NodeInfo {
+	r.nm.RLock()
+	defer r.nm.RUnlock()
+	var nodes = make([]ipfs.NodeInfo, 0, len(r.hibernated))
+	for network := range r.hibernated {
+		if n, found := r.nodes[network]; found {
+			nodes = append(nodes, *n)
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,174 @@
+package registry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RTradeLtd/Nexus/config"
+	"github.com/RTradeLtd/Nexus/ipfs"
+)
+
+func TestNodeRegistry_Touch(t *testing.T) {
+	r := newTestRegistry()
+	defer r.Close()
+
+	if _, err := r.LastActive(""); err == nil {
+		t.Error("expected error for invalid network")
+	}
+	if _, err := r.LastActive("timhortons"); err == nil {
+		t.Error("expected error for unknown network")
+	}
+
+	before, err := r.LastActive(defaultNode.NetworkID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before.IsZero() {
+		t.Error("expected registration to count as activity")
+	}
+	time.Sleep(time.Millisecond)
+	r.Touch(defaultNode.NetworkID)
+	if after, _ := r.LastActive(defaultNode.NetworkID); !after.After(before) {
+		t.Errorf("expected activity to be recorded, got %v (before %v)", after, before)
+	}
+
+	// unknown networks should not be tracked
+	r.Touch("timhortons")
+	if _, found := r.activity["timhortons"]; found {
+		t.Error("expected activity on unknown network to be ignored")
+	}
+}
+
+func TestNodeRegistry_Hibernate(t *testing.T) {
+	r := newTestCapacityRegistry(config.Capacity{CPUs: 4})
+	defer r.Close()
+	var network = defaultNode.NetworkID
+
+	type args struct {
+		network string
+	}
+	tests := []struct {
+		name           string
+		op             func(string) error
+		args           args
+		wantErr        bool
+		wantHibernated bool
+	}{
+		{"wake invalid network", r.Wake, args{""}, true, false},
+		{"wake unknown network", r.Wake, args{"timhortons"}, true, false},
+		{"wake running network", r.Wake, args{network}, true, false},
+		{"hibernate invalid network", r.Hibernate, args{""}, true, false},
+		{"hibernate unknown network", r.Hibernate, args{"timhortons"}, true, false},
+		{"hibernate running network", r.Hibernate, args{network}, false, true},
+		{"hibernate hibernated network", r.Hibernate, args{network}, true, true},
+		{"wake hibernated network", r.Wake, args{network}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.op(tt.args.network); (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if h := r.Hibernated(network); h != tt.wantHibernated {
+				t.Errorf("expected hibernated = %v, got %v", tt.wantHibernated, h)
+			}
+			if _, err := r.Get(network); err != nil {
+				t.Errorf("expected node to remain registered, got %v", err)
+			}
+		})
+	}
+}
+
+func TestNodeRegistry_Hibernate_resources(t *testing.T) {
+	r := newTestCapacityRegistry(config.Capacity{CPUs: 4})
+	defer r.Close()
+	var network = defaultNode.NetworkID
+
+	// hibernated nodes should release resources, but retain ports
+	if err := r.Hibernate(network); err != nil {
+		t.Fatal(err)
+	}
+	if u := r.Utilization(); u.Allocated != (ipfs.NodeResources{}) {
+		t.Errorf("expected resources to be released, got %+v", u.Allocated)
+	}
+	if !r.reserved(defaultNode.Ports.Swarm) {
+		t.Error("expected ports to remain reserved")
+	}
+
+	// updates to hibernated nodes should not allocate resources
+	var updated = defaultNode
+	updated.Resources = ipfs.NodeResources{CPUs: 2}
+	if err := r.Update(&updated); err != nil {
+		t.Fatal(err)
+	}
+	if u := r.Utilization(); u.Allocated != (ipfs.NodeResources{}) {
+		t.Errorf("expected no allocation for hibernated node, got %+v", u.Allocated)
+	}
+
+	// waking should be subject to capacity
+	if err := r.Register(&ipfs.NodeInfo{NetworkID: "postables", Resources: ipfs.NodeResources{CPUs: 3}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Wake(network); err == nil {
+		t.Error("expected wake to exceed capacity")
+	} else if _, ok := err.(*CapacityError); !ok {
+		t.Errorf("expected *CapacityError, got %v", err)
+	}
+	if !r.Hibernated(network) {
+		t.Error("expected node to remain hibernated")
+	}
+
+	// deregistering hibernated nodes should not release resources again
+	var allocated = r.Utilization().Allocated
+	if err := r.Deregister(network); err != nil {
+		t.Fatal(err)
+	}
+	if u := r.Utilization(); u.Allocated != allocated {
+		t.Errorf("expected allocation %+v, got %+v", allocated, u.Allocated)
+	}
+	if r.Hibernated(network) {
+		t.Error("expected deregistered node to not be hibernated")
+	}
+}
+
+func TestNodeRegistry_AdoptHibernated(t *testing.T) {
+	r := newTestCapacityRegistry(config.Capacity{CPUs: 4})
+	defer r.Close()
+
+	type args struct {
+		node ipfs.NodeInfo
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{"invalid network", args{ipfs.NodeInfo{}}, true},
+		{"no ports", args{ipfs.NodeInfo{NetworkID: "postables"}}, true},
+		{"existing network", args{defaultNode}, true},
+		{"ports in use", args{ipfs.NodeInfo{NetworkID: "postables", Ports: defaultNode.Ports}}, true},
+		{"hibernated node", args{ipfs.NodeInfo{
+			NetworkID: "postables",
+			Ports:     ipfs.NodePorts{Swarm: "4002", API: "5002", Gateway: "8081"},
+			Resources: ipfs.NodeResources{CPUs: 8},
+		}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var n = tt.args.node
+			if err := r.AdoptHibernated(&n); (err != nil) != tt.wantErr {
+				t.Errorf("NodeRegistry.AdoptHibernated() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// adopted nodes should reserve ports without allocating resources
+	if !r.Hibernated("postables") || !r.reserved("4002") {
+		t.Error("expected node to be hibernated with its ports reserved")
+	}
+	if u := r.Utilization(); u.Allocated.CPUs != defaultNode.Resources.WithDefaults().CPUs {
+		t.Errorf("expected no allocation for hibernated node, got %+v", u.Allocated)
+	}
+	if nodes := r.HibernatedNodes(); len(nodes) != 1 || nodes[0].NetworkID != "postables" {
+		t.Errorf("expected hibernated node to be listed, got %+v", nodes)
+	}
+}
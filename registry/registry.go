@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -24,9 +25,17 @@ const (
 type NodeRegistry struct {
 	l *zap.SugaredLogger
 
-	// node registry - locked by NodeRegistry::nm
-	nodes map[string]*ipfs.NodeInfo
-	nm    sync.RWMutex
+	// node registry, node activity, node health, and resource allocations -
+	// locked by NodeRegistry::nm
+	nodes      map[string]*ipfs.NodeInfo
+	activity   map[string]time.Time
+	hibernated map[string]bool
+	health     map[string]Health
+	allocated  ipfs.NodeResources
+	nm         sync.RWMutex
+
+	// maximum allocatable resources
+	limits ipfs.NodeResources
 
 	// port registry
 	swarmPorts   *network.Registry
@@ -34,20 +43,50 @@ type NodeRegistry struct {
 	gatewayPorts *network.Registry
 }
 
-// New sets up a new registry with provided nodes
+// New sets up a new registry with provided nodes, without limits on resource
+// allocation
 func New(logger *zap.SugaredLogger, ports config.Ports, nodes ...*ipfs.NodeInfo) *NodeRegistry {
+	return NewWithCapacity(logger, ports, config.Capacity{}, nodes...)
+}
+
+// NewWithCapacity sets up a new registry with provided nodes, which only
+// allows resources to be allocated to nodes up to the given host capacity.
+// Provided nodes are always registered, even if they exceed capacity.
+func NewWithCapacity(logger *zap.SugaredLogger, ports config.Ports, capacity config.Capacity,
+	nodes ...*ipfs.NodeInfo) *NodeRegistry {
 	// parse nodes
-	m := make(map[string]*ipfs.NodeInfo)
+	var (
+		m         = make(map[string]*ipfs.NodeInfo)
+		activity  = make(map[string]time.Time)
+		allocated ipfs.NodeResources
+	)
 	if nodes != nil {
 		for _, n := range nodes {
 			m[n.NetworkID] = n
+			activity[n.NetworkID] = time.Now()
+			allocated = add(allocated, n.Resources.WithDefaults())
 		}
 	}
 
+	// check existing allocations
+	var (
+		l   = logger.Named("registry")
+		max = limits(capacity)
+	)
+	if err := admit(ipfs.NodeResources{}, allocated, max); err != nil {
+		l.Warnw("existing nodes exceed host capacity",
+			"error", err, "allocated", allocated, "limits", max)
+	}
+
 	// build registry
 	return &NodeRegistry{
-		l:     logger.Named("registry"),
-		nodes: m,
+		l:          l,
+		nodes:      m,
+		activity:   activity,
+		hibernated: make(map[string]bool),
+		health:     make(map[string]Health),
+		allocated:  allocated,
+		limits:     max,
 
 		// See documentation regarding public/private-ness of IPFS ports in package
 		// ipfs
@@ -57,8 +96,21 @@ func New(logger *zap.SugaredLogger, ports config.Ports, nodes ...*ipfs.NodeInfo)
 	}
 }
 
-// Register registers a node and allocates appropriate ports
+// Register registers a node and allocates appropriate ports and resources.
+// Unset resources are allocated with node defaults. A *CapacityError is
+// returned if the host does not have enough resources available for the node.
 func (r *NodeRegistry) Register(node *ipfs.NodeInfo) error {
+	return r.register(node, true)
+}
+
+// Adopt registers a node that is already running on this host, for example
+// one that was started outside of the orchestrator. Its resources are
+// allocated even if they exceed host capacity, since they are already in use.
+func (r *NodeRegistry) Adopt(node *ipfs.NodeInfo) error {
+	return r.register(node, false)
+}
+
+func (r *NodeRegistry) register(node *ipfs.NodeInfo, enforceCapacity bool) error {
 	if node.NetworkID == "" {
 		return errors.New(ErrInvalidNetwork)
 	}
@@ -70,25 +122,70 @@ func (r *NodeRegistry) Register(node *ipfs.NodeInfo) error {
 		return errors.New(ErrNetworkExists)
 	}
 
+	// check that resources are available for this node
+	var resources = node.Resources.WithDefaults()
+	if err := admit(r.allocated, resources, r.limits); err != nil {
+		if enforceCapacity {
+			return err
+		}
+		r.l.Warnw("adopted node exceeds host capacity",
+			"error", err, "network", node.NetworkID)
+	}
+
 	// assign ports to this node - do not assign new ones if ports are already
 	// provided in node.Ports
 	if node.Ports.Swarm == "" || node.Ports.Gateway == "" || node.Ports.API == "" {
 		var err error
 		var swarm, api, gateway string
-		if swarm, err = r.swarmPorts.AssignPort(); err != nil {
+		if swarm, err = r.assignPort(r.swarmPorts); err != nil {
 			return fmt.Errorf("failed to register node: %s", err.Error())
 		}
-		if api, err = r.apiPorts.AssignPort(); err != nil {
+		if api, err = r.assignPort(r.apiPorts); err != nil {
 			return fmt.Errorf("failed to register node: %s", err.Error())
 		}
-		if gateway, err = r.gatewayPorts.AssignPort(); err != nil {
+		if gateway, err = r.assignPort(r.gatewayPorts); err != nil {
 			return fmt.Errorf("failed to register node: %s", err.Error())
 		}
 		node.Ports = ipfs.NodePorts{Swarm: swarm, API: api, Gateway: gateway}
 	}
 
 	r.nodes[node.NetworkID] = node
+	r.activity[node.NetworkID] = time.Now()
+	r.allocated = add(r.allocated, resources)
+
+	return nil
+}
+
+// Update atomically replaces the registered node for the given node's network.
+// Ports are not reallocated, so the given node should retain the ports of the
+// node it replaces. A *CapacityError is returned if the host does not have
+// enough resources available for the node's new resources. Resources of
+// hibernated nodes are not allocated until they are woken.
+func (r *NodeRegistry) Update(node *ipfs.NodeInfo) error {
+	if node.NetworkID == "" {
+		return errors.New(ErrInvalidNetwork)
+	}
+
+	r.nm.Lock()
+	defer r.nm.Unlock()
+
+	existing, found := r.nodes[node.NetworkID]
+	if !found {
+		return fmt.Errorf("node for network '%s' not found", node.NetworkID)
+	}
+	if r.hibernated[node.NetworkID] {
+		r.nodes[node.NetworkID] = node
+		return nil
+	}
+
+	// check that resources are available for the node's new configuration
+	var allocated = subtract(r.allocated, existing.Resources.WithDefaults())
+	if err := admit(allocated, node.Resources.WithDefaults(), r.limits); err != nil {
+		return err
+	}
 
+	r.nodes[node.NetworkID] = node
+	r.allocated = add(allocated, node.Resources.WithDefaults())
 	return nil
 }
 
@@ -101,11 +198,19 @@ func (r *NodeRegistry) Deregister(network string) error {
 	r.nm.Lock()
 	defer r.nm.Unlock()
 
-	if _, found := r.nodes[network]; !found {
+	n, found := r.nodes[network]
+	if !found {
 		return fmt.Errorf("node for network '%s' not found", network)
 	}
 
 	delete(r.nodes, network)
+	delete(r.activity, network)
+	delete(r.health, network)
+	if r.hibernated[network] {
+		delete(r.hibernated, network)
+	} else {
+		r.allocated = subtract(r.allocated, n.Resources.WithDefaults())
+	}
 	return nil
 }
 
@@ -145,6 +250,44 @@ func (r *NodeRegistry) Get(network string) (ipfs.NodeInfo, error) {
 	return node, nil
 }
 
+// Utilization retrieves the resources currently allocated to nodes and the
+// host's resource limits
+func (r *NodeRegistry) Utilization() Utilization {
+	r.nm.RLock()
+	defer r.nm.RUnlock()
+	return Utilization{
+		Allocated: r.allocated,
+		Limits:    r.limits,
+	}
+}
+
+// assignPort assigns an available port from the given port registry that is
+// not reserved by a registered node. Ports of hibernated nodes are not in use
+// on the host, but remain reserved so that the nodes can be woken. The caller
+// must hold NodeRegistry::nm.
+func (r *NodeRegistry) assignPort(ports *network.Registry) (string, error) {
+	for {
+		p, err := ports.AssignPort()
+		if err != nil {
+			return "", err
+		}
+		if !r.reserved(p) {
+			return p, nil
+		}
+	}
+}
+
+// reserved checks if the given port is assigned to a registered node. The
+// caller must hold NodeRegistry::nm.
+func (r *NodeRegistry) reserved(port string) bool {
+	for _, n := range r.nodes {
+		if n.Ports.Swarm == port || n.Ports.API == port || n.Ports.Gateway == port {
+			return true
+		}
+	}
+	return false
+}
+
 // Close stops registry background jobs
 func (r *NodeRegistry) Close() {
 	r.apiPorts.Close()
@@ -97,6 +97,43 @@ func TestNodeRegistry_Deregister(t *testing.T) {
 	}
 }
 
+func TestNodeRegistry_Update(t *testing.T) {
+	type args struct {
+		node *ipfs.NodeInfo
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{"invalid input", args{&ipfs.NodeInfo{}}, true},
+		{"unknown network", args{&ipfs.NodeInfo{NetworkID: "timhortons"}}, true},
+		{"successful update", args{&ipfs.NodeInfo{
+			NetworkID: "bobheadxi",
+			Ports:     defaultNode.Ports,
+			DockerID:  "postables",
+		}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRegistry()
+			defer r.Close()
+			if err := r.Update(tt.args.node); (err != nil) != tt.wantErr {
+				t.Errorf("NodeRegistry.Update() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr {
+				n, err := r.Get(tt.args.node.NetworkID)
+				if err != nil {
+					t.Errorf("expected node to remain registered, got %v", err)
+				} else if n.DockerID != tt.args.node.DockerID {
+					t.Errorf("expected updated node, got %+v", n)
+				}
+			}
+		})
+	}
+}
+
 func TestNodeRegistry_List(t *testing.T) {
 	r := newTestRegistry()
 	nodes := r.List()
@@ -1,11 +1,36 @@
 package temporal
 
-import "github.com/RTradeLtd/database/models"
+import (
+	"time"
+
+	"github.com/RTradeLtd/database/models"
+)
 
 // PrivateNetworks is an interface to wrap the Temporal IPFSNetworkManager
 // database class
 type PrivateNetworks interface {
 	GetNetworkByName(name string) (*models.HostedIPFSPrivateNetwork, error)
+	GetActiveNetworks() ([]*models.HostedIPFSPrivateNetwork, error)
 	UpdateNetworkByName(name string, attrs map[string]interface{}) error
 	SaveNetwork(n *models.HostedIPFSPrivateNetwork) error
 }
+
+// NetworkManager extends the Temporal IPFSNetworkManager database class with
+// additional queries. It implements PrivateNetworks
+type NetworkManager struct {
+	*models.HostedIPFSNetworkManager
+}
+
+// NewNetworkManager wraps the given IPFSNetworkManager
+func NewNetworkManager(m *models.HostedIPFSNetworkManager) *NetworkManager {
+	return &NetworkManager{m}
+}
+
+// GetActiveNetworks retrieves all networks that are currently activated
+func (n *NetworkManager) GetActiveNetworks() ([]*models.HostedIPFSPrivateNetwork, error) {
+	var networks []*models.HostedIPFSPrivateNetwork
+	if check := n.DB.Where("activated > ?", time.Time{}).Find(&networks); check.Error != nil {
+		return nil, check.Error
+	}
+	return networks, nil
+}
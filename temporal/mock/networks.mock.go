@@ -9,6 +9,18 @@ import (
 )
 
 type FakePrivateNetworks struct {
+	GetActiveNetworksStub        func() ([]*models.HostedIPFSPrivateNetwork, error)
+	getActiveNetworksMutex       sync.RWMutex
+	getActiveNetworksArgsForCall []struct {
+	}
+	getActiveNetworksReturns struct {
+		result1 []*models.HostedIPFSPrivateNetwork
+		result2 error
+	}
+	getActiveNetworksReturnsOnCall map[int]struct {
+		result1 []*models.HostedIPFSPrivateNetwork
+		result2 error
+	}
 	GetNetworkByNameStub        func(string) (*models.HostedIPFSPrivateNetwork, error)
 	getNetworkByNameMutex       sync.RWMutex
 	getNetworkByNameArgsForCall []struct {
@@ -49,6 +61,61 @@ type FakePrivateNetworks struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakePrivateNetworks) GetActiveNetworks() ([]*models.HostedIPFSPrivateNetwork, error) {
+	fake.getActiveNetworksMutex.Lock()
+	ret, specificReturn := fake.getActiveNetworksReturnsOnCall[len(fake.getActiveNetworksArgsForCall)]
+	fake.getActiveNetworksArgsForCall = append(fake.getActiveNetworksArgsForCall, struct {
+	}{})
+	fake.recordInvocation("GetActiveNetworks", []interface{}{})
+	fake.getActiveNetworksMutex.Unlock()
+	if fake.GetActiveNetworksStub != nil {
+		return fake.GetActiveNetworksStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getActiveNetworksReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakePrivateNetworks) GetActiveNetworksCallCount() int {
+	fake.getActiveNetworksMutex.RLock()
+	defer fake.getActiveNetworksMutex.RUnlock()
+	return len(fake.getActiveNetworksArgsForCall)
+}
+
+func (fake *FakePrivateNetworks) GetActiveNetworksCalls(stub func() ([]*models.HostedIPFSPrivateNetwork, error)) {
+	fake.getActiveNetworksMutex.Lock()
+	defer fake.getActiveNetworksMutex.Unlock()
+	fake.GetActiveNetworksStub = stub
+}
+
+func (fake *FakePrivateNetworks) GetActiveNetworksReturns(result1 []*models.HostedIPFSPrivateNetwork, result2 error) {
+	fake.getActiveNetworksMutex.Lock()
+	defer fake.getActiveNetworksMutex.Unlock()
+	fake.GetActiveNetworksStub = nil
+	fake.getActiveNetworksReturns = struct {
+		result1 []*models.HostedIPFSPrivateNetwork
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakePrivateNetworks) GetActiveNetworksReturnsOnCall(i int, result1 []*models.HostedIPFSPrivateNetwork, result2 error) {
+	fake.getActiveNetworksMutex.Lock()
+	defer fake.getActiveNetworksMutex.Unlock()
+	fake.GetActiveNetworksStub = nil
+	if fake.getActiveNetworksReturnsOnCall == nil {
+		fake.getActiveNetworksReturnsOnCall = make(map[int]struct {
+			result1 []*models.HostedIPFSPrivateNetwork
+			result2 error
+		})
+	}
+	fake.getActiveNetworksReturnsOnCall[i] = struct {
+		result1 []*models.HostedIPFSPrivateNetwork
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakePrivateNetworks) GetNetworkByName(arg1 string) (*models.HostedIPFSPrivateNetwork, error) {
 	fake.getNetworkByNameMutex.Lock()
 	ret, specificReturn := fake.getNetworkByNameReturnsOnCall[len(fake.getNetworkByNameArgsForCall)]
@@ -236,6 +303,8 @@ func (fake *FakePrivateNetworks) UpdateNetworkByNameReturnsOnCall(i int, result1
 func (fake *FakePrivateNetworks) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.getActiveNetworksMutex.RLock()
+	defer fake.getActiveNetworksMutex.RUnlock()
 	fake.getNetworkByNameMutex.RLock()
 	defer fake.getNetworkByNameMutex.RUnlock()
 	fake.saveNetworkMutex.RLock()